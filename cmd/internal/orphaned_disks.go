@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/orphaneddisk"
+	"code.cloudfoundry.org/quarks-utils/pkg/cmd"
+	"code.cloudfoundry.org/quarks-utils/pkg/logger"
+)
+
+const orphanedDisksFailedMessage = "orphaned-disks command failed."
+
+// orphanedDisksCmd groups the commands for the persistent volume claims of removed instance groups
+var orphanedDisksCmd = &cobra.Command{
+	Use:   "orphaned-disks",
+	Short: "Lists and reattaches orphaned persistent disks",
+	Long: `Lists and reattaches orphaned persistent disks.
+
+The persistent volume claims of instance groups, which are removed from a
+deployment, are kept for a retention period before the operator deletes them.
+`,
+}
+
+// orphanedDisksListCmd lists the orphaned persistent volume claims of a namespace
+var orphanedDisksListCmd = &cobra.Command{
+	Use:   "list [flags]",
+	Short: "Lists orphaned persistent disks",
+	Args:  cobra.NoArgs,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		viper.BindPFlag("namespace", cmd.Flags().Lookup("namespace"))
+		viper.BindPFlag("deployment-name", cmd.Flags().Lookup("deployment-name"))
+	},
+	RunE: func(_ *cobra.Command, args []string) error {
+		c, err := orphanedDisksClient()
+		if err != nil {
+			return errors.Wrap(err, orphanedDisksFailedMessage)
+		}
+
+		pvcs, err := orphaneddisk.List(context.Background(), c, viper.GetString("namespace"), viper.GetString("deployment-name"))
+		if err != nil {
+			return errors.Wrap(err, orphanedDisksFailedMessage)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tDEPLOYMENT\tINSTANCE GROUP\tSIZE\tORPHANED AT")
+		for _, pvc := range pvcs {
+			size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+			orphanedAt := "unknown"
+			if t, ok := orphaneddisk.OrphanedAt(&pvc); ok {
+				orphanedAt = t.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				pvc.Name,
+				pvc.Labels[bdv1.LabelDeploymentName],
+				pvc.Labels[bdv1.LabelInstanceGroupName],
+				size.String(),
+				orphanedAt,
+			)
+		}
+		return w.Flush()
+	},
+}
+
+// orphanedDisksReattachCmd reattaches an orphaned persistent volume claim to an instance group
+var orphanedDisksReattachCmd = &cobra.Command{
+	Use:   "reattach NAME [flags]",
+	Short: "Reattaches an orphaned persistent disk",
+	Long: `Reattaches an orphaned persistent disk.
+
+Without --instance-group-name the claim is no longer orphaned and kept for the
+instance group it belonged to. The instance group's stateful set reuses the
+claim, when it's added to the deployment again.
+
+With --instance-group-name the volume of the claim is moved to a new claim,
+which has the name the stateful set of that instance group expects. Reattach
+the disk before the instance group is deployed, otherwise its stateful set
+already created a claim with that name. Use migrated_from in the manifest to
+rename an instance group without orphaning its disks.
+`,
+	Args: cobra.ExactArgs(1),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		viper.BindPFlag("namespace", cmd.Flags().Lookup("namespace"))
+		viper.BindPFlag("instance-group-name", cmd.Flags().Lookup("instance-group-name"))
+	},
+	RunE: func(_ *cobra.Command, args []string) error {
+		c, err := orphanedDisksClient()
+		if err != nil {
+			return errors.Wrap(err, orphanedDisksFailedMessage)
+		}
+
+		ctx := context.Background()
+		pvc := &corev1.PersistentVolumeClaim{}
+		err = c.Get(ctx, types.NamespacedName{Namespace: viper.GetString("namespace"), Name: args[0]}, pvc)
+		if err != nil {
+			return errors.Wrapf(err, "%s Getting persistent volume claim '%s' failed.", orphanedDisksFailedMessage, args[0])
+		}
+
+		reattached, err := orphaneddisk.Reattach(ctx, c, pvc, viper.GetString("instance-group-name"))
+		if err != nil {
+			return errors.Wrap(err, orphanedDisksFailedMessage)
+		}
+
+		fmt.Printf("Reattached persistent volume claim '%s' to instance group '%s'\n", reattached.Name, reattached.Labels[bdv1.LabelInstanceGroupName])
+		return nil
+	},
+}
+
+func orphanedDisksClient() (client.Client, error) {
+	log = logger.New(cmd.LogLevel())
+	defer func() {
+		_ = log.Sync()
+	}()
+
+	restConfig, err := cmd.KubeConfig(log)
+	if err != nil {
+		return nil, err
+	}
+	c, err := client.New(restConfig, client.Options{})
+	if err != nil {
+		return nil, errors.Wrap(err, "Creating kube client failed.")
+	}
+	return c, nil
+}
+
+func init() {
+	utilCmd.AddCommand(orphanedDisksCmd)
+	orphanedDisksCmd.AddCommand(orphanedDisksListCmd)
+	orphanedDisksCmd.AddCommand(orphanedDisksReattachCmd)
+
+	pf := orphanedDisksListCmd.Flags()
+	pf.String("namespace", "default", "namespace of the deployment")
+	pf.StringP("deployment-name", "n", "", "only list the orphaned disks of this deployment")
+
+	pf = orphanedDisksReattachCmd.Flags()
+	pf.String("namespace", "default", "namespace of the persistent volume claim")
+	pf.StringP("instance-group-name", "g", "", "the instance group, which takes over the disk")
+}
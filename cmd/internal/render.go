@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/render"
+	"code.cloudfoundry.org/quarks-utils/pkg/cmd"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+	"code.cloudfoundry.org/quarks-utils/pkg/logger"
+)
+
+const renderFailedMessage = "render command failed."
+
+// renderCmd renders the Kubernetes resources of a BOSH deployment offline
+var renderCmd = &cobra.Command{
+	Use:   "render [flags]",
+	Short: "Renders the Kubernetes resources of a BOSH deployment",
+	Long: `Renders the Kubernetes resources of a BOSH deployment.
+
+This will apply the ops and vars files to the manifest, resolve all instance
+groups and print the QuarksSecrets, QuarksJobs, QuarksStatefulSets, Services
+and PersistentVolumeClaims the operator would create, without applying them.
+
+The base directory needs to contain the job specs and templates of all
+releases, like they are found in the release images.
+
+With --diff the rendered resources are compared to the live objects in the
+cluster instead. The resources are rendered with the live versions of the
+QuarksStatefulSets and instance group resolved secrets.
+`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		boshManifestFlagViperBind(cmd.Flags())
+		baseDirFlagViperBind(cmd.Flags())
+		deploymentNameFlagViperBind(cmd.Flags())
+		initialRolloutFlagViperBind(cmd.Flags())
+		viper.BindPFlag("ops-file", cmd.Flags().Lookup("ops-file"))
+		viper.BindPFlag("vars-file", cmd.Flags().Lookup("vars-file"))
+		viper.BindPFlag("namespace", cmd.Flags().Lookup("namespace"))
+		viper.BindPFlag("diff", cmd.Flags().Lookup("diff"))
+	},
+
+	RunE: func(_ *cobra.Command, args []string) error {
+		boshManifestPath, err := boshManifestFlagValidation()
+		if err != nil {
+			return errors.Wrap(err, renderFailedMessage)
+		}
+
+		baseDir, err := baseDirFlagValidation()
+		if err != nil {
+			return errors.Wrap(err, renderFailedMessage)
+		}
+
+		deploymentName, err := deploymentNameFlagValidation()
+		if err != nil {
+			return errors.Wrap(err, renderFailedMessage)
+		}
+
+		namespace := viper.GetString("namespace")
+		if len(namespace) == 0 {
+			return errors.Errorf("%s namespace flag is empty.", renderFailedMessage)
+		}
+
+		in := render.Input{
+			Namespace:      namespace,
+			DeploymentName: deploymentName,
+			BaseDir:        baseDir,
+			InitialRollout: viper.GetBool("initial-rollout"),
+		}
+
+		in.Manifest, err = ioutil.ReadFile(boshManifestPath)
+		if err != nil {
+			return errors.Wrapf(err, "%s Reading file specified in the bosh-manifest-path flag failed.", renderFailedMessage)
+		}
+
+		for _, path := range viper.GetStringSlice("ops-file") {
+			ops, err := ioutil.ReadFile(path)
+			if err != nil {
+				return errors.Wrapf(err, "%s Reading ops file failed.", renderFailedMessage)
+			}
+			in.Ops = append(in.Ops, ops)
+		}
+
+		for _, path := range viper.GetStringSlice("vars-file") {
+			vars, err := ioutil.ReadFile(path)
+			if err != nil {
+				return errors.Wrapf(err, "%s Reading vars file failed.", renderFailedMessage)
+			}
+			in.Vars = append(in.Vars, vars)
+		}
+
+		log = logger.NewControllerLogger(cmd.LogLevel())
+		defer func() {
+			_ = log.Sync()
+		}()
+		ctx := ctxlog.NewParentContext(log)
+
+		scheme, err := render.NewScheme()
+		if err != nil {
+			return errors.Wrap(err, renderFailedMessage)
+		}
+
+		if !viper.GetBool("diff") {
+			resources, err := render.Render(ctx, afero.NewOsFs(), in)
+			if err != nil {
+				return errors.Wrap(err, renderFailedMessage)
+			}
+			out, err := resources.YAML(scheme)
+			if err != nil {
+				return errors.Wrap(err, renderFailedMessage)
+			}
+			_, err = os.Stdout.Write(out)
+			return err
+		}
+
+		restConfig, err := cmd.KubeConfig(log)
+		if err != nil {
+			return errors.Wrap(err, renderFailedMessage)
+		}
+		in.Client, err = client.New(restConfig, client.Options{Scheme: scheme})
+		if err != nil {
+			return errors.Wrapf(err, "%s Creating kube client failed.", renderFailedMessage)
+		}
+
+		resources, err := render.Render(ctx, afero.NewOsFs(), in)
+		if err != nil {
+			return errors.Wrap(err, renderFailedMessage)
+		}
+
+		report, err := render.Diff(context.Background(), in.Client, scheme, resources)
+		if err != nil {
+			return errors.Wrap(err, renderFailedMessage)
+		}
+		fmt.Print(report)
+
+		return nil
+	},
+}
+
+func init() {
+	utilCmd.AddCommand(renderCmd)
+
+	pf := renderCmd.Flags()
+	argToEnv := map[string]string{}
+
+	pf.StringSliceP("ops-file", "o", []string{}, "path to an ops file, can be repeated")
+	pf.StringSliceP("vars-file", "l", []string{}, "path to a vars file, can be repeated")
+	pf.String("namespace", "default", "namespace of the deployment")
+	pf.Bool("diff", false, "compare the rendered resources with the live objects in the cluster")
+
+	boshManifestFlagCobraSet(pf, argToEnv)
+	baseDirFlagCobraSet(pf, argToEnv)
+	deploymentNameFlagCobraSet(pf, argToEnv)
+	initialRolloutFlagCobraSet(pf, argToEnv)
+	cmd.AddEnvToUsage(renderCmd, argToEnv)
+}
@@ -21,7 +21,10 @@ import (
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/boshdns"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/logrotate"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/operatorimage"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/orphaneddisk"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/zones"
 	"code.cloudfoundry.org/quarks-operator/version"
+	qstsv1a1 "code.cloudfoundry.org/quarks-statefulset/pkg/kube/apis/quarksstatefulset/v1alpha1"
 	"code.cloudfoundry.org/quarks-utils/pkg/cmd"
 	"code.cloudfoundry.org/quarks-utils/pkg/config"
 	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
@@ -92,6 +95,13 @@ var rootCmd = &cobra.Command{
 		cfg.WebhookUseServiceRef = useServiceRef
 		cfg.MaxBoshDeploymentWorkers = viper.GetInt("max-boshdeployment-workers")
 		logrotate.SetInterval(viper.GetInt("logrotate-interval"))
+		orphaneddisk.SetRetention(viper.GetDuration("orphaned-disk-retention"))
+		zones.SetNodeLabel(viper.GetString("zone-node-label"))
+		zoneMapping, err := zones.ParseMapping(viper.GetString("az-zone-mapping"))
+		if err != nil {
+			return wrapError(err, "Couldn't parse az-zone-mapping.")
+		}
+		zones.SetMapping(zoneMapping)
 
 		cmd.CtxTimeOut(cfg)
 
@@ -102,11 +112,26 @@ var rootCmd = &cobra.Command{
 			return wrapError(err, "Couldn't apply CRDs.")
 		}
 
+		leaderElectionNamespace := viper.GetString("leader-election-namespace")
+		if leaderElectionNamespace == "" {
+			leaderElectionNamespace = cfg.OperatorNamespace
+		}
+		leaseDuration := viper.GetDuration("leader-election-lease-duration")
+		renewDeadline := viper.GetDuration("leader-election-renew-deadline")
+		retryPeriod := viper.GetDuration("leader-election-retry-period")
+
+		// Webhooks are served by every replica, only the reconcilers
+		// require the leader lock.
 		mgr, err := operator.NewManager(ctx, cfg, restConfig, manager.Options{
-			MetricsBindAddress: "0",
-			LeaderElection:     false,
-			Port:               managerPort,
-			Host:               "0.0.0.0",
+			MetricsBindAddress:      viper.GetString("metrics-bind-address"),
+			LeaderElection:          viper.GetBool("leader-election"),
+			LeaderElectionID:        viper.GetString("leader-election-id"),
+			LeaderElectionNamespace: leaderElectionNamespace,
+			LeaseDuration:           &leaseDuration,
+			RenewDeadline:           &renewDeadline,
+			RetryPeriod:             &retryPeriod,
+			Port:                    managerPort,
+			Host:                    "0.0.0.0",
 		})
 		if err != nil {
 			return wrapError(err, "Failed to create new manager.")
@@ -150,33 +175,63 @@ func init() {
 	cmd.ApplyCRDsFlags(pf, argToEnv)
 	cmd.MeltdownFlags(pf, argToEnv)
 
+	pf.String("az-zone-mapping", "", "Zones of the availability zones in BOSH manifests, e.g. 'z1=eu-west-1a,z2=eu-west-1b'")
 	pf.StringP("bosh-dns-docker-image", "", "coredns/coredns:1.6.3", "The docker image used for emulating bosh DNS (a CoreDNS image)")
 	pf.String("cluster-domain", "cluster.local", "The Kubernetes cluster domain")
+	pf.Bool("leader-election", false, "Enable leader election, so only one of multiple operator replicas runs the controllers")
+	pf.String("leader-election-id", "quarks-operator-lock", "Name of the config map used as the leader election lock")
+	pf.String("leader-election-namespace", "", "Namespace of the leader election lock, defaults to the operator namespace")
+	pf.Duration("leader-election-lease-duration", 15*time.Second, "Duration non-leader replicas wait before trying to acquire leadership")
+	pf.Duration("leader-election-renew-deadline", 10*time.Second, "Duration the leader retries refreshing leadership before giving up")
+	pf.Duration("leader-election-retry-period", 2*time.Second, "Duration between leader election actions")
 	pf.IntP("logrotate-interval", "i", 24*60, "Interval between logrotate calls for instance groups in minutes")
 	pf.Int("max-boshdeployment-workers", 1, "Maximum number of workers concurrently running BOSHDeployment controller")
+	pf.String("metrics-bind-address", "0", "Address the prometheus metrics endpoint binds to, e.g. ':60000'. Use '0' to disable it")
 	pf.StringP("operator-webhook-service-host", "w", "", "Hostname/IP under which the webhook server can be reached from the cluster")
 	pf.StringP("operator-webhook-service-port", "p", "2999", "Port the webhook server listens on")
 	pf.BoolP("operator-webhook-use-service-reference", "x", false, "If true the webhook service is targeted using a service reference instead of a URL")
+	pf.Duration("orphaned-disk-retention", orphaneddisk.DefaultRetention, "Time the persistent disks of removed instance groups are kept, before they are deleted")
+	pf.String("zone-node-label", qstsv1a1.DefaultZoneNodeLabel, "Node label, which holds the zone of a node")
 
 	for _, name := range []string{
+		"az-zone-mapping",
 		"bosh-dns-docker-image",
 		"cluster-domain",
+		"leader-election",
+		"leader-election-id",
+		"leader-election-namespace",
+		"leader-election-lease-duration",
+		"leader-election-renew-deadline",
+		"leader-election-retry-period",
 		"logrotate-interval",
 		"max-boshdeployment-workers",
+		"metrics-bind-address",
 		"operator-webhook-service-host",
 		"operator-webhook-service-port",
 		"operator-webhook-use-service-reference",
+		"orphaned-disk-retention",
+		"zone-node-label",
 	} {
 		viper.BindPFlag(name, pf.Lookup(name))
 	}
 
+	argToEnv["az-zone-mapping"] = "AZ_ZONE_MAPPING"
 	argToEnv["bosh-dns-docker-image"] = "BOSH_DNS_DOCKER_IMAGE"
 	argToEnv["cluster-domain"] = "CLUSTER_DOMAIN"
+	argToEnv["leader-election"] = "LEADER_ELECTION"
+	argToEnv["leader-election-id"] = "LEADER_ELECTION_ID"
+	argToEnv["leader-election-namespace"] = "LEADER_ELECTION_NAMESPACE"
+	argToEnv["leader-election-lease-duration"] = "LEADER_ELECTION_LEASE_DURATION"
+	argToEnv["leader-election-renew-deadline"] = "LEADER_ELECTION_RENEW_DEADLINE"
+	argToEnv["leader-election-retry-period"] = "LEADER_ELECTION_RETRY_PERIOD"
 	argToEnv["logrotate-interval"] = "LOGROTATE_INTERVAL"
 	argToEnv["max-boshdeployment-workers"] = "MAX_BOSHDEPLOYMENT_WORKERS"
+	argToEnv["metrics-bind-address"] = "METRICS_BIND_ADDRESS"
 	argToEnv["operator-webhook-service-host"] = "CF_OPERATOR_WEBHOOK_SERVICE_HOST"
 	argToEnv["operator-webhook-service-port"] = "CF_OPERATOR_WEBHOOK_SERVICE_PORT"
 	argToEnv["operator-webhook-use-service-reference"] = "CF_OPERATOR_WEBHOOK_USE_SERVICE_REFERENCE"
+	argToEnv["orphaned-disk-retention"] = "ORPHANED_DISK_RETENTION"
+	argToEnv["zone-node-label"] = "ZONE_NODE_LABEL"
 
 	// Add env variables to help
 	cmd.AddEnvToUsage(rootCmd, argToEnv)
@@ -33,6 +33,7 @@ This will render a provided manifest instance-group
 `,
 	PreRun: func(cmd *cobra.Command, args []string) {
 		boshManifestFlagViperBind(cmd.Flags())
+		deploymentNameFlagViperBind(cmd.Flags())
 		instanceGroupFlagViperBind(cmd.Flags())
 		initialRolloutFlagViperBind(cmd.Flags())
 	},
@@ -49,6 +50,11 @@ This will render a provided manifest instance-group
 			return errors.Wrap(err, tRenderFailedMessage)
 		}
 
+		deploymentName, err := deploymentNameFlagValidation()
+		if err != nil {
+			return errors.Wrap(err, tRenderFailedMessage)
+		}
+
 		jobsDir := viper.GetString("jobs-dir")
 		outputDir := viper.GetString("output-dir")
 
@@ -99,7 +105,7 @@ This will render a provided manifest instance-group
 			replicas = podOrdinal + 1
 		}
 
-		return manifest.RenderJobTemplates(boshManifestPath, jobsDir, outputDir, instanceGroupName, specIndex, podIP, replicas, initialRollout)
+		return manifest.RenderJobTemplates(boshManifestPath, deploymentName, jobsDir, outputDir, instanceGroupName, specIndex, podIP, replicas, initialRollout)
 	},
 }
 
@@ -131,6 +137,7 @@ func init() {
 	}
 
 	boshManifestFlagCobraSet(pf, argToEnv)
+	deploymentNameFlagCobraSet(pf, argToEnv)
 	instanceGroupFlagCobraSet(pf, argToEnv)
 	initialRolloutFlagCobraSet(pf, argToEnv)
 	cmd.AddEnvToUsage(templateRenderCmd, argToEnv)
@@ -28,26 +28,33 @@ var _ = Describe("CLI", func() {
 			session, err := act("help")
 			Expect(err).ToNot(HaveOccurred())
 			Eventually(session.Out).Should(Say(`Flags:
-      --apply-crd                                \(APPLY_CRD\) If true, apply CRDs on start \(default true\)
-      --bosh-dns-docker-image string             \(BOSH_DNS_DOCKER_IMAGE\) The docker image used for emulating bosh DNS \(a CoreDNS image\) \(default "coredns/coredns:\d+.\d+.\d+"\)
-  -n, --cf-operator-namespace string             \(CF_OPERATOR_NAMESPACE\) The operator namespace, for the webhook service \(default "default"\)
-      --cluster-domain string                    \(CLUSTER_DOMAIN\) The Kubernetes cluster domain \(default "cluster.local"\)
-      --ctx-timeout int                          \(CTX_TIMEOUT\) context timeout for each k8s API request in seconds \(default 300\)
-  -o, --docker-image-org string                  \(DOCKER_IMAGE_ORG\) Dockerhub organization that provides the operator docker image \(default "cfcontainerization"\)
-      --docker-image-pull-policy string          \(DOCKER_IMAGE_PULL_POLICY\) Image pull policy \(default "IfNotPresent"\)
-  -r, --docker-image-repository string           \(DOCKER_IMAGE_REPOSITORY\) Dockerhub repository that provides the operator docker image \(default "quarks-operator"\)
-  -t, --docker-image-tag string                  \(DOCKER_IMAGE_TAG\) Tag of the operator docker image \(default "\d+.\d+.\d+"\)
-  -h, --help                                     help for quarks-operator
-  -c, --kubeconfig string                        \(KUBECONFIG\) Path to a kubeconfig, not required in-cluster
-  -l, --log-level string                         \(LOG_LEVEL\) Only print log messages from this level onward \(trace,debug,info,warn\) \(default "debug"\)
-  -i, --logrotate-interval int                   \(LOGROTATE_INTERVAL\) Interval between logrotate calls for instance groups in minutes \(default 1440\)
-      --max-boshdeployment-workers int           \(MAX_BOSHDEPLOYMENT_WORKERS\) Maximum number of workers concurrently running BOSHDeployment controller \(default 1\)
-      --meltdown-duration int                    \(MELTDOWN_DURATION\) Duration \(in seconds\) of the meltdown period, in which we postpone further reconciles for the same resource \(default 60\)
-      --meltdown-requeue-after int               \(MELTDOWN_REQUEUE_AFTER\) Duration \(in seconds\) for which we delay the requeuing of the reconcile \(default 30\)
-      --monitored-id string                      \(MONITORED_ID\) only monitor namespaces with this id in their namespace label \(default "default"\)
-  -w, --operator-webhook-service-host string     \(CF_OPERATOR_WEBHOOK_SERVICE_HOST\) Hostname/IP under which the webhook server can be reached from the cluster
-  -p, --operator-webhook-service-port string     \(CF_OPERATOR_WEBHOOK_SERVICE_PORT\) Port the webhook server listens on \(default "2999"\)
-  -x, --operator-webhook-use-service-reference   \(CF_OPERATOR_WEBHOOK_USE_SERVICE_REFERENCE\) If true the webhook service is targeted using a service reference instead of a URL`))
+      --apply-crd                                 \(APPLY_CRD\) If true, apply CRDs on start \(default true\)
+      --bosh-dns-docker-image string              \(BOSH_DNS_DOCKER_IMAGE\) The docker image used for emulating bosh DNS \(a CoreDNS image\) \(default "coredns/coredns:\d+.\d+.\d+"\)
+  -n, --cf-operator-namespace string              \(CF_OPERATOR_NAMESPACE\) The operator namespace, for the webhook service \(default "default"\)
+      --cluster-domain string                     \(CLUSTER_DOMAIN\) The Kubernetes cluster domain \(default "cluster.local"\)
+      --ctx-timeout int                           \(CTX_TIMEOUT\) context timeout for each k8s API request in seconds \(default 300\)
+  -o, --docker-image-org string                   \(DOCKER_IMAGE_ORG\) Dockerhub organization that provides the operator docker image \(default "cfcontainerization"\)
+      --docker-image-pull-policy string           \(DOCKER_IMAGE_PULL_POLICY\) Image pull policy \(default "IfNotPresent"\)
+  -r, --docker-image-repository string            \(DOCKER_IMAGE_REPOSITORY\) Dockerhub repository that provides the operator docker image \(default "quarks-operator"\)
+  -t, --docker-image-tag string                   \(DOCKER_IMAGE_TAG\) Tag of the operator docker image \(default "\d+.\d+.\d+"\)
+  -h, --help                                      help for quarks-operator
+  -c, --kubeconfig string                         \(KUBECONFIG\) Path to a kubeconfig, not required in-cluster
+      --leader-election                           \(LEADER_ELECTION\) Enable leader election, so only one of multiple operator replicas runs the controllers
+      --leader-election-id string                 \(LEADER_ELECTION_ID\) Name of the config map used as the leader election lock \(default "quarks-operator-lock"\)
+      --leader-election-lease-duration duration   \(LEADER_ELECTION_LEASE_DURATION\) Duration non-leader replicas wait before trying to acquire leadership \(default 15s\)
+      --leader-election-namespace string          \(LEADER_ELECTION_NAMESPACE\) Namespace of the leader election lock, defaults to the operator namespace
+      --leader-election-renew-deadline duration   \(LEADER_ELECTION_RENEW_DEADLINE\) Duration the leader retries refreshing leadership before giving up \(default 10s\)
+      --leader-election-retry-period duration     \(LEADER_ELECTION_RETRY_PERIOD\) Duration between leader election actions \(default 2s\)
+  -l, --log-level string                          \(LOG_LEVEL\) Only print log messages from this level onward \(trace,debug,info,warn\) \(default "debug"\)
+  -i, --logrotate-interval int                    \(LOGROTATE_INTERVAL\) Interval between logrotate calls for instance groups in minutes \(default 1440\)
+      --max-boshdeployment-workers int            \(MAX_BOSHDEPLOYMENT_WORKERS\) Maximum number of workers concurrently running BOSHDeployment controller \(default 1\)
+      --meltdown-duration int                     \(MELTDOWN_DURATION\) Duration \(in seconds\) of the meltdown period, in which we postpone further reconciles for the same resource \(default 60\)
+      --meltdown-requeue-after int                \(MELTDOWN_REQUEUE_AFTER\) Duration \(in seconds\) for which we delay the requeuing of the reconcile \(default 30\)
+      --metrics-bind-address string               \(METRICS_BIND_ADDRESS\) Address the prometheus metrics endpoint binds to, e.g. ':60000'. Use '0' to disable it \(default "0"\)
+      --monitored-id string                       \(MONITORED_ID\) only monitor namespaces with this id in their namespace label \(default "default"\)
+  -w, --operator-webhook-service-host string      \(CF_OPERATOR_WEBHOOK_SERVICE_HOST\) Hostname/IP under which the webhook server can be reached from the cluster
+  -p, --operator-webhook-service-port string      \(CF_OPERATOR_WEBHOOK_SERVICE_PORT\) Port the webhook server listens on \(default "2999"\)
+  -x, --operator-webhook-use-service-reference    \(CF_OPERATOR_WEBHOOK_USE_SERVICE_REFERENCE\) If true the webhook service is targeted using a service reference instead of a URL`))
 		})
 
 		It("shows all available commands", func() {
@@ -177,6 +184,29 @@ var _ = Describe("CLI", func() {
 		})
 	})
 
+	Describe("render", func() {
+		It("lists its flags incl. ENV binding", func() {
+			session, err := act("util", "render", "-h")
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(session.Out).Should(Say(`Flags:
+  -b, --base-dir string             \(BASE_DIR\) a path to the base directory
+  -m, --bosh-manifest-path string   \(BOSH_MANIFEST_PATH\) path to the bosh manifest file
+  -n, --deployment-name string      \(DEPLOYMENT_NAME\) name of the bdpl resource
+      --diff                        compare the rendered resources with the live objects in the cluster
+  -h, --help                        help for render
+      --initial-rollout             \(INITIAL_ROLLOUT\) Initial rollout of bosh deployment. \(default true\)
+      --namespace string            namespace of the deployment \(default "default"\)
+  -o, --ops-file strings            path to an ops file, can be repeated
+  -l, --vars-file strings           path to a vars file, can be repeated`))
+		})
+
+		It("accepts the bosh-manifest-path as a parameter", func() {
+			session, err := act("util", "render", "--base-dir=.", "-n", "foo", "-m", "foo.txt")
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(session.Err).Should(Say("open foo.txt: no such file or directory"))
+		})
+	})
+
 	Describe("template-render", func() {
 		It("lists its flags incl. ENV binding", func() {
 			session, err := act("util", "template-render", "-h")
@@ -184,6 +214,7 @@ var _ = Describe("CLI", func() {
 			Eventually(session.Out).Should(Say(`Flags:
       --az-index int                 \(AZ_INDEX\) az index \(default -1\)
   -m, --bosh-manifest-path string    \(BOSH_MANIFEST_PATH\) path to the bosh manifest file
+  -n, --deployment-name string       \(DEPLOYMENT_NAME\) name of the bdpl resource
   -h, --help                         help for template-render
       --initial-rollout              \(INITIAL_ROLLOUT\) Initial rollout of bosh deployment. \(default true\)
   -g, --instance-group-name string   \(INSTANCE_GROUP_NAME\) name of the instance group for data gathering
@@ -201,6 +232,7 @@ var _ = Describe("CLI", func() {
 				"--az-index=1",
 				"--replicas=1",
 				"--pod-ordinal=1",
+				"-n", "foo",
 				"-m", "foo.txt",
 				"-g", "log-api",
 				"--pod-ip", "127.0.0.1",
@@ -224,6 +256,7 @@ var _ = Describe("CLI", func() {
 					"--az-index=1",
 					"--replicas=1",
 					"--pod-ordinal=1",
+					"-n", "foo",
 					"-g", "log-api",
 					"--pod-ip", "127.0.0.1",
 				)
@@ -32,6 +32,7 @@ var _ = Describe("render-template", func() {
 		args := []string{
 			"util", "template-render",
 			"-m", manifestPath,
+			"-n", "foo",
 			"-j", assetPath,
 			"-g", "log-api",
 			"--az-index", "1",
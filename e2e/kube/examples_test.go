@@ -134,18 +134,18 @@ var _ = Describe("Examples Directory", func() {
 			waitReady("pod/nats-0")
 
 			// Check that we didn't create new secrets
-			sd, err := cmdHelper.GetData(namespace, "secret", "var-nats-password", "go-template={{.data}}")
+			sd, err := cmdHelper.GetData(namespace, "secret", "nats-deployment.var-nats-password", "go-template={{.data}}")
 			Expect(err).ToNot(HaveOccurred())
 			Expect(sd).To(BeEmpty())
-			sd, err = cmdHelper.GetData(namespace, "secret", "var-nats-ca", "go-template={{.data}}")
+			sd, err = cmdHelper.GetData(namespace, "secret", "nats-deployment.var-nats-ca", "go-template={{.data}}")
 			Expect(err).ToNot(HaveOccurred())
 			Expect(sd).To(BeEmpty())
-			sd, err = cmdHelper.GetData(namespace, "secret", "var-nats-cert", "go-template={{.data}}")
+			sd, err = cmdHelper.GetData(namespace, "secret", "nats-deployment.var-nats-cert", "go-template={{.data}}")
 			Expect(err).ToNot(HaveOccurred())
 			Expect(sd).To(BeEmpty())
 
 			// Check that the manifest contains the user's certs and passwords
-			outSecret, err := cmdHelper.GetData(namespace, "secret", "desired-manifest-v1", `go-template={{index .data "manifest.yaml"}}`)
+			outSecret, err := cmdHelper.GetData(namespace, "secret", "nats-deployment.desired-manifest-v1", `go-template={{index .data "manifest.yaml"}}`)
 			Expect(err).ToNot(HaveOccurred())
 			desiredManifest, _ := b64.StdEncoding.DecodeString(string(outSecret))
 			Expect(string(desiredManifest)).To(ContainSubstring("password: deadbeef"))
@@ -36,6 +36,8 @@ var _ = Describe("Quarks Upgrade test", func() {
 	Context("upgrade from latest released chart", func() {
 		var singleNamespace bool
 		selector := "example=owned-by-bdpl"
+		// passwords generated by the released operator, by namespace
+		passwords := map[string]string{}
 
 		upgradeOperatorToCurrent := func(singlenamespace bool) {
 			dir, err := os.Getwd()
@@ -183,6 +185,10 @@ var _ = Describe("Quarks Upgrade test", func() {
 			exists, err = kubectl.PodExists(namespace, "quarks.cloudfoundry.org/deployment-name=gora-test-deployment", "quarks-gora-0")
 			Expect(err).ToNot(HaveOccurred())
 			Expect(exists).To(BeTrue())
+
+			password, err := cmdHelper.GetData(namespace, "secret", "var-gora-password", "go-template={{.data.password}}")
+			Expect(err).ToNot(HaveOccurred())
+			passwords[namespace] = string(password)
 		}
 
 		checkUpgrade := func(namespace string) {
@@ -200,7 +206,7 @@ var _ = Describe("Quarks Upgrade test", func() {
 
 			By("Checking autoerrand was executed")
 			// Check autoerrand was executed
-			err = kubectl.WaitLabelFilter(namespace, "complete", "pod", "quarks.cloudfoundry.org/qjob-name=autoerrand")
+			err = kubectl.WaitLabelFilter(namespace, "complete", "pod", "quarks.cloudfoundry.org/qjob-name=gora-test-deployment-autoerrand")
 			Expect(err).ToNot(HaveOccurred())
 
 			Eventually(func() int {
@@ -220,14 +226,13 @@ var _ = Describe("Quarks Upgrade test", func() {
 			// Try scaling
 			scale(namespace, "3")
 			waitReady(namespace, "pod/quarks-gora-2")
-			err = kubectl.WaitForService(namespace, "quarks-gora-2")
+			err = kubectl.WaitForService(namespace, "gora-test-deployment-quarks-gora-2")
 			Expect(err).ToNot(HaveOccurred())
 
 			By("Checking if secrets are still present")
-			for _, s := range []string{"link-quarks-gora-server-data",
-				"link-quarks-gora-server-data",
-				"var-example-cert",
-				"var-gora-password",
+			for _, s := range []string{"link-gora-test-deployment-quarks-gora-server-data",
+				"gora-test-deployment.var-example-cert",
+				"gora-test-deployment.var-gora-password",
 				"var-quarks-gora-ssl",
 				"var-quarks-gora-ssl-ca",
 				"var-user-provided-password",
@@ -237,17 +242,22 @@ var _ = Describe("Quarks Upgrade test", func() {
 				Expect(exists).To(BeTrue(), "secret '%s' doesn't exist", s)
 			}
 
+			By("Checking generated variables kept their values")
+			password, err := cmdHelper.GetData(namespace, "secret", "gora-test-deployment.var-gora-password", "go-template={{.data.password}}")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(password)).To(Equal(passwords[namespace]))
+
 			// Check autoerrand was executed
-			err = kubectl.WaitLabelFilter(namespace, "complete", "pod", "quarks.cloudfoundry.org/qjob-name=autoerrand")
+			err = kubectl.WaitLabelFilter(namespace, "complete", "pod", "quarks.cloudfoundry.org/qjob-name=gora-test-deployment-autoerrand")
 			Expect(err).ToNot(HaveOccurred())
 
 			By("Running quarks-gora smoke tests from the current quarks code")
 			// Run smoke tests again (manual-errand) after the quarks upgrade to verify that certificates and variables interpolation are working
 			// as expected, and our deployment is still accessible
-			err = cmdHelper.TriggerQJob(namespace, "smoke")
+			err = cmdHelper.TriggerQJob(namespace, "gora-test-deployment-smoke")
 			Expect(err).ToNot(HaveOccurred())
 
-			err = kubectl.WaitLabelFilter(namespace, "complete", "pod", "quarks.cloudfoundry.org/qjob-name=smoke")
+			err = kubectl.WaitLabelFilter(namespace, "complete", "pod", "quarks.cloudfoundry.org/qjob-name=gora-test-deployment-smoke")
 			Expect(err).ToNot(HaveOccurred())
 		}
 
@@ -83,7 +83,7 @@ var _ = Describe("BDPL updates", func() {
 			})
 
 			It("should create a new secret for the variable", func() {
-				err := env.WaitForSecret(env.Namespace, "var-nats-password")
+				err := env.WaitForSecret(env.Namespace, "test.var-nats-password")
 				Expect(err).NotTo(HaveOccurred(), "error waiting for new generated variable secret")
 			})
 		})
@@ -146,7 +146,7 @@ var _ = Describe("BDPL updates", func() {
 			})
 
 			It("should update the service with new port", func() {
-				err := env.WaitForSecret(env.Namespace, "bpm.nats-v2")
+				err := env.WaitForSecret(env.Namespace, "test.bpm.nats-v2")
 				Expect(err).NotTo(HaveOccurred(), "error waiting for new bpm config")
 
 				err = env.WaitForServiceVersion(env.Namespace, "nats", "2")
@@ -394,7 +394,7 @@ var _ = Describe("BDPL updates", func() {
 
 		Context("unnecessary secret updates should not happen", func() {
 			It("update the instance group", func() {
-				secret, err := env.GetSecret(env.Namespace, "var-nats-password")
+				secret, err := env.GetSecret(env.Namespace, "test.var-nats-password")
 				Expect(err).NotTo(HaveOccurred(), "error getting var-nats-password secret")
 				passwordv1 := string(secret.Data["password"])
 
@@ -413,7 +413,7 @@ var _ = Describe("BDPL updates", func() {
 				err = env.WaitForInstanceGroup(env.Namespace, deploymentName, "nats", "2", 2)
 				Expect(err).NotTo(HaveOccurred(), "error waiting for instance group pods from deployment")
 
-				secret, err = env.GetSecret(env.Namespace, "var-nats-password")
+				secret, err = env.GetSecret(env.Namespace, "test.var-nats-password")
 				Expect(err).NotTo(HaveOccurred(), "error getting var-nats-password secret")
 				passwordv2 := string(secret.Data["password"])
 				Expect(passwordv1).To(Equal(passwordv2))
@@ -434,10 +434,10 @@ var _ = Describe("BDPL updates", func() {
 			})
 
 			It("should use the value from the user's secret", func() {
-				err := env.WaitForSecret(env.Namespace, "desired-manifest-v2")
+				err := env.WaitForSecret(env.Namespace, "test.desired-manifest-v2")
 				Expect(err).NotTo(HaveOccurred(), "error waiting for new desired manifest")
 
-				secret, err := env.GetSecret(env.Namespace, "desired-manifest-v2")
+				secret, err := env.GetSecret(env.Namespace, "test.desired-manifest-v2")
 				Expect(err).NotTo(HaveOccurred(), "error getting new desired manifest")
 
 				manifest := string(secret.Data["manifest.yaml"])
@@ -446,17 +446,17 @@ var _ = Describe("BDPL updates", func() {
 			})
 
 			It("should update when the user's secret changes", func() {
-				err := env.WaitForSecret(env.Namespace, "desired-manifest-v2")
+				err := env.WaitForSecret(env.Namespace, "test.desired-manifest-v2")
 				Expect(err).NotTo(HaveOccurred(), "error waiting for new desired manifest")
 
 				_, tearDown, err := env.UpdateSecret(env.Namespace, env.UserExplicitPassword("my-var", "anothersupersecret"))
 				Expect(err).NotTo(HaveOccurred(), "error updating user var")
 				tearDowns = append(tearDowns, tearDown)
 
-				err = env.WaitForSecret(env.Namespace, "desired-manifest-v3")
+				err = env.WaitForSecret(env.Namespace, "test.desired-manifest-v3")
 				Expect(err).NotTo(HaveOccurred(), "error waiting for new desired manifest")
 
-				secret, err := env.GetSecret(env.Namespace, "desired-manifest-v3")
+				secret, err := env.GetSecret(env.Namespace, "test.desired-manifest-v3")
 				Expect(err).NotTo(HaveOccurred(), "error getting new desired manifest")
 
 				manifest := string(secret.Data["manifest.yaml"])
@@ -532,7 +532,7 @@ var _ = Describe("BDPL updates", func() {
 		Context("by rotating the explicit secret", func() {
 			BeforeEach(func() {
 				qsecCatalog := qsecm.Catalog{}
-				rotationConfig := qsecCatalog.RotationConfig("var-nats-password")
+				rotationConfig := qsecCatalog.RotationConfig("test.var-nats-password")
 				tearDown, err := env.CreateConfigMap(env.Namespace, rotationConfig)
 				Expect(err).NotTo(HaveOccurred())
 				tearDowns = append(tearDowns, tearDown)
@@ -581,7 +581,7 @@ var _ = Describe("BDPL updates", func() {
 				if err != nil {
 					return err
 				}
-				if pod.Spec.Volumes[4].Secret.SecretName != "ig-resolved.nats-v2" {
+				if pod.Spec.Volumes[4].Secret.SecretName != "test.ig-resolved.nats-v2" {
 					return fmt.Errorf("wrong ig resolved secret version")
 				}
 				Expect(pod.Spec.InitContainers[2].VolumeMounts[2].Name).To(Equal("ig-resolved"))
@@ -590,7 +590,7 @@ var _ = Describe("BDPL updates", func() {
 				if err != nil {
 					return err
 				}
-				if pod.Spec.Volumes[4].Secret.SecretName != "ig-resolved.route-registrar-v2" {
+				if pod.Spec.Volumes[4].Secret.SecretName != "test.ig-resolved.route-registrar-v2" {
 					return fmt.Errorf("wrong ig resolved secret version")
 				}
 				Expect(pod.Spec.InitContainers[2].VolumeMounts[2].Name).To(Equal("ig-resolved"))
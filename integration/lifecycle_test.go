@@ -37,9 +37,9 @@ var _ = Describe("Lifecycle", func() {
 			defer func(tdf machine.TearDownFunc) { Expect(tdf()).To(Succeed()) }(tearDown)
 
 			By("checking for desired manifest secret and its owner reference")
-			err = env.WaitForSecret(env.Namespace, "desired-manifest-v1")
+			err = env.WaitForSecret(env.Namespace, "test.desired-manifest-v1")
 			Expect(err).NotTo(HaveOccurred(), "error waiting for new desired manifest")
-			secret, err := env.GetSecret(env.Namespace, "desired-manifest-v1")
+			secret, err := env.GetSecret(env.Namespace, "test.desired-manifest-v1")
 			Expect(err).NotTo(HaveOccurred(), "error getting new desired manifest")
 			ownerReference := secret.GetOwnerReferences()[0]
 			Expect(ownerReference.Kind).To(Equal(bdv1.BOSHDeploymentResourceKind))
@@ -8,6 +8,7 @@ import (
 	"github.com/imdario/mergo"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"sigs.k8s.io/yaml"
 )
 
@@ -192,12 +193,36 @@ func indexOfBPMProcess(processes []Process, processName string) (int, bool) {
 	return -1, false
 }
 
-// ValidateProcesses checks if all processes have an executable
+// ValidateProcesses checks if all processes have an executable and valid limits
 func (c Config) ValidateProcesses() error {
 	for _, process := range c.Processes {
 		if process.Executable == "" {
 			return errors.Errorf("no executable specified for process %s", process.Name)
 		}
+		if err := process.Limits.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid limits for process %s", process.Name)
+		}
+	}
+	return nil
+}
+
+// Validate checks if the limits can be applied to a container
+func (l Limits) Validate() error {
+	if l.Memory != "" {
+		if _, err := resource.ParseQuantity(l.Memory); err != nil {
+			return errors.Wrapf(err, "invalid memory limit '%s'", l.Memory)
+		}
+	}
+	if l.CPU != "" {
+		if _, err := resource.ParseQuantity(l.CPU); err != nil {
+			return errors.Wrapf(err, "invalid cpu limit '%s'", l.CPU)
+		}
+	}
+	if l.OpenFiles < 0 {
+		return errors.Errorf("invalid open_files limit '%d', must not be negative", l.OpenFiles)
+	}
+	if l.Processes < 0 {
+		return errors.Errorf("invalid processes limit '%d', must not be negative", l.Processes)
 	}
 	return nil
 }
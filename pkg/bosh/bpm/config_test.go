@@ -83,6 +83,46 @@ var _ = Describe("BPM Config", func() {
 		})
 	})
 
+	Describe("ValidateProcesses", func() {
+		var config bpm.Config
+
+		BeforeEach(func() {
+			config = bpm.Config{
+				Processes: []bpm.Process{
+					{
+						Name:       "server",
+						Executable: "/var/vcap/packages/server/bin/serve",
+						Limits:     bpm.Limits{Memory: "1G", CPU: "2", OpenFiles: 100000, Processes: 10},
+					},
+				},
+			}
+		})
+
+		It("accepts valid limits", func() {
+			Expect(config.ValidateProcesses()).To(Succeed())
+		})
+
+		It("fails for processes without an executable", func() {
+			config.Processes[0].Executable = ""
+			Expect(config.ValidateProcesses()).To(MatchError("no executable specified for process server"))
+		})
+
+		It("fails for invalid memory limits", func() {
+			config.Processes[0].Limits.Memory = "1 gigabyte"
+			Expect(config.ValidateProcesses()).To(MatchError(ContainSubstring("invalid limits for process server: invalid memory limit '1 gigabyte'")))
+		})
+
+		It("fails for negative open files limits", func() {
+			config.Processes[0].Limits.OpenFiles = -1
+			Expect(config.ValidateProcesses()).To(MatchError(ContainSubstring("invalid open_files limit '-1'")))
+		})
+
+		It("fails for negative processes limits", func() {
+			config.Processes[0].Limits.Processes = -1
+			Expect(config.ValidateProcesses()).To(MatchError(ContainSubstring("invalid processes limit '-1'")))
+		})
+	})
+
 	Describe("NewEnvs", func() {
 		var (
 			process   bpm.Process
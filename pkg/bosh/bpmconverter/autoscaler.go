@@ -0,0 +1,96 @@
+package bpmconverter
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	bdm "code.cloudfoundry.org/quarks-operator/pkg/bosh/manifest"
+	qstsv1a1 "code.cloudfoundry.org/quarks-statefulset/pkg/kube/apis/quarksstatefulset/v1alpha1"
+	"code.cloudfoundry.org/quarks-utils/pkg/pointers"
+)
+
+// defaultTargetCPUUtilization is used, if autoscaling doesn't set any target
+const defaultTargetCPUUtilization = int32(80)
+
+// autoscale returns a horizontal pod autoscaler for each stateful set of the
+// quarks stateful set. The instances of the instance group are the initial
+// replicas. The replica count isn't injected into the pods, so scaling
+// doesn't restart them.
+func autoscale(namespace string, instanceGroup *bdm.InstanceGroup, qSts *qstsv1a1.QuarksStatefulSet) ([]autoscalingv2beta2.HorizontalPodAutoscaler, error) {
+	autoscaling := instanceGroup.Env.AgentEnvBoshConfig.Agent.Settings.Autoscaling
+	if err := autoscaling.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid autoscaling for instance group '%s'", instanceGroup.Name)
+	}
+
+	replicas := int32(instanceGroup.Instances)
+	if replicas < autoscaling.MinReplicas {
+		replicas = autoscaling.MinReplicas
+	}
+	if replicas > autoscaling.MaxReplicas {
+		replicas = autoscaling.MaxReplicas
+	}
+	qSts.Spec.Template.Spec.Replicas = &replicas
+	qSts.Spec.InjectReplicasEnv = pointers.Bool(false)
+
+	metrics := []autoscalingv2beta2.MetricSpec{}
+	if autoscaling.TargetCPUUtilization != nil || autoscaling.TargetMemoryUtilization == nil {
+		target := defaultTargetCPUUtilization
+		if autoscaling.TargetCPUUtilization != nil {
+			target = *autoscaling.TargetCPUUtilization
+		}
+		metrics = append(metrics, utilizationMetric(corev1.ResourceCPU, target))
+	}
+	if autoscaling.TargetMemoryUtilization != nil {
+		metrics = append(metrics, utilizationMetric(corev1.ResourceMemory, *autoscaling.TargetMemoryUtilization))
+	}
+
+	// The quarks stateful set creates a stateful set per zone
+	statefulSetNames := []string{qSts.Name}
+	if len(qSts.Spec.Zones) > 0 {
+		statefulSetNames = []string{}
+		for i := range qSts.Spec.Zones {
+			statefulSetNames = append(statefulSetNames, fmt.Sprintf("%s-z%d", qSts.Name, i))
+		}
+	}
+
+	autoscalers := []autoscalingv2beta2.HorizontalPodAutoscaler{}
+	for _, name := range statefulSetNames {
+		autoscalers = append(autoscalers, autoscalingv2beta2.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    FilterLabels(instanceGroup.Env.AgentEnvBoshConfig.Agent.Settings.Labels),
+			},
+			Spec: autoscalingv2beta2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2beta2.CrossVersionObjectReference{
+					APIVersion: "apps/v1",
+					Kind:       "StatefulSet",
+					Name:       name,
+				},
+				MinReplicas: pointers.Int32(autoscaling.MinReplicas),
+				MaxReplicas: autoscaling.MaxReplicas,
+				Metrics:     metrics,
+			},
+		})
+	}
+
+	return autoscalers, nil
+}
+
+func utilizationMetric(name corev1.ResourceName, target int32) autoscalingv2beta2.MetricSpec {
+	return autoscalingv2beta2.MetricSpec{
+		Type: autoscalingv2beta2.ResourceMetricSourceType,
+		Resource: &autoscalingv2beta2.ResourceMetricSource{
+			Name: name,
+			Target: autoscalingv2beta2.MetricTarget{
+				Type:               autoscalingv2beta2.UtilizationMetricType,
+				AverageUtilization: pointers.Int32(target),
+			},
+		},
+	}
+}
@@ -0,0 +1,175 @@
+package bpmconverter
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+
+	bdm "code.cloudfoundry.org/quarks-operator/pkg/bosh/manifest"
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/names"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/zones"
+	qstsv1a1 "code.cloudfoundry.org/quarks-statefulset/pkg/kube/apis/quarksstatefulset/v1alpha1"
+)
+
+// applyCloudConfig applies the vm type, vm extensions and availability zones
+// of the cloud config, which the instance group refers to, to its resources
+func applyCloudConfig(res *Resources, cc *bdm.CloudConfig, namespace string, deploymentName string, instanceGroup *bdm.InstanceGroup, ports []corev1.ServicePort) error {
+	if cc == nil {
+		return nil
+	}
+
+	vmType, err := cc.VMType(instanceGroup.VMType)
+	if err != nil {
+		return errors.Wrapf(err, "instance group '%s'", instanceGroup.Name)
+	}
+
+	extensions := []*bdm.VMExtension{}
+	for _, name := range instanceGroup.VMExtensions {
+		extension, err := cc.VMExtension(name)
+		if err != nil {
+			return errors.Wrapf(err, "instance group '%s'", instanceGroup.Name)
+		}
+		if extension != nil {
+			extensions = append(extensions, extension)
+		}
+	}
+
+	for i := range res.InstanceGroups {
+		qSts := &res.InstanceGroups[i]
+		if len(instanceGroup.AZs) > 0 {
+			qSts.Spec.Zones, err = cloudConfigZones(cc, instanceGroup.AZs)
+			if err != nil {
+				return errors.Wrapf(err, "instance group '%s'", instanceGroup.Name)
+			}
+		}
+		if err := applyVMConfig(&qSts.Spec.Template.Spec.Template, vmType, extensions); err != nil {
+			return errors.Wrapf(err, "instance group '%s'", instanceGroup.Name)
+		}
+
+		if len(ports) == 0 {
+			continue
+		}
+		for _, extension := range extensions {
+			if extension.CloudProperties.Service != nil {
+				res.Services = append(res.Services, extensionService(namespace, deploymentName, instanceGroup, qSts, extension, ports))
+			}
+		}
+	}
+	for i := range res.Errands {
+		if err := applyVMConfig(&res.Errands[i].Spec.Template.Spec.Template, vmType, extensions); err != nil {
+			return errors.Wrapf(err, "instance group '%s'", instanceGroup.Name)
+		}
+	}
+	for i := range res.ScheduledErrands {
+		if err := applyVMConfig(&res.ScheduledErrands[i].Spec.JobTemplate.Spec.Template, vmType, extensions); err != nil {
+			return errors.Wrapf(err, "instance group '%s'", instanceGroup.Name)
+		}
+	}
+
+	return nil
+}
+
+// cloudConfigZones returns the zones of the availability zones. Availability
+// zones without a zone in the cloud config are mapped by the operator's
+// zone mapping.
+func cloudConfigZones(cc *bdm.CloudConfig, azs []string) ([]string, error) {
+	result := zones.Zones(azs)
+	for i, name := range azs {
+		az, err := cc.AZ(name)
+		if err != nil {
+			return nil, err
+		}
+		if az != nil && az.CloudProperties.Zone != "" {
+			result[i] = az.CloudProperties.Zone
+		}
+	}
+	return result, nil
+}
+
+// applyVMConfig applies a vm type and vm extensions to a pod template. The
+// resources of the vm type are divided across the job containers by the
+// container factory.
+func applyVMConfig(pod *corev1.PodTemplateSpec, vmType *bdm.VMType, extensions []*bdm.VMExtension) error {
+	if vmType != nil {
+		props := vmType.CloudProperties
+		if len(props.NodeSelector) > 0 {
+			pod.Spec.NodeSelector = labels.Merge(props.NodeSelector, pod.Spec.NodeSelector)
+		}
+		pod.Spec.Tolerations = append(pod.Spec.Tolerations, props.Tolerations...)
+	}
+
+	for _, extension := range extensions {
+		props := extension.CloudProperties
+		// Merge into new maps, pod labels are shared with selectors
+		pod.Labels = labels.Merge(pod.Labels, props.Labels)
+		pod.Annotations = labels.Merge(pod.Annotations, props.Annotations)
+
+		if len(props.PodTemplate) == 0 {
+			continue
+		}
+		if err := patchPodTemplate(pod, props.PodTemplate); err != nil {
+			return errors.Wrapf(err, "failed to apply pod template of vm extension '%s'", extension.Name)
+		}
+	}
+
+	return nil
+}
+
+// patchPodTemplate applies a strategic merge patch to the pod template
+func patchPodTemplate(pod *corev1.PodTemplateSpec, patch map[string]interface{}) error {
+	original, err := json.Marshal(pod)
+	if err != nil {
+		return err
+	}
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	patched, err := strategicpatch.StrategicMergePatch(original, patchBytes, corev1.PodTemplateSpec{})
+	if err != nil {
+		return err
+	}
+
+	result := corev1.PodTemplateSpec{}
+	if err := json.Unmarshal(patched, &result); err != nil {
+		return err
+	}
+	*pod = result
+	return nil
+}
+
+// extensionService exposes the pods of an instance group with the service of a vm extension
+func extensionService(namespace string, deploymentName string, instanceGroup *bdm.InstanceGroup, qSts *qstsv1a1.QuarksStatefulSet, extension *bdm.VMExtension, ports []corev1.ServicePort) corev1.Service {
+	props := extension.CloudProperties.Service
+	selector := map[string]string{
+		bdv1.LabelDeploymentName:    deploymentName,
+		bdv1.LabelInstanceGroupName: instanceGroup.Name,
+	}
+	if len(qSts.Spec.ActivePassiveProbes) > 0 {
+		selector[qstsv1a1.LabelActivePod] = "active"
+	}
+
+	return corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      names.ExtensionServiceName(deploymentName, instanceGroup.Name, extension.Name),
+			Namespace: namespace,
+			Labels: labels.Merge(
+				instanceGroup.Env.AgentEnvBoshConfig.Agent.Settings.Labels,
+				map[string]string{bdv1.LabelInstanceGroupName: instanceGroup.Name},
+			),
+			Annotations: props.Annotations,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:                     props.Type,
+			Ports:                    ports,
+			Selector:                 selector,
+			LoadBalancerSourceRanges: props.LoadBalancerSourceRanges,
+			ExternalTrafficPolicy:    props.ExternalTrafficPolicy,
+		},
+	}
+}
@@ -13,6 +13,7 @@ import (
 	qjv1a1 "code.cloudfoundry.org/quarks-job/pkg/kube/apis/quarksjob/v1alpha1"
 	"code.cloudfoundry.org/quarks-operator/pkg/bosh/bpm"
 	bdm "code.cloudfoundry.org/quarks-operator/pkg/bosh/manifest"
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/logrotate"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/operatorimage"
 	"code.cloudfoundry.org/quarks-utils/pkg/names"
@@ -31,6 +32,14 @@ var (
 			},
 		},
 	}
+	deploymentNameEnv = corev1.EnvVar{
+		Name: EnvDeploymentName,
+		ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{
+				FieldPath: fmt.Sprintf("metadata.labels['%s']", bdv1.LabelDeploymentName),
+			},
+		},
+	}
 	replicasEnv = corev1.EnvVar{
 		Name:  EnvReplicas,
 		Value: "1",
@@ -56,16 +65,19 @@ type ContainerFactoryImpl struct {
 	disableLogSidecar    bool
 	releaseImageProvider bdm.ReleaseImageProvider
 	bpmConfigs           bpm.Configs
+	resources            corev1.ResourceRequirements
 }
 
 // NewContainerFactory returns a concrete implementation of ContainerFactory.
-func NewContainerFactory(instanceGroupName string, version string, disableLogSidecar bool, releaseImageProvider bdm.ReleaseImageProvider, bpmConfigs bpm.Configs) *ContainerFactoryImpl {
+// The resources of the instance group are divided across the job containers.
+func NewContainerFactory(instanceGroupName string, version string, disableLogSidecar bool, releaseImageProvider bdm.ReleaseImageProvider, bpmConfigs bpm.Configs, resources corev1.ResourceRequirements) *ContainerFactoryImpl {
 	return &ContainerFactoryImpl{
 		instanceGroupName:    instanceGroupName,
 		version:              version,
 		disableLogSidecar:    disableLogSidecar,
 		releaseImageProvider: releaseImageProvider,
 		bpmConfigs:           bpmConfigs,
+		resources:            resources,
 	}
 }
 
@@ -112,6 +124,7 @@ func (c *ContainerFactoryImpl) JobsToInitContainers(
 		if len(persistentDiskDisks) > 0 {
 			persistentDiskMount = persistentDiskDisks[0].VolumeMount
 		}
+		namedPersistentDiskMounts := jobDisks.Filter("named_persistent", "true").VolumeMounts()
 
 		for _, process := range bpmConfig.Processes {
 			if process.Hooks.PreStart != "" {
@@ -127,6 +140,7 @@ func (c *ContainerFactoryImpl) JobsToInitContainers(
 				if persistentDiskMount != nil {
 					processVolumeMounts = append(processVolumeMounts, *persistentDiskMount)
 				}
+				processVolumeMounts = append(processVolumeMounts, namedPersistentDiskMounts...)
 				container := bpmPreStartInitContainer(
 					process,
 					jobImage,
@@ -216,6 +230,7 @@ func (c *ContainerFactoryImpl) JobsToContainers(
 		if len(persistentDiskDisks) > 0 {
 			persistentDiskMount = persistentDiskDisks[0].VolumeMount
 		}
+		namedPersistentDiskMounts := jobDisks.Filter("named_persistent", "true").VolumeMounts()
 
 		for processIndex, process := range bpmConfig.Processes {
 			processDisks := jobDisks.Filter("process_name", process.Name)
@@ -230,6 +245,7 @@ func (c *ContainerFactoryImpl) JobsToContainers(
 			if persistentDiskMount != nil {
 				processVolumeMounts = append(processVolumeMounts, *persistentDiskMount)
 			}
+			processVolumeMounts = append(processVolumeMounts, namedPersistentDiskMounts...)
 
 			// The post-start script should be executed only once per job, so we set it up in the first
 			// process container.
@@ -267,6 +283,17 @@ func (c *ContainerFactoryImpl) JobsToContainers(
 		}
 	}
 
+	// The resources of the instance group are defaults for the job
+	// containers, resources of BPM processes take precedence. Default
+	// requests never exceed the limits of a container.
+	requests := divideResources(c.resources.Requests, len(containers))
+	limits := divideResources(c.resources.Limits, len(containers))
+	for i := range containers {
+		containers[i].Resources.Limits = mergeResources(containers[i].Resources.Limits, limits)
+		defaults := capResources(requests, containers[i].Resources.Limits)
+		containers[i].Resources.Requests = mergeResources(containers[i].Resources.Requests, defaults)
+	}
+
 	// When disableLogSidecar is true, it will stop
 	// appending the sidecar, default behaviour is to
 	// colocate it always in the pod.
@@ -370,6 +397,7 @@ func templateRenderingContainer(instanceGroupName string, initialRollout bool) c
 				Name:  qjv1a1.RemoteIDKey,
 				Value: instanceGroupName,
 			},
+			deploymentNameEnv,
 			{
 				Name:  EnvBOSHManifestPath,
 				Value: fmt.Sprintf(resolvedPropertiesFormat+"/properties.yaml", instanceGroupName),
@@ -662,6 +690,52 @@ echo "Done"`,
 	return container, nil
 }
 
+// divideResources divides the resources into n equal shares
+func divideResources(resources corev1.ResourceList, n int) corev1.ResourceList {
+	if len(resources) == 0 || n == 0 {
+		return nil
+	}
+	result := corev1.ResourceList{}
+	for name, quantity := range resources {
+		if name == corev1.ResourceCPU {
+			result[name] = *resource.NewMilliQuantity(quantity.MilliValue()/int64(n), quantity.Format)
+			continue
+		}
+		result[name] = *resource.NewQuantity(quantity.Value()/int64(n), quantity.Format)
+	}
+	return result
+}
+
+// capResources returns the resources, lowered to the limits
+func capResources(resources corev1.ResourceList, limits corev1.ResourceList) corev1.ResourceList {
+	if len(resources) == 0 {
+		return resources
+	}
+	result := corev1.ResourceList{}
+	for name, quantity := range resources {
+		if limit, ok := limits[name]; ok && quantity.Cmp(limit) > 0 {
+			quantity = limit
+		}
+		result[name] = quantity.DeepCopy()
+	}
+	return result
+}
+
+// mergeResources returns the resources, with defaults for missing ones
+func mergeResources(resources corev1.ResourceList, defaults corev1.ResourceList) corev1.ResourceList {
+	if len(defaults) == 0 {
+		return resources
+	}
+	result := corev1.ResourceList{}
+	for name, quantity := range defaults {
+		result[name] = quantity.DeepCopy()
+	}
+	for name, quantity := range resources {
+		result[name] = quantity
+	}
+	return result
+}
+
 // defaultEnv adds the default value if no value is set
 func defaultEnv(envs []corev1.EnvVar, defaults map[string]corev1.EnvVar) []corev1.EnvVar {
 	for _, env := range envs {
@@ -728,8 +802,29 @@ func generateBPMCommand(
 	args = append(args, "--job-name", jobName)
 	args = append(args, "--process-name", process.Name)
 	args = append(args, "--")
+	args = append(args, rlimitArgs(process.Limits)...)
 	args = append(args, process.Executable)
 	args = append(args, process.Args...)
 
 	return command, args
 }
+
+// rlimitArgs returns a shell command, which sets the open files and
+// processes limits of the BPM process before executing it. The process
+// isn't started, if a limit can't be set.
+// Bash sets the processes limit with `-u`, dash with `-p`.
+func rlimitArgs(limits bpm.Limits) []string {
+	ulimits := []string{}
+	if limits.OpenFiles > 0 {
+		ulimits = append(ulimits, fmt.Sprintf("ulimit -n %d", limits.OpenFiles))
+	}
+	if limits.Processes > 0 {
+		ulimits = append(ulimits, fmt.Sprintf("{ ulimit -u %d 2>/dev/null || ulimit -p %d; }", limits.Processes, limits.Processes))
+	}
+	if len(ulimits) == 0 {
+		return ulimits
+	}
+
+	script := strings.Join(append(ulimits, `exec "$@"`), " && ")
+	return []string{"/bin/sh", "-c", script, "rlimit"}
+}
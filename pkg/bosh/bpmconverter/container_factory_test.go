@@ -2,7 +2,9 @@ package bpmconverter_test
 
 import (
 	"fmt"
+	"os/exec"
 	"path"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/api/resource"
 
@@ -165,7 +167,7 @@ var _ = Describe("ContainerFactory", func() {
 	})
 
 	JustBeforeEach(func() {
-		containerFactory = NewContainerFactory("fake-ig", "v1", false, releaseImageProvider, bpmConfigs)
+		containerFactory = NewContainerFactory("fake-ig", "v1", false, releaseImageProvider, bpmConfigs, corev1.ResourceRequirements{})
 	})
 
 	Context("JobsToContainers", func() {
@@ -284,6 +286,80 @@ var _ = Describe("ContainerFactory", func() {
 				}))
 		})
 
+		It("adds the named persistent disks consumed by the job", func() {
+			namedDiskMount := corev1.VolumeMount{
+				Name:      "fake-instance-group-name-data-pvc",
+				MountPath: fmt.Sprintf("%s/%s", VolumeStoreDirMountPath, "data"),
+			}
+			bpmDisks = append(bpmDisks, bdm.Disk{
+				VolumeMount: &namedDiskMount,
+				Filters: map[string]string{
+					"job_name":         "fake-job",
+					"named_persistent": "true",
+				},
+			})
+
+			containers, err := act()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(containers[0].VolumeMounts).To(ContainElement(namedDiskMount))
+			Expect(containers[1].VolumeMounts).ToNot(ContainElement(namedDiskMount))
+		})
+
+		It("divides the resources of the instance group across the job containers", func() {
+			process := bpmConfigs["other-job"].Processes[0]
+			process.Requests = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}
+			process.Limits.CPU = "2"
+			bpmConfigs["other-job"] = bpm.Config{Processes: []bpm.Process{process}}
+			containerFactory = NewContainerFactory("fake-ig", "v1", false, releaseImageProvider, bpmConfigs, corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("1"),
+					corev1.ResourceMemory: resource.MustParse("3Gi"),
+				},
+				Limits: corev1.ResourceList{
+					corev1.ResourceCPU: resource.MustParse("3"),
+				},
+			})
+
+			containers, err := act()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(containers).To(HaveLen(3))
+
+			Expect(containers[0].Resources.Requests.Cpu().String()).To(Equal("500m"))
+			Expect(containers[0].Resources.Requests.Memory().String()).To(Equal("1536Mi"))
+			Expect(containers[0].Resources.Limits.Cpu().String()).To(Equal("1500m"))
+
+			Expect(containers[1].Resources.Requests.Cpu().String()).To(Equal("500m"))
+			Expect(containers[1].Resources.Requests.Memory().String()).To(Equal("1Gi"))
+			Expect(containers[1].Resources.Limits.Cpu().String()).To(Equal("2"))
+
+			Expect(containers[2].Name).To(Equal("logs"))
+			Expect(containers[2].Resources.Requests).To(BeEmpty())
+		})
+
+		It("caps the share of the instance group's requests at a BPM limit below it", func() {
+			process := bpmConfigs["other-job"].Processes[0]
+			process.Limits.CPU = "250m"
+			process.Limits.Memory = "512Mi"
+			bpmConfigs["other-job"] = bpm.Config{Processes: []bpm.Process{process}}
+			containerFactory = NewContainerFactory("fake-ig", "v1", false, releaseImageProvider, bpmConfigs, corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("1"),
+					corev1.ResourceMemory: resource.MustParse("3Gi"),
+				},
+			})
+
+			containers, err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(containers[0].Resources.Requests.Cpu().String()).To(Equal("500m"))
+			Expect(containers[0].Resources.Requests.Memory().String()).To(Equal("1536Mi"))
+
+			Expect(containers[1].Resources.Requests.Cpu().String()).To(Equal("250m"))
+			Expect(containers[1].Resources.Requests.Memory().String()).To(Equal("512Mi"))
+			Expect(containers[1].Resources.Limits.Cpu().String()).To(Equal("250m"))
+			Expect(containers[1].Resources.Limits.Memory().String()).To(Equal("512Mi"))
+		})
+
 		It("adds the additional volumes", func() {
 			containers, err := act()
 			Expect(err).ToNot(HaveOccurred())
@@ -320,7 +396,7 @@ var _ = Describe("ContainerFactory", func() {
 					},
 				},
 			}
-			containerFactory = NewContainerFactory("fake-ig", "v1", false, releaseImageProvider, bpmConfigsWithError)
+			containerFactory = NewContainerFactory("fake-ig", "v1", false, releaseImageProvider, bpmConfigsWithError, corev1.ResourceRequirements{})
 			actWithError := func() ([]corev1.Container, error) {
 				return containerFactory.JobsToContainers(jobs, []corev1.VolumeMount{}, bdm.Disks{})
 			}
@@ -470,6 +546,47 @@ var _ = Describe("ContainerFactory", func() {
 					""))
 			})
 
+			It("sets the open files and processes limits of the process", func() {
+				config := bpmConfigs["fake-job"]
+				config.Processes[0].Executable = "/var/vcap/packages/fake/bin/fake"
+				config.Processes[0].Args = []string{"--fake"}
+				config.Processes[0].Limits = bpm.Limits{OpenFiles: 100000, Processes: 10}
+				bpmConfigs["fake-job"] = config
+
+				containers, err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				args := containers[0].Args
+				Expect(args[len(args)-7:]).To(Equal([]string{
+					"--",
+					"/bin/sh",
+					"-c",
+					`ulimit -n 100000 && { ulimit -u 10 2>/dev/null || ulimit -p 10; } && exec "$@"`,
+					"rlimit",
+					"/var/vcap/packages/fake/bin/fake",
+					"--fake",
+				}))
+				Expect(containers[1].Args).ToNot(ContainElement("/bin/sh"))
+			})
+
+			It("starts the process with the limits", func() {
+				config := bpmConfigs["fake-job"]
+				config.Processes[0].Executable = "/bin/sh"
+				config.Processes[0].Args = []string{"-c", "ulimit -n"}
+				config.Processes[0].Limits = bpm.Limits{OpenFiles: 100}
+				bpmConfigs["fake-job"] = config
+
+				containers, err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				args := containers[0].Args
+				args = args[len(args)-7:]
+				Expect(args[0]).To(Equal("/bin/sh"))
+				out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+				Expect(err).ToNot(HaveOccurred(), string(out))
+				Expect(strings.TrimSpace(string(out))).To(Equal("100"))
+			})
+
 			It("creates a health check for the job", func() {
 				config := bpmConfigs["fake-job"]
 				config.Run = bpm.RunConfig{
@@ -552,7 +669,7 @@ var _ = Describe("ContainerFactory", func() {
 
 				disableSideCar := ig.Env.AgentEnvBoshConfig.Agent.Settings.DisableLogSidecar
 
-				containerFactory := NewContainerFactory(ig.Name, "v1", disableSideCar, releaseImageProvider, bpmJobConfigs)
+				containerFactory := NewContainerFactory(ig.Name, "v1", disableSideCar, releaseImageProvider, bpmJobConfigs, corev1.ResourceRequirements{})
 				act := func() ([]corev1.Container, error) {
 					return containerFactory.JobsToContainers(ig.Jobs, []corev1.VolumeMount{}, bdm.Disks{})
 				}
@@ -579,7 +696,7 @@ var _ = Describe("ContainerFactory", func() {
 
 				disableSideCar := ig.Env.AgentEnvBoshConfig.Agent.Settings.DisableLogSidecar
 
-				containerFactory := NewContainerFactory(ig.Name, "v1", disableSideCar, releaseImageProvider, bpmJobConfigs)
+				containerFactory := NewContainerFactory(ig.Name, "v1", disableSideCar, releaseImageProvider, bpmJobConfigs, corev1.ResourceRequirements{})
 				act := func() ([]corev1.Container, error) {
 					return containerFactory.JobsToContainers(ig.Jobs, []corev1.VolumeMount{}, bdm.Disks{})
 				}
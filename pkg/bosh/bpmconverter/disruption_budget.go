@@ -0,0 +1,76 @@
+package bpmconverter
+
+import (
+	"github.com/pkg/errors"
+
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	bdm "code.cloudfoundry.org/quarks-operator/pkg/bosh/manifest"
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+)
+
+// disruptionBudget returns the pod disruption budget of a service instance
+// group. Voluntary disruptions, like node drains, take down at most
+// max_in_flight instances at once, but always leave one instance running.
+// It returns nil for instance groups with a single instance or a disabled
+// budget.
+func disruptionBudget(namespace string, deploymentName string, instanceGroup *bdm.InstanceGroup) (*policyv1beta1.PodDisruptionBudget, error) {
+	settings := instanceGroup.Env.AgentEnvBoshConfig.Agent.Settings
+	override := settings.DisruptionBudget
+	if override != nil && override.Disabled {
+		return nil, nil
+	}
+
+	instances := instanceGroup.Instances
+	if len(instanceGroup.AZs) > 0 {
+		instances *= len(instanceGroup.AZs)
+	}
+
+	spec := policyv1beta1.PodDisruptionBudgetSpec{
+		Selector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				bdv1.LabelDeploymentName:    deploymentName,
+				bdv1.LabelInstanceGroupName: instanceGroup.Name,
+			},
+		},
+	}
+
+	switch {
+	case override != nil && (override.MinAvailable != nil || override.MaxUnavailable != nil):
+		if override.MinAvailable != nil && override.MaxUnavailable != nil {
+			return nil, errors.Errorf("disruption budget of instance group '%s' can't set both minAvailable and maxUnavailable", instanceGroup.Name)
+		}
+		spec.MinAvailable = override.MinAvailable
+		spec.MaxUnavailable = override.MaxUnavailable
+	case instances > 1:
+		maxInFlight := 1
+		if instanceGroup.Update != nil {
+			var err error
+			maxInFlight, err = bdm.ExtractMaxInFlight(instanceGroup.Update.MaxInFlight, instances)
+			if err != nil {
+				return nil, errors.Wrap(err, "update block has invalid max_in_flight")
+			}
+		}
+		if maxInFlight < 1 {
+			maxInFlight = 1
+		}
+		if maxInFlight > instances-1 {
+			maxInFlight = instances - 1
+		}
+		maxUnavailable := intstr.FromInt(maxInFlight)
+		spec.MaxUnavailable = &maxUnavailable
+	default:
+		return nil, nil
+	}
+
+	return &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instanceGroup.NameSanitized(),
+			Namespace: namespace,
+			Labels:    FilterLabels(settings.Labels),
+		},
+		Spec: spec,
+	}, nil
+}
@@ -0,0 +1,132 @@
+package bpmconverter
+
+import (
+	"github.com/pkg/errors"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	bdm "code.cloudfoundry.org/quarks-operator/pkg/bosh/manifest"
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/names"
+	qstsv1a1 "code.cloudfoundry.org/quarks-statefulset/pkg/kube/apis/quarksstatefulset/v1alpha1"
+)
+
+// expose returns the service and the optional ingress, which expose the pods
+// of an instance group outside of the cluster. The static IPs of the
+// instance group's networks become the load balancer IP or the external IPs
+// of the service.
+func expose(namespace string, deploymentName string, instanceGroup *bdm.InstanceGroup, qSts *qstsv1a1.QuarksStatefulSet, ports []corev1.ServicePort) (*corev1.Service, *networkingv1beta1.Ingress, error) {
+	exposure := instanceGroup.Env.AgentEnvBoshConfig.Agent.Settings.Expose
+	if err := exposure.Validate(); err != nil {
+		return nil, nil, errors.Wrapf(err, "invalid exposure of instance group '%s'", instanceGroup.Name)
+	}
+
+	if len(exposure.Ports) > 0 {
+		ports = exposure.Ports
+	}
+	if len(ports) == 0 {
+		return nil, nil, errors.Errorf("instance group '%s' has no ports to expose", instanceGroup.Name)
+	}
+
+	name := names.ExposedServiceName(deploymentName, instanceGroup.Name)
+	selector := map[string]string{
+		bdv1.LabelDeploymentName:    deploymentName,
+		bdv1.LabelInstanceGroupName: instanceGroup.Name,
+	}
+	if len(qSts.Spec.ActivePassiveProbes) > 0 {
+		selector[qstsv1a1.LabelActivePod] = "active"
+	}
+	serviceLabels := labels.Merge(
+		instanceGroup.Env.AgentEnvBoshConfig.Agent.Settings.Labels,
+		map[string]string{bdv1.LabelInstanceGroupName: instanceGroup.Name},
+	)
+
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Labels:      serviceLabels,
+			Annotations: exposure.Annotations,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     exposure.ServiceType(),
+			Ports:    ports,
+			Selector: selector,
+		},
+	}
+	if svc.Spec.Type != corev1.ServiceTypeClusterIP {
+		svc.Spec.LoadBalancerSourceRanges = exposure.LoadBalancerSourceRanges
+		svc.Spec.ExternalTrafficPolicy = exposure.ExternalTrafficPolicy
+	}
+
+	if staticIPs := instanceGroup.StaticIPs(); len(staticIPs) > 0 {
+		if svc.Spec.Type == corev1.ServiceTypeLoadBalancer {
+			svc.Spec.LoadBalancerIP = staticIPs[0]
+		} else {
+			svc.Spec.ExternalIPs = staticIPs
+		}
+	}
+
+	if exposure.Ingress == nil {
+		return svc, nil, nil
+	}
+
+	port := ports[0].Port
+	if exposure.Ingress.Port != 0 {
+		port = exposure.Ingress.Port
+		found := false
+		for _, p := range ports {
+			if p.Port == port {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, nil, errors.Errorf("ingress port %d of instance group '%s' isn't exposed", port, instanceGroup.Name)
+		}
+	}
+
+	ingress := &networkingv1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Labels:      serviceLabels,
+			Annotations: exposure.Ingress.Annotations,
+		},
+		Spec: networkingv1beta1.IngressSpec{
+			IngressClassName: exposure.Ingress.ClassName,
+			Rules: []networkingv1beta1.IngressRule{
+				{
+					Host: exposure.Ingress.Host,
+					IngressRuleValue: networkingv1beta1.IngressRuleValue{
+						HTTP: &networkingv1beta1.HTTPIngressRuleValue{
+							Paths: []networkingv1beta1.HTTPIngressPath{
+								{
+									Path: exposure.Ingress.Path,
+									Backend: networkingv1beta1.IngressBackend{
+										ServiceName: name,
+										ServicePort: intstr.FromInt(int(port)),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if exposure.Ingress.TLSSecretName != "" {
+		ingress.Spec.TLS = []networkingv1beta1.IngressTLS{
+			{
+				Hosts:      []string{exposure.Ingress.Host},
+				SecretName: exposure.Ingress.TLSSecretName,
+			},
+		}
+	}
+
+	return svc, ingress, nil
+}
@@ -25,18 +25,21 @@ type FakeVolumeFactory struct {
 		result1 manifest.Disks
 		result2 error
 	}
-	GenerateDefaultDisksStub        func(*manifest.InstanceGroup, string, string) manifest.Disks
+	GenerateDefaultDisksStub        func(string, *manifest.InstanceGroup, string, string) (manifest.Disks, error)
 	generateDefaultDisksMutex       sync.RWMutex
 	generateDefaultDisksArgsForCall []struct {
-		arg1 *manifest.InstanceGroup
-		arg2 string
+		arg1 string
+		arg2 *manifest.InstanceGroup
 		arg3 string
+		arg4 string
 	}
 	generateDefaultDisksReturns struct {
 		result1 manifest.Disks
+		result2 error
 	}
 	generateDefaultDisksReturnsOnCall map[int]struct {
 		result1 manifest.Disks
+		result2 error
 	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
@@ -107,24 +110,25 @@ func (fake *FakeVolumeFactory) GenerateBPMDisksReturnsOnCall(i int, result1 mani
 	}{result1, result2}
 }
 
-func (fake *FakeVolumeFactory) GenerateDefaultDisks(arg1 *manifest.InstanceGroup, arg2 string, arg3 string) manifest.Disks {
+func (fake *FakeVolumeFactory) GenerateDefaultDisks(arg1 string, arg2 *manifest.InstanceGroup, arg3 string, arg4 string) (manifest.Disks, error) {
 	fake.generateDefaultDisksMutex.Lock()
 	ret, specificReturn := fake.generateDefaultDisksReturnsOnCall[len(fake.generateDefaultDisksArgsForCall)]
 	fake.generateDefaultDisksArgsForCall = append(fake.generateDefaultDisksArgsForCall, struct {
-		arg1 *manifest.InstanceGroup
-		arg2 string
+		arg1 string
+		arg2 *manifest.InstanceGroup
 		arg3 string
-	}{arg1, arg2, arg3})
-	fake.recordInvocation("GenerateDefaultDisks", []interface{}{arg1, arg2, arg3})
+		arg4 string
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("GenerateDefaultDisks", []interface{}{arg1, arg2, arg3, arg4})
 	fake.generateDefaultDisksMutex.Unlock()
 	if fake.GenerateDefaultDisksStub != nil {
-		return fake.GenerateDefaultDisksStub(arg1, arg2, arg3)
+		return fake.GenerateDefaultDisksStub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
-		return ret.result1
+		return ret.result1, ret.result2
 	}
 	fakeReturns := fake.generateDefaultDisksReturns
-	return fakeReturns.result1
+	return fakeReturns.result1, fakeReturns.result2
 }
 
 func (fake *FakeVolumeFactory) GenerateDefaultDisksCallCount() int {
@@ -133,40 +137,43 @@ func (fake *FakeVolumeFactory) GenerateDefaultDisksCallCount() int {
 	return len(fake.generateDefaultDisksArgsForCall)
 }
 
-func (fake *FakeVolumeFactory) GenerateDefaultDisksCalls(stub func(*manifest.InstanceGroup, string, string) manifest.Disks) {
+func (fake *FakeVolumeFactory) GenerateDefaultDisksCalls(stub func(string, *manifest.InstanceGroup, string, string) (manifest.Disks, error)) {
 	fake.generateDefaultDisksMutex.Lock()
 	defer fake.generateDefaultDisksMutex.Unlock()
 	fake.GenerateDefaultDisksStub = stub
 }
 
-func (fake *FakeVolumeFactory) GenerateDefaultDisksArgsForCall(i int) (*manifest.InstanceGroup, string, string) {
+func (fake *FakeVolumeFactory) GenerateDefaultDisksArgsForCall(i int) (string, *manifest.InstanceGroup, string, string) {
 	fake.generateDefaultDisksMutex.RLock()
 	defer fake.generateDefaultDisksMutex.RUnlock()
 	argsForCall := fake.generateDefaultDisksArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
-func (fake *FakeVolumeFactory) GenerateDefaultDisksReturns(result1 manifest.Disks) {
+func (fake *FakeVolumeFactory) GenerateDefaultDisksReturns(result1 manifest.Disks, result2 error) {
 	fake.generateDefaultDisksMutex.Lock()
 	defer fake.generateDefaultDisksMutex.Unlock()
 	fake.GenerateDefaultDisksStub = nil
 	fake.generateDefaultDisksReturns = struct {
 		result1 manifest.Disks
-	}{result1}
+		result2 error
+	}{result1, result2}
 }
 
-func (fake *FakeVolumeFactory) GenerateDefaultDisksReturnsOnCall(i int, result1 manifest.Disks) {
+func (fake *FakeVolumeFactory) GenerateDefaultDisksReturnsOnCall(i int, result1 manifest.Disks, result2 error) {
 	fake.generateDefaultDisksMutex.Lock()
 	defer fake.generateDefaultDisksMutex.Unlock()
 	fake.GenerateDefaultDisksStub = nil
 	if fake.generateDefaultDisksReturnsOnCall == nil {
 		fake.generateDefaultDisksReturnsOnCall = make(map[int]struct {
 			result1 manifest.Disks
+			result2 error
 		})
 	}
 	fake.generateDefaultDisksReturnsOnCall[i] = struct {
 		result1 manifest.Disks
-	}{result1}
+		result2 error
+	}{result1, result2}
 }
 
 func (fake *FakeVolumeFactory) Invocations() map[string][][]interface{} {
@@ -7,9 +7,12 @@ import (
 	"github.com/pkg/errors"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
 	batchv1 "k8s.io/api/batch/v1"
 	batchv1b1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 
@@ -19,6 +22,8 @@ import (
 	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/boshdns"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/names"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/operatorimage"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/zones"
 	qstsv1a1 "code.cloudfoundry.org/quarks-statefulset/pkg/kube/apis/quarksstatefulset/v1alpha1"
 	"code.cloudfoundry.org/quarks-statefulset/pkg/kube/controllers/statefulset"
 	"code.cloudfoundry.org/quarks-utils/pkg/pointers"
@@ -41,16 +46,16 @@ type ContainerFactory interface {
 }
 
 // NewContainerFactoryFunc returns ContainerFactory from single BOSH instance group.
-type NewContainerFactoryFunc func(instanceGroupName string, version string, disableLogSidecar bool, releaseImageProvider bdm.ReleaseImageProvider, bpmConfigs bpm.Configs) ContainerFactory
+type NewContainerFactoryFunc func(instanceGroupName string, version string, disableLogSidecar bool, releaseImageProvider bdm.ReleaseImageProvider, bpmConfigs bpm.Configs, resources corev1.ResourceRequirements) ContainerFactory
 
 // NewContainerFactoryImplFunc returns a ContainerFactoryImpl
-func NewContainerFactoryImplFunc(instanceGroupName string, version string, disableLogSidecar bool, releaseImageProvider bdm.ReleaseImageProvider, bpmConfigs bpm.Configs) ContainerFactory {
-	return NewContainerFactory(instanceGroupName, version, disableLogSidecar, releaseImageProvider, bpmConfigs)
+func NewContainerFactoryImplFunc(instanceGroupName string, version string, disableLogSidecar bool, releaseImageProvider bdm.ReleaseImageProvider, bpmConfigs bpm.Configs, resources corev1.ResourceRequirements) ContainerFactory {
+	return NewContainerFactory(instanceGroupName, version, disableLogSidecar, releaseImageProvider, bpmConfigs, resources)
 }
 
 // VolumeFactory builds Kubernetes containers from BOSH jobs.
 type VolumeFactory interface {
-	GenerateDefaultDisks(instanceGroupName *bdm.InstanceGroup, igResolvedSecretVersion string, namespace string) bdm.Disks
+	GenerateDefaultDisks(deploymentName string, instanceGroup *bdm.InstanceGroup, igResolvedSecretVersion string, namespace string) (bdm.Disks, error)
 	GenerateBPMDisks(instanceGroup *bdm.InstanceGroup, bpmConfigs bpm.Configs, namespace string) (bdm.Disks, error)
 }
 
@@ -72,8 +77,12 @@ func NewConverter(volumeFactory VolumeFactory, newContainerFactoryFunc NewContai
 type Resources struct {
 	InstanceGroups         []qstsv1a1.QuarksStatefulSet
 	Errands                []qjv1a1.QuarksJob
+	ScheduledErrands       []batchv1b1.CronJob
 	Services               []corev1.Service
 	PersistentVolumeClaims []corev1.PersistentVolumeClaim
+	DisruptionBudgets      []policyv1beta1.PodDisruptionBudget
+	Autoscalers            []autoscalingv2beta2.HorizontalPodAutoscaler
+	Ingresses              []networkingv1beta1.Ingress
 }
 
 // FilterLabels filters out labels, that are not suitable for StatefulSet updates
@@ -91,8 +100,15 @@ func FilterLabels(labels map[string]string) map[string]string {
 // It returns quarks stateful sets, services and quarks jobs.
 func (kc *BPMConverter) Resources(manifest bdm.Manifest, namespace string, deploymentName string, serviceIP string, qStsVersion string, instanceGroup *bdm.InstanceGroup, bpmConfigs bpm.Configs, igResolvedSecretVersion string) (*Resources, error) {
 	instanceGroup.Env.AgentEnvBoshConfig.Agent.Settings.Set(deploymentName, instanceGroup.Name, qStsVersion)
+	instanceGroup.UseTmpfsJobConfig(manifest.Features)
+	if err := instanceGroup.ApplyDiskTypes(manifest.CloudConfig); err != nil {
+		return nil, err
+	}
 
-	defaultDisks := kc.volumeFactory.GenerateDefaultDisks(instanceGroup, igResolvedSecretVersion, namespace)
+	defaultDisks, err := kc.volumeFactory.GenerateDefaultDisks(deploymentName, instanceGroup, igResolvedSecretVersion, namespace)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Generate of default disks failed for manifest name %s, instance group %s.", deploymentName, instanceGroup.Name)
+	}
 	bpmDisks, err := kc.volumeFactory.GenerateBPMDisks(instanceGroup, bpmConfigs, namespace)
 	if err != nil {
 		return nil, errors.Wrapf(err, "Generate of BPM disks failed for manifest name %s, instance group %s.", deploymentName, instanceGroup.Name)
@@ -107,43 +123,144 @@ func (kc *BPMConverter) Resources(manifest bdm.Manifest, namespace string, deplo
 		PersistentVolumeClaims: allDisks.PVCs(),
 	}
 
+	computeResources, err := instanceGroup.ComputeResources(manifest.CloudConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	cfac := kc.newContainerFactoryFunc(
 		instanceGroup.Name,
 		igResolvedSecretVersion,
 		instanceGroup.Env.AgentEnvBoshConfig.Agent.Settings.DisableLogSidecar,
 		&manifest,
 		bpmConfigs,
+		computeResources,
 	)
 
 	switch instanceGroup.LifeCycle {
 	case bdm.IGTypeService, "":
-		convertedExtStatefulSet, err := kc.serviceToQuarksStatefulSet(manifest, namespace, cfac, serviceIP, instanceGroup, defaultDisks, bpmDisks, bpmConfigs.ActivePassiveProbes())
+		convertedExtStatefulSet, err := kc.serviceToQuarksStatefulSet(manifest, namespace, deploymentName, cfac, serviceIP, instanceGroup, defaultDisks, bpmDisks, bpmConfigs.ActivePassiveProbes())
 		if err != nil {
 			return nil, err
 		}
 
+		if instanceGroup.Autoscaled() {
+			autoscalers, err := autoscale(namespace, instanceGroup, &convertedExtStatefulSet)
+			if err != nil {
+				return nil, err
+			}
+			res.Autoscalers = append(res.Autoscalers, autoscalers...)
+		}
+
 		services := kc.serviceToKubeServices(namespace, deploymentName, instanceGroup, &convertedExtStatefulSet, bpmConfigs)
 		if len(services) != 0 {
 			res.Services = append(res.Services, services...)
 		}
 
+		if instanceGroup.Env.AgentEnvBoshConfig.Agent.Settings.Expose != nil {
+			svc, ingress, err := expose(namespace, deploymentName, instanceGroup, &convertedExtStatefulSet, bpmConfigs.ServicePorts())
+			if err != nil {
+				return nil, err
+			}
+			res.Services = append(res.Services, *svc)
+			if ingress != nil {
+				res.Ingresses = append(res.Ingresses, *ingress)
+			}
+		}
+
 		res.InstanceGroups = append(res.InstanceGroups, convertedExtStatefulSet)
+
+		pdb, err := disruptionBudget(namespace, deploymentName, instanceGroup)
+		if err != nil {
+			return nil, err
+		}
+		if pdb != nil {
+			res.DisruptionBudgets = append(res.DisruptionBudgets, *pdb)
+		}
 	case bdm.IGTypeErrand, bdm.IGTypeAutoErrand:
-		convertedQJob, err := kc.errandToQuarksJob(manifest, namespace, cfac, serviceIP, instanceGroup, defaultDisks, bpmDisks)
+		if instanceGroup.Autoscaled() {
+			return nil, errors.Errorf("autoscaling is not supported for errand instance group '%s'", instanceGroup.Name)
+		}
+		if instanceGroup.Env.AgentEnvBoshConfig.Agent.Settings.Expose != nil {
+			return nil, errors.Errorf("exposing errand instance group '%s' is not supported", instanceGroup.Name)
+		}
+
+		convertedQJob, err := kc.errandToQuarksJob(manifest, namespace, deploymentName, cfac, serviceIP, instanceGroup, defaultDisks, bpmDisks)
 		if err != nil {
 			return nil, err
 		}
 
 		res.Errands = append(res.Errands, convertedQJob)
+
+		if schedule := instanceGroup.Env.AgentEnvBoshConfig.Agent.Settings.Schedule; schedule != nil {
+			res.ScheduledErrands = append(res.ScheduledErrands, errandTriggerCronJob(convertedQJob, schedule))
+		}
+	}
+
+	err = applyCloudConfig(res, manifest.CloudConfig, namespace, deploymentName, instanceGroup, bpmConfigs.ServicePorts())
+	if err != nil {
+		return nil, err
 	}
 
+	applyTags(res, manifest.Tags)
+
 	return res, nil
 }
 
+// applyTags adds the manifest's tags to all resources and their pod templates.
+// Selectors and volume claim templates are left alone, since they can't be updated.
+func applyTags(res *Resources, tags bdm.Tags) {
+	if len(tags) == 0 {
+		return
+	}
+	tagLabels := tags.Labels()
+	tagAnnotations := tags.Annotations()
+
+	// Merge into new maps, labels are shared with selectors. Tags don't override existing keys.
+	tag := func(meta *metav1.ObjectMeta) {
+		meta.Labels = labels.Merge(tagLabels, meta.Labels)
+		meta.Annotations = labels.Merge(tagAnnotations, meta.Annotations)
+	}
+
+	for i := range res.InstanceGroups {
+		qSts := &res.InstanceGroups[i]
+		tag(&qSts.ObjectMeta)
+		tag(&qSts.Spec.Template.ObjectMeta)
+		tag(&qSts.Spec.Template.Spec.Template.ObjectMeta)
+	}
+	for i := range res.Errands {
+		qJob := &res.Errands[i]
+		tag(&qJob.ObjectMeta)
+		tag(&qJob.Spec.Template.Spec.Template.ObjectMeta)
+	}
+	for i := range res.ScheduledErrands {
+		cronJob := &res.ScheduledErrands[i]
+		tag(&cronJob.ObjectMeta)
+		tag(&cronJob.Spec.JobTemplate.ObjectMeta)
+		tag(&cronJob.Spec.JobTemplate.Spec.Template.ObjectMeta)
+	}
+	for i := range res.Services {
+		tag(&res.Services[i].ObjectMeta)
+	}
+	for i := range res.PersistentVolumeClaims {
+		tag(&res.PersistentVolumeClaims[i].ObjectMeta)
+	}
+	for i := range res.DisruptionBudgets {
+		tag(&res.DisruptionBudgets[i].ObjectMeta)
+	}
+	for i := range res.Autoscalers {
+		tag(&res.Autoscalers[i].ObjectMeta)
+	}
+	for i := range res.Ingresses {
+		tag(&res.Ingresses[i].ObjectMeta)
+	}
+}
+
 // serviceToQuarksStatefulSet will generate an QuarksStatefulSet
 func (kc *BPMConverter) serviceToQuarksStatefulSet(
 	manifest bdm.Manifest,
 	namespace string,
+	deploymentName string,
 	cfac ContainerFactory,
 	serviceIP string,
 	instanceGroup *bdm.InstanceGroup,
@@ -173,12 +290,27 @@ func (kc *BPMConverter) serviceToQuarksStatefulSet(
 	volumeClaims := make([]corev1.PersistentVolumeClaim, 0, len(defaultVolumeClaims)+len(bpmVolumeClaims))
 	volumeClaims = append(volumeClaims, defaultVolumeClaims...)
 	volumeClaims = append(volumeClaims, bpmVolumeClaims...)
+	// Claims only get the stateful set's selector labels, which quarks-statefulset
+	// replaces with its own, so the claim templates carry the instance group labels
+	for i := range volumeClaims {
+		volumeClaims[i].Labels = labels.Merge(volumeClaims[i].Labels, map[string]string{
+			bdv1.LabelDeploymentName:    deploymentName,
+			bdv1.LabelInstanceGroupName: instanceGroup.Name,
+		})
+	}
 
 	statefulSetLabels := FilterLabels(instanceGroup.Env.AgentEnvBoshConfig.Agent.Settings.Labels)
 	statefulSetAnnotations, err := computeAnnotations(instanceGroup)
 	if err != nil {
 		return qstsv1a1.QuarksStatefulSet{}, errors.Wrapf(err, "computing annotations failed for instance group %s", instanceGroup.Name)
 	}
+	// Pods are spread across the zones only for randomized placement,
+	// otherwise the scheduler keeps its default placement
+	var spreadConstraints []corev1.TopologySpreadConstraint
+	if manifest.RandomizesAZPlacement() {
+		spreadConstraints = zones.SpreadConstraints(statefulSetLabels)
+	}
+
 	extSts := qstsv1a1.QuarksStatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        instanceGroup.NameSanitized(),
@@ -187,7 +319,8 @@ func (kc *BPMConverter) serviceToQuarksStatefulSet(
 			Annotations: instanceGroup.Env.AgentEnvBoshConfig.Agent.Settings.Annotations,
 		},
 		Spec: qstsv1a1.QuarksStatefulSetSpec{
-			Zones:                instanceGroup.AZs,
+			Zones:                zones.Zones(instanceGroup.AZs),
+			ZoneNodeLabel:        zones.NodeLabel(),
 			UpdateOnConfigChange: true,
 			ActivePassiveProbes:  activePassiveProbes,
 			InjectReplicasEnv:    instanceGroup.Env.AgentEnvBoshConfig.Agent.Settings.InjectReplicasEnv,
@@ -211,13 +344,14 @@ func (kc *BPMConverter) serviceToQuarksStatefulSet(
 						Spec: corev1.PodSpec{
 							TerminationGracePeriodSeconds: instanceGroup.Env.AgentEnvBoshConfig.Agent.Settings.TerminationGracePeriodSeconds,
 							Affinity:                      instanceGroup.Env.AgentEnvBoshConfig.Agent.Settings.Affinity,
+							TopologySpreadConstraints:     spreadConstraints,
 							Volumes:                       volumes,
 							InitContainers:                initContainers,
 							Containers:                    containers,
 							SecurityContext: &corev1.PodSecurityContext{
 								FSGroup: &admGroupID,
 							},
-							Subdomain:        names.ServiceName(instanceGroup.Name),
+							Subdomain:        names.ServiceName(deploymentName, instanceGroup.Name),
 							ImagePullSecrets: instanceGroup.Env.AgentEnvBoshConfig.Agent.Settings.ImagePullSecrets,
 						},
 					},
@@ -289,7 +423,7 @@ func (kc *BPMConverter) serviceToKubeServices(namespace string, deploymentName s
 			ports)
 	}
 
-	headlessServiceName := names.ServiceName(instanceGroup.Name)
+	headlessServiceName := names.ServiceName(deploymentName, instanceGroup.Name)
 	headlessServiceSelector := map[string]string{
 		bdv1.LabelDeploymentName:    deploymentName,
 		bdv1.LabelInstanceGroupName: instanceGroup.Name,
@@ -328,6 +462,7 @@ func (kc *BPMConverter) serviceToKubeServices(namespace string, deploymentName s
 func (kc *BPMConverter) errandToQuarksJob(
 	manifest bdm.Manifest,
 	namespace string,
+	deploymentName string,
 	cfac ContainerFactory,
 	serviceIP string,
 	instanceGroup *bdm.InstanceGroup,
@@ -367,7 +502,7 @@ func (kc *BPMConverter) errandToQuarksJob(
 
 	qJob := qjv1a1.QuarksJob{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:        instanceGroup.Name,
+			Name:        names.QuarksJobName(deploymentName, instanceGroup.Name),
 			Namespace:   namespace,
 			Labels:      instanceGroup.Env.AgentEnvBoshConfig.Agent.Settings.Labels,
 			Annotations: instanceGroup.Env.AgentEnvBoshConfig.Agent.Settings.Annotations,
@@ -434,6 +569,58 @@ func (kc *BPMConverter) errandToQuarksJob(
 	return qJob, nil
 }
 
+// errandTriggerCronJob generates a cron job, which triggers the errand's
+// QuarksJob periodically. The jobs of the cron job don't start any pods, the
+// operator sets the trigger strategy of the QuarksJob for each of them.
+func errandTriggerCronJob(qJob qjv1a1.QuarksJob, schedule *bdm.ErrandSchedule) batchv1b1.CronJob {
+	concurrencyPolicy := schedule.ConcurrencyPolicy
+	if concurrencyPolicy == "" {
+		concurrencyPolicy = batchv1b1.ForbidConcurrent
+	}
+
+	triggerLabels := FilterLabels(qJob.Labels)
+	triggerLabels[bdv1.LabelErrandTrigger] = qJob.Name
+
+	return batchv1b1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        qJob.Name,
+			Namespace:   qJob.Namespace,
+			Labels:      FilterLabels(qJob.Labels),
+			Annotations: qJob.Annotations,
+		},
+		Spec: batchv1b1.CronJobSpec{
+			Schedule:                   schedule.Cron,
+			ConcurrencyPolicy:          concurrencyPolicy,
+			SuccessfulJobsHistoryLimit: schedule.SuccessfulJobsHistoryLimit,
+			FailedJobsHistoryLimit:     schedule.FailedJobsHistoryLimit,
+			StartingDeadlineSeconds:    schedule.StartingDeadlineSeconds,
+			Suspend:                    schedule.Suspend,
+			JobTemplate: batchv1b1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: triggerLabels,
+				},
+				Spec: batchv1.JobSpec{
+					// the job only records the trigger, the QuarksJob runs the errand
+					Parallelism: pointers.Int32(0),
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: triggerLabels,
+						},
+						Spec: corev1.PodSpec{
+							RestartPolicy: corev1.RestartPolicyNever,
+							Containers: []corev1.Container{{
+								Name:    "trigger",
+								Image:   operatorimage.GetOperatorDockerImage(),
+								Command: []string{"/bin/true"},
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func (kc *BPMConverter) generateServices(
 	services []corev1.Service,
 	namespace string,
@@ -461,7 +648,7 @@ func (kc *BPMConverter) generateServices(
 	for i := 0; i < instanceGroup.Instances; i++ {
 		services = append(services, corev1.Service{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      instanceGroup.IndexedServiceName(i, azIndex),
+				Name:      instanceGroup.IndexedServiceName(deploymentName, i, azIndex),
 				Namespace: namespace,
 				Labels:    serviceLabels(azIndex, i, false),
 			},
@@ -502,5 +689,14 @@ func computeAnnotations(ig *bdm.InstanceGroup) (map[string]string, error) {
 		statefulSetAnnotations[statefulset.AnnotationUpdateWatchTime] = updateWatchTime
 	}
 
+	// The rollout controller of the stateful sets updates a single canary and
+	// then one pod at a time in place, max_in_flight only limits disruptions.
+	if err := bdm.ValidateCanaries(ig.Update.Canaries); err != nil {
+		return nil, errors.Wrap(err, "update block has invalid canaries")
+	}
+	if err := bdm.ValidateVMStrategy(ig.Update.VMStrategy); err != nil {
+		return nil, errors.Wrap(err, "update block has invalid vm_strategy")
+	}
+
 	return statefulSetAnnotations, nil
 }
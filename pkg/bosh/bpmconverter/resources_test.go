@@ -7,9 +7,12 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/pkg/errors"
 
+	batchv1b1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
 
 	qjv1a1 "code.cloudfoundry.org/quarks-job/pkg/kube/apis/quarksjob/v1alpha1"
 	"code.cloudfoundry.org/quarks-operator/pkg/bosh/bpm"
@@ -17,6 +20,7 @@ import (
 	"code.cloudfoundry.org/quarks-operator/pkg/bosh/bpmconverter/fakes"
 	"code.cloudfoundry.org/quarks-operator/pkg/bosh/manifest"
 	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/zones"
 	"code.cloudfoundry.org/quarks-operator/testing"
 	"code.cloudfoundry.org/quarks-operator/testing/boshreleases"
 	qstsv1a1 "code.cloudfoundry.org/quarks-statefulset/pkg/kube/apis/quarksstatefulset/v1alpha1"
@@ -30,6 +34,7 @@ var _ = Describe("BPM Converter", func() {
 		deploymentName   string
 		volumeFactory    *fakes.FakeVolumeFactory
 		containerFactory *fakes.FakeContainerFactory
+		computeResources corev1.ResourceRequirements
 		env              testing.Catalog
 		err              error
 	)
@@ -38,7 +43,8 @@ var _ = Describe("BPM Converter", func() {
 		act := func(bpmConfigs bpm.Configs, instanceGroup *manifest.InstanceGroup) (*bpmconverter.Resources, error) {
 			c := bpmconverter.NewConverter(
 				volumeFactory,
-				func(instanceGroupName string, version string, disableLogSidecar bool, releaseImageProvider manifest.ReleaseImageProvider, bpmConfigs bpm.Configs) bpmconverter.ContainerFactory {
+				func(instanceGroupName string, version string, disableLogSidecar bool, releaseImageProvider manifest.ReleaseImageProvider, bpmConfigs bpm.Configs, resources corev1.ResourceRequirements) bpmconverter.ContainerFactory {
+					computeResources = resources
 					return containerFactory
 				})
 			resources, err := c.Resources(*m, "foo", deploymentName, "1.2.3.4", "1", instanceGroup, bpmConfigs, "1")
@@ -98,7 +104,7 @@ var _ = Describe("BPM Converter", func() {
 
 					// Test labels and annotations in the quarks job
 					qJob := resources.Errands[0]
-					Expect(qJob.Name).To(Equal("redis-slave"))
+					Expect(qJob.Name).To(Equal(deploymentName + "-redis-slave"))
 					Expect(qJob.GetLabels()).To(HaveKeyWithValue(bdv1.LabelDeploymentName, deploymentName))
 					Expect(qJob.GetLabels()).To(HaveKeyWithValue(bdv1.LabelInstanceGroupName, m.InstanceGroups[0].Name))
 					Expect(qJob.GetLabels()).To(HaveKeyWithValue(bdv1.LabelDeploymentVersion, "1"))
@@ -125,6 +131,94 @@ var _ = Describe("BPM Converter", func() {
 					Expect(qJob.Spec.Template.Spec.Template.Spec.RestartPolicy).To(Equal(corev1.RestartPolicyOnFailure))
 				})
 
+				It("puts the job dirs on tmpfs, if the deployment uses tmpfs for job configs", func() {
+					m.Features = &manifest.Feature{UseTmpfsJobConfig: pointers.Bool(true)}
+					_, err := act(bpmConfigs[0], m.InstanceGroups[0])
+					Expect(err).ShouldNot(HaveOccurred())
+
+					_, ig, _, _ := volumeFactory.GenerateDefaultDisksArgsForCall(0)
+					Expect(*ig.Env.AgentEnvBoshConfig.JobDir.Tmpfs).To(BeTrue())
+				})
+
+				It("prefers the tmpfs setting of the instance group over the deployment feature", func() {
+					m.Features = &manifest.Feature{UseTmpfsJobConfig: pointers.Bool(true)}
+					m.InstanceGroups[0].Env.AgentEnvBoshConfig.JobDir = &manifest.JobDir{Tmpfs: pointers.Bool(false)}
+					_, err := act(bpmConfigs[0], m.InstanceGroups[0])
+					Expect(err).ShouldNot(HaveOccurred())
+
+					_, ig, _, _ := volumeFactory.GenerateDefaultDisksArgsForCall(0)
+					Expect(*ig.Env.AgentEnvBoshConfig.JobDir.Tmpfs).To(BeFalse())
+				})
+
+				It("handles an error when generating default disks", func() {
+					volumeFactory.GenerateDefaultDisksReturns(manifest.Disks{}, errors.New("invalid job_dir.tmpfs_size 'lots'"))
+					_, err := act(bpmConfigs[0], m.InstanceGroups[0])
+					Expect(err).Should(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("invalid job_dir.tmpfs_size 'lots'"))
+				})
+
+				It("doesn't create a cron job if the errand has no schedule", func() {
+					resources, err := act(bpmConfigs[0], m.InstanceGroups[0])
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(resources.ScheduledErrands).To(BeEmpty())
+				})
+
+				It("converts a scheduled errand to a cron job, which triggers the quarksJob", func() {
+					limit := int32(2)
+					m.InstanceGroups[0].Env.AgentEnvBoshConfig.Agent.Settings.Schedule = &manifest.ErrandSchedule{
+						Cron:                   "*/30 * * * *",
+						FailedJobsHistoryLimit: &limit,
+					}
+					resources, err := act(bpmConfigs[0], m.InstanceGroups[0])
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(resources.Errands).To(HaveLen(1))
+					Expect(resources.ScheduledErrands).To(HaveLen(1))
+
+					cronJob := resources.ScheduledErrands[0]
+					Expect(cronJob.Name).To(Equal(deploymentName + "-redis-slave"))
+					Expect(cronJob.GetLabels()).To(HaveKeyWithValue(bdv1.LabelDeploymentName, deploymentName))
+					Expect(cronJob.GetLabels()).To(HaveKeyWithValue(bdv1.LabelInstanceGroupName, m.InstanceGroups[0].Name))
+					Expect(cronJob.GetLabels()).ToNot(HaveKey(bdv1.LabelDeploymentVersion))
+					Expect(cronJob.Spec.JobTemplate.Labels).To(HaveKeyWithValue(bdv1.LabelInstanceGroupName, m.InstanceGroups[0].Name))
+
+					Expect(cronJob.Spec.Schedule).To(Equal("*/30 * * * *"))
+					Expect(cronJob.Spec.ConcurrencyPolicy).To(Equal(batchv1b1.ForbidConcurrent))
+					Expect(*cronJob.Spec.FailedJobsHistoryLimit).To(Equal(int32(2)))
+					Expect(cronJob.Spec.SuccessfulJobsHistoryLimit).To(BeNil())
+
+					Expect(cronJob.Spec.JobTemplate.Labels).To(HaveKeyWithValue(bdv1.LabelErrandTrigger, resources.Errands[0].Name))
+					Expect(*cronJob.Spec.JobTemplate.Spec.Parallelism).To(Equal(int32(0)))
+					Expect(cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers).To(HaveLen(1))
+					Expect(cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Name).To(Equal("trigger"))
+				})
+
+				It("uses the concurrency policy of the schedule", func() {
+					m.InstanceGroups[0].Env.AgentEnvBoshConfig.Agent.Settings.Schedule = &manifest.ErrandSchedule{
+						Cron:              "@daily",
+						ConcurrencyPolicy: batchv1b1.ReplaceConcurrent,
+					}
+					resources, err := act(bpmConfigs[0], m.InstanceGroups[0])
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(resources.ScheduledErrands).To(HaveLen(1))
+					Expect(resources.ScheduledErrands[0].Spec.ConcurrencyPolicy).To(Equal(batchv1b1.ReplaceConcurrent))
+				})
+
+				It("adds the manifest's tags to the quarksJob and cron job", func() {
+					m.Tags = manifest.Tags{"owner": "team a"}
+					m.InstanceGroups[0].Env.AgentEnvBoshConfig.Agent.Settings.Schedule = &manifest.ErrandSchedule{Cron: "@daily"}
+					resources, err := act(bpmConfigs[0], m.InstanceGroups[0])
+					Expect(err).ShouldNot(HaveOccurred())
+
+					qJob := resources.Errands[0]
+					Expect(qJob.GetLabels()).To(HaveKeyWithValue("tags.quarks.cloudfoundry.org/owner", "team-a"))
+					Expect(qJob.GetAnnotations()).To(HaveKeyWithValue("tags.quarks.cloudfoundry.org/owner", "team a"))
+					Expect(qJob.Spec.Template.Spec.Template.Labels).To(HaveKeyWithValue("tags.quarks.cloudfoundry.org/owner", "team-a"))
+
+					cronJob := resources.ScheduledErrands[0]
+					Expect(cronJob.GetLabels()).To(HaveKeyWithValue("tags.quarks.cloudfoundry.org/owner", "team-a"))
+					Expect(cronJob.Spec.JobTemplate.Spec.Template.Labels).To(HaveKeyWithValue("tags.quarks.cloudfoundry.org/owner", "team-a"))
+				})
+
 				It("converts the AgentEnvBoshConfig information", func() {
 					affinityCase := corev1.Affinity{
 						NodeAffinity: &corev1.NodeAffinity{
@@ -266,7 +360,7 @@ var _ = Describe("BPM Converter", func() {
 
 					// Test services for the quarks statefulSet
 					service0 := resources.Services[0]
-					Expect(service0.Name).To(Equal(fmt.Sprintf("%s-z%d-0", stS.Name, 0)))
+					Expect(service0.Name).To(Equal(fmt.Sprintf("%s-%s-z%d-0", deploymentName, stS.Name, 0)))
 					Expect(service0.Spec.Selector).To(Equal(map[string]string{
 						bdv1.LabelDeploymentName:    deploymentName,
 						bdv1.LabelInstanceGroupName: stS.Name,
@@ -282,7 +376,7 @@ var _ = Describe("BPM Converter", func() {
 					}))
 
 					service1 := resources.Services[1]
-					Expect(service1.Name).To(Equal(fmt.Sprintf("%s-z%d-1", stS.Name, 0)))
+					Expect(service1.Name).To(Equal(fmt.Sprintf("%s-%s-z%d-1", deploymentName, stS.Name, 0)))
 					Expect(service1.Spec.Selector).To(Equal(map[string]string{
 						bdv1.LabelDeploymentName:    deploymentName,
 						bdv1.LabelInstanceGroupName: stS.Name,
@@ -298,7 +392,7 @@ var _ = Describe("BPM Converter", func() {
 					}))
 
 					service2 := resources.Services[2]
-					Expect(service2.Name).To(Equal(fmt.Sprintf("%s-z%d-0", stS.Name, 1)))
+					Expect(service2.Name).To(Equal(fmt.Sprintf("%s-%s-z%d-0", deploymentName, stS.Name, 1)))
 					Expect(service2.Spec.Selector).To(Equal(map[string]string{
 						bdv1.LabelDeploymentName:    deploymentName,
 						bdv1.LabelInstanceGroupName: stS.Name,
@@ -314,7 +408,7 @@ var _ = Describe("BPM Converter", func() {
 					}))
 
 					service3 := resources.Services[3]
-					Expect(service3.Name).To(Equal(fmt.Sprintf("%s-z%d-1", stS.Name, 1)))
+					Expect(service3.Name).To(Equal(fmt.Sprintf("%s-%s-z%d-1", deploymentName, stS.Name, 1)))
 					Expect(service3.Spec.Selector).To(Equal(map[string]string{
 						bdv1.LabelDeploymentName:    deploymentName,
 						bdv1.LabelInstanceGroupName: stS.Name,
@@ -330,7 +424,7 @@ var _ = Describe("BPM Converter", func() {
 					}))
 
 					headlessService := resources.Services[4]
-					Expect(headlessService.Name).To(Equal(stS.Name))
+					Expect(headlessService.Name).To(Equal(deploymentName + "-" + stS.Name))
 					Expect(headlessService.Spec.Selector).To(Equal(map[string]string{
 						bdv1.LabelDeploymentName:    deploymentName,
 						bdv1.LabelInstanceGroupName: stS.Name,
@@ -348,6 +442,327 @@ var _ = Describe("BPM Converter", func() {
 					Expect(stS.Spec.Affinity).To(BeNil())
 					Expect(stS.Spec.Tolerations).To(Equal(tolerations))
 				})
+
+				Context("when the deployment has a cloud config", func() {
+					BeforeEach(func() {
+						m.InstanceGroups[1].VMType = "small"
+						m.InstanceGroups[1].VMExtensions = []string{"public"}
+						m.InstanceGroups[1].AZs = []string{"z1", "z2"}
+						m.CloudConfig, err = manifest.LoadCloudConfigYAML([]byte(`---
+azs:
+- name: z1
+  cloud_properties:
+    zone: eu-west-1a
+- name: z2
+vm_types:
+- name: small
+  cloud_properties:
+    resources:
+      requests:
+        cpu: 250m
+        memory: 128Mi
+    node_selector:
+      pool: small
+vm_extensions:
+- name: public
+  cloud_properties:
+    labels:
+      exposed: "true"
+    pod_template:
+      spec:
+        priorityClassName: high
+    service:
+      type: LoadBalancer
+`))
+						Expect(err).ToNot(HaveOccurred())
+
+						containerFactory.JobsToContainersReturns([]corev1.Container{{Name: "bpm-process"}}, nil)
+					})
+
+					It("applies the vm type, vm extensions and availability zones", func() {
+						resources, err := act(bpmConfigs[1], m.InstanceGroups[1])
+						Expect(err).ShouldNot(HaveOccurred())
+
+						qSts := resources.InstanceGroups[0]
+						Expect(qSts.Spec.Zones).To(Equal([]string{"eu-west-1a", "z2"}))
+
+						Expect(computeResources.Requests.Cpu().String()).To(Equal("250m"))
+						Expect(computeResources.Requests.Memory().String()).To(Equal("128Mi"))
+
+						pod := qSts.Spec.Template.Spec.Template
+						Expect(pod.Spec.NodeSelector).To(Equal(map[string]string{"pool": "small"}))
+						Expect(pod.Spec.PriorityClassName).To(Equal("high"))
+						Expect(pod.Labels).To(HaveKeyWithValue("exposed", "true"))
+						Expect(qSts.Spec.Template.Spec.Selector.MatchLabels).ToNot(HaveKey("exposed"))
+
+						service := resources.Services[len(resources.Services)-1]
+						Expect(service.Name).To(Equal(deploymentName + "-diego-cell-public"))
+						Expect(service.Spec.Type).To(Equal(corev1.ServiceTypeLoadBalancer))
+						Expect(service.Spec.Selector).To(Equal(map[string]string{
+							bdv1.LabelDeploymentName:    deploymentName,
+							bdv1.LabelInstanceGroupName: "diego-cell",
+						}))
+						Expect(service.Spec.Ports).ToNot(BeEmpty())
+					})
+
+					It("prefers the cpu and ram of the vm resources over the vm type", func() {
+						m.InstanceGroups[1].VMResources = &manifest.VMResource{CPU: 2, RAM: 4096}
+						_, err := act(bpmConfigs[1], m.InstanceGroups[1])
+						Expect(err).ShouldNot(HaveOccurred())
+
+						Expect(computeResources.Requests.Cpu().String()).To(Equal("2"))
+						Expect(computeResources.Requests.Memory().String()).To(Equal("4Gi"))
+						Expect(computeResources.Limits).To(BeEmpty())
+					})
+
+					It("fails for vm types missing in the cloud config", func() {
+						m.InstanceGroups[1].VMType = "large"
+						_, err := act(bpmConfigs[1], m.InstanceGroups[1])
+						Expect(err).To(MatchError(ContainSubstring("vm type 'large' is not defined in the cloud config")))
+					})
+				})
+
+				Context("when converting the disruption budget", func() {
+					It("limits the unavailable instances to max_in_flight", func() {
+						m.InstanceGroups[1].Update.MaxInFlight = "2"
+						resources, err := act(bpmConfigs[1], m.InstanceGroups[1])
+						Expect(err).ShouldNot(HaveOccurred())
+
+						Expect(resources.DisruptionBudgets).To(HaveLen(1))
+						pdb := resources.DisruptionBudgets[0]
+						Expect(pdb.Name).To(Equal("diego-cell"))
+						Expect(pdb.Labels).To(HaveKeyWithValue(bdv1.LabelInstanceGroupName, "diego-cell"))
+						Expect(pdb.Labels).ToNot(HaveKey(bdv1.LabelDeploymentVersion))
+						Expect(pdb.Spec.MaxUnavailable).To(Equal(&intstr.IntOrString{Type: intstr.Int, IntVal: 2}))
+						Expect(pdb.Spec.Selector.MatchLabels).To(Equal(map[string]string{
+							bdv1.LabelDeploymentName:    deploymentName,
+							bdv1.LabelInstanceGroupName: "diego-cell",
+						}))
+					})
+
+					It("keeps one instance of all zones available", func() {
+						m.InstanceGroups[1].Update.MaxInFlight = "100%"
+						resources, err := act(bpmConfigs[1], m.InstanceGroups[1])
+						Expect(err).ShouldNot(HaveOccurred())
+
+						Expect(resources.DisruptionBudgets[0].Spec.MaxUnavailable.IntValue()).To(Equal(3))
+					})
+
+					It("skips instance groups with a single instance", func() {
+						m.InstanceGroups[1].Instances = 1
+						m.InstanceGroups[1].AZs = nil
+						resources, err := act(bpmConfigs[1], m.InstanceGroups[1])
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(resources.DisruptionBudgets).To(BeEmpty())
+					})
+
+					It("uses the budget of the agent settings", func() {
+						minAvailable := intstr.FromString("50%")
+						m.InstanceGroups[1].Env.AgentEnvBoshConfig.Agent.Settings.DisruptionBudget = &manifest.DisruptionBudget{MinAvailable: &minAvailable}
+						resources, err := act(bpmConfigs[1], m.InstanceGroups[1])
+						Expect(err).ShouldNot(HaveOccurred())
+
+						Expect(resources.DisruptionBudgets[0].Spec.MinAvailable).To(Equal(&minAvailable))
+						Expect(resources.DisruptionBudgets[0].Spec.MaxUnavailable).To(BeNil())
+					})
+
+					It("skips disabled budgets", func() {
+						m.InstanceGroups[1].Env.AgentEnvBoshConfig.Agent.Settings.DisruptionBudget = &manifest.DisruptionBudget{Disabled: true}
+						resources, err := act(bpmConfigs[1], m.InstanceGroups[1])
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(resources.DisruptionBudgets).To(BeEmpty())
+					})
+				})
+
+				Context("when the instance group is autoscaled", func() {
+					BeforeEach(func() {
+						m.InstanceGroups[1].Env.AgentEnvBoshConfig.Agent.Settings.Autoscaling = &manifest.Autoscaling{
+							MinReplicas:             3,
+							MaxReplicas:             10,
+							TargetMemoryUtilization: pointers.Int32(70),
+						}
+					})
+
+					It("creates an autoscaler for the stateful set of each zone", func() {
+						resources, err := act(bpmConfigs[1], m.InstanceGroups[1])
+						Expect(err).ShouldNot(HaveOccurred())
+
+						qSts := resources.InstanceGroups[0]
+						Expect(*qSts.Spec.Template.Spec.Replicas).To(Equal(int32(3)))
+						Expect(*qSts.Spec.InjectReplicasEnv).To(BeFalse())
+
+						Expect(resources.Autoscalers).To(HaveLen(2))
+						hpa := resources.Autoscalers[1]
+						Expect(hpa.Name).To(Equal("diego-cell-z1"))
+						Expect(hpa.Labels).To(HaveKeyWithValue(bdv1.LabelInstanceGroupName, "diego-cell"))
+						Expect(hpa.Spec.ScaleTargetRef.Kind).To(Equal("StatefulSet"))
+						Expect(hpa.Spec.ScaleTargetRef.Name).To(Equal("diego-cell-z1"))
+						Expect(*hpa.Spec.MinReplicas).To(Equal(int32(3)))
+						Expect(hpa.Spec.MaxReplicas).To(Equal(int32(10)))
+						Expect(hpa.Spec.Metrics).To(HaveLen(1))
+						Expect(hpa.Spec.Metrics[0].Resource.Name).To(Equal(corev1.ResourceMemory))
+						Expect(*hpa.Spec.Metrics[0].Resource.Target.AverageUtilization).To(Equal(int32(70)))
+					})
+
+					It("targets the cpu utilization by default", func() {
+						m.InstanceGroups[1].Env.AgentEnvBoshConfig.Agent.Settings.Autoscaling.TargetMemoryUtilization = nil
+						resources, err := act(bpmConfigs[1], m.InstanceGroups[1])
+						Expect(err).ShouldNot(HaveOccurred())
+
+						metrics := resources.Autoscalers[0].Spec.Metrics
+						Expect(metrics).To(HaveLen(1))
+						Expect(metrics[0].Resource.Name).To(Equal(corev1.ResourceCPU))
+						Expect(*metrics[0].Resource.Target.AverageUtilization).To(Equal(int32(80)))
+					})
+
+					It("fails for invalid replica ranges", func() {
+						m.InstanceGroups[1].Env.AgentEnvBoshConfig.Agent.Settings.Autoscaling.MaxReplicas = 2
+						_, err := act(bpmConfigs[1], m.InstanceGroups[1])
+						Expect(err).To(MatchError(ContainSubstring("invalid autoscaling for instance group 'diego-cell': maxReplicas must not be less than minReplicas")))
+					})
+
+					It("fails for errands", func() {
+						m.InstanceGroups[0].Env.AgentEnvBoshConfig.Agent.Settings.Autoscaling = &manifest.Autoscaling{MinReplicas: 1, MaxReplicas: 2}
+						_, err := act(bpmConfigs[0], m.InstanceGroups[0])
+						Expect(err).To(MatchError("autoscaling is not supported for errand instance group 'redis-slave'"))
+					})
+				})
+
+				Context("when the instance group is exposed", func() {
+					BeforeEach(func() {
+						config := bpmConfigs[1]["cflinuxfs3-rootfs-setup"]
+						config.Ports = []bpm.Port{{Name: "rep-server", Protocol: "TCP", Internal: 1801}}
+						bpmConfigs[1]["cflinuxfs3-rootfs-setup"] = config
+						m.InstanceGroups[1].Env.AgentEnvBoshConfig.Agent.Settings.Expose = &manifest.Expose{
+							Annotations: map[string]string{"service.beta.kubernetes.io/aws-load-balancer-type": "nlb"},
+						}
+						m.InstanceGroups[1].Networks = []*manifest.Network{{Name: "default", StaticIps: []string{"10.0.0.10"}}}
+					})
+
+					It("creates a load balancer for the ports of the bpm configs", func() {
+						resources, err := act(bpmConfigs[1], m.InstanceGroups[1])
+						Expect(err).ShouldNot(HaveOccurred())
+
+						service := resources.Services[len(resources.Services)-1]
+						Expect(service.Name).To(Equal("fake-deployment-diego-cell-exposed"))
+						Expect(service.Labels).To(HaveKeyWithValue(bdv1.LabelInstanceGroupName, "diego-cell"))
+						Expect(service.Annotations).To(HaveKeyWithValue("service.beta.kubernetes.io/aws-load-balancer-type", "nlb"))
+						Expect(service.Spec.Type).To(Equal(corev1.ServiceTypeLoadBalancer))
+						Expect(service.Spec.LoadBalancerIP).To(Equal("10.0.0.10"))
+						Expect(service.Spec.Selector).To(Equal(map[string]string{
+							bdv1.LabelDeploymentName:    deploymentName,
+							bdv1.LabelInstanceGroupName: "diego-cell",
+						}))
+						Expect(service.Spec.Ports).To(HaveLen(1))
+						Expect(service.Spec.Ports[0].Port).To(Equal(int32(1801)))
+						Expect(resources.Ingresses).To(BeEmpty())
+					})
+
+					It("creates an ingress for the exposed service", func() {
+						m.InstanceGroups[1].Env.AgentEnvBoshConfig.Agent.Settings.Expose = &manifest.Expose{
+							Ports:   []corev1.ServicePort{{Name: "http", Port: 80, TargetPort: intstr.FromInt(8080)}},
+							Ingress: &manifest.ExposeIngress{Host: "cell.example.com", Path: "/", TLSSecretName: "cell-tls"},
+						}
+						resources, err := act(bpmConfigs[1], m.InstanceGroups[1])
+						Expect(err).ShouldNot(HaveOccurred())
+
+						service := resources.Services[len(resources.Services)-1]
+						Expect(service.Spec.Type).To(Equal(corev1.ServiceTypeClusterIP))
+						Expect(service.Spec.ExternalIPs).To(Equal([]string{"10.0.0.10"}))
+						Expect(service.Spec.Ports[0].Port).To(Equal(int32(80)))
+
+						Expect(resources.Ingresses).To(HaveLen(1))
+						ingress := resources.Ingresses[0]
+						Expect(ingress.Name).To(Equal("fake-deployment-diego-cell-exposed"))
+						Expect(ingress.Labels).To(HaveKeyWithValue(bdv1.LabelInstanceGroupName, "diego-cell"))
+						Expect(ingress.Spec.TLS[0].Hosts).To(Equal([]string{"cell.example.com"}))
+						Expect(ingress.Spec.TLS[0].SecretName).To(Equal("cell-tls"))
+						rule := ingress.Spec.Rules[0]
+						Expect(rule.Host).To(Equal("cell.example.com"))
+						Expect(rule.HTTP.Paths[0].Path).To(Equal("/"))
+						Expect(rule.HTTP.Paths[0].Backend.ServiceName).To(Equal(service.Name))
+						Expect(rule.HTTP.Paths[0].Backend.ServicePort).To(Equal(intstr.FromInt(80)))
+					})
+
+					It("fails if the ingress port isn't exposed", func() {
+						m.InstanceGroups[1].Env.AgentEnvBoshConfig.Agent.Settings.Expose.Ingress = &manifest.ExposeIngress{Host: "cell.example.com", Port: 443}
+						_, err := act(bpmConfigs[1], m.InstanceGroups[1])
+						Expect(err).To(MatchError("ingress port 443 of instance group 'diego-cell' isn't exposed"))
+					})
+
+					It("fails for invalid service types", func() {
+						m.InstanceGroups[1].Env.AgentEnvBoshConfig.Agent.Settings.Expose.Type = corev1.ServiceTypeExternalName
+						_, err := act(bpmConfigs[1], m.InstanceGroups[1])
+						Expect(err).To(MatchError(ContainSubstring("invalid exposure of instance group 'diego-cell': service type must be ClusterIP, NodePort or LoadBalancer")))
+					})
+
+					It("fails for errands", func() {
+						m.InstanceGroups[0].Env.AgentEnvBoshConfig.Agent.Settings.Expose = &manifest.Expose{}
+						_, err := act(bpmConfigs[0], m.InstanceGroups[0])
+						Expect(err).To(MatchError("exposing errand instance group 'redis-slave' is not supported"))
+					})
+				})
+
+				It("maps the availability zones to the zones of the nodes", func() {
+					zones.SetMapping(map[string]string{m.InstanceGroups[1].AZs[0]: "eu-west-1a"})
+					zones.SetNodeLabel("topology.kubernetes.io/zone")
+					defer zones.SetMapping(nil)
+					defer zones.SetNodeLabel("")
+
+					resources, err := act(bpmConfigs[1], m.InstanceGroups[1])
+					Expect(err).ShouldNot(HaveOccurred())
+
+					qSts := resources.InstanceGroups[0]
+					Expect(qSts.Spec.Zones[0]).To(Equal("eu-west-1a"))
+					Expect(qSts.Spec.ZoneNodeLabel).To(Equal("topology.kubernetes.io/zone"))
+
+					Expect(qSts.Spec.Template.Spec.Template.Spec.TopologySpreadConstraints).To(BeEmpty())
+				})
+
+				It("spreads the pods across zones, if the placement is randomized", func() {
+					zones.SetNodeLabel("topology.kubernetes.io/zone")
+					defer zones.SetNodeLabel("")
+					m.Features = &manifest.Feature{RandomizeAzPlacement: pointers.Bool(true)}
+
+					resources, err := act(bpmConfigs[1], m.InstanceGroups[1])
+					Expect(err).ShouldNot(HaveOccurred())
+
+					qSts := resources.InstanceGroups[0]
+					constraints := qSts.Spec.Template.Spec.Template.Spec.TopologySpreadConstraints
+					Expect(constraints).To(HaveLen(2))
+					Expect(constraints[0].TopologyKey).To(Equal("topology.kubernetes.io/zone"))
+					Expect(constraints[0].LabelSelector.MatchLabels).To(Equal(qSts.Spec.Template.Spec.Selector.MatchLabels))
+				})
+
+				It("adds the manifest's tags to all resources, but not to the selector", func() {
+					m.Tags = manifest.Tags{"owner": "team a"}
+					config := bpmConfigs[1]["cflinuxfs3-rootfs-setup"]
+					config.Ports = []bpm.Port{{Name: "rep-server", Protocol: "TCP", Internal: 1801}}
+					bpmConfigs[1]["cflinuxfs3-rootfs-setup"] = config
+					volumeFactory.GenerateDefaultDisksReturns(manifest.Disks{
+						{PersistentVolumeClaim: &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "pvc"}}},
+					}, nil)
+
+					resources, err := act(bpmConfigs[1], m.InstanceGroups[1])
+					Expect(err).ShouldNot(HaveOccurred())
+
+					key := "tags.quarks.cloudfoundry.org/owner"
+					qSts := resources.InstanceGroups[0]
+					Expect(qSts.GetLabels()).To(HaveKeyWithValue(key, "team-a"))
+					Expect(qSts.GetAnnotations()).To(HaveKeyWithValue(key, "team a"))
+					Expect(qSts.Spec.Template.Labels).To(HaveKeyWithValue(key, "team-a"))
+					Expect(qSts.Spec.Template.Spec.Template.Labels).To(HaveKeyWithValue(key, "team-a"))
+					Expect(qSts.Spec.Template.Spec.Selector.MatchLabels).ToNot(HaveKey(key))
+
+					Expect(resources.Services).ToNot(BeEmpty())
+					for _, svc := range resources.Services {
+						Expect(svc.GetLabels()).To(HaveKeyWithValue(key, "team-a"))
+						Expect(svc.Spec.Selector).ToNot(HaveKey(key))
+					}
+
+					Expect(resources.PersistentVolumeClaims).To(HaveLen(1))
+					Expect(resources.PersistentVolumeClaims[0].GetLabels()).To(HaveKeyWithValue(key, "team-a"))
+				})
 			})
 
 			It("adds the canaryWatchTime of an instance group to an QuarksStatefulSet", func() {
@@ -369,6 +784,54 @@ var _ = Describe("BPM Converter", func() {
 				Expect(extStS.Spec.Template.Annotations).To(HaveKeyWithValue("custom-annotation", "bar"))
 			})
 
+			Context("when the update block configures the rollout", func() {
+				BeforeEach(func() {
+					m.InstanceGroups[1].Instances = 4
+					m.InstanceGroups[1].Update.Canaries = 1
+					m.InstanceGroups[1].Update.MaxInFlight = "50%"
+					m.InstanceGroups[1].Update.VMStrategy = pointers.String(manifest.VMStrategyDeleteCreate)
+				})
+
+				It("accepts settings the stateful set rollout honors", func() {
+					resources, err := act(bpmConfigs[1], m.InstanceGroups[1])
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(resources.InstanceGroups).To(HaveLen(1))
+				})
+
+				It("accepts more than one canary", func() {
+					m.InstanceGroups[1].Update.Canaries = 2
+					_, err := act(bpmConfigs[1], m.InstanceGroups[1])
+					Expect(err).ShouldNot(HaveOccurred())
+				})
+
+				It("handles negative canaries", func() {
+					m.InstanceGroups[1].Update.Canaries = -1
+					_, err := act(bpmConfigs[1], m.InstanceGroups[1])
+					Expect(err).Should(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("update block has invalid canaries"))
+				})
+
+				It("accepts the create-swap-delete vm_strategy", func() {
+					m.InstanceGroups[1].Update.VMStrategy = pointers.String(manifest.VMStrategyCreateSwapDelete)
+					_, err := act(bpmConfigs[1], m.InstanceGroups[1])
+					Expect(err).ShouldNot(HaveOccurred())
+				})
+
+				It("handles an invalid max_in_flight", func() {
+					m.InstanceGroups[1].Update.MaxInFlight = "many"
+					_, err := act(bpmConfigs[1], m.InstanceGroups[1])
+					Expect(err).Should(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("update block has invalid max_in_flight"))
+				})
+
+				It("handles an invalid vm_strategy", func() {
+					m.InstanceGroups[1].Update.VMStrategy = pointers.String("swap")
+					_, err := act(bpmConfigs[1], m.InstanceGroups[1])
+					Expect(err).Should(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("update block has invalid vm_strategy"))
+				})
+			})
+
 			It("converts the AgentEnvBoshConfig information", func() {
 				serviceAccount := "fake-service-account"
 				automountServiceAccountToken := true
@@ -545,6 +1008,28 @@ var _ = Describe("BPM Converter", func() {
 				Expect(pvcs[0].Name).To(Equal("fake-pvc"))
 			})
 
+			It("labels the claims of the stateful set with deployment and instance group", func() {
+				volumeFactory.GenerateBPMDisksReturns(manifest.Disks{
+					{PersistentVolumeClaim: &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "fake-pvc"}}},
+				}, nil)
+				resources, err := act(bpmConfigs[0], m.InstanceGroups[0])
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(resources.InstanceGroups).To(HaveLen(1))
+
+				// a stateful set creates its claims from the template and adds its selector labels
+				qSts := resources.InstanceGroups[0]
+				template := qSts.Spec.Template.Spec.VolumeClaimTemplates[0]
+				pvc := corev1.PersistentVolumeClaim{ObjectMeta: *template.ObjectMeta.DeepCopy()}
+				pvc.Name = fmt.Sprintf("%s-%s-0", template.Name, qSts.Name)
+				pvc.Labels = labels.Merge(pvc.Labels, map[string]string{qstsv1a1.LabelQStsName: qSts.Name})
+
+				selector := labels.SelectorFromSet(labels.Set{
+					bdv1.LabelDeploymentName:    deploymentName,
+					bdv1.LabelInstanceGroupName: m.InstanceGroups[0].Name,
+				})
+				Expect(selector.Matches(labels.Set(pvc.Labels))).To(BeTrue())
+			})
+
 			Context("when multiple BPM processes exist", func() {
 				var (
 					bpmConfigs []bpm.Configs
@@ -615,13 +1100,14 @@ var _ = Describe("BPM Converter", func() {
 
 					c := bpmconverter.NewConverter(
 						bpmconverter.NewVolumeFactory(),
-						func(instanceGroupName string, version string, disableLogSidecar bool, releaseImageProvider manifest.ReleaseImageProvider, bpmConfigs bpm.Configs) bpmconverter.ContainerFactory {
+						func(instanceGroupName string, version string, disableLogSidecar bool, releaseImageProvider manifest.ReleaseImageProvider, bpmConfigs bpm.Configs, resources corev1.ResourceRequirements) bpmconverter.ContainerFactory {
 							return bpmconverter.NewContainerFactory(
 								instanceGroupName,
 								"1",
 								true,
 								releaseImageProvider,
-								bpmConfigs)
+								bpmConfigs,
+								resources)
 						})
 					resources, err := c.Resources(*m, "foo", deploymentName, "1.2.3.4", "1", m.InstanceGroups[1], bpmConfigs[1], "1")
 
@@ -85,8 +85,10 @@ func NewVolumeFactory() *VolumeFactoryImpl {
 // - the sys volume
 // - the "not interpolated" manifest volume
 // - resolved properties data volume
-func (f *VolumeFactoryImpl) GenerateDefaultDisks(instanceGroup *bdm.InstanceGroup, igResolvedSecretVersion string, namespace string) bdm.Disks {
+func (f *VolumeFactoryImpl) GenerateDefaultDisks(deploymentName string, instanceGroup *bdm.InstanceGroup, igResolvedSecretVersion string, namespace string) (bdm.Disks, error) {
 	resolvedPropertiesSecretName := boshnames.InstanceGroupSecretName(
+		bdv1.DeploymentSecretTypeInstanceGroupResolvedProperties,
+		deploymentName,
 		instanceGroup.Name,
 		igResolvedSecretVersion,
 	)
@@ -97,13 +99,20 @@ func (f *VolumeFactoryImpl) GenerateDefaultDisks(instanceGroup *bdm.InstanceGrou
 		pvc = ephemeralPVC(instanceGroup, namespace)
 	}
 
+	// Rendered job configs contain credentials, keep them in memory if
+	// requested
+	tmpfsSize, err := instanceGroup.JobDirTmpfsSize()
+	if err != nil {
+		return bdm.Disks{}, err
+	}
+
 	defaultDisks := bdm.Disks{
 		{
-			Volume:      renderingVolume(),
+			Volume:      renderingVolume(tmpfsSize),
 			VolumeMount: renderingVolumeMount(),
 		},
 		{
-			Volume:      jobsDirVolume(),
+			Volume:      jobsDirVolume(tmpfsSize),
 			VolumeMount: jobsDirVolumeMount(),
 		},
 		{
@@ -128,7 +137,7 @@ func (f *VolumeFactoryImpl) GenerateDefaultDisks(instanceGroup *bdm.InstanceGrou
 		},
 	}
 
-	return defaultDisks
+	return defaultDisks, nil
 }
 
 // GenerateBPMDisks defines any other volumes required to be mounted,
@@ -137,8 +146,12 @@ func (f *VolumeFactoryImpl) GenerateDefaultDisks(instanceGroup *bdm.InstanceGrou
 // - persistent_disk (boolean)
 // - additional_volumes (list of volumes)
 // - unrestricted_volumes (list of volumes)
+// It also adds the named persistent disks of the instance group.
 func (f *VolumeFactoryImpl) GenerateBPMDisks(instanceGroup *bdm.InstanceGroup, bpmConfigs bpm.Configs, namespace string) (bdm.Disks, error) {
-	bpmDisks := make(bdm.Disks, 0)
+	bpmDisks, err := namedPersistentDisks(instanceGroup, namespace)
+	if err != nil {
+		return nil, err
+	}
 
 	rAdditionalVolumes := regexp.MustCompile(AdditionalVolumesRegex)
 
@@ -286,6 +299,57 @@ func (f *VolumeFactoryImpl) GenerateBPMDisks(instanceGroup *bdm.InstanceGroup, b
 	return bpmDisks, nil
 }
 
+// namedPersistentDisks returns a PVC for each named persistent disk of the
+// instance group and mounts it into the jobs, which consume it by name
+func namedPersistentDisks(instanceGroup *bdm.InstanceGroup, namespace string) (bdm.Disks, error) {
+	disks := make(bdm.Disks, 0)
+	uniqueNames := map[string]struct{}{}
+
+	for _, namedDisk := range instanceGroup.PersistentDisks {
+		if namedDisk.Name == "" {
+			return nil, errors.Errorf("instance group '%s' has a persistent disk without name", instanceGroup.Name)
+		}
+		if _, ok := uniqueNames[namedDisk.Name]; ok {
+			return nil, errors.Errorf("instance group '%s' has more than one persistent disk named '%s'", instanceGroup.Name, namedDisk.Name)
+		}
+		uniqueNames[namedDisk.Name] = struct{}{}
+		if namedDisk.Size <= 0 {
+			return nil, errors.Errorf("persistent disk '%s' of instance group '%s' has no size", namedDisk.Name, instanceGroup.Name)
+		}
+
+		persistentVolumeClaim := namedPersistentVolumeClaim(instanceGroup.Name, namedDisk, namespace)
+		disks = append(disks, bdm.Disk{
+			PersistentVolumeClaim: &persistentVolumeClaim,
+			Volume: &corev1.Volume{
+				Name: persistentVolumeClaim.Name,
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+						ClaimName: persistentVolumeClaim.Name,
+					},
+				},
+			},
+		})
+
+		for _, job := range instanceGroup.Jobs {
+			if !job.ConsumesDisk(namedDisk.Name) {
+				continue
+			}
+			disks = append(disks, bdm.Disk{
+				VolumeMount: &corev1.VolumeMount{
+					Name:      persistentVolumeClaim.Name,
+					MountPath: path.Join(VolumeStoreDirMountPath, namedDisk.Name),
+				},
+				Filters: map[string]string{
+					"job_name":         job.Name,
+					"named_persistent": "true",
+				},
+			})
+		}
+	}
+
+	return disks, nil
+}
+
 func persistentDisk(namespace string, instanceGroup *bdm.InstanceGroup, job bdm.Job) bdm.Disk {
 	persistentVolumeClaim := generatePersistentVolumeClaim(instanceGroup, namespace)
 
@@ -431,6 +495,31 @@ func generatePersistentVolumeClaim(instanceGroup *bdm.InstanceGroup, namespace s
 	return persistentVolumeClaim
 }
 
+func namedPersistentVolumeClaim(instanceGroupName string, namedDisk bdm.PersistentDisk, namespace string) corev1.PersistentVolumeClaim {
+	persistentVolumeClaim := corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      namedPersistentVolumeClaimName(instanceGroupName, namedDisk.Name),
+			Namespace: namespace,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceName(corev1.ResourceStorage): resource.MustParse(fmt.Sprintf("%d%s", namedDisk.Size, "Mi")),
+				},
+			},
+		},
+	}
+
+	// add storage class if specified
+	if namedDisk.Type != "" {
+		storageClassName := namedDisk.Type
+		persistentVolumeClaim.Spec.StorageClassName = &storageClassName
+	}
+
+	return persistentVolumeClaim
+}
+
 func ephemeralPVCName(instanceGroupName string) string {
 	return names.Sanitize(fmt.Sprintf("%s-%s", instanceGroupName, "ephemeral"))
 }
@@ -439,10 +528,14 @@ func generatePersistentVolumeClaimName(instanceGroupName string) string {
 	return names.Sanitize(fmt.Sprintf("%s-%s", instanceGroupName, "pvc"))
 }
 
-func renderingVolume() *corev1.Volume {
+func namedPersistentVolumeClaimName(instanceGroupName string, diskName string) string {
+	return names.Sanitize(fmt.Sprintf("%s-%s-%s", instanceGroupName, diskName, "pvc"))
+}
+
+func renderingVolume(tmpfsSize *resource.Quantity) *corev1.Volume {
 	return &corev1.Volume{
 		Name:         VolumeRenderingDataName,
-		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		VolumeSource: corev1.VolumeSource{EmptyDir: jobDirEmptyDir(tmpfsSize)},
 	}
 }
 
@@ -453,10 +546,21 @@ func renderingVolumeMount() *corev1.VolumeMount {
 	}
 }
 
-func jobsDirVolume() *corev1.Volume {
+func jobsDirVolume(tmpfsSize *resource.Quantity) *corev1.Volume {
 	return &corev1.Volume{
 		Name:         VolumeJobsDirName,
-		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		VolumeSource: corev1.VolumeSource{EmptyDir: jobDirEmptyDir(tmpfsSize)},
+	}
+}
+
+// jobDirEmptyDir returns a memory backed emptyDir, if a tmpfs size is given
+func jobDirEmptyDir(tmpfsSize *resource.Quantity) *corev1.EmptyDirVolumeSource {
+	if tmpfsSize == nil {
+		return &corev1.EmptyDirVolumeSource{}
+	}
+	return &corev1.EmptyDirVolumeSource{
+		Medium:    corev1.StorageMediumMemory,
+		SizeLimit: tmpfsSize,
 	}
 }
 
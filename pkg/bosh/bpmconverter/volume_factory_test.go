@@ -45,7 +45,8 @@ var _ = Describe("VolumeFactory", func() {
 
 	Describe("GenerateDefaultDisks", func() {
 		It("creates default disks", func() {
-			disks := factory.GenerateDefaultDisks(instanceGroup, version, namespace)
+			disks, err := factory.GenerateDefaultDisks("foo", instanceGroup, version, namespace)
+			Expect(err).ToNot(HaveOccurred())
 
 			Expect(disks).Should(HaveLen(5))
 			Expect(disks).Should(ContainElement(bdm.Disk{
@@ -93,12 +94,56 @@ var _ = Describe("VolumeFactory", func() {
 					Name: "ig-resolved",
 					VolumeSource: corev1.VolumeSource{
 						Secret: &corev1.SecretVolumeSource{
-							SecretName: fmt.Sprintf("ig-resolved.%s-v%s", instanceGroup.Name, version),
+							SecretName: fmt.Sprintf("foo.ig-resolved.%s-v%s", instanceGroup.Name, version),
 						},
 					},
 				},
 			}))
 		})
+
+		Context("when the job dir is on tmpfs", func() {
+			emptyDir := func(disks bdm.Disks, name string) *corev1.EmptyDirVolumeSource {
+				for _, disk := range disks {
+					if disk.Volume != nil && disk.Volume.Name == name {
+						return disk.Volume.VolumeSource.EmptyDir
+					}
+				}
+				return nil
+			}
+
+			BeforeEach(func() {
+				instanceGroup.Env.AgentEnvBoshConfig.JobDir = &bdm.JobDir{Tmpfs: pointers.Bool(true)}
+			})
+
+			It("uses memory backed volumes with the default size for the job configs", func() {
+				size := resource.MustParse("100Mi")
+				disks, err := factory.GenerateDefaultDisks("foo", instanceGroup, version, namespace)
+				Expect(err).ToNot(HaveOccurred())
+
+				for _, name := range []string{VolumeRenderingDataName, VolumeJobsDirName} {
+					Expect(emptyDir(disks, name)).To(Equal(&corev1.EmptyDirVolumeSource{
+						Medium:    corev1.StorageMediumMemory,
+						SizeLimit: &size,
+					}))
+				}
+				Expect(emptyDir(disks, VolumeSysDirName)).To(Equal(&corev1.EmptyDirVolumeSource{}))
+			})
+
+			It("uses the tmpfs size of the instance group", func() {
+				instanceGroup.Env.AgentEnvBoshConfig.JobDir.TmpfsSize = "1g"
+				size := resource.MustParse("1Gi")
+				disks, err := factory.GenerateDefaultDisks("foo", instanceGroup, version, namespace)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(emptyDir(disks, VolumeJobsDirName).SizeLimit).To(Equal(&size))
+			})
+
+			It("returns an error for an invalid tmpfs size", func() {
+				instanceGroup.Env.AgentEnvBoshConfig.JobDir.TmpfsSize = "lots"
+				_, err := factory.GenerateDefaultDisks("foo", instanceGroup, version, namespace)
+				Expect(err).To(MatchError(ContainSubstring("invalid job_dir.tmpfs_size 'lots'")))
+			})
+		})
 	})
 
 	Describe("GenerateBPMDisks", func() {
@@ -236,6 +281,101 @@ var _ = Describe("VolumeFactory", func() {
 			}))
 		})
 
+		Context("when the instance group has named persistent disks", func() {
+			BeforeEach(func() {
+				instanceGroup.PersistentDisks = []bdm.PersistentDisk{
+					{Name: "data", Type: "fast", Size: 1024},
+					{Name: "logs", Size: 512},
+				}
+				instanceGroup.Jobs = []bdm.Job{
+					{
+						Name: "fake-job",
+						Consumes: map[string]interface{}{
+							"data-disk": map[string]interface{}{"from": "data"},
+						},
+						Properties: bdm.JobProperties{
+							Quarks: bdm.Quarks{ConsumedDisks: []string{"data"}},
+						},
+					},
+					{
+						Name: "other-job",
+					},
+					{
+						// consumes a link of another type, which has the name of a disk
+						Name: "link-job",
+						Consumes: map[string]interface{}{
+							"data": map[string]interface{}{"from": "data"},
+						},
+					},
+				}
+				bpmConfigs = &bpm.Configs{
+					"fake-job":  bpm.Config{},
+					"other-job": bpm.Config{},
+					"link-job":  bpm.Config{},
+				}
+			})
+
+			It("creates a pvc with its own storage class and size for each disk", func() {
+				disks, err := factory.GenerateBPMDisks(instanceGroup, *bpmConfigs, namespace)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				pvcs := disks.PVCs()
+				Expect(pvcs).To(HaveLen(2))
+				Expect(pvcs[0].Name).To(Equal("fake-instance-group-name-data-pvc"))
+				Expect(pvcs[0].Namespace).To(Equal(namespace))
+				Expect(pvcs[0].Spec.StorageClassName).To(Equal(pointers.String("fast")))
+				Expect(pvcs[0].Spec.Resources.Requests[corev1.ResourceStorage]).To(Equal(resource.MustParse("1024Mi")))
+				Expect(pvcs[1].Name).To(Equal("fake-instance-group-name-logs-pvc"))
+				Expect(pvcs[1].Spec.StorageClassName).To(BeNil())
+				Expect(pvcs[1].Spec.Resources.Requests[corev1.ResourceStorage]).To(Equal(resource.MustParse("512Mi")))
+
+				Expect(disks.Volumes()).To(ContainElement(corev1.Volume{
+					Name: "fake-instance-group-name-data-pvc",
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+							ClaimName: "fake-instance-group-name-data-pvc",
+						},
+					},
+				}))
+			})
+
+			It("mounts the disks into the jobs, which consume them with a disk link", func() {
+				disks, err := factory.GenerateBPMDisks(instanceGroup, *bpmConfigs, namespace)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				Expect(disks.Filter("job_name", "other-job")).To(BeEmpty())
+				Expect(disks.Filter("job_name", "link-job")).To(BeEmpty())
+				Expect(disks.Filter("job_name", "fake-job")).To(Equal(bdm.Disks{
+					{
+						VolumeMount: &corev1.VolumeMount{
+							Name:      "fake-instance-group-name-data-pvc",
+							MountPath: path.Join(VolumeStoreDirMountPath, "data"),
+						},
+						Filters: map[string]string{
+							"job_name":         "fake-job",
+							"named_persistent": "true",
+						},
+					},
+				}))
+			})
+
+			It("handles an error when disk names are not unique", func() {
+				instanceGroup.PersistentDisks[1].Name = "data"
+
+				_, err := factory.GenerateBPMDisks(instanceGroup, *bpmConfigs, namespace)
+				Expect(err).Should(HaveOccurred())
+				Expect(err.Error()).Should(ContainSubstring("more than one persistent disk named 'data'"))
+			})
+
+			It("handles an error when a disk has no size", func() {
+				instanceGroup.PersistentDisks[1].Size = 0
+
+				_, err := factory.GenerateBPMDisks(instanceGroup, *bpmConfigs, namespace)
+				Expect(err).Should(HaveOccurred())
+				Expect(err.Error()).Should(ContainSubstring("persistent disk 'logs' of instance group 'fake-instance-group-name' has no size"))
+			})
+		})
+
 		It("creates additional volumes", func() {
 			bpmConfigs = &bpm.Configs{
 				"fake-job": bpm.Config{
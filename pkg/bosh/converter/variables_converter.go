@@ -5,6 +5,7 @@ import (
 
 	certv1 "k8s.io/api/certificates/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 
 	bdm "code.cloudfoundry.org/quarks-operator/pkg/bosh/manifest"
 	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
@@ -21,27 +22,30 @@ func NewVariablesConverter() *VariablesConverter {
 	return &VariablesConverter{}
 }
 
-// Variables returns quarks secrets for a list of BOSH variables
-func (vc *VariablesConverter) Variables(namespace string, manifestName string, variables []bdm.Variable) ([]qsv1a1.QuarksSecret, error) {
+// Variables returns quarks secrets for a list of BOSH variables. The
+// manifest's tags are added to the quarks secrets and their secrets.
+func (vc *VariablesConverter) Variables(namespace string, manifestName string, variables []bdm.Variable, tags bdm.Tags) ([]qsv1a1.QuarksSecret, error) {
 	secrets := []qsv1a1.QuarksSecret{}
 
 	for _, v := range variables {
-		secretName := names.SecretVariableName(v.Name)
+		secretName := names.DeploymentSecretName(bdv1.DeploymentSecretTypeVariable, manifestName, v.Name)
 		s := qsv1a1.QuarksSecret{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      secretName,
 				Namespace: namespace,
-				Labels: map[string]string{
+				Labels: labels.Merge(tags.Labels(), map[string]string{
 					"variableName":           v.Name,
 					bdv1.LabelDeploymentName: manifestName,
-				},
+				}),
+				Annotations: tags.Annotations(),
 			},
 			Spec: qsv1a1.QuarksSecretSpec{
 				Type:       v.Type,
 				SecretName: secretName,
-				SecretLabels: map[string]string{
+				SecretLabels: labels.Merge(tags.Labels(), map[string]string{
 					bdv1.LabelDeploymentName: manifestName,
-				},
+				}),
+				SecretAnnotations: tags.Annotations(),
 			},
 		}
 
@@ -89,11 +93,11 @@ func (vc *VariablesConverter) Variables(namespace string, manifestName string, v
 			}
 			if v.Options.CA != "" {
 				certRequest.CARef = qsv1a1.SecretReference{
-					Name: names.SecretVariableName(v.Options.CA),
+					Name: names.DeploymentSecretName(bdv1.DeploymentSecretTypeVariable, manifestName, v.Options.CA),
 					Key:  "certificate",
 				}
 				certRequest.CAKeyRef = qsv1a1.SecretReference{
-					Name: names.SecretVariableName(v.Options.CA),
+					Name: names.DeploymentSecretName(bdv1.DeploymentSecretTypeVariable, manifestName, v.Options.CA),
 					Key:  "private_key",
 				}
 			}
@@ -30,17 +30,17 @@ var _ = Describe("kube converter", func() {
 
 		act := func() ([]qsv1a1.QuarksSecret, error) {
 			kubeConverter := converter.NewVariablesConverter()
-			return kubeConverter.Variables("foo", deploymentName, m.Variables)
+			return kubeConverter.Variables("foo", deploymentName, m.Variables, m.Tags)
 		}
 
 		Context("converting variables", func() {
 			It("sanitizes secret names", func() {
-				deploymentName = "-abc_123.?!\"§$&/()=?"
+				deploymentName = "abc-123"
 				m.Variables[0].Name = "def-456.?!\"§$&/()=?-"
 
 				variables, err := act()
 				Expect(err).NotTo(HaveOccurred())
-				Expect(variables[0].Name).To(Equal("var-def-456"))
+				Expect(variables[0].Name).To(Equal("abc-123.var-def-456"))
 			})
 
 			It("trims secret names", func() {
@@ -50,7 +50,7 @@ var _ = Describe("kube converter", func() {
 
 				variables, err := act()
 				Expect(err).NotTo(HaveOccurred())
-				Expect(variables[0].Name).To(Equal("var-" + long[:216] + "-cd1aedf32e5af6a8401880952515840e"))
+				Expect(variables[0].Name).To(Equal("foo.var-" + long[:212] + "-fb1e2b65c8feba8b359ec9a4f84b0e02"))
 			})
 
 			It("converts password variables", func() {
@@ -59,9 +59,22 @@ var _ = Describe("kube converter", func() {
 				Expect(len(variables)).To(Equal(1))
 
 				var1 := variables[0]
-				Expect(var1.Name).To(Equal("var-adminpass"))
+				Expect(var1.Name).To(Equal("foo-deployment.var-adminpass"))
 				Expect(var1.Spec.Type).To(Equal(qsv1a1.Password))
-				Expect(var1.Spec.SecretName).To(Equal("var-adminpass"))
+				Expect(var1.Spec.SecretName).To(Equal("foo-deployment.var-adminpass"))
+			})
+
+			It("adds the manifest's tags to the quarks secrets and their secrets", func() {
+				m.Tags = manifest.Tags{"owner": "team a"}
+				variables, err := act()
+				Expect(err).NotTo(HaveOccurred())
+
+				var1 := variables[0]
+				Expect(var1.GetLabels()).To(HaveKeyWithValue("tags.quarks.cloudfoundry.org/owner", "team-a"))
+				Expect(var1.GetLabels()).To(HaveKeyWithValue(bdv1.LabelDeploymentName, deploymentName))
+				Expect(var1.GetAnnotations()).To(HaveKeyWithValue("tags.quarks.cloudfoundry.org/owner", "team a"))
+				Expect(var1.Spec.SecretLabels).To(HaveKeyWithValue("tags.quarks.cloudfoundry.org/owner", "team-a"))
+				Expect(var1.Spec.SecretAnnotations).To(HaveKeyWithValue("tags.quarks.cloudfoundry.org/owner", "team a"))
 			})
 
 			It("converts rsa key variables", func() {
@@ -74,10 +87,10 @@ var _ = Describe("kube converter", func() {
 				Expect(variables).To(HaveLen(1))
 
 				var1 := variables[0]
-				Expect(var1.Name).To(Equal("var-adminkey"))
+				Expect(var1.Name).To(Equal("foo-deployment.var-adminkey"))
 				Expect(var1.GetLabels()).To(HaveKeyWithValue(bdv1.LabelDeploymentName, deploymentName))
 				Expect(var1.Spec.Type).To(Equal(qsv1a1.RSAKey))
-				Expect(var1.Spec.SecretName).To(Equal("var-adminkey"))
+				Expect(var1.Spec.SecretName).To(Equal("foo-deployment.var-adminkey"))
 			})
 
 			It("converts ssh key variables", func() {
@@ -90,10 +103,10 @@ var _ = Describe("kube converter", func() {
 				Expect(variables).To(HaveLen(1))
 
 				var1 := variables[0]
-				Expect(var1.Name).To(Equal("var-adminkey"))
+				Expect(var1.Name).To(Equal("foo-deployment.var-adminkey"))
 				Expect(var1.GetLabels()).To(HaveKeyWithValue(bdv1.LabelDeploymentName, deploymentName))
 				Expect(var1.Spec.Type).To(Equal(qsv1a1.SSHKey))
-				Expect(var1.Spec.SecretName).To(Equal("var-adminkey"))
+				Expect(var1.Spec.SecretName).To(Equal("foo-deployment.var-adminkey"))
 			})
 
 			It("raises an error when the options are missing for a certificate variable", func() {
@@ -143,15 +156,15 @@ var _ = Describe("kube converter", func() {
 				Expect(variables).To(HaveLen(1))
 
 				var1 := variables[0]
-				Expect(var1.Name).To(Equal("var-foo-cert"))
+				Expect(var1.Name).To(Equal("foo-deployment.var-foo-cert"))
 				Expect(var1.GetLabels()).To(HaveKeyWithValue(bdv1.LabelDeploymentName, deploymentName))
 				Expect(var1.Spec.Type).To(Equal(qsv1a1.Certificate))
-				Expect(var1.Spec.SecretName).To(Equal("var-foo-cert"))
+				Expect(var1.Spec.SecretName).To(Equal("foo-deployment.var-foo-cert"))
 				request := var1.Spec.Request.CertificateRequest
 				Expect(request.CommonName).To(Equal("example.com"))
 				Expect(request.AlternativeNames).To(Equal([]string{"foo.com", "bar.com"}))
 				Expect(request.IsCA).To(Equal(true))
-				Expect(request.CARef.Name).To(Equal("var-theca"))
+				Expect(request.CARef.Name).To(Equal("foo-deployment.var-theca"))
 				Expect(request.CARef.Key).To(Equal("certificate"))
 			})
 		})
@@ -9,11 +9,14 @@ import (
 
 type matcher func(*InstanceGroup, *AddOnPlacementRules) (bool, error)
 
-// jobMatch matches stemcell rules for addon placement
+// stemcellMatch matches stemcell rules for addon placement
 func (m *Manifest) stemcellMatch(instanceGroup *InstanceGroup, rules *AddOnPlacementRules) (bool, error) {
 	if instanceGroup == nil || rules == nil {
 		return false, nil
 	}
+	if len(rules.Stemcell) == 0 {
+		return true, nil
+	}
 
 	osList := map[string]struct{}{}
 
@@ -73,7 +76,79 @@ func (m *Manifest) instanceGroupMatch(instanceGroup *InstanceGroup, rules *AddOn
 	return false, nil
 }
 
-// addOnPlacementMatch returns true if any placement rule of the addon matches the instance group
+// jobOrInstanceGroupMatch matches job and instance group rules for addon
+// placement. As in BOSH, it's enough for one of them to match.
+func (m *Manifest) jobOrInstanceGroupMatch(instanceGroup *InstanceGroup, rules *AddOnPlacementRules) (bool, error) {
+	if instanceGroup == nil || rules == nil {
+		return false, nil
+	}
+	if len(rules.Jobs) == 0 && len(rules.InstanceGroup) == 0 {
+		return true, nil
+	}
+
+	matched, err := m.jobMatch(instanceGroup, rules)
+	if err != nil || matched {
+		return matched, err
+	}
+
+	return m.instanceGroupMatch(instanceGroup, rules)
+}
+
+// deploymentMatch matches deployment rules for addon placement against the
+// name of the manifest, like BOSH does
+func (m *Manifest) deploymentMatch(instanceGroup *InstanceGroup, rules *AddOnPlacementRules) (bool, error) {
+	if instanceGroup == nil || rules == nil {
+		return false, nil
+	}
+	if len(rules.Deployments) == 0 {
+		return true, nil
+	}
+
+	for _, d := range rules.Deployments {
+		if d == m.Name {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// networkMatch matches network rules for addon placement
+func (m *Manifest) networkMatch(instanceGroup *InstanceGroup, rules *AddOnPlacementRules) (bool, error) {
+	if instanceGroup == nil || rules == nil {
+		return false, nil
+	}
+	if len(rules.Networks) == 0 {
+		return true, nil
+	}
+
+	networks := map[string]struct{}{}
+	for _, n := range instanceGroup.Networks {
+		networks[n.Name] = struct{}{}
+	}
+
+	for _, n := range rules.Networks {
+		if _, ok := networks[n]; ok {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// teamMatch matches team rules for addon placement. Deployments managed by
+// the operator don't belong to any BOSH director team, so team rules never match.
+func (m *Manifest) teamMatch(instanceGroup *InstanceGroup, rules *AddOnPlacementRules) (bool, error) {
+	if instanceGroup == nil || rules == nil {
+		return false, nil
+	}
+
+	return len(rules.Teams) == 0, nil
+}
+
+// addOnPlacementMatch returns true if the placement rules of the addon match
+// the instance group. Like BOSH, all given rule types need to match, except for
+// jobs and instance groups, of which only one needs to match.
 func (m *Manifest) addOnPlacementMatch(log *zap.SugaredLogger, placementType string, instanceGroup *InstanceGroup, rules *AddOnPlacementRules) (bool, error) {
 	// This check is special, not a matcher. Lifecycle always needs to match
 	if (instanceGroup.LifeCycle == IGTypeErrand ||
@@ -91,25 +166,24 @@ func (m *Manifest) addOnPlacementMatch(log *zap.SugaredLogger, placementType str
 	}
 
 	matchers := []matcher{
+		m.deploymentMatch,
+		m.teamMatch,
 		m.stemcellMatch,
-		m.jobMatch,
-		m.instanceGroupMatch,
+		m.networkMatch,
+		m.jobOrInstanceGroupMatch,
 	}
 
-	matchResult := false
-
 	for _, matcher := range matchers {
 		matched, err := matcher(instanceGroup, rules)
 		if err != nil {
 			return false, errors.Wrapf(err, "failed to process match for instance group %s", instanceGroup.Name)
 		}
 
-		matchResult = matchResult || matched
-	}
-
-	if !matchResult {
-		log.Debugf("Instance group '%s' did not match the %s placement rules", instanceGroup.Name, placementType)
+		if !matched {
+			log.Debugf("Instance group '%s' did not match the %s placement rules", instanceGroup.Name, placementType)
+			return false, nil
+		}
 	}
 
-	return matchResult, nil
+	return true, nil
 }
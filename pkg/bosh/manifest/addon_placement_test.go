@@ -51,6 +51,45 @@ var _ = Describe("Addons", func() {
 		Expect(manifest.InstanceGroups[2].Jobs[1].Name).To(Equal("addon-job3"))
 	})
 
+	Context("when using deployment, network and team placement rules", func() {
+		jobNames := func(ig *InstanceGroup) []string {
+			names := []string{}
+			for _, job := range ig.Jobs {
+				names = append(names, job.Name)
+			}
+			return names
+		}
+
+		JustBeforeEach(func() {
+			var err error
+			manifest, err = LoadYAML([]byte(boshmanifest.WithAddonPlacementRules))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should combine the rules like BOSH", func() {
+			err := manifest.ApplyAddons(log)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(manifest.InstanceGroups).To(HaveLen(2))
+			Expect(jobNames(manifest.InstanceGroups[0])).To(Equal([]string{
+				"redis-server", "deployment-job", "or-job", "exclude-team-job",
+			}))
+			Expect(jobNames(manifest.InstanceGroups[1])).To(Equal([]string{
+				"cflinuxfs3-rootfs-setup", "network-job", "and-job", "or-job",
+			}))
+		})
+
+		It("should not apply addons for other deployments", func() {
+			manifest.Name = "bar-deployment"
+			err := manifest.ApplyAddons(log)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(jobNames(manifest.InstanceGroups[0])).To(ContainElement("other-deployment-job"))
+			Expect(jobNames(manifest.InstanceGroups[0])).ToNot(ContainElement("deployment-job"))
+			Expect(jobNames(manifest.InstanceGroups[1])).To(ContainElement("other-deployment-job"))
+		})
+	})
+
 	Context("when using trace logger", func() {
 		BeforeEach(func() {
 			logger.Trace = true
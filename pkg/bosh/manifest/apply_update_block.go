@@ -3,16 +3,16 @@ package manifest
 import "code.cloudfoundry.org/quarks-operator/pkg/kube/util/names"
 
 // ApplyUpdateBlock interprets and propagates information of the 'update'-blocks
-func (m *Manifest) ApplyUpdateBlock() {
+func (m *Manifest) ApplyUpdateBlock(deploymentName string) {
 	m.PropagateGlobalUpdateBlockToIGs()
-	m.calculateRequiredServices()
+	m.calculateRequiredServices(deploymentName)
 }
 
 // calculateRequiredServices calculates the required services using the update.serial property
 // It follows the algorithm from BOSH:
 // * it will use the last service as a dependency that had update.serial set
 // * if there are no service ports, it will use the last value
-func (m *Manifest) calculateRequiredServices() {
+func (m *Manifest) calculateRequiredServices(deploymentName string) {
 	var requiredService *string
 	var lastUsedService *string
 
@@ -30,7 +30,7 @@ func (m *Manifest) calculateRequiredServices() {
 
 		ports := ig.ServicePorts()
 		if len(ports) > 0 {
-			serviceName := names.ServiceName(ig.Name)
+			serviceName := names.ServiceName(deploymentName, ig.Name)
 			requiredService = &serviceName
 		}
 
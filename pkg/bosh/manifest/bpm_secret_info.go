@@ -21,4 +21,7 @@ type BPMInstanceGroup struct {
 	Instances int      `json:"instances"`
 	AZs       []string `json:"azs"`
 	Env       AgentEnv `json:"env,omitempty"`
+	// ConsumedDisks are the named persistent disks, which each job consumes
+	// with a link of type disk
+	ConsumedDisks map[string][]string `json:"consumed_disks,omitempty"`
 }
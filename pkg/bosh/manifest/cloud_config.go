@@ -0,0 +1,246 @@
+package manifest
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// CloudConfig is a BOSH cloud config, which defines the availability zones,
+// vm types, vm extensions and disk types instance groups refer to. References
+// are only checked against a section, if the cloud config defines it.
+type CloudConfig struct {
+	AZs          []*CloudConfigAZ `json:"azs,omitempty"`
+	VMTypes      []*VMType        `json:"vm_types,omitempty"`
+	VMExtensions []*VMExtension   `json:"vm_extensions,omitempty"`
+	DiskTypes    []*DiskType      `json:"disk_types,omitempty"`
+}
+
+// CloudConfigAZ is an availability zone of a cloud config
+type CloudConfigAZ struct {
+	Name            string            `json:"name"`
+	CloudProperties AZCloudProperties `json:"cloud_properties,omitempty"`
+}
+
+// AZCloudProperties maps an availability zone to the zone of nodes
+type AZCloudProperties struct {
+	// Zone is the value of the zone node label, defaults to the name of the
+	// availability zone
+	Zone string `json:"zone,omitempty"`
+}
+
+// VMType of a cloud config, it's applied to the pods of instance groups
+type VMType struct {
+	Name            string                `json:"name"`
+	CloudProperties VMTypeCloudProperties `json:"cloud_properties,omitempty"`
+}
+
+// VMTypeCloudProperties are the scheduling properties of a vm type
+type VMTypeCloudProperties struct {
+	// Resources are the default resources of each container
+	Resources    corev1.ResourceRequirements `json:"resources,omitempty"`
+	NodeSelector map[string]string           `json:"node_selector,omitempty"`
+	Tolerations  []corev1.Toleration         `json:"tolerations,omitempty"`
+}
+
+// VMExtension of a cloud config, it's applied to the pods of instance groups
+type VMExtension struct {
+	Name            string                     `json:"name"`
+	CloudProperties VMExtensionCloudProperties `json:"cloud_properties,omitempty"`
+}
+
+// VMExtensionCloudProperties patch the pod template of instance groups and
+// can expose them with an additional service
+type VMExtensionCloudProperties struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// PodTemplate is a strategic merge patch for the pod template
+	PodTemplate map[string]interface{} `json:"pod_template,omitempty"`
+	Service     *VMExtensionService    `json:"service,omitempty"`
+}
+
+// VMExtensionService is an additional service for the pods of an instance group
+type VMExtensionService struct {
+	Type                     corev1.ServiceType                      `json:"type,omitempty"`
+	Annotations              map[string]string                       `json:"annotations,omitempty"`
+	LoadBalancerSourceRanges []string                                `json:"load_balancer_source_ranges,omitempty"`
+	ExternalTrafficPolicy    corev1.ServiceExternalTrafficPolicyType `json:"external_traffic_policy,omitempty"`
+}
+
+// DiskType of a cloud config, it's used for the persistent disks of instance groups
+type DiskType struct {
+	Name            string                  `json:"name"`
+	DiskSize        int                     `json:"disk_size"`
+	CloudProperties DiskTypeCloudProperties `json:"cloud_properties,omitempty"`
+}
+
+// DiskTypeCloudProperties maps a disk type to a storage class
+type DiskTypeCloudProperties struct {
+	// StorageClass defaults to the name of the disk type
+	StorageClass string `json:"storage_class,omitempty"`
+}
+
+// LoadCloudConfigYAML returns a new BOSH cloud config from a yaml representation
+func LoadCloudConfigYAML(data []byte) (*CloudConfig, error) {
+	cc := &CloudConfig{}
+	err := yaml.Unmarshal(data, cc, func(opt *json.Decoder) *json.Decoder {
+		opt.UseNumber()
+		return opt
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal BOSH cloud config %s", string(data))
+	}
+
+	return cc, nil
+}
+
+// ApplyCloudConfig merges the cloud config into the manifest's cloud config.
+// Entries, which already exist by name, take precedence.
+func (m *Manifest) ApplyCloudConfig(cc *CloudConfig) {
+	if m.CloudConfig == nil {
+		m.CloudConfig = &CloudConfig{}
+	}
+
+	azs := map[string]struct{}{}
+	for _, az := range m.CloudConfig.AZs {
+		azs[az.Name] = struct{}{}
+	}
+	for _, az := range cc.AZs {
+		if _, ok := azs[az.Name]; !ok {
+			azs[az.Name] = struct{}{}
+			m.CloudConfig.AZs = append(m.CloudConfig.AZs, az)
+		}
+	}
+
+	vmTypes := map[string]struct{}{}
+	for _, t := range m.CloudConfig.VMTypes {
+		vmTypes[t.Name] = struct{}{}
+	}
+	for _, t := range cc.VMTypes {
+		if _, ok := vmTypes[t.Name]; !ok {
+			vmTypes[t.Name] = struct{}{}
+			m.CloudConfig.VMTypes = append(m.CloudConfig.VMTypes, t)
+		}
+	}
+
+	vmExtensions := map[string]struct{}{}
+	for _, e := range m.CloudConfig.VMExtensions {
+		vmExtensions[e.Name] = struct{}{}
+	}
+	for _, e := range cc.VMExtensions {
+		if _, ok := vmExtensions[e.Name]; !ok {
+			vmExtensions[e.Name] = struct{}{}
+			m.CloudConfig.VMExtensions = append(m.CloudConfig.VMExtensions, e)
+		}
+	}
+
+	diskTypes := map[string]struct{}{}
+	for _, d := range m.CloudConfig.DiskTypes {
+		diskTypes[d.Name] = struct{}{}
+	}
+	for _, d := range cc.DiskTypes {
+		if _, ok := diskTypes[d.Name]; !ok {
+			diskTypes[d.Name] = struct{}{}
+			m.CloudConfig.DiskTypes = append(m.CloudConfig.DiskTypes, d)
+		}
+	}
+}
+
+// AZ returns the availability zone with the given name. It returns nil, if
+// the cloud config doesn't define availability zones.
+func (cc *CloudConfig) AZ(name string) (*CloudConfigAZ, error) {
+	if cc == nil || len(cc.AZs) == 0 {
+		return nil, nil
+	}
+	for _, az := range cc.AZs {
+		if az.Name == name {
+			return az, nil
+		}
+	}
+	return nil, errors.Errorf("availability zone '%s' is not defined in the cloud config", name)
+}
+
+// VMType returns the vm type with the given name. It returns nil, if the name
+// is empty or the cloud config doesn't define vm types.
+func (cc *CloudConfig) VMType(name string) (*VMType, error) {
+	if cc == nil || name == "" || len(cc.VMTypes) == 0 {
+		return nil, nil
+	}
+	for _, t := range cc.VMTypes {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	return nil, errors.Errorf("vm type '%s' is not defined in the cloud config", name)
+}
+
+// VMExtension returns the vm extension with the given name. It returns nil,
+// if the cloud config doesn't define vm extensions.
+func (cc *CloudConfig) VMExtension(name string) (*VMExtension, error) {
+	if cc == nil || len(cc.VMExtensions) == 0 {
+		return nil, nil
+	}
+	for _, e := range cc.VMExtensions {
+		if e.Name == name {
+			return e, nil
+		}
+	}
+	return nil, errors.Errorf("vm extension '%s' is not defined in the cloud config", name)
+}
+
+// DiskType returns the disk type with the given name. It returns nil, if the
+// name is empty or the cloud config doesn't define disk types.
+func (cc *CloudConfig) DiskType(name string) (*DiskType, error) {
+	if cc == nil || name == "" || len(cc.DiskTypes) == 0 {
+		return nil, nil
+	}
+	for _, d := range cc.DiskTypes {
+		if d.Name == name {
+			return d, nil
+		}
+	}
+	return nil, errors.Errorf("disk type '%s' is not defined in the cloud config", name)
+}
+
+// StorageClass returns the storage class of the disk type
+func (d *DiskType) StorageClass() string {
+	if d.CloudProperties.StorageClass != "" {
+		return d.CloudProperties.StorageClass
+	}
+	return d.Name
+}
+
+// ApplyDiskTypes resolves the disk types of the instance group's persistent
+// disks to storage classes. Disks without a size get the disk type's size.
+func (ig *InstanceGroup) ApplyDiskTypes(cc *CloudConfig) error {
+	diskType, err := cc.DiskType(ig.PersistentDiskType)
+	if err != nil {
+		return errors.Wrapf(err, "persistent disk of instance group '%s'", ig.Name)
+	}
+	if diskType != nil {
+		if ig.PersistentDisk == nil && diskType.DiskSize > 0 {
+			size := diskType.DiskSize
+			ig.PersistentDisk = &size
+		}
+		ig.PersistentDiskType = diskType.StorageClass()
+	}
+
+	for i := range ig.PersistentDisks {
+		disk := &ig.PersistentDisks[i]
+		diskType, err := cc.DiskType(disk.Type)
+		if err != nil {
+			return errors.Wrapf(err, "persistent disk '%s' of instance group '%s'", disk.Name, ig.Name)
+		}
+		if diskType == nil {
+			continue
+		}
+		if disk.Size == 0 {
+			disk.Size = diskType.DiskSize
+		}
+		disk.Type = diskType.StorageClass()
+	}
+
+	return nil
+}
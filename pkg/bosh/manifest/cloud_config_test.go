@@ -0,0 +1,105 @@
+package manifest_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "code.cloudfoundry.org/quarks-operator/pkg/bosh/manifest"
+)
+
+var _ = Describe("CloudConfig", func() {
+	var cc *CloudConfig
+
+	BeforeEach(func() {
+		var err error
+		cc, err = LoadCloudConfigYAML([]byte(`---
+azs:
+- name: z1
+  cloud_properties:
+    zone: eu-west-1a
+vm_types:
+- name: small
+  cloud_properties:
+    resources:
+      requests:
+        cpu: 250m
+    node_selector:
+      pool: small
+disk_types:
+- name: fast
+  disk_size: 2048
+  cloud_properties:
+    storage_class: ssd
+- name: standard
+  disk_size: 1024
+`))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("loads the cloud properties", func() {
+		az, err := cc.AZ("z1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(az.CloudProperties.Zone).To(Equal("eu-west-1a"))
+
+		vmType, err := cc.VMType("small")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vmType.CloudProperties.Resources.Requests.Cpu().String()).To(Equal("250m"))
+		Expect(vmType.CloudProperties.NodeSelector).To(Equal(map[string]string{"pool": "small"}))
+	})
+
+	It("fails for references, which are missing in a defined section", func() {
+		_, err := cc.VMType("large")
+		Expect(err).To(MatchError(ContainSubstring("vm type 'large' is not defined")))
+		_, err = cc.AZ("z2")
+		Expect(err).To(MatchError(ContainSubstring("availability zone 'z2' is not defined")))
+	})
+
+	It("doesn't check sections, which are not defined", func() {
+		extension, err := cc.VMExtension("public")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(extension).To(BeNil())
+
+		var empty *CloudConfig
+		vmType, err := empty.VMType("small")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vmType).To(BeNil())
+	})
+
+	It("merges cloud configs, keeping existing entries", func() {
+		m := &Manifest{}
+		m.ApplyCloudConfig(cc)
+		m.ApplyCloudConfig(&CloudConfig{
+			VMTypes:      []*VMType{{Name: "small"}, {Name: "large"}},
+			VMExtensions: []*VMExtension{{Name: "public"}},
+		})
+
+		Expect(m.CloudConfig.VMTypes).To(HaveLen(2))
+		Expect(m.CloudConfig.VMTypes[0].CloudProperties.NodeSelector).To(HaveKey("pool"))
+		Expect(m.CloudConfig.VMExtensions).To(HaveLen(1))
+		Expect(m.CloudConfig.DiskTypes).To(HaveLen(2))
+	})
+
+	Describe("ApplyDiskTypes", func() {
+		It("uses the storage class and size of the disk types", func() {
+			ig := &InstanceGroup{
+				Name:               "redis",
+				PersistentDiskType: "fast",
+				PersistentDisks: []PersistentDisk{
+					{Name: "data", Type: "standard"},
+					{Name: "logs", Type: "fast", Size: 512},
+				},
+			}
+			Expect(ig.ApplyDiskTypes(cc)).To(Succeed())
+
+			Expect(ig.PersistentDiskType).To(Equal("ssd"))
+			Expect(*ig.PersistentDisk).To(Equal(2048))
+			Expect(ig.PersistentDisks[0]).To(Equal(PersistentDisk{Name: "data", Type: "standard", Size: 1024}))
+			Expect(ig.PersistentDisks[1]).To(Equal(PersistentDisk{Name: "logs", Type: "ssd", Size: 512}))
+		})
+
+		It("fails for unknown disk types", func() {
+			ig := &InstanceGroup{Name: "redis", PersistentDiskType: "slow"}
+			Expect(ig.ApplyDiskTypes(cc)).To(MatchError(ContainSubstring("disk type 'slow' is not defined")))
+		})
+	})
+})
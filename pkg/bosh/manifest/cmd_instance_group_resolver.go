@@ -24,6 +24,9 @@ const QuarksLinksProperty = "quarks_links"
 // LinkFile is the property in the secrets data, containing the link properties yaml
 const LinkFile = "link"
 
+// diskLinkType is the type of consumes, which refer to a named persistent disk
+const diskLinkType = "disk"
+
 // InstanceGroupResolver gathers data for jobs in the manifest, it handles links and returns a deployment manifest
 // that only has information pertinent to an instance group.
 type InstanceGroupResolver struct {
@@ -66,6 +69,12 @@ func (igr *InstanceGroupResolver) Resolve(initialRollout bool) error {
 		return err
 	}
 
+	return igr.ResolveWithoutScripts(initialRollout)
+}
+
+// ResolveWithoutScripts is like Resolve, but doesn't run the pre-render
+// scripts of the jobs, since those expect to run inside the release images.
+func (igr *InstanceGroupResolver) ResolveWithoutScripts(initialRollout bool) error {
 	if err := igr.collectReleaseSpecsAndProviderLinks(initialRollout); err != nil {
 		return err
 	}
@@ -94,6 +103,13 @@ func (igr *InstanceGroupResolver) BPMInfo() (BPMInfo, error) {
 			return bpmInfo, errors.Errorf("Empty bpm configs about job '%s'", job.Name)
 		}
 		bpmInfo.Configs[job.Name] = *job.Properties.Quarks.BPM
+
+		if len(job.Properties.Quarks.ConsumedDisks) > 0 {
+			if bpmInfo.InstanceGroup.ConsumedDisks == nil {
+				bpmInfo.InstanceGroup.ConsumedDisks = map[string][]string{}
+			}
+			bpmInfo.InstanceGroup.ConsumedDisks[job.Name] = job.Properties.Quarks.ConsumedDisks
+		}
 	}
 
 	bpmInfo.InstanceGroup.Name = igr.instanceGroup.Name
@@ -239,7 +255,7 @@ func (igr *InstanceGroupResolver) CollectQuarksLinks(linksPath string) error {
 // collectReleaseSpecsAndProviderLinks will collect all release specs and generate bosh links for provider jobs
 func (igr *InstanceGroupResolver) collectReleaseSpecsAndProviderLinks(initialRollout bool) error {
 	for _, instanceGroup := range igr.manifest.InstanceGroups {
-		serviceName := names.ServiceName(instanceGroup.Name)
+		serviceName := names.ServiceName(igr.deploymentName, instanceGroup.Name)
 
 		for jobIdx, job := range instanceGroup.Jobs {
 			// make sure a map entry exists for the current job release
@@ -262,7 +278,7 @@ func (igr *InstanceGroupResolver) collectReleaseSpecsAndProviderLinks(initialRol
 			// Generate instance spec for each ig instance
 			// This will be stored inside the current job under
 			// job.properties.quarks
-			jobsInstances := instanceGroup.jobInstances(job.Name, initialRollout)
+			jobsInstances := instanceGroup.jobInstances(igr.deploymentName, job.Name, initialRollout)
 
 			// set jobs.properties.quarks.instances with the ig instances
 			instanceGroup.Jobs[jobIdx].Properties.Quarks.Instances = jobsInstances
@@ -463,6 +479,12 @@ func generateJobConsumersData(currentJob *Job, jobReleaseSpecs map[string]map[st
 	for _, provider := range currentJobSpecData.Consumes {
 		providerName := getProviderNameFromConsumer(*currentJob, provider.Name)
 
+		// Named persistent disks are not links, they are mounted by the BPM converter
+		if provider.Type == diskLinkType {
+			currentJob.Properties.Quarks.ConsumedDisks = append(currentJob.Properties.Quarks.ConsumedDisks, providerName)
+			continue
+		}
+
 		link, hasLink := jobProviderLinks.lookup(&provider)
 		if !hasLink && !provider.Optional {
 			return errors.Errorf("cannot resolve non-optional link for provider %s in job %s", providerName, currentJob.Name)
@@ -26,6 +26,7 @@ const (
 // boshManifest is a resolved manifest for a single instance group
 func RenderJobTemplates(
 	boshManifestPath string,
+	deploymentName string,
 	jobsDir string,
 	jobsOutputDir string,
 	instanceGroupName string,
@@ -68,7 +69,7 @@ func RenderJobTemplates(
 		// Generate instance spec for each ig instance
 		// This will be stored inside the current job under
 		// job.properties.quarks
-		jobsInstances := currentInstanceGroup.jobInstances(job.Name, initialRollout)
+		jobsInstances := currentInstanceGroup.jobInstances(deploymentName, job.Name, initialRollout)
 
 		// set jobs.properties.quarks.instances with the ig instances
 		currentInstanceGroup.Jobs[jobIdx].Properties.Quarks.Instances = jobsInstances
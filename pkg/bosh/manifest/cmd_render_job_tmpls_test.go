@@ -36,7 +36,7 @@ var _ = Describe("Trender", func() {
 		})
 
 		act := func() error {
-			return manifest.RenderJobTemplates(deploymentManifest, jobsDir, jobsDir, instanceGroupName, index, podIP, replicas, true)
+			return manifest.RenderJobTemplates(deploymentManifest, "foo-deployment", jobsDir, jobsDir, instanceGroupName, index, podIP, replicas, true)
 		}
 
 		It("fails", func() {
@@ -55,7 +55,7 @@ var _ = Describe("Trender", func() {
 		})
 
 		act := func() error {
-			return manifest.RenderJobTemplates(deploymentManifest, jobsDir, jobsDir, instanceGroupName, index, podIP, replicas, true)
+			return manifest.RenderJobTemplates(deploymentManifest, "foo-deployment", jobsDir, jobsDir, instanceGroupName, index, podIP, replicas, true)
 		}
 
 		Context("with an invalid instance index", func() {
@@ -135,7 +135,7 @@ var _ = Describe("Trender", func() {
 		})
 
 		It("renders the job erb files correctly", func() {
-			err := manifest.RenderJobTemplates(deploymentManifest, jobsDir, jobsDir, instanceGroupName, index, podIP, replicas, true)
+			err := manifest.RenderJobTemplates(deploymentManifest, "foo-deployment", jobsDir, jobsDir, instanceGroupName, index, podIP, replicas, true)
 			Expect(err).ToNot(HaveOccurred())
 
 			drainFile := filepath.Join(jobsDir, "pxc-mysql", "bin/drain")
@@ -172,7 +172,7 @@ var _ = Describe("Trender", func() {
 		})
 
 		It("renders the configuration erb file correctly", func() {
-			err := manifest.RenderJobTemplates(deploymentManifest, jobsDir, jobsDir, instanceGroupName, index, podIP, replicas, true)
+			err := manifest.RenderJobTemplates(deploymentManifest, "foo-deployment", jobsDir, jobsDir, instanceGroupName, index, podIP, replicas, true)
 			Expect(err).ToNot(HaveOccurred())
 
 			configFile := filepath.Join(jobsDir, "redis-server", "config/redis.conf")
@@ -201,7 +201,7 @@ var _ = Describe("Trender", func() {
 		})
 
 		It("usage of spec field in an ERB template should work", func() {
-			err := manifest.RenderJobTemplates(deploymentManifest, jobsDir, jobsDir, instanceGroupName, index, podIP, replicas, true)
+			err := manifest.RenderJobTemplates(deploymentManifest, "foo-deployment", jobsDir, jobsDir, instanceGroupName, index, podIP, replicas, true)
 			Expect(err).ToNot(HaveOccurred())
 
 			configFile := filepath.Join(jobsDir, "metricsserver", "config/metricsserver.yml")
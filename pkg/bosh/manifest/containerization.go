@@ -21,6 +21,7 @@ type Quarks struct {
 	IsAddon             bool                    `json:"is_addon" yaml:"is_addon"`
 	Envs                []corev1.EnvVar         `json:"envs" yaml:"envs"`
 	ActivePassiveProbes map[string]corev1.Probe `json:"activePassiveProbes,omitempty"`
+	ConsumedDisks       []string                `json:"consumed_disks,omitempty" yaml:"consumed_disks,omitempty"`
 }
 
 // Port represents the port to be opened up for this job.
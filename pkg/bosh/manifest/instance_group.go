@@ -4,12 +4,17 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v2"
 
+	batchv1b1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
 
 	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
 	boshnames "code.cloudfoundry.org/quarks-operator/pkg/kube/util/names"
@@ -30,6 +35,19 @@ func (instanceGroups InstanceGroups) InstanceGroupByName(name string) (*Instance
 	return nil, false
 }
 
+// MigratedTo returns the instance group, which lists the given name in its
+// migrated_from. The second return parameter indicates if it was found.
+func (instanceGroups InstanceGroups) MigratedTo(name string) (*InstanceGroup, bool) {
+	for _, instanceGroup := range instanceGroups {
+		for _, migratedFrom := range instanceGroup.MigratedFrom {
+			if migratedFrom != nil && migratedFrom.Name == name {
+				return instanceGroup, true
+			}
+		}
+	}
+	return nil, false
+}
+
 // InstanceGroup from BOSH deployment manifest.
 type InstanceGroup struct {
 	Name               string                  `json:"name"`
@@ -42,6 +60,7 @@ type InstanceGroup struct {
 	Stemcell           string                  `json:"stemcell"`
 	PersistentDisk     *int                    `json:"persistent_disk,omitempty"`
 	PersistentDiskType string                  `json:"persistent_disk_type,omitempty"`
+	PersistentDisks    []PersistentDisk        `json:"persistent_disks,omitempty"`
 	Networks           []*Network              `json:"networks,omitempty"`
 	Update             *Update                 `json:"update,omitempty"`
 	MigratedFrom       []*MigratedFrom         `json:"migrated_from,omitempty"`
@@ -102,10 +121,24 @@ func (ig *InstanceGroup) NameSanitized() string {
 	return names.Sanitize(ig.Name)
 }
 
+// SetConsumedDisks sets the named persistent disks, which the jobs consume,
+// from the BPM information of the instance group
+func (ig *InstanceGroup) SetConsumedDisks(consumedDisks map[string][]string) {
+	for i := range ig.Jobs {
+		ig.Jobs[i].Properties.Quarks.ConsumedDisks = consumedDisks[ig.Jobs[i].Name]
+	}
+}
+
+// Autoscaled returns true if a horizontal pod autoscaler scales the
+// instance group's pods.
+func (ig *InstanceGroup) Autoscaled() bool {
+	return ig.Env.AgentEnvBoshConfig.Agent.Settings.Autoscaling != nil
+}
+
 // IndexedServiceName constructs an indexed service name. It's used to construct the service
 // names other than the headless service.
-func (ig *InstanceGroup) IndexedServiceName(index int, azIndex int) string {
-	sn := boshnames.TruncatedServiceName(ig.Name, 53)
+func (ig *InstanceGroup) IndexedServiceName(deploymentName string, index int, azIndex int) string {
+	sn := boshnames.TruncatedServiceName(deploymentName, ig.Name, 53)
 	if azIndex > -1 {
 		return fmt.Sprintf("%s-z%d-%d", sn, azIndex, index)
 	}
@@ -113,6 +146,7 @@ func (ig *InstanceGroup) IndexedServiceName(index int, azIndex int) string {
 }
 
 func (ig *InstanceGroup) jobInstances(
+	deploymentName string,
 	jobName string,
 	initialRollout bool,
 ) []JobInstance {
@@ -126,20 +160,29 @@ func (ig *InstanceGroup) jobInstances(
 		}
 
 		bootstrapIndex = ig.Instances*azCount - 1
+
+		// Autoscalers remove the pods with the highest ordinal first. Keep
+		// the last instance as bootstrap instance as long as it's not
+		// scaled down, otherwise the first instance is the only one which
+		// always exists.
+		if ig.Autoscaled() && ig.Instances > int(ig.Env.AgentEnvBoshConfig.Agent.Settings.Autoscaling.MinReplicas) {
+			bootstrapIndex = 0
+		}
 	}
 
 	if len(ig.AZs) > 0 {
 		for azIndex, az := range ig.AZs {
-			jobsInstances = ig.generateJobInstances(jobsInstances, azIndex, az, jobName, bootstrapIndex)
+			jobsInstances = ig.generateJobInstances(jobsInstances, deploymentName, azIndex, az, jobName, bootstrapIndex)
 		}
 	} else {
-		jobsInstances = ig.generateJobInstances(jobsInstances, -1, "", jobName, bootstrapIndex)
+		jobsInstances = ig.generateJobInstances(jobsInstances, deploymentName, -1, "", jobName, bootstrapIndex)
 	}
 
 	return jobsInstances
 }
 
 func (ig *InstanceGroup) generateJobInstances(jobsInstances []JobInstance,
+	deploymentName string,
 	azIndex int,
 	az string,
 	jobName string,
@@ -147,11 +190,17 @@ func (ig *InstanceGroup) generateJobInstances(jobsInstances []JobInstance,
 
 	for i := 0; i < ig.Instances; i++ {
 		index := len(jobsInstances)
-		address := ig.IndexedServiceName(i, azIndex)
+		if ig.Autoscaled() && azIndex > -1 {
+			// The number of instances differs between the pods of an
+			// autoscaled instance group, interleave the zones to keep
+			// the index of each instance stable.
+			index = i*len(ig.AZs) + azIndex
+		}
+		address := ig.IndexedServiceName(deploymentName, i, azIndex)
 		name := fmt.Sprintf("%s-%s", ig.NameSanitized(), jobName)
 		id := ""
 		if azIndex > -1 {
-			id = fmt.Sprintf("%s-z%d-%d", ig.NameSanitized(), azIndex, index%ig.Instances)
+			id = fmt.Sprintf("%s-z%d-%d", ig.NameSanitized(), azIndex, i)
 		} else {
 			id = fmt.Sprintf("%s-%d", ig.NameSanitized(), index)
 		}
@@ -192,6 +241,49 @@ type VMResource struct {
 	EphemeralDiskSize int `json:"ephemeral_disk_size"`
 }
 
+// Requests returns the CPU (in cores) and RAM (in MB) of the vm resources
+// as resource requests.
+func (r *VMResource) Requests() corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	if r == nil {
+		return requests
+	}
+	if r.CPU > 0 {
+		requests[corev1.ResourceCPU] = *resource.NewQuantity(int64(r.CPU), resource.DecimalSI)
+	}
+	if r.RAM > 0 {
+		requests[corev1.ResourceMemory] = *resource.NewQuantity(int64(r.RAM)*1024*1024, resource.BinarySI)
+	}
+	return requests
+}
+
+// ComputeResources returns the compute resources of the instance group.
+// CPU and RAM from `vm_resources` take precedence over the resources of the
+// vm type in the cloud config.
+func (ig *InstanceGroup) ComputeResources(cc *CloudConfig) (corev1.ResourceRequirements, error) {
+	if requests := ig.VMResources.Requests(); len(requests) > 0 {
+		return corev1.ResourceRequirements{Requests: requests}, nil
+	}
+
+	vmType, err := cc.VMType(ig.VMType)
+	if err != nil {
+		return corev1.ResourceRequirements{}, errors.Wrapf(err, "instance group '%s'", ig.Name)
+	}
+	if vmType == nil {
+		return corev1.ResourceRequirements{}, nil
+	}
+	return *vmType.CloudProperties.Resources.DeepCopy(), nil
+}
+
+// PersistentDisk is a named persistent disk of an instance group. Jobs
+// consume it by name, e.g. `consumes: {data: {from: <name>}}`.
+type PersistentDisk struct {
+	Name            string                 `json:"name"`
+	Type            string                 `json:"type,omitempty"`
+	Size            int                    `json:"size"`
+	CloudProperties map[string]interface{} `json:"cloud_properties,omitempty"`
+}
+
 // Network from BOSH deployment manifest.
 type Network struct {
 	Name      string   `json:"name"`
@@ -226,6 +318,53 @@ type JobDir struct {
 	TmpfsSize string `json:"tmpfs_size,omitempty"`
 }
 
+// DefaultJobDirTmpfsSize is the size of the job directories on tmpfs, if
+// `job_dir.tmpfs_size` is not set. It's the same default BOSH uses.
+const DefaultJobDirTmpfsSize = "100m"
+
+// tmpfsSizeRegex matches sizes in the format of the tmpfs mount option, e.g. '100m'
+var tmpfsSizeRegex = regexp.MustCompile(`^([0-9]+)([kKmMgG])$`)
+
+// UseTmpfsJobConfig puts the job directories of the instance group on tmpfs,
+// if the deployment enables the `use_tmpfs_job_config` feature. The
+// instance group's `job_dir.tmpfs` setting takes precedence.
+func (ig *InstanceGroup) UseTmpfsJobConfig(features *Feature) {
+	if features == nil || features.UseTmpfsJobConfig == nil {
+		return
+	}
+	if ig.Env.AgentEnvBoshConfig.JobDir == nil {
+		ig.Env.AgentEnvBoshConfig.JobDir = &JobDir{}
+	}
+	if ig.Env.AgentEnvBoshConfig.JobDir.Tmpfs == nil {
+		tmpfs := *features.UseTmpfsJobConfig
+		ig.Env.AgentEnvBoshConfig.JobDir.Tmpfs = &tmpfs
+	}
+}
+
+// JobDirTmpfsSize returns the size limit of the job directories, if they are
+// on tmpfs, or nil otherwise. The size accepts the BOSH format, e.g. '100m',
+// as well as Kubernetes quantities.
+func (ig *InstanceGroup) JobDirTmpfsSize() (*resource.Quantity, error) {
+	jobDir := ig.Env.AgentEnvBoshConfig.JobDir
+	if jobDir == nil || jobDir.Tmpfs == nil || !*jobDir.Tmpfs {
+		return nil, nil
+	}
+
+	size := jobDir.TmpfsSize
+	if size == "" {
+		size = DefaultJobDirTmpfsSize
+	}
+	if m := tmpfsSizeRegex.FindStringSubmatch(size); m != nil {
+		size = m[1] + strings.ToUpper(m[2]) + "i"
+	}
+
+	q, err := resource.ParseQuantity(size)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid job_dir.tmpfs_size '%s' for instance group '%s'", jobDir.TmpfsSize, ig.Name)
+	}
+	return &q, nil
+}
+
 // OpsPatch represents a Json patch that can be performed
 // on an Instance Group or BPM properties yaml file
 type OpsPatch struct {
@@ -268,6 +407,111 @@ type AgentSettings struct {
 	InjectReplicasEnv             *bool                         `json:"injectReplicasEnv,omitempty"`
 	TerminationGracePeriodSeconds *int64                        `json:"terminationGracePeriodSeconds,omitempty" yaml:"terminationGracePeriodSeconds,omitempty"`
 	DNS                           string                        `json:"dns,omitempty"`
+	Schedule                      *ErrandSchedule               `json:"schedule,omitempty"`
+	DisruptionBudget              *DisruptionBudget             `json:"disruptionBudget,omitempty"`
+	Autoscaling                   *Autoscaling                  `json:"autoscaling,omitempty"`
+	Expose                        *Expose                       `json:"expose,omitempty"`
+}
+
+// ErrandSchedule runs an errand instance group periodically, using cron
+// semantics. The concurrency policy defaults to Forbid.
+type ErrandSchedule struct {
+	Cron                       string                      `json:"cron"`
+	ConcurrencyPolicy          batchv1b1.ConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
+	SuccessfulJobsHistoryLimit *int32                      `json:"successfulJobsHistoryLimit,omitempty"`
+	FailedJobsHistoryLimit     *int32                      `json:"failedJobsHistoryLimit,omitempty"`
+	StartingDeadlineSeconds    *int64                      `json:"startingDeadlineSeconds,omitempty"`
+	Suspend                    *bool                       `json:"suspend,omitempty"`
+}
+
+// DisruptionBudget overrides the pod disruption budget of a service instance
+// group, which is derived from its instances and max_in_flight by default.
+type DisruptionBudget struct {
+	Disabled       bool                `json:"disabled,omitempty"`
+	MinAvailable   *intstr.IntOrString `json:"minAvailable,omitempty"`
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// Autoscaling scales the pods of a stateless service instance group with a
+// horizontal pod autoscaler. The replicas are per availability zone, like
+// `instances`. Without a target, the CPU utilization target defaults to 80%.
+type Autoscaling struct {
+	MinReplicas             int32  `json:"minReplicas"`
+	MaxReplicas             int32  `json:"maxReplicas"`
+	TargetCPUUtilization    *int32 `json:"targetCPUUtilization,omitempty"`
+	TargetMemoryUtilization *int32 `json:"targetMemoryUtilization,omitempty"`
+}
+
+// Validate checks the replica range and utilization targets
+func (a *Autoscaling) Validate() error {
+	if a.MinReplicas < 1 {
+		return errors.Errorf("minReplicas must be at least 1, got %d", a.MinReplicas)
+	}
+	if a.MaxReplicas < a.MinReplicas {
+		return errors.Errorf("maxReplicas must not be less than minReplicas, got %d", a.MaxReplicas)
+	}
+	for _, target := range []*int32{a.TargetCPUUtilization, a.TargetMemoryUtilization} {
+		if target != nil && *target < 1 {
+			return errors.Errorf("utilization targets must be at least 1%%, got %d", *target)
+		}
+	}
+	return nil
+}
+
+// Expose makes the pods of a service instance group reachable from outside
+// the cluster, with a service and an optional ingress. The service type
+// defaults to LoadBalancer, or to ClusterIP if an ingress is set. Without
+// ports, all ports of the BPM configs are exposed.
+type Expose struct {
+	Type                     corev1.ServiceType                      `json:"type,omitempty"`
+	Annotations              map[string]string                       `json:"annotations,omitempty"`
+	Ports                    []corev1.ServicePort                    `json:"ports,omitempty"`
+	LoadBalancerSourceRanges []string                                `json:"loadBalancerSourceRanges,omitempty"`
+	ExternalTrafficPolicy    corev1.ServiceExternalTrafficPolicyType `json:"externalTrafficPolicy,omitempty"`
+	Ingress                  *ExposeIngress                          `json:"ingress,omitempty"`
+}
+
+// ExposeIngress routes the requests for a host and path to the exposed service
+type ExposeIngress struct {
+	Host          string            `json:"host"`
+	Path          string            `json:"path,omitempty"`
+	Port          int32             `json:"port,omitempty"`
+	ClassName     *string           `json:"className,omitempty"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+	TLSSecretName string            `json:"tlsSecretName,omitempty"`
+}
+
+// ServiceType returns the type of the exposed service
+func (e *Expose) ServiceType() corev1.ServiceType {
+	if e.Type != "" {
+		return e.Type
+	}
+	if e.Ingress != nil {
+		return corev1.ServiceTypeClusterIP
+	}
+	return corev1.ServiceTypeLoadBalancer
+}
+
+// Validate checks the service type and the ingress
+func (e *Expose) Validate() error {
+	switch e.ServiceType() {
+	case corev1.ServiceTypeClusterIP, corev1.ServiceTypeNodePort, corev1.ServiceTypeLoadBalancer:
+	default:
+		return errors.Errorf("service type must be ClusterIP, NodePort or LoadBalancer, got '%s'", e.Type)
+	}
+	if e.Ingress != nil && e.Ingress.Host == "" {
+		return errors.New("ingress must have a host")
+	}
+	return nil
+}
+
+// StaticIPs returns the static IPs of the instance group's networks
+func (ig *InstanceGroup) StaticIPs() []string {
+	ips := []string{}
+	for _, network := range ig.Networks {
+		ips = append(ips, network.StaticIps...)
+	}
+	return ips
 }
 
 // Set overrides labels and annotations with operator-owned metadata.
@@ -83,6 +83,18 @@ func (p *JobProperties) UnmarshalJSON(b []byte) error {
 	return p.FromMap(j)
 }
 
+// ConsumesDisk returns true if one of the job's consumes of type disk
+// refers to the named persistent disk. The consumed disks are collected
+// from the job specs by the instance group resolver.
+func (j *Job) ConsumesDisk(diskName string) bool {
+	for _, name := range j.Properties.Quarks.ConsumedDisks {
+		if name == diskName {
+			return true
+		}
+	}
+	return false
+}
+
 func (j *Job) specDir(baseDir string) string {
 	return filepath.Join(baseDir, "jobs-src", j.Release, j.Name)
 }
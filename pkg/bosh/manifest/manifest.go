@@ -147,10 +147,11 @@ type AddOn struct {
 
 // Manifest is a BOSH deployment manifest
 type Manifest struct {
+	Name           string                 `json:"name,omitempty"`
 	DirectorUUID   string                 `json:"director_uuid"`
 	InstanceGroups InstanceGroups         `json:"instance_groups,omitempty"`
 	Features       *Feature               `json:"features,omitempty"`
-	Tags           map[string]string      `json:"tags,omitempty"`
+	Tags           Tags                   `json:"tags,omitempty"`
 	Releases       []*Release             `json:"releases,omitempty"`
 	Stemcells      []*Stemcell            `json:"stemcells,omitempty"`
 	AddOns         []*AddOn               `json:"addons,omitempty"`
@@ -158,6 +159,7 @@ type Manifest struct {
 	Variables      []Variable             `json:"variables,omitempty"`
 	Update         *Update                `json:"update,omitempty"`
 	AddOnsApplied  bool                   `json:"addons_applied,omitempty"`
+	CloudConfig    *CloudConfig           `json:"cloud_config,omitempty"`
 }
 
 // duplicateYamlValue is a struct used for size compression
@@ -530,7 +532,7 @@ func (m *Manifest) PropagateGlobalUpdateBlockToIGs() {
 	for _, ig := range m.InstanceGroups {
 		if ig.Update == nil {
 			ig.Update = m.Update
-		} else {
+		} else if m.Update != nil {
 			if ig.Update.CanaryWatchTime == "" {
 				ig.Update.CanaryWatchTime = m.Update.CanaryWatchTime
 			}
@@ -540,6 +542,15 @@ func (m *Manifest) PropagateGlobalUpdateBlockToIGs() {
 			if ig.Update.Serial == nil {
 				ig.Update.Serial = m.Update.Serial
 			}
+			if ig.Update.Canaries == 0 {
+				ig.Update.Canaries = m.Update.Canaries
+			}
+			if ig.Update.MaxInFlight == "" {
+				ig.Update.MaxInFlight = m.Update.MaxInFlight
+			}
+			if ig.Update.VMStrategy == nil {
+				ig.Update.VMStrategy = m.Update.VMStrategy
+			}
 		}
 	}
 }
@@ -612,6 +612,14 @@ var _ = Describe("Manifest", func() {
 				})
 			})
 
+			Describe("PersistentDisks", func() {
+				It("contains desired values", func() {
+					Expect(getStructTagForName("PersistentDisks", instanceGroup)).To(Equal(
+						`json:"persistent_disks,omitempty"`,
+					))
+				})
+			})
+
 			Describe("Networks", func() {
 				It("contains desired values", func() {
 					Expect(getStructTagForName("Networks", instanceGroup)).To(Equal(
@@ -997,6 +1005,21 @@ var _ = Describe("Manifest", func() {
 			})
 		})
 
+		Describe("PersistentDisk", func() {
+			var disk *PersistentDisk
+
+			BeforeEach(func() {
+				disk = &PersistentDisk{}
+			})
+
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Name", disk)).To(Equal(`json:"name"`))
+				Expect(getStructTagForName("Type", disk)).To(Equal(`json:"type,omitempty"`))
+				Expect(getStructTagForName("Size", disk)).To(Equal(`json:"size"`))
+				Expect(getStructTagForName("CloudProperties", disk)).To(Equal(`json:"cloud_properties,omitempty"`))
+			})
+		})
+
 		Describe("Job", func() {
 			var job *Job
 
@@ -1315,7 +1338,7 @@ var _ = Describe("Manifest", func() {
 				})
 
 				It("serializes instancegroup quarks", func() {
-					m1.ApplyUpdateBlock()
+					m1.ApplyUpdateBlock("foo")
 					text, err := m1.Marshal()
 					Expect(err).NotTo(HaveOccurred())
 					By("loading marshalled manifest again")
@@ -1323,9 +1346,9 @@ var _ = Describe("Manifest", func() {
 					Expect(err).NotTo(HaveOccurred())
 					Expect(manifest.InstanceGroups).To(HaveLen(3))
 					Expect(manifest.InstanceGroups[0].Properties.Quarks.RequiredService).To(BeNil())
-					expectedRequireService := "bpm1"
+					expectedRequireService := "foo-bpm1"
 					Expect(manifest.InstanceGroups[1].Properties.Quarks.RequiredService).To(Equal(&expectedRequireService))
-					expectedRequireService = "bpm2"
+					expectedRequireService = "foo-bpm2"
 					Expect(manifest.InstanceGroups[2].Properties.Quarks.RequiredService).To(Equal(&expectedRequireService))
 
 				})
@@ -1403,30 +1426,30 @@ var _ = Describe("Manifest", func() {
 			})
 
 			It("calculates first instance group without dependency", func() {
-				manifest.ApplyUpdateBlock()
+				manifest.ApplyUpdateBlock("foo")
 				Expect(manifest.InstanceGroups).To(HaveLen(4))
 				Expect(manifest.InstanceGroups[0].Properties.Quarks.RequiredService).To(BeNil())
 			})
 
 			It("respects serial=true on instance group as barrier", func() {
-				manifest.ApplyUpdateBlock()
+				manifest.ApplyUpdateBlock("foo")
 				Expect(manifest.InstanceGroups).To(HaveLen(4))
-				expectedRequireService := "bpm1"
+				expectedRequireService := "foo-bpm1"
 				Expect(manifest.InstanceGroups[1].Properties.Quarks.RequiredService).To(Equal(&expectedRequireService))
 				Expect(manifest.InstanceGroups[2].Properties.Quarks.RequiredService).To(Equal(&expectedRequireService))
 			})
 
 			It("respects serial=true to wait for the predecessor", func() {
-				manifest.ApplyUpdateBlock()
+				manifest.ApplyUpdateBlock("foo")
 				Expect(manifest.InstanceGroups).To(HaveLen(4))
-				expectedRequireService := "bpm3"
+				expectedRequireService := "foo-bpm3"
 				Expect(manifest.InstanceGroups[3].Properties.Quarks.RequiredService).To(Equal(&expectedRequireService))
 			})
 
 			It("respects update serial in manifest", func() {
 				manifestWithUpdate, err := env.BOSHManifestWithUpdateSerialInManifest()
 				Expect(err).NotTo(HaveOccurred())
-				manifestWithUpdate.ApplyUpdateBlock()
+				manifestWithUpdate.ApplyUpdateBlock("foo")
 				Expect(manifestWithUpdate.InstanceGroups).To(HaveLen(2))
 				Expect(manifestWithUpdate.InstanceGroups[0].Properties.Quarks.RequiredService).To(BeNil())
 				Expect(manifestWithUpdate.InstanceGroups[1].Properties.Quarks.RequiredService).To(BeNil())
@@ -1435,9 +1458,9 @@ var _ = Describe("Manifest", func() {
 			It("doesn't wait for instance groups without ports", func() {
 				manifestWithUpdate, err := env.BOSHManifestWithUpdateSerialAndWithoutPorts()
 				Expect(err).NotTo(HaveOccurred())
-				manifestWithUpdate.ApplyUpdateBlock()
+				manifestWithUpdate.ApplyUpdateBlock("foo")
 				Expect(manifestWithUpdate.InstanceGroups).To(HaveLen(3))
-				expectedRequireService := "bpm1"
+				expectedRequireService := "foo-bpm1"
 				Expect(manifestWithUpdate.InstanceGroups[0].Properties.Quarks.RequiredService).To(BeNil())
 				Expect(manifestWithUpdate.InstanceGroups[1].Properties.Quarks.RequiredService).To(Equal(&expectedRequireService))
 				Expect(manifestWithUpdate.InstanceGroups[2].Properties.Quarks.RequiredService).To(Equal(&expectedRequireService))
@@ -1446,7 +1469,7 @@ var _ = Describe("Manifest", func() {
 			It("propagates global update block correctly", func() {
 				manifest, err = env.BOSHManifestWithGlobalUpdateBlock()
 				Expect(err).NotTo(HaveOccurred())
-				manifest.ApplyUpdateBlock()
+				manifest.ApplyUpdateBlock("foo")
 				By("propagating if ig has no update block")
 				Expect(*manifest.InstanceGroups[0].Update).To(Equal(Update{
 					CanaryWatchTime: "20000-1200000",
@@ -1466,6 +1489,36 @@ var _ = Describe("Manifest", func() {
 					Serial:          pointer.BoolPtr(false),
 				}))
 			})
+
+			It("propagates the global rollout configuration", func() {
+				manifest, err = LoadYAML([]byte(`---
+update:
+  canaries: 2
+  max_in_flight: 25%
+  vm_strategy: create-swap-delete
+instance_groups:
+- name: inherit
+  update:
+    serial: true
+- name: override
+  update:
+    canaries: 1
+    max_in_flight: "3"
+    vm_strategy: delete-create
+`))
+				Expect(err).NotTo(HaveOccurred())
+				manifest.ApplyUpdateBlock("foo")
+
+				By("inheriting unset values from the global update block")
+				Expect(manifest.InstanceGroups[0].Update.Canaries).To(Equal(2))
+				Expect(manifest.InstanceGroups[0].Update.MaxInFlight).To(Equal("25%"))
+				Expect(*manifest.InstanceGroups[0].Update.VMStrategy).To(Equal(VMStrategyCreateSwapDelete))
+
+				By("retaining the instance group's values")
+				Expect(manifest.InstanceGroups[1].Update.Canaries).To(Equal(1))
+				Expect(manifest.InstanceGroups[1].Update.MaxInFlight).To(Equal("3"))
+				Expect(*manifest.InstanceGroups[1].Update.VMStrategy).To(Equal(VMStrategyDeleteCreate))
+			})
 		})
 
 		Describe("ListMissingProviders", func() {
@@ -0,0 +1,61 @@
+package manifest
+
+import (
+	"hash/fnv"
+	"math/rand"
+)
+
+// RandomizesAZPlacement returns true if the randomize_az_placement feature
+// is enabled
+func (m *Manifest) RandomizesAZPlacement() bool {
+	return m.Features != nil && m.Features.RandomizeAzPlacement != nil && *m.Features.RandomizeAzPlacement
+}
+
+// RandomizeAZPlacement shuffles the availability zones of the instance
+// groups, if the randomize_az_placement feature is enabled. The zone index
+// of the deployed instance groups is kept, only zones which are not part of
+// the deployed manifest are shuffled and added after them. The order is
+// derived from the deployment and instance group name, so it stays the same
+// between reconciles.
+func (m *Manifest) RandomizeAZPlacement(deploymentName string, deployed *Manifest) {
+	if !m.RandomizesAZPlacement() {
+		return
+	}
+
+	for _, ig := range m.InstanceGroups {
+		if len(ig.AZs) < 2 {
+			continue
+		}
+
+		current := map[string]bool{}
+		for _, az := range ig.AZs {
+			current[az] = true
+		}
+
+		azs := []string{}
+		if deployed != nil {
+			if old, found := deployed.InstanceGroups.InstanceGroupByName(ig.Name); found {
+				for _, az := range old.AZs {
+					if current[az] {
+						azs = append(azs, az)
+						delete(current, az)
+					}
+				}
+			}
+		}
+
+		added := []string{}
+		for _, az := range ig.AZs {
+			if current[az] {
+				added = append(added, az)
+			}
+		}
+
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(deploymentName + "/" + ig.Name))
+		r := rand.New(rand.NewSource(int64(h.Sum64())))
+		r.Shuffle(len(added), func(i, j int) { added[i], added[j] = added[j], added[i] })
+
+		ig.AZs = append(azs, added...)
+	}
+}
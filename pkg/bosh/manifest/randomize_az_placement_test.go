@@ -0,0 +1,83 @@
+package manifest_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "code.cloudfoundry.org/quarks-operator/pkg/bosh/manifest"
+)
+
+var _ = Describe("RandomizeAZPlacement", func() {
+	var (
+		m       *Manifest
+		enabled bool
+		azs     []string
+	)
+
+	BeforeEach(func() {
+		enabled = true
+		azs = []string{"z1", "z2", "z3", "z4", "z5", "z6"}
+	})
+
+	JustBeforeEach(func() {
+		m = &Manifest{
+			Features: &Feature{RandomizeAzPlacement: &enabled},
+			InstanceGroups: InstanceGroups{
+				{Name: "nats", AZs: append([]string{}, azs...)},
+				{Name: "api", AZs: []string{"z1"}},
+			},
+		}
+	})
+
+	It("shuffles the availability zones of the instance groups", func() {
+		m.RandomizeAZPlacement("foo", nil)
+		Expect(m.InstanceGroups[0].AZs).To(ConsistOf(azs))
+		Expect(m.InstanceGroups[0].AZs).ToNot(Equal(azs))
+		Expect(m.InstanceGroups[1].AZs).To(Equal([]string{"z1"}))
+	})
+
+	It("keeps the order between reconciles", func() {
+		m.RandomizeAZPlacement("foo", nil)
+		other := &Manifest{
+			Features:       m.Features,
+			InstanceGroups: InstanceGroups{{Name: "nats", AZs: append([]string{}, azs...)}},
+		}
+		other.RandomizeAZPlacement("foo", nil)
+		Expect(other.InstanceGroups[0].AZs).To(Equal(m.InstanceGroups[0].AZs))
+	})
+
+	Context("when the instance group is already deployed", func() {
+		var deployed *Manifest
+
+		BeforeEach(func() {
+			azs = []string{"z1", "z2", "z3", "z4", "z5", "z6"}
+			deployed = &Manifest{
+				InstanceGroups: InstanceGroups{{Name: "nats", AZs: []string{"z3", "z1", "z2", "z7"}}},
+			}
+		})
+
+		It("keeps the order of the deployed zones and shuffles only the added ones", func() {
+			m.RandomizeAZPlacement("foo", deployed)
+			Expect(m.InstanceGroups[0].AZs).To(HaveLen(6))
+			Expect(m.InstanceGroups[0].AZs[:3]).To(Equal([]string{"z3", "z1", "z2"}))
+			Expect(m.InstanceGroups[0].AZs[3:]).To(ConsistOf("z4", "z5", "z6"))
+		})
+
+		It("keeps the order of a deployment, which didn't randomize the zones before", func() {
+			deployed.InstanceGroups[0].AZs = append([]string{}, azs...)
+			m.RandomizeAZPlacement("foo", deployed)
+			Expect(m.InstanceGroups[0].AZs).To(Equal(azs))
+		})
+	})
+
+	Context("when the feature is disabled", func() {
+		BeforeEach(func() {
+			enabled = false
+		})
+
+		It("keeps the order of the availability zones", func() {
+			m.RandomizeAZPlacement("foo", nil)
+			Expect(m.InstanceGroups[0].AZs).To(Equal(azs))
+		})
+	})
+})
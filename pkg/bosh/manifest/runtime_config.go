@@ -0,0 +1,58 @@
+package manifest
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// RuntimeConfig is a BOSH runtime config, which adds releases and addons
+// to deployment manifests
+type RuntimeConfig struct {
+	Releases []*Release `json:"releases,omitempty"`
+	AddOns   []*AddOn   `json:"addons,omitempty"`
+}
+
+// LoadRuntimeConfigYAML returns a new BOSH runtime config from a yaml representation
+func LoadRuntimeConfigYAML(data []byte) (*RuntimeConfig, error) {
+	rc := &RuntimeConfig{}
+	err := yaml.Unmarshal(data, rc, func(opt *json.Decoder) *json.Decoder {
+		opt.UseNumber()
+		return opt
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal BOSH runtime config %s", string(data))
+	}
+
+	return rc, nil
+}
+
+// ApplyRuntimeConfig merges the releases and addons of the runtime config
+// into the manifest. Releases and addons, which already exist in the manifest
+// by name, take precedence.
+func (m *Manifest) ApplyRuntimeConfig(rc *RuntimeConfig) {
+	releases := map[string]struct{}{}
+	for _, r := range m.Releases {
+		releases[r.Name] = struct{}{}
+	}
+	for _, r := range rc.Releases {
+		if _, ok := releases[r.Name]; ok {
+			continue
+		}
+		releases[r.Name] = struct{}{}
+		m.Releases = append(m.Releases, r)
+	}
+
+	addons := map[string]struct{}{}
+	for _, a := range m.AddOns {
+		addons[a.Name] = struct{}{}
+	}
+	for _, a := range rc.AddOns {
+		if _, ok := addons[a.Name]; ok {
+			continue
+		}
+		addons[a.Name] = struct{}{}
+		m.AddOns = append(m.AddOns, a)
+	}
+}
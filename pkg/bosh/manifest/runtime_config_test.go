@@ -0,0 +1,73 @@
+package manifest_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "code.cloudfoundry.org/quarks-operator/pkg/bosh/manifest"
+	"code.cloudfoundry.org/quarks-operator/testing/boshmanifest"
+)
+
+var _ = Describe("RuntimeConfig", func() {
+	var (
+		manifest *Manifest
+		rc       *RuntimeConfig
+	)
+
+	BeforeEach(func() {
+		var err error
+		manifest, err = LoadYAML([]byte(boshmanifest.WithAddons))
+		Expect(err).NotTo(HaveOccurred())
+
+		rc, err = LoadRuntimeConfigYAML([]byte(`---
+releases:
+- name: redis
+  version: 99.9.9
+- name: log-forwarder
+  version: 1.0.0
+addons:
+- name: test
+  jobs:
+  - name: overridden-job
+    release: redis
+- name: log-forwarder
+  jobs:
+  - name: forwarder
+    release: log-forwarder
+  include:
+    instance_groups:
+    - redis-slave
+`))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("fails to load invalid yaml", func() {
+		_, err := LoadRuntimeConfigYAML([]byte("addons: {"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("adds missing releases and addons", func() {
+		releases := len(manifest.Releases)
+		addons := len(manifest.AddOns)
+
+		manifest.ApplyRuntimeConfig(rc)
+
+		Expect(manifest.Releases).To(HaveLen(releases + 1))
+		Expect(manifest.Releases[releases].Name).To(Equal("log-forwarder"))
+		Expect(manifest.AddOns).To(HaveLen(addons + 1))
+		Expect(manifest.AddOns[addons].Name).To(Equal("log-forwarder"))
+	})
+
+	It("keeps the manifest's releases and addons", func() {
+		manifest.ApplyRuntimeConfig(rc)
+
+		for _, r := range manifest.Releases {
+			Expect(r.Version).ToNot(Equal("99.9.9"))
+		}
+		for _, a := range manifest.AddOns {
+			if a.Name == "test" {
+				Expect(a.Jobs[0].Name).ToNot(Equal("overridden-job"))
+			}
+		}
+	})
+})
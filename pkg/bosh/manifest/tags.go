@@ -0,0 +1,76 @@
+package manifest
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+)
+
+// maxLabelLength is the maximum length of a label value and of the name part of a label key
+const maxLabelLength = 63
+
+var invalidLabelChars = regexp.MustCompile(`[^-A-Za-z0-9_.]`)
+
+// Tags from the BOSH deployment manifest, which are added to all resources of a deployment
+type Tags map[string]string
+
+// Labels returns the tags as labels. Tag names and values are sanitized to
+// be valid label keys and values, tags with an empty name are skipped.
+func (t Tags) Labels() map[string]string {
+	if len(t) == 0 {
+		return nil
+	}
+
+	labels := make(map[string]string, len(t))
+	for _, name := range t.names() {
+		key := sanitizeLabelValue(name)
+		if key == "" {
+			continue
+		}
+		labels[bdv1.LabelTagPrefix+key] = sanitizeLabelValue(t[name])
+	}
+	return labels
+}
+
+// Annotations returns the tags as annotations, which keep the original tag
+// values, in case they had to be changed for the labels.
+func (t Tags) Annotations() map[string]string {
+	if len(t) == 0 {
+		return nil
+	}
+
+	annotations := make(map[string]string, len(t))
+	for _, name := range t.names() {
+		key := sanitizeLabelValue(name)
+		if key == "" {
+			continue
+		}
+		annotations[bdv1.LabelTagPrefix+key] = t[name]
+	}
+	return annotations
+}
+
+// names returns the sorted tag names, so tags which sanitize to the same key
+// always result in the same label
+func (t Tags) names() []string {
+	names := make([]string, 0, len(t))
+	for name := range t {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sanitizeLabelValue replaces invalid characters with '-' and makes sure the
+// value starts and ends with an alphanumeric character and is not too long
+func sanitizeLabelValue(value string) string {
+	value = invalidLabelChars.ReplaceAllString(value, "-")
+	if len(value) > maxLabelLength {
+		value = value[:maxLabelLength]
+	}
+	return strings.TrimFunc(value, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+}
@@ -0,0 +1,69 @@
+package manifest_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	. "code.cloudfoundry.org/quarks-operator/pkg/bosh/manifest"
+)
+
+var _ = Describe("Tags", func() {
+	var tags Tags
+
+	Context("when there are no tags", func() {
+		BeforeEach(func() {
+			tags = nil
+		})
+
+		It("returns no labels and annotations", func() {
+			Expect(tags.Labels()).To(BeNil())
+			Expect(tags.Annotations()).To(BeNil())
+		})
+	})
+
+	Context("when tags are valid labels", func() {
+		BeforeEach(func() {
+			tags = Tags{"owner": "team-a", "cost-center": "1234"}
+		})
+
+		It("returns prefixed labels and annotations", func() {
+			Expect(tags.Labels()).To(Equal(map[string]string{
+				"tags.quarks.cloudfoundry.org/owner":       "team-a",
+				"tags.quarks.cloudfoundry.org/cost-center": "1234",
+			}))
+			Expect(tags.Annotations()).To(Equal(tags.Labels()))
+		})
+	})
+
+	Context("when tags are not valid labels", func() {
+		BeforeEach(func() {
+			tags = Tags{
+				"owner email": "me@example.com",
+				"long":        strings.Repeat("x", 70) + "!",
+				"!!!":         "dropped",
+			}
+		})
+
+		It("sanitizes the labels", func() {
+			labels := tags.Labels()
+			Expect(labels).To(Equal(map[string]string{
+				"tags.quarks.cloudfoundry.org/owner-email": "me-example.com",
+				"tags.quarks.cloudfoundry.org/long":        strings.Repeat("x", 63),
+			}))
+			for k, v := range labels {
+				Expect(validation.IsQualifiedName(k)).To(BeEmpty())
+				Expect(validation.IsValidLabelValue(v)).To(BeEmpty())
+			}
+		})
+
+		It("keeps the original values in the annotations", func() {
+			Expect(tags.Annotations()).To(Equal(map[string]string{
+				"tags.quarks.cloudfoundry.org/owner-email": "me@example.com",
+				"tags.quarks.cloudfoundry.org/long":        strings.Repeat("x", 70) + "!",
+			}))
+		})
+	})
+})
@@ -0,0 +1,74 @@
+package manifest
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+const (
+	// VMStrategyDeleteCreate replaces instances by deleting the old one first
+	VMStrategyDeleteCreate = "delete-create"
+	// VMStrategyCreateSwapDelete creates new instances before deleting the old ones
+	VMStrategyCreateSwapDelete = "create-swap-delete"
+)
+
+// ExtractMaxInFlight computes the number of instances which are updated in
+// parallel from an absolute value or a percentage of the instance count.
+// This parses the max_in_flight string used in the BOSH manifest's update config:
+// https://bosh.io/docs/manifest-v2/#update
+// An empty value returns 0, every other value results in at least 1.
+func ExtractMaxInFlight(rawMaxInFlight string, instances int) (int, error) {
+	if rawMaxInFlight == "" {
+		return 0, nil
+	}
+
+	percentRegex := regexp.MustCompile(`^\s*(\d+)\s*%\s*$`) // https://github.com/cloudfoundry/bosh/blob/914edca5278b994df7d91620c4f55f1c6665f81c/src/bosh-director/lib/bosh/director/deployment_plan/numerical_value_calculator.rb
+	if matches := percentRegex.FindStringSubmatch(rawMaxInFlight); len(matches) > 0 {
+		percent, _ := strconv.Atoi(matches[1])
+		if percent < 1 || percent > 100 {
+			return 0, fmt.Errorf("max in flight percentage must be between 1%% and 100%%: %s", rawMaxInFlight)
+		}
+		return atLeastOne(percent * instances / 100), nil
+	}
+
+	absoluteRegex := regexp.MustCompile(`^\s*(\d+)\s*$`)
+	if matches := absoluteRegex.FindStringSubmatch(rawMaxInFlight); len(matches) > 0 {
+		value, _ := strconv.Atoi(matches[1])
+		return atLeastOne(value), nil
+	}
+
+	return 0, fmt.Errorf("max in flight string did not match regexp: %s", rawMaxInFlight)
+}
+
+// ValidateVMStrategy checks the vm_strategy of the BOSH manifest's update config.
+// Stateful sets replace their pods in place, so both strategies result in the
+// same rollout.
+func ValidateVMStrategy(vmStrategy *string) error {
+	if vmStrategy == nil {
+		return nil
+	}
+
+	switch *vmStrategy {
+	case VMStrategyDeleteCreate, VMStrategyCreateSwapDelete:
+		return nil
+	}
+	return fmt.Errorf("vm strategy must be '%s' or '%s': %s", VMStrategyDeleteCreate, VMStrategyCreateSwapDelete, *vmStrategy)
+}
+
+// ValidateCanaries checks the canaries of the BOSH manifest's update config.
+// The rollout of a stateful set updates a single canary, further canaries are
+// updated like the remaining pods.
+func ValidateCanaries(canaries int) error {
+	if canaries < 0 {
+		return fmt.Errorf("canaries must not be negative: %d", canaries)
+	}
+	return nil
+}
+
+func atLeastOne(i int) int {
+	if i < 1 {
+		return 1
+	}
+	return i
+}
@@ -10,13 +10,13 @@ import (
 	batchv1b1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 
 	qjv1a1 "code.cloudfoundry.org/quarks-job/pkg/kube/apis/quarksjob/v1alpha1"
 	"code.cloudfoundry.org/quarks-operator/pkg/bosh/bpmconverter"
 	"code.cloudfoundry.org/quarks-operator/pkg/bosh/converter"
 	bdm "code.cloudfoundry.org/quarks-operator/pkg/bosh/manifest"
 	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
-	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/desiredmanifest"
 	boshnames "code.cloudfoundry.org/quarks-operator/pkg/kube/util/names"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/operatorimage"
 	"code.cloudfoundry.org/quarks-utils/pkg/names"
@@ -55,7 +55,7 @@ func NewJobFactory() *JobFactory {
 
 // InstanceGroupManifestJob generates the job to create an instance group manifest, this needs to run on the BOSH release image
 func (f *JobFactory) InstanceGroupManifestJob(namespace string, deploymentName string, manifest bdm.Manifest, linkInfos converter.LinkInfos, initialRollout bool) (*qjv1a1.QuarksJob, error) {
-	dmName := desiredManifestName()
+	dmName := desiredManifestName(deploymentName)
 	ct := containerTemplate{
 		deploymentName: deploymentName,
 		manifestName:   dmName,
@@ -70,7 +70,7 @@ func (f *JobFactory) InstanceGroupManifestJob(namespace string, deploymentName s
 		if ig.Instances != 0 {
 			// Additional secret for BOSH links per instance group
 			containerName := names.Sanitize(ig.Name)
-			linkOutputs[containerName] = boshnames.QuarksLinkSecretName()
+			linkOutputs[containerName] = boshnames.QuarksLinkSecretName(deploymentName)
 
 			// One container per instance group
 			containers = append(containers, ct.newUtilContainer(ig.Name, linkInfos.VolumeMounts()))
@@ -99,8 +99,8 @@ func (f *JobFactory) InstanceGroupManifestJob(namespace string, deploymentName s
 
 // desiredManifestName returns the sanitized, versioned name of the manifest.
 // QuarksJob will always pick the latest version for versioned secrets
-func desiredManifestName() string {
-	return versionedsecretstore.VersionedName(desiredmanifest.Name, 1)
+func desiredManifestName(deploymentName string) string {
+	return versionedsecretstore.VersionedName(boshnames.DesiredManifestName(deploymentName), 1)
 }
 
 type containerTemplate struct {
@@ -184,13 +184,10 @@ func (f *JobFactory) releaseImageQJob(namespace string, deploymentName string, d
 	}
 
 	outputMap := qjv1a1.OutputMap{}
-	igPrefix := bdv1.DeploymentSecretTypeInstanceGroupResolvedProperties.Prefix()
-	bpmPrefix := bdv1.DeploymentSecretBPMInformation.Prefix()
 	for _, container := range containers {
 		outputMap[container.Name] = qjv1a1.FilesToSecrets{
 			InstanceGroupOutputFilename: qjv1a1.SecretOptions{
-				// the same as names.InstanceGroupSecretName(container.Name, "")
-				Name: igPrefix + container.Name,
+				Name: boshnames.InstanceGroupSecretName(bdv1.DeploymentSecretTypeInstanceGroupResolvedProperties, deploymentName, container.Name, ""),
 				AdditionalSecretLabels: map[string]string{
 					bdv1.LabelEntanglementKey:      "true",
 					bdv1.LabelDeploymentSecretType: bdv1.DeploymentSecretTypeInstanceGroupResolvedProperties.String(),
@@ -199,7 +196,7 @@ func (f *JobFactory) releaseImageQJob(namespace string, deploymentName string, d
 				Versioned:                   true,
 			},
 			BPMOutputFilename: qjv1a1.SecretOptions{
-				Name: bpmPrefix + container.Name,
+				Name: boshnames.InstanceGroupSecretName(bdv1.DeploymentSecretBPMInformation, deploymentName, container.Name, ""),
 				AdditionalSecretLabels: map[string]string{
 					bdv1.LabelEntanglementKey:      "true",
 					bdv1.LabelDeploymentSecretType: bdv1.DeploymentSecretBPMInformation.String(),
@@ -213,11 +210,12 @@ func (f *JobFactory) releaseImageQJob(namespace string, deploymentName string, d
 	// Construct the "BPM configs" or "data gathering" auto-errand qJob
 	qJob := &qjv1a1.QuarksJob{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "ig",
+			Name:      boshnames.QuarksJobName(deploymentName, "ig"),
 			Namespace: namespace,
-			Labels: map[string]string{
+			Labels: labels.Merge(manifest.Tags.Labels(), map[string]string{
 				bdv1.LabelDeploymentName: deploymentName,
-			},
+			}),
+			Annotations: manifest.Tags.Annotations(),
 		},
 		Spec: qjv1a1.QuarksJobSpec{
 			Output: &qjv1a1.Output{
@@ -234,11 +232,12 @@ func (f *JobFactory) releaseImageQJob(namespace string, deploymentName string, d
 				Spec: batchv1.JobSpec{
 					Template: corev1.PodTemplateSpec{
 						ObjectMeta: metav1.ObjectMeta{
-							Name: "ig",
-							Labels: map[string]string{
+							Name: boshnames.QuarksJobName(deploymentName, "ig"),
+							Labels: labels.Merge(manifest.Tags.Labels(), map[string]string{
 								"delete":                 "pod",
-								bdv1.LabelDeploymentName: "deploymentName",
-							},
+								bdv1.LabelDeploymentName: deploymentName,
+							}),
+							Annotations: manifest.Tags.Annotations(),
 						},
 						Spec: corev1.PodSpec{
 							RestartPolicy: corev1.RestartPolicyOnFailure,
@@ -101,7 +101,7 @@ var _ = Describe("JobFactory", func() {
 					qjv1a1.OutputMap{
 						"redis-slave": qjv1a1.FilesToSecrets{
 							"ig.json": qjv1a1.SecretOptions{
-								Name: "ig-resolved.redis-slave",
+								Name: "foo-deployment.ig-resolved.redis-slave",
 								AdditionalSecretLabels: map[string]string{
 									"quarks.cloudfoundry.org/entanglement": "true",
 									"quarks.cloudfoundry.org/secret-type":  "ig-resolved",
@@ -111,7 +111,7 @@ var _ = Describe("JobFactory", func() {
 								PersistenceMethod:           "",
 							},
 							"bpm.json": qjv1a1.SecretOptions{
-								Name: "bpm.redis-slave",
+								Name: "foo-deployment.bpm.redis-slave",
 								AdditionalSecretLabels: map[string]string{
 									"quarks.cloudfoundry.org/entanglement": "true",
 									"quarks.cloudfoundry.org/secret-type":  "bpm",
@@ -121,7 +121,7 @@ var _ = Describe("JobFactory", func() {
 								PersistenceMethod:           "",
 							},
 							"provides.json": qjv1a1.SecretOptions{
-								Name: "link",
+								Name: "link-foo-deployment",
 								AdditionalSecretLabels: map[string]string{
 									"quarks.cloudfoundry.org/entanglement": "true",
 								},
@@ -132,7 +132,7 @@ var _ = Describe("JobFactory", func() {
 						},
 						"diego-cell": qjv1a1.FilesToSecrets{
 							"ig.json": qjv1a1.SecretOptions{
-								Name: "ig-resolved.diego-cell",
+								Name: "foo-deployment.ig-resolved.diego-cell",
 								AdditionalSecretLabels: map[string]string{
 									"quarks.cloudfoundry.org/entanglement": "true",
 									"quarks.cloudfoundry.org/secret-type":  "ig-resolved",
@@ -142,7 +142,7 @@ var _ = Describe("JobFactory", func() {
 								PersistenceMethod:           "",
 							},
 							"bpm.json": qjv1a1.SecretOptions{
-								Name: "bpm.diego-cell",
+								Name: "foo-deployment.bpm.diego-cell",
 								AdditionalSecretLabels: map[string]string{
 									"quarks.cloudfoundry.org/entanglement": "true",
 									"quarks.cloudfoundry.org/secret-type":  "bpm",
@@ -152,7 +152,7 @@ var _ = Describe("JobFactory", func() {
 								PersistenceMethod:           "",
 							},
 							"provides.json": qjv1a1.SecretOptions{
-								Name: "link",
+								Name: "link-foo-deployment",
 								AdditionalSecretLabels: map[string]string{
 									"quarks.cloudfoundry.org/entanglement": "true",
 								},
@@ -197,5 +197,16 @@ var _ = Describe("JobFactory", func() {
 			Expect(len(spec.Containers)).To(BeNumerically("<", 2))
 		})
 
+		It("adds the manifest's tags to the job and its pods", func() {
+			m.Tags = manifest.Tags{"owner": "team a"}
+			job, err := factory.InstanceGroupManifestJob("namespace", deploymentName, *m, linkInfos, true)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(job.GetLabels()).To(HaveKeyWithValue("tags.quarks.cloudfoundry.org/owner", "team-a"))
+			Expect(job.GetLabels()).To(HaveKeyWithValue(bdv1.LabelDeploymentName, deploymentName))
+			Expect(job.GetAnnotations()).To(HaveKeyWithValue("tags.quarks.cloudfoundry.org/owner", "team a"))
+			Expect(job.Spec.Template.Spec.Template.Labels).To(HaveKeyWithValue("tags.quarks.cloudfoundry.org/owner", "team-a"))
+		})
+
 	})
 })
@@ -0,0 +1,39 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// This file is safe to edit
+// It's used as input for the Kube code generator
+// Run "make generate" after modifying this file
+
+// CloudConfigSpec defines the desired state of CloudConfig
+type CloudConfigSpec struct {
+	// Config is a BOSH cloud config in YAML. Its azs, vm_types,
+	// vm_extensions and disk_types are referenced by the instance groups of
+	// all BOSHDeployments in the namespace. Cloud configs in the operator
+	// namespace apply to all namespaces.
+	Config string `json:"config"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CloudConfig is the Schema for the cloudconfigs API
+// +k8s:openapi-gen=true
+type CloudConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec CloudConfigSpec `json:"spec,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CloudConfigList contains a list of CloudConfig
+type CloudConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CloudConfig `json:"items"`
+}
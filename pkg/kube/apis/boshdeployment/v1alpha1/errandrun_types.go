@@ -0,0 +1,98 @@
+package v1alpha1
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/apis"
+)
+
+// This file is safe to edit
+// It's used as input for the Kube code generator
+// Run "make generate" after modifying this file
+
+var (
+	// LabelErrandRunName is the name of a label for the errand run, which created a job
+	LabelErrandRunName = fmt.Sprintf("%s/errand-run-name", apis.GroupName)
+	// LabelErrandTrigger is the name of a label for the jobs of a scheduled errand's cron job, set to the QuarksJob they trigger
+	LabelErrandTrigger = fmt.Sprintf("%s/errand-trigger", apis.GroupName)
+)
+
+// ErrandRunPhase is the phase of an errand run
+type ErrandRunPhase string
+
+// Valid phases of an errand run
+const (
+	// ErrandRunPending is the phase before the errand's job was created
+	ErrandRunPending ErrandRunPhase = "Pending"
+	// ErrandRunRunning is the phase while the errand's job is running
+	ErrandRunRunning ErrandRunPhase = "Running"
+	// ErrandRunSucceeded is the phase after the errand exited with 0
+	ErrandRunSucceeded ErrandRunPhase = "Succeeded"
+	// ErrandRunFailed is the phase after the errand failed or could not be started
+	ErrandRunFailed ErrandRunPhase = "Failed"
+)
+
+// ErrandRunSpec defines the desired state of ErrandRun
+type ErrandRunSpec struct {
+	// Deployment is the name of the BOSHDeployment in the same namespace
+	Deployment string `json:"deployment"`
+	// Errand is the name of the errand instance group
+	Errand string `json:"errand"`
+	// Env is added to the errand's containers, to pass parameters
+	Env []corev1.EnvVar `json:"env,omitempty"`
+}
+
+// ErrandRunStatus defines the observed state of ErrandRun
+type ErrandRunStatus struct {
+	Phase ErrandRunPhase `json:"phase,omitempty"`
+	// JobName is the name of the job running the errand
+	JobName        string       `json:"jobName,omitempty"`
+	StartTime      *metav1.Time `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// ExitCode is the first non-zero exit code of the errand's containers
+	ExitCode *int32 `json:"exitCode,omitempty"`
+	// Output holds the last lines of the logs of the errand's containers
+	Output  string `json:"output,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ErrandRun is the Schema for the errandruns API
+// +k8s:openapi-gen=true
+type ErrandRun struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ErrandRunSpec   `json:"spec,omitempty"`
+	Status ErrandRunStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ErrandRunList contains a list of ErrandRun
+type ErrandRunList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ErrandRun `json:"items"`
+}
+
+// GetNamespacedName returns the resource name with its namespace
+func (e *ErrandRun) GetNamespacedName() string {
+	return fmt.Sprintf("%s/%s", e.Namespace, e.Name)
+}
+
+// IsFinished returns true if the errand run succeeded or failed
+func (e *ErrandRun) IsFinished() bool {
+	return e.Status.Phase == ErrandRunSucceeded || e.Status.Phase == ErrandRunFailed
+}
+
+// HasErrandRunName returns true if the errand run name label is present in the set of labels
+func HasErrandRunName(l map[string]string) bool {
+	_, ok := l[LabelErrandRunName]
+	return ok
+}
@@ -20,6 +20,21 @@ const (
 	BOSHDeploymentResourceKind = "BOSHDeployment"
 	// BOSHDeploymentResourcePlural is the plural name of BOSHDeployment
 	BOSHDeploymentResourcePlural = "boshdeployments"
+
+	// RuntimeConfigResourceKind is the kind name of RuntimeConfig
+	RuntimeConfigResourceKind = "RuntimeConfig"
+	// RuntimeConfigResourcePlural is the plural name of RuntimeConfig
+	RuntimeConfigResourcePlural = "runtimeconfigs"
+
+	// CloudConfigResourceKind is the kind name of CloudConfig
+	CloudConfigResourceKind = "CloudConfig"
+	// CloudConfigResourcePlural is the plural name of CloudConfig
+	CloudConfigResourcePlural = "cloudconfigs"
+
+	// ErrandRunResourceKind is the kind name of ErrandRun
+	ErrandRunResourceKind = "ErrandRun"
+	// ErrandRunResourcePlural is the plural name of ErrandRun
+	ErrandRunResourcePlural = "errandruns"
 )
 
 var (
@@ -61,6 +76,20 @@ var (
 										},
 									},
 								},
+								"sha256": {
+									Type:    "string",
+									Pattern: "^[a-fA-F0-9]{64}$",
+								},
+								"secret": {
+									Type:      "string",
+									MinLength: pointers.Int64(1),
+								},
+								"caBundle": {
+									Type: "string",
+								},
+								"timeout": {
+									Type: "string",
+								},
 							},
 							Required: []string{
 								"type",
@@ -91,6 +120,20 @@ var (
 												},
 											},
 										},
+										"sha256": {
+											Type:    "string",
+											Pattern: "^[a-fA-F0-9]{64}$",
+										},
+										"secret": {
+											Type:      "string",
+											MinLength: pointers.Int64(1),
+										},
+										"caBundle": {
+											Type: "string",
+										},
+										"timeout": {
+											Type: "string",
+										},
 									},
 									Required: []string{
 										"type",
@@ -121,6 +164,9 @@ var (
 								},
 							},
 						},
+						"paused": {
+							Type: "boolean",
+						},
 					},
 					Required: []string{
 						"manifest",
@@ -155,6 +201,118 @@ var (
 							Type:     "string",
 							Nullable: true,
 						},
+						"observedGeneration": {
+							Type: "integer",
+						},
+						"resumedGeneration": {
+							Type: "integer",
+						},
+						"conditions": {
+							Type: "array",
+							Items: &extv1.JSONSchemaPropsOrArray{
+								Schema: &extv1.JSONSchemaProps{
+									Type: "object",
+									Properties: map[string]extv1.JSONSchemaProps{
+										"type": {
+											Type: "string",
+										},
+										"status": {
+											Type: "string",
+										},
+										"reason": {
+											Type: "string",
+										},
+										"message": {
+											Type: "string",
+										},
+										"lastTransitionTime": {
+											Type:     "string",
+											Nullable: true,
+										},
+									},
+								},
+							},
+						},
+						"instanceGroups": {
+							Type: "array",
+							Items: &extv1.JSONSchemaPropsOrArray{
+								Schema: &extv1.JSONSchemaProps{
+									Type: "object",
+									Properties: map[string]extv1.JSONSchemaProps{
+										"name": {
+											Type: "string",
+										},
+										"replicas": {
+											Type: "integer",
+										},
+										"readyReplicas": {
+											Type: "integer",
+										},
+										"version": {
+											Type: "integer",
+										},
+										"lastError": {
+											Type: "string",
+										},
+									},
+								},
+							},
+						},
+						"scheduledErrands": {
+							Type: "array",
+							Items: &extv1.JSONSchemaPropsOrArray{
+								Schema: &extv1.JSONSchemaProps{
+									Type: "object",
+									Properties: map[string]extv1.JSONSchemaProps{
+										"name": {
+											Type: "string",
+										},
+										"schedule": {
+											Type: "string",
+										},
+										"active": {
+											Type: "integer",
+										},
+										"lastScheduleTime": {
+											Type:     "string",
+											Nullable: true,
+										},
+										"lastCompletionTime": {
+											Type:     "string",
+											Nullable: true,
+										},
+										"lastResult": {
+											Type: "string",
+										},
+									},
+								},
+							},
+						},
+						"persistentDisks": {
+							Type: "array",
+							Items: &extv1.JSONSchemaPropsOrArray{
+								Schema: &extv1.JSONSchemaProps{
+									Type: "object",
+									Properties: map[string]extv1.JSONSchemaProps{
+										"name": {
+											Type: "string",
+										},
+										"instanceGroup": {
+											Type: "string",
+										},
+										"size": {
+											Type: "string",
+										},
+										"capacity": {
+											Type: "string",
+										},
+										"phase": {
+											Type: "string",
+										},
+									},
+								},
+							},
+						},
 					},
 				},
 			},
@@ -192,6 +350,168 @@ var (
 	// BOSHDeploymentResourceName is the resource name of BOSHDeployment
 	BOSHDeploymentResourceName = fmt.Sprintf("%s.%s", BOSHDeploymentResourcePlural, apis.GroupName)
 
+	// RuntimeConfigResourceShortNames is the short names of RuntimeConfig
+	RuntimeConfigResourceShortNames = []string{"rtc", "rtcs"}
+
+	// RuntimeConfigValidation is the validation method for RuntimeConfig
+	RuntimeConfigValidation = extv1.CustomResourceValidation{
+		OpenAPIV3Schema: &extv1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]extv1.JSONSchemaProps{
+				"spec": {
+					Type: "object",
+					Properties: map[string]extv1.JSONSchemaProps{
+						"config": {
+							Type:      "string",
+							MinLength: pointers.Int64(1),
+						},
+					},
+					Required: []string{
+						"config",
+					},
+				},
+			},
+		},
+	}
+
+	// RuntimeConfigResourceName is the resource name of RuntimeConfig
+	RuntimeConfigResourceName = fmt.Sprintf("%s.%s", RuntimeConfigResourcePlural, apis.GroupName)
+
+	// CloudConfigResourceShortNames is the short names of CloudConfig
+	CloudConfigResourceShortNames = []string{"cc", "ccs"}
+
+	// CloudConfigValidation is the validation method for CloudConfig
+	CloudConfigValidation = extv1.CustomResourceValidation{
+		OpenAPIV3Schema: &extv1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]extv1.JSONSchemaProps{
+				"spec": {
+					Type: "object",
+					Properties: map[string]extv1.JSONSchemaProps{
+						"config": {
+							Type:      "string",
+							MinLength: pointers.Int64(1),
+						},
+					},
+					Required: []string{
+						"config",
+					},
+				},
+			},
+		},
+	}
+
+	// CloudConfigResourceName is the resource name of CloudConfig
+	CloudConfigResourceName = fmt.Sprintf("%s.%s", CloudConfigResourcePlural, apis.GroupName)
+
+	// ErrandRunResourceShortNames is the short names of ErrandRun
+	ErrandRunResourceShortNames = []string{"errand", "errands"}
+
+	// ErrandRunValidation is the validation method for ErrandRun
+	ErrandRunValidation = extv1.CustomResourceValidation{
+		OpenAPIV3Schema: &extv1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]extv1.JSONSchemaProps{
+				"spec": {
+					Type: "object",
+					Properties: map[string]extv1.JSONSchemaProps{
+						"deployment": {
+							Type:      "string",
+							MinLength: pointers.Int64(1),
+						},
+						"errand": {
+							Type:      "string",
+							MinLength: pointers.Int64(1),
+						},
+						"env": {
+							Type: "array",
+							Items: &extv1.JSONSchemaPropsOrArray{
+								Schema: &extv1.JSONSchemaProps{
+									Type: "object",
+									Properties: map[string]extv1.JSONSchemaProps{
+										"name": {
+											Type:      "string",
+											MinLength: pointers.Int64(1),
+										},
+										"value": {
+											Type: "string",
+										},
+									},
+									Required: []string{
+										"name",
+									},
+								},
+							},
+						},
+					},
+					Required: []string{
+						"deployment",
+						"errand",
+					},
+				},
+				"status": {
+					Type: "object",
+					Properties: map[string]extv1.JSONSchemaProps{
+						"phase": {
+							Type: "string",
+						},
+						"jobName": {
+							Type: "string",
+						},
+						"startTime": {
+							Type:     "string",
+							Nullable: true,
+						},
+						"completionTime": {
+							Type:     "string",
+							Nullable: true,
+						},
+						"exitCode": {
+							Type: "integer",
+						},
+						"output": {
+							Type: "string",
+						},
+						"message": {
+							Type: "string",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// ErrandRunAdditionalPrinterColumns are used by `kubectl get`
+	ErrandRunAdditionalPrinterColumns = []extv1.CustomResourceColumnDefinition{
+		{
+			Name:     "deployment",
+			Type:     "string",
+			Priority: 0,
+			JSONPath: ".spec.deployment",
+		},
+		{
+			Name:     "errand",
+			Type:     "string",
+			Priority: 0,
+			JSONPath: ".spec.errand",
+		},
+		{
+			Name:     "phase",
+			Type:     "string",
+			Priority: 0,
+			JSONPath: ".status.phase",
+		},
+		{
+			Name:     "exitCode",
+			Type:     "integer",
+			Priority: 0,
+			JSONPath: ".status.exitCode",
+		},
+	}
+
+	// ErrandRunResourceName is the resource name of ErrandRun
+	ErrandRunResourceName = fmt.Sprintf("%s.%s", ErrandRunResourcePlural, apis.GroupName)
+
 	// SchemeGroupVersion is group version used to register these objects
 	SchemeGroupVersion = schema.GroupVersion{Group: apis.GroupName, Version: "v1alpha1"}
 )
@@ -211,6 +531,12 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&BOSHDeployment{},
 		&BOSHDeploymentList{},
+		&RuntimeConfig{},
+		&RuntimeConfigList{},
+		&CloudConfig{},
+		&CloudConfigList{},
+		&ErrandRun{},
+		&ErrandRunList{},
 	)
 
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
@@ -0,0 +1,38 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// This file is safe to edit
+// It's used as input for the Kube code generator
+// Run "make generate" after modifying this file
+
+// RuntimeConfigSpec defines the desired state of RuntimeConfig
+type RuntimeConfigSpec struct {
+	// Config is a BOSH runtime config in YAML. Its releases and addons are
+	// merged into the manifests of all BOSHDeployments in the namespace.
+	// Runtime configs in the operator namespace apply to all namespaces.
+	Config string `json:"config"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// RuntimeConfig is the Schema for the runtimeconfigs API
+// +k8s:openapi-gen=true
+type RuntimeConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec RuntimeConfigSpec `json:"spec,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// RuntimeConfigList contains a list of RuntimeConfig
+type RuntimeConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RuntimeConfig `json:"items"`
+}
@@ -3,6 +3,7 @@ package v1alpha1
 import (
 	"fmt"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/apis"
@@ -27,6 +28,13 @@ const (
 	ManifestSpecName        string = "manifest"
 	OpsSpecName             string = "ops"
 	ImplicitVariableKeyName string = "value"
+
+	// URLAuthUsernameKey is the key for the basic auth user in a URL reference's secret
+	URLAuthUsernameKey string = "username"
+	// URLAuthPasswordKey is the key for the basic auth password in a URL reference's secret
+	URLAuthPasswordKey string = "password"
+	// URLAuthTokenKey is the key for the bearer token in a URL reference's secret
+	URLAuthTokenKey string = "token"
 )
 
 // DeploymentSecretType lists all the types of secrets used in
@@ -80,6 +88,12 @@ var (
 	AnnotationJSONValue = fmt.Sprintf("%s/json-value", apis.GroupName)
 	// LabelEntanglementKey to identify a quarks link
 	LabelEntanglementKey = fmt.Sprintf("%s/entanglement", apis.GroupName)
+	// LabelOrphanedDisk marks the persistent volume claims of a removed instance group, which are kept for the retention period
+	LabelOrphanedDisk = fmt.Sprintf("%s/orphaned-disk", apis.GroupName)
+	// AnnotationOrphanedAt is the RFC3339 timestamp at which a persistent volume claim was orphaned
+	AnnotationOrphanedAt = fmt.Sprintf("%s/orphaned-at", apis.GroupName)
+	// LabelTagPrefix is the prefix of the label and annotation keys for the manifest's tags
+	LabelTagPrefix = fmt.Sprintf("tags.%s/", apis.GroupName)
 )
 
 // BOSHDeploymentSpec defines the desired state of BOSHDeployment
@@ -87,6 +101,9 @@ type BOSHDeploymentSpec struct {
 	Manifest ResourceReference   `json:"manifest"`
 	Ops      []ResourceReference `json:"ops,omitempty"`
 	Vars     []VarReference      `json:"vars,omitempty"`
+	// Paused stops the operator from applying changes to the deployment.
+	// Changes made while paused are applied once, when it is resumed.
+	Paused bool `json:"paused,omitempty"`
 }
 
 // VarReference represents a user-defined secret for an explicit variable
@@ -99,6 +116,19 @@ type VarReference struct {
 type ResourceReference struct {
 	Name string        `json:"name"`
 	Type ReferenceType `json:"type"`
+
+	// The following fields only apply to URL references
+
+	// SHA256 is the expected hex encoded checksum of the downloaded content
+	SHA256 string `json:"sha256,omitempty"`
+	// Secret is the name of a secret in the deployment's namespace, which
+	// contains either 'username' and 'password' for basic auth, or a 'token'
+	// for bearer auth
+	Secret string `json:"secret,omitempty"`
+	// CABundle is a PEM encoded CA bundle used to verify the server's certificate
+	CABundle string `json:"caBundle,omitempty"`
+	// Timeout for the download, defaults to 30s
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
 }
 
 // BOSHDeploymentStatus defines the observed state of BOSHDeployment
@@ -112,6 +142,154 @@ type BOSHDeploymentStatus struct {
 	TotalInstanceGroups    int          `json:"totalInstanceGroups"`
 	DeployedInstanceGroups int          `json:"deployedInstanceGroups"`
 	StateTimestamp         *metav1.Time `json:"stateTimestamp"`
+
+	// ObservedGeneration is the generation of the spec, which was last
+	// processed by the deployment reconciler
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// ResumedGeneration is the generation of the spec, which resumed the
+	// paused deployment last
+	ResumedGeneration int64 `json:"resumedGeneration,omitempty"`
+	// Conditions describe the progress of the deployment
+	Conditions []BOSHDeploymentCondition `json:"conditions,omitempty"`
+	// InstanceGroups holds the status of each deployed instance group
+	InstanceGroups []InstanceGroupStatus `json:"instanceGroups,omitempty"`
+	// ScheduledErrands holds the status of each errand with a cron schedule
+	ScheduledErrands []ScheduledErrandStatus `json:"scheduledErrands,omitempty"`
+	// PersistentDisks holds the status of each persistent volume claim of the instance groups
+	PersistentDisks []PersistentDiskStatus `json:"persistentDisks,omitempty"`
+}
+
+// BOSHDeploymentConditionType is the type of a BOSHDeployment condition
+type BOSHDeploymentConditionType string
+
+// Valid condition types for a BOSHDeployment
+const (
+	// ManifestResolved is true, once the manifest, ops files and implicit variables have been resolved
+	ManifestResolved BOSHDeploymentConditionType = "ManifestResolved"
+	// VariablesGenerated is true, once the QuarksSecrets for all explicit variables have been created
+	VariablesGenerated BOSHDeploymentConditionType = "VariablesGenerated"
+	// InstanceGroupsResolved is true, once the instance group manifest QuarksJob completed
+	InstanceGroupsResolved BOSHDeploymentConditionType = "InstanceGroupsResolved"
+	// Deployed is true, once all instance groups are ready
+	Deployed BOSHDeploymentConditionType = "Deployed"
+	// Degraded is true, if a previously deployed instance group is no longer ready or failed to update
+	Degraded BOSHDeploymentConditionType = "Degraded"
+	// Paused is true, while the reconciliation of the deployment is paused
+	Paused BOSHDeploymentConditionType = "Paused"
+)
+
+// BOSHDeploymentCondition describes the state of a BOSHDeployment at a certain point
+type BOSHDeploymentCondition struct {
+	Type   BOSHDeploymentConditionType `json:"type"`
+	Status corev1.ConditionStatus      `json:"status"`
+	// Reason is a CamelCase reason for the condition's last transition
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable message with details about the transition
+	Message            string      `json:"message,omitempty"`
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// InstanceGroupStatus defines the observed state of an instance group
+type InstanceGroupStatus struct {
+	Name          string `json:"name"`
+	Replicas      int32  `json:"replicas"`
+	ReadyReplicas int32  `json:"readyReplicas"`
+	// Version is the version of the most recent statefulset of the instance group
+	Version int `json:"version"`
+	// LastError is the last error, which occurred while deploying the instance group
+	LastError string `json:"lastError,omitempty"`
+}
+
+// ScheduledErrandStatus defines the observed state of a scheduled errand
+type ScheduledErrandStatus struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`
+	// Active is the number of currently running jobs of the errand
+	Active           int32        `json:"active"`
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+	// LastCompletionTime is the time the last finished job of the errand succeeded or failed
+	LastCompletionTime *metav1.Time `json:"lastCompletionTime,omitempty"`
+	// LastResult is the phase of the last finished job, either Succeeded or Failed
+	LastResult ErrandRunPhase `json:"lastResult,omitempty"`
+}
+
+// PersistentDiskPhase is the phase of a persistent disk resize
+type PersistentDiskPhase string
+
+// Valid phases of a persistent disk
+const (
+	// PersistentDiskReady means the volume has the size requested by the manifest
+	PersistentDiskReady PersistentDiskPhase = "Ready"
+	// PersistentDiskResizing means the volume is being expanded to the size requested by the manifest
+	PersistentDiskResizing PersistentDiskPhase = "Resizing"
+	// PersistentDiskResizeNotSupported means the disk grew in the manifest, but its storage class doesn't allow volume expansion
+	PersistentDiskResizeNotSupported PersistentDiskPhase = "ResizeNotSupported"
+)
+
+// PersistentDiskStatus defines the observed state of a persistent volume claim of an instance group
+type PersistentDiskStatus struct {
+	// Name is the name of the persistent volume claim
+	Name          string `json:"name"`
+	InstanceGroup string `json:"instanceGroup"`
+	// Size is the size of the disk in the manifest
+	Size string `json:"size"`
+	// Capacity is the actual size of the volume
+	Capacity string              `json:"capacity,omitempty"`
+	Phase    PersistentDiskPhase `json:"phase"`
+}
+
+// GetCondition returns the condition with the given type or nil
+func (s *BOSHDeploymentStatus) GetCondition(t BOSHDeploymentConditionType) *BOSHDeploymentCondition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == t {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsConditionTrue returns true if the condition with the given type exists and is true
+func (s *BOSHDeploymentStatus) IsConditionTrue(t BOSHDeploymentConditionType) bool {
+	c := s.GetCondition(t)
+	return c != nil && c.Status == corev1.ConditionTrue
+}
+
+// SetCondition adds or updates the condition with the given type. The
+// transition time is only updated if the status changes. Returns true if
+// anything changed.
+func (s *BOSHDeploymentStatus) SetCondition(t BOSHDeploymentConditionType, status corev1.ConditionStatus, reason string, message string) bool {
+	c := s.GetCondition(t)
+	if c == nil {
+		s.Conditions = append(s.Conditions, BOSHDeploymentCondition{
+			Type:               t,
+			Status:             status,
+			Reason:             reason,
+			Message:            message,
+			LastTransitionTime: metav1.Now(),
+		})
+		return true
+	}
+
+	if c.Status == status && c.Reason == reason && c.Message == message {
+		return false
+	}
+	if c.Status != status {
+		c.LastTransitionTime = metav1.Now()
+	}
+	c.Status = status
+	c.Reason = reason
+	c.Message = message
+	return true
+}
+
+// GetInstanceGroup returns the status of the named instance group or nil
+func (s *BOSHDeploymentStatus) GetInstanceGroup(name string) *InstanceGroupStatus {
+	for i := range s.InstanceGroups {
+		if s.InstanceGroups[i].Name == name {
+			return &s.InstanceGroups[i]
+		}
+	}
+	return nil
 }
 
 // +genclient
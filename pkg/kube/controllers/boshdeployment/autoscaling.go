@@ -0,0 +1,68 @@
+package boshdeployment
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	qstsv1a1 "code.cloudfoundry.org/quarks-statefulset/pkg/kube/apis/quarksstatefulset/v1alpha1"
+	qstscontroller "code.cloudfoundry.org/quarks-statefulset/pkg/kube/controllers/quarksstatefulset"
+)
+
+// autoscaledReplicas returns the highest replica count of the stateful sets
+// of an existing QuarksStatefulSet. Autoscalers change the replicas of the
+// stateful sets, updating the QuarksStatefulSet must not reset them.
+func autoscaledReplicas(ctx context.Context, c client.Client, namespace string, name string) (*int32, error) {
+	qSts := &qstsv1a1.QuarksStatefulSet{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, qSts)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get QuarksStatefulSet '%s/%s'", namespace, name)
+	}
+
+	statefulSets, _, err := qstscontroller.GetMaxStatefulSetVersion(ctx, c, qSts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list stateful sets of QuarksStatefulSet '%s/%s'", namespace, name)
+	}
+
+	var replicas *int32
+	for _, sts := range statefulSets {
+		if sts.Spec.Replicas != nil && (replicas == nil || *sts.Spec.Replicas > *replicas) {
+			r := *sts.Spec.Replicas
+			replicas = &r
+		}
+	}
+	return replicas, nil
+}
+
+// deleteInstanceGroupAutoscalers deletes the horizontal pod autoscalers of an
+// instance group, except for the ones to keep
+func deleteInstanceGroupAutoscalers(ctx context.Context, c client.Client, bdpl *bdv1.BOSHDeployment, instanceGroupName string, keep map[string]bool) error {
+	autoscalers := &autoscalingv2beta2.HorizontalPodAutoscalerList{}
+	labels := map[string]string{
+		bdv1.LabelDeploymentName:    bdpl.Name,
+		bdv1.LabelInstanceGroupName: instanceGroupName,
+	}
+	err := c.List(ctx, autoscalers, client.InNamespace(bdpl.Namespace), client.MatchingLabels(labels))
+	if err != nil {
+		return errors.Wrapf(err, "failed to list horizontal pod autoscalers for instance group %s", instanceGroupName)
+	}
+	for i := range autoscalers.Items {
+		if keep[autoscalers.Items[i].Name] {
+			continue
+		}
+		err = c.Delete(ctx, &autoscalers.Items[i])
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
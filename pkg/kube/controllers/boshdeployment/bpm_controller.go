@@ -7,17 +7,21 @@ import (
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"code.cloudfoundry.org/quarks-operator/pkg/bosh/bpmconverter"
 	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/desiredmanifest"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/metrics"
 	"code.cloudfoundry.org/quarks-utils/pkg/config"
 	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
 	"code.cloudfoundry.org/quarks-utils/pkg/meltdown"
@@ -41,7 +45,7 @@ func AddBPM(ctx context.Context, config *config.Config, mgr manager.Manager) err
 
 	// Create a new controller
 	c, err := controller.New("bpm-controller", mgr, controller.Options{
-		Reconciler:              r,
+		Reconciler:              metrics.InstrumentReconciler(metrics.ControllerBPM, r),
 		MaxConcurrentReconciles: config.MaxBoshDeploymentWorkers,
 	})
 	if err != nil {
@@ -82,9 +86,76 @@ func AddBPM(ctx context.Context, config *config.Config, mgr manager.Manager) err
 		return errors.Wrapf(err, "Watching secrets failed in BPM controller.")
 	}
 
+	// Watch for resumed BOSHDeployments, to apply the BPM secrets skipped while paused
+	p = predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return false },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+		UpdateFunc:  isResumed,
+	}
+	err = c.Watch(&source.Kind{Type: &bdv1.BOSHDeployment{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+			reconciles, err := unreconciledBPMSecrets(ctx, mgr.GetClient(), a.Meta.GetNamespace(), a.Meta.GetName())
+			if err != nil {
+				ctxlog.Errorf(ctx, "Failed to calculate reconciles for resumed BOSHDeployment '%s/%s': %v", a.Meta.GetNamespace(), a.Meta.GetName(), err)
+			}
+
+			for _, reconciliation := range reconciles {
+				ctxlog.NewMappingEvent(a.Object).Debug(ctx, reconciliation, names.Secret, a.Meta.GetName(), "bdv1.BOSHDeployment")
+			}
+
+			return reconciles
+		}),
+	}, nsPred, p)
+	if err != nil {
+		return errors.Wrapf(err, "Watching bosh deployment failed in BPM controller.")
+	}
+
 	return nil
 }
 
+// unreconciledBPMSecrets returns a request for the latest BPM secret of
+// each instance group, which has not been reconciled yet
+func unreconciledBPMSecrets(ctx context.Context, c client.Client, namespace string, deploymentName string) ([]reconcile.Request, error) {
+	list := &corev1.SecretList{}
+	err := c.List(ctx, list, client.InNamespace(namespace), client.MatchingLabels{
+		bdv1.LabelDeploymentName:       deploymentName,
+		bdv1.LabelDeploymentSecretType: bdv1.DeploymentSecretBPMInformation.String(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list BPM secrets")
+	}
+
+	latest := map[string]corev1.Secret{}
+	versions := map[string]int{}
+	for _, secret := range list.Items {
+		if !isBPMInfoSecret(&secret) {
+			continue
+		}
+		version, err := vss.Version(secret)
+		if err != nil {
+			continue
+		}
+		prefix := vss.NamePrefix(secret.Name)
+		if v, ok := versions[prefix]; !ok || version > v {
+			versions[prefix] = version
+			latest[prefix] = secret
+		}
+	}
+
+	result := []reconcile.Request{}
+	for _, secret := range latest {
+		if metav1.HasAnnotation(secret.ObjectMeta, meltdown.AnnotationLastReconcile) {
+			continue
+		}
+		result = append(result, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace},
+		})
+	}
+
+	return result, nil
+}
+
 func isBPMInfoSecret(secret *corev1.Secret) bool {
 	ok := vss.IsVersionedSecret(*secret)
 	if !ok {
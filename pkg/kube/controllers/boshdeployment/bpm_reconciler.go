@@ -6,8 +6,12 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	batchv1b1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -23,8 +27,10 @@ import (
 	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/controllers/quarksrestart"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/boshdns"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/metrics"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/mutate"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/names"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/orphaneddisk"
 	qstsv1a1 "code.cloudfoundry.org/quarks-statefulset/pkg/kube/apis/quarksstatefulset/v1alpha1"
 	qstscontroller "code.cloudfoundry.org/quarks-statefulset/pkg/kube/controllers/quarksstatefulset"
 	"code.cloudfoundry.org/quarks-utils/pkg/config"
@@ -40,7 +46,7 @@ type BPMConverter interface {
 
 // DesiredManifest unmarshals desired manifest from the manifest secret
 type DesiredManifest interface {
-	DesiredManifest(ctx context.Context, namespace string) (*bdm.Manifest, error)
+	DesiredManifest(ctx context.Context, deploymentName string, namespace string) (*bdm.Manifest, error)
 }
 
 var _ reconcile.Reconciler = &ReconcileBOSHDeployment{}
@@ -97,6 +103,7 @@ func (r *ReconcileBPM) Reconcile(request reconcile.Request) (reconcile.Result, e
 
 	if meltdown.NewAnnotationWindow(r.config.MeltdownDuration, bpmSecret.ObjectMeta.Annotations).Contains(time.Now()) {
 		log.WithEvent(bpmSecret, "Meltdown").Debugf(ctx, "Resource '%s/%s' is in meltdown, requeue reconcile after %s", bpmSecret.Namespace, bpmSecret.Name, r.config.MeltdownRequeueAfter)
+		metrics.MeltdownSkipped(metrics.ControllerBPM)
 		return reconcile.Result{RequeueAfter: r.config.MeltdownRequeueAfter}, nil
 	}
 
@@ -113,7 +120,7 @@ func (r *ReconcileBPM) Reconcile(request reconcile.Request) (reconcile.Result, e
 			log.WithEvent(bpmSecret, "LabelMissingError").Errorf(ctx, "There's no label for a instance group name on the BPM secret '%s'", request.NamespacedName)
 	}
 
-	manifest, err := r.resolver.DesiredManifest(ctx, request.Namespace)
+	manifest, err := r.resolver.DesiredManifest(ctx, deploymentName, request.Namespace)
 	if err != nil {
 		return reconcile.Result{},
 			log.WithEvent(bpmSecret, "DesiredManifestReadError").Errorf(ctx, "Failed to read desired manifest for bpm '%s': %v", request.NamespacedName, err)
@@ -126,12 +133,20 @@ func (r *ReconcileBPM) Reconcile(request reconcile.Request) (reconcile.Result, e
 			log.WithEvent(bpmSecret, "GetBOSHDeployment").Errorf(ctx, "Failed to get BoshDeployment instance '%s/%s': %v", request.Namespace, deploymentName, err)
 	}
 
+	// The BPM secret is not marked as reconciled, so the controller picks
+	// it up again, when the deployment is resumed
+	if bdpl.Spec.Paused {
+		log.Infof(ctx, "Skip reconcile: BOSHDeployment '%s' is paused", bdpl.GetNamespacedName())
+		return reconcile.Result{}, nil
+	}
+
 	dnsService := &corev1.Service{}
 	if boshdns.HasBoshDNSAddOn(*manifest) != -1 {
-		err = r.client.Get(ctx, types.NamespacedName{Namespace: request.Namespace, Name: boshdns.AppName}, dnsService)
+		dnsServiceName := boshdns.DNSName(deploymentName)
+		err = r.client.Get(ctx, types.NamespacedName{Namespace: request.Namespace, Name: dnsServiceName}, dnsService)
 		if err != nil {
 			return reconcile.Result{},
-				log.WithEvent(bpmSecret, "GetBOSHDeployment").Errorf(ctx, "Failed to get '%s' service '%s/%s': %v", boshdns.AppName, request.Namespace, deploymentName, err)
+				log.WithEvent(bpmSecret, "GetBOSHDeployment").Errorf(ctx, "Failed to get DNS service '%s/%s' for deployment '%s': %v", request.Namespace, dnsServiceName, deploymentName, err)
 		}
 	}
 
@@ -142,7 +157,9 @@ func (r *ReconcileBPM) Reconcile(request reconcile.Request) (reconcile.Result, e
 			log.WithEvent(bpmSecret, "SkipReconcile").Debugf(ctx, "Requeue reconcile: %s", err)
 			return reconcile.Result{RequeueAfter: time.Second * 5}, nil
 		}
-		return reconcile.Result{}, log.WithEvent(bpmSecret, "BPMApplyingError").Errorf(ctx, "Failed to apply BPM information: %v", err)
+		err = log.WithEvent(bpmSecret, "BPMApplyingError").Errorf(ctx, "Failed to apply BPM information: %v", err)
+		r.setInstanceGroupError(ctx, bdpl, instanceGroupName, err)
+		return reconcile.Result{}, err
 	}
 
 	if resources == nil {
@@ -150,12 +167,27 @@ func (r *ReconcileBPM) Reconcile(request reconcile.Request) (reconcile.Result, e
 		return reconcile.Result{}, nil
 	}
 
+	// Take over the disks of instance groups listed in migrated_from
+	if ig, found := manifest.InstanceGroups.InstanceGroupByName(instanceGroupName); found && len(ig.MigratedFrom) > 0 {
+		waiting, err := migrateInstanceGroups(ctx, r.client, bdpl, manifest, ig)
+		if err != nil {
+			err = log.WithEvent(bpmSecret, "MigrateInstanceGroupError").Errorf(ctx, "Failed to migrate instance groups to '%s': %v", instanceGroupName, err)
+			r.setInstanceGroupError(ctx, bdpl, instanceGroupName, err)
+			return reconcile.Result{}, err
+		}
+		if waiting {
+			return reconcile.Result{RequeueAfter: time.Second * 5}, nil
+		}
+	}
+
 	// Deploy instance groups
 	err = r.deployInstanceGroups(ctx, bdpl, instanceGroupName, resources)
 	if err != nil {
-		return reconcile.Result{},
-			log.WithEvent(bpmSecret, "InstanceGroupStartError").Errorf(ctx, "Failed to start: %v", err)
+		err = log.WithEvent(bpmSecret, "InstanceGroupStartError").Errorf(ctx, "Failed to start: %v", err)
+		r.setInstanceGroupError(ctx, bdpl, instanceGroupName, err)
+		return reconcile.Result{}, err
 	}
+	r.setInstanceGroupError(ctx, bdpl, instanceGroupName, nil)
 
 	meltdown.SetLastReconcile(&bpmSecret.ObjectMeta, time.Now())
 	err = r.client.Update(ctx, bpmSecret)
@@ -167,6 +199,33 @@ func (r *ReconcileBPM) Reconcile(request reconcile.Request) (reconcile.Result, e
 	return reconcile.Result{}, nil
 }
 
+// setInstanceGroupError records the last error of an instance group in the
+// BOSHDeployment's status, a nil error clears it. The status reconcilers
+// keep it, when they refresh the instance group status.
+func (r *ReconcileBPM) setInstanceGroupError(ctx context.Context, bdpl *bdv1.BOSHDeployment, instanceGroupName string, err error) {
+	lastError := ""
+	if err != nil {
+		lastError = err.Error()
+	}
+
+	ig := bdpl.Status.GetInstanceGroup(instanceGroupName)
+	if ig == nil {
+		if lastError == "" {
+			return
+		}
+		bdpl.Status.InstanceGroups = append(bdpl.Status.InstanceGroups, bdv1.InstanceGroupStatus{Name: instanceGroupName})
+		ig = &bdpl.Status.InstanceGroups[len(bdpl.Status.InstanceGroups)-1]
+	}
+	if ig.LastError == lastError {
+		return
+	}
+	ig.LastError = lastError
+
+	if err := r.client.Status().Update(ctx, bdpl); err != nil {
+		log.WithEvent(bdpl, "UpdateStatusError").Errorf(ctx, "Failed to update instance group status on BDPL '%s' (%v): %s", bdpl.GetNamespacedName(), bdpl.ResourceVersion, err)
+	}
+}
+
 func (r *ReconcileBPM) applyBPMResources(bdplName string, instanceGroupName string, bpmSecret *corev1.Secret, manifest *bdm.Manifest, serviceIP string) (*bpmconverter.Resources, error) {
 	var bpmInfo bdm.BPMInfo
 	if val, ok := bpmSecret.Data["bpm.yaml"]; ok {
@@ -182,6 +241,7 @@ func (r *ReconcileBPM) applyBPMResources(bdplName string, instanceGroupName stri
 	if !found {
 		return nil, errors.Errorf("instance group '%s' not found", instanceGroupName)
 	}
+	instanceGroup.SetConsumedDisks(bpmInfo.InstanceGroup.ConsumedDisks)
 
 	// Fetch qSts version
 	quarksStatefulSet := &qstsv1a1.QuarksStatefulSet{}
@@ -193,7 +253,7 @@ func (r *ReconcileBPM) applyBPMResources(bdplName string, instanceGroupName stri
 		}
 	}
 
-	igResolvedSecretVersion, err := r.fetchIGresolvedVersion(bpmSecret.Namespace, instanceGroupName)
+	igResolvedSecretVersion, err := r.fetchIGresolvedVersion(bpmSecret.Namespace, bdplName, instanceGroupName)
 	if err != nil {
 		return nil, err
 	}
@@ -216,8 +276,8 @@ func (r *ReconcileBPM) applyBPMResources(bdplName string, instanceGroupName stri
 	return resources, nil
 }
 
-func (r *ReconcileBPM) fetchIGresolvedVersion(namespace string, instanceGroupName string) (string, error) {
-	igResolvedSecretName := names.InstanceGroupSecretName(instanceGroupName, "")
+func (r *ReconcileBPM) fetchIGresolvedVersion(namespace string, deploymentName string, instanceGroupName string) (string, error) {
+	igResolvedSecretName := names.InstanceGroupSecretName(bdv1.DeploymentSecretTypeInstanceGroupResolvedProperties, deploymentName, instanceGroupName, "")
 	igResolvedSecret, err := r.versionedSecretStore.Latest(r.ctx, namespace, igResolvedSecretName)
 	if err != nil {
 		if igResolvedSecret == nil {
@@ -250,11 +310,48 @@ func (r *ReconcileBPM) deployInstanceGroups(ctx context.Context, bdpl *bdv1.BOSH
 		log.Debugf(ctx, "QuarksJob '%s/%s' has been %s", bdpl.Namespace, qJob.Name, op)
 	}
 
+	scheduled := map[string]bool{}
+	for _, cronJob := range resources.ScheduledErrands {
+		if cronJob.Labels[bdv1.LabelInstanceGroupName] != instanceGroupName {
+			log.Debugf(ctx, "Skipping apply CronJob '%s/%s' for instance group '%s' because of mismatching '%s' label", bdpl.Namespace, cronJob.Name, bdpl.Name, bdv1.LabelInstanceGroupName)
+			continue
+		}
+		scheduled[cronJob.Name] = true
+
+		if err := r.setReference(bdpl, &cronJob, r.scheme); err != nil {
+			return log.WithEvent(bdpl, "CronJobForDeploymentError").Errorf(ctx, "Failed to set reference for CronJob instance group '%s' : %v", instanceGroupName, err)
+		}
+
+		op, err := controllerutil.CreateOrUpdate(ctx, r.client, &cronJob, mutate.CronJobMutateFn(&cronJob))
+		if err != nil {
+			return log.WithEvent(bdpl, "ApplyCronJobError").Errorf(ctx, "Failed to apply CronJob for instance group '%s' : %v", instanceGroupName, err)
+		}
+
+		log.Debugf(ctx, "CronJob '%s/%s' has been %s", bdpl.Namespace, cronJob.Name, op)
+	}
+
+	// Remove the cron job of errands, which are no longer scheduled
+	for _, qJob := range resources.Errands {
+		if qJob.Labels[bdv1.LabelInstanceGroupName] != instanceGroupName || scheduled[qJob.Name] {
+			continue
+		}
+
+		cronJob := &batchv1b1.CronJob{ObjectMeta: metav1.ObjectMeta{Name: qJob.Name, Namespace: bdpl.Namespace}}
+		if err := deleteInstanceGroupObject(ctx, r.client, bdpl, instanceGroupName, cronJob, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil {
+			return log.WithEvent(bdpl, "DeleteCronJobError").Errorf(ctx, "Failed to delete CronJob for instance group '%s' : %v", instanceGroupName, err)
+		}
+	}
+
+	exposedName := names.ExposedServiceName(bdpl.Name, instanceGroupName)
+	exposed := false
 	for _, svc := range resources.Services {
 		if svc.Labels[bdv1.LabelInstanceGroupName] != instanceGroupName {
 			log.Debugf(ctx, "Skipping apply Service '%s/%s' for instance group '%s' because of mismatching '%s' label", bdpl.Namespace, svc.Name, bdpl.Name, bdv1.LabelInstanceGroupName)
 			continue
 		}
+		if svc.Name == exposedName {
+			exposed = true
+		}
 
 		if err := r.setReference(bdpl, &svc, r.scheme); err != nil {
 			return log.WithEvent(bdpl, "ServiceForDeploymentError").Errorf(ctx, "Failed to set reference for Service instance group '%s' : %v", instanceGroupName, err)
@@ -268,6 +365,100 @@ func (r *ReconcileBPM) deployInstanceGroups(ctx context.Context, bdpl *bdv1.BOSH
 		log.Debugf(ctx, "Service '%s/%s' has been %s", bdpl.Namespace, svc.Name, op)
 	}
 
+	ingressExposed := false
+	for _, ingress := range resources.Ingresses {
+		if ingress.Labels[bdv1.LabelInstanceGroupName] != instanceGroupName {
+			log.Debugf(ctx, "Skipping apply Ingress '%s/%s' for instance group '%s' because of mismatching '%s' label", bdpl.Namespace, ingress.Name, bdpl.Name, bdv1.LabelInstanceGroupName)
+			continue
+		}
+		if ingress.Name == exposedName {
+			ingressExposed = true
+		}
+
+		if err := r.setReference(bdpl, &ingress, r.scheme); err != nil {
+			return log.WithEvent(bdpl, "IngressForDeploymentError").Errorf(ctx, "Failed to set reference for Ingress instance group '%s' : %v", instanceGroupName, err)
+		}
+
+		op, err := controllerutil.CreateOrUpdate(ctx, r.client, &ingress, mutate.IngressMutateFn(&ingress))
+		if err != nil {
+			return log.WithEvent(bdpl, "ApplyIngressError").Errorf(ctx, "Failed to apply Ingress for instance group '%s' : %v", instanceGroupName, err)
+		}
+
+		log.Debugf(ctx, "Ingress '%s/%s' has been %s", bdpl.Namespace, ingress.Name, op)
+	}
+
+	// Remove the exposed service and ingress, when the instance group is no longer exposed
+	if !exposed {
+		svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: exposedName, Namespace: bdpl.Namespace}}
+		if err := deleteInstanceGroupObject(ctx, r.client, bdpl, instanceGroupName, svc); err != nil {
+			return log.WithEvent(bdpl, "DeleteServiceError").Errorf(ctx, "Failed to delete Service for instance group '%s' : %v", instanceGroupName, err)
+		}
+	}
+	if !ingressExposed {
+		ingress := &networkingv1beta1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: exposedName, Namespace: bdpl.Namespace}}
+		if err := deleteInstanceGroupObject(ctx, r.client, bdpl, instanceGroupName, ingress); err != nil {
+			return log.WithEvent(bdpl, "DeleteIngressError").Errorf(ctx, "Failed to delete Ingress for instance group '%s' : %v", instanceGroupName, err)
+		}
+	}
+
+	budgets := map[string]bool{}
+	for _, pdb := range resources.DisruptionBudgets {
+		if pdb.Labels[bdv1.LabelInstanceGroupName] != instanceGroupName {
+			log.Debugf(ctx, "Skipping apply PodDisruptionBudget '%s/%s' for instance group '%s' because of mismatching '%s' label", bdpl.Namespace, pdb.Name, bdpl.Name, bdv1.LabelInstanceGroupName)
+			continue
+		}
+		budgets[pdb.Name] = true
+
+		if err := r.setReference(bdpl, &pdb, r.scheme); err != nil {
+			return log.WithEvent(bdpl, "PodDisruptionBudgetForDeploymentError").Errorf(ctx, "Failed to set reference for PodDisruptionBudget instance group '%s' : %v", instanceGroupName, err)
+		}
+
+		op, err := controllerutil.CreateOrUpdate(ctx, r.client, &pdb, mutate.PodDisruptionBudgetMutateFn(&pdb))
+		if err != nil {
+			return log.WithEvent(bdpl, "ApplyPodDisruptionBudgetError").Errorf(ctx, "Failed to apply PodDisruptionBudget for instance group '%s' : %v", instanceGroupName, err)
+		}
+
+		log.Debugf(ctx, "PodDisruptionBudget '%s/%s' has been %s", bdpl.Namespace, pdb.Name, op)
+	}
+
+	// Remove the disruption budget of instance groups, which no longer need one
+	for _, qSts := range resources.InstanceGroups {
+		if qSts.Labels[bdv1.LabelInstanceGroupName] != instanceGroupName || budgets[qSts.Name] {
+			continue
+		}
+
+		pdb := &policyv1beta1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{Name: qSts.Name, Namespace: bdpl.Namespace}}
+		err := r.client.Delete(ctx, pdb)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return log.WithEvent(bdpl, "DeletePodDisruptionBudgetError").Errorf(ctx, "Failed to delete PodDisruptionBudget for instance group '%s' : %v", instanceGroupName, err)
+		}
+	}
+
+	autoscaled := map[string]bool{}
+	for _, hpa := range resources.Autoscalers {
+		if hpa.Labels[bdv1.LabelInstanceGroupName] != instanceGroupName {
+			log.Debugf(ctx, "Skipping apply HorizontalPodAutoscaler '%s/%s' for instance group '%s' because of mismatching '%s' label", bdpl.Namespace, hpa.Name, bdpl.Name, bdv1.LabelInstanceGroupName)
+			continue
+		}
+		autoscaled[hpa.Name] = true
+
+		if err := r.setReference(bdpl, &hpa, r.scheme); err != nil {
+			return log.WithEvent(bdpl, "HorizontalPodAutoscalerForDeploymentError").Errorf(ctx, "Failed to set reference for HorizontalPodAutoscaler instance group '%s' : %v", instanceGroupName, err)
+		}
+
+		op, err := controllerutil.CreateOrUpdate(ctx, r.client, &hpa, mutate.HorizontalPodAutoscalerMutateFn(&hpa))
+		if err != nil {
+			return log.WithEvent(bdpl, "ApplyHorizontalPodAutoscalerError").Errorf(ctx, "Failed to apply HorizontalPodAutoscaler for instance group '%s' : %v", instanceGroupName, err)
+		}
+
+		log.Debugf(ctx, "HorizontalPodAutoscaler '%s/%s' has been %s", bdpl.Namespace, hpa.Name, op)
+	}
+
+	// Remove the autoscalers of zones or instance groups, which are no longer autoscaled
+	if err := deleteInstanceGroupAutoscalers(ctx, r.client, bdpl, instanceGroupName, autoscaled); err != nil {
+		return log.WithEvent(bdpl, "DeleteHorizontalPodAutoscalerError").Errorf(ctx, "Failed to delete HorizontalPodAutoscaler for instance group '%s' : %v", instanceGroupName, err)
+	}
+
 	for _, qSts := range resources.InstanceGroups {
 		// Automatically restart instance groups if any of the secret changes
 		annotations := qSts.Spec.Template.Spec.Template.Annotations
@@ -282,6 +473,32 @@ func (r *ReconcileBPM) deployInstanceGroups(ctx context.Context, bdpl *bdv1.BOSH
 			continue
 		}
 
+		// stateful sets created before the claim templates were labeled don't label their claims
+		if err := orphaneddisk.Label(ctx, r.client, bdpl.Namespace, bdpl.Name, instanceGroupName); err != nil {
+			return log.WithEvent(bdpl, "LabelPersistentDiskError").Errorf(ctx, "Failed to label persistent disks of instance group '%s' : %v", instanceGroupName, err)
+		}
+
+		// the stateful set reuses the claims of an instance group, which was removed and added again
+		if err := orphaneddisk.Adopt(ctx, r.client, bdpl.Namespace, bdpl.Name, instanceGroupName); err != nil {
+			return log.WithEvent(bdpl, "AdoptPersistentDiskError").Errorf(ctx, "Failed to adopt orphaned persistent disks of instance group '%s' : %v", instanceGroupName, err)
+		}
+
+		// keep the replicas of the stateful sets, which the autoscalers set
+		if len(autoscaled) > 0 {
+			replicas, err := autoscaledReplicas(ctx, r.client, bdpl.Namespace, qSts.Name)
+			if err != nil {
+				return log.WithEvent(bdpl, "AutoscaledReplicasError").Errorf(ctx, "Failed to get the autoscaled replicas of instance group '%s' : %v", instanceGroupName, err)
+			}
+			if replicas != nil {
+				qSts.Spec.Template.Spec.Replicas = replicas
+			}
+		}
+
+		// volumes can only be expanded, the stateful set is not updated if a disk would shrink
+		if err := persistentDisksNotShrunk(ctx, r.client, bdpl, &qSts); err != nil {
+			return log.WithEvent(bdpl, "ShrinkPersistentDiskError").Errorf(ctx, "Failed to apply QuarksStatefulSet for instance group '%s' : %v", instanceGroupName, err)
+		}
+
 		if err := r.setReference(bdpl, &qSts, r.scheme); err != nil {
 			return log.WithEvent(bdpl, "QuarksStatefulSetForDeploymentError").Errorf(ctx, "Failed to set reference for QuarksStatefulSet instance group '%s' : %v", instanceGroupName, err)
 		}
@@ -292,6 +509,10 @@ func (r *ReconcileBPM) deployInstanceGroups(ctx context.Context, bdpl *bdv1.BOSH
 		}
 
 		log.Debugf(ctx, "QuarksStatefulSet '%s/%s' has been %s", bdpl.Namespace, qSts.Name, op)
+
+		if err := resizePersistentDisks(ctx, r.client, bdpl, &qSts); err != nil {
+			return log.WithEvent(bdpl, "ResizePersistentDiskError").Errorf(ctx, "Failed to resize persistent disks of instance group '%s' : %v", instanceGroupName, err)
+		}
 	}
 
 	return nil
@@ -10,12 +10,21 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest/observer"
 
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	batchv1b1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	storagev1 "k8s.io/api/storage/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/record"
 	crc "sigs.k8s.io/controller-runtime/pkg/client"
@@ -29,8 +38,10 @@ import (
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/controllers"
 	cfd "code.cloudfoundry.org/quarks-operator/pkg/kube/controllers/boshdeployment"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/controllers/fakes"
+	qstsv1a1 "code.cloudfoundry.org/quarks-statefulset/pkg/kube/apis/quarksstatefulset/v1alpha1"
 	cfcfg "code.cloudfoundry.org/quarks-utils/pkg/config"
 	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+	"code.cloudfoundry.org/quarks-utils/pkg/pointers"
 	"code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore"
 	helper "code.cloudfoundry.org/quarks-utils/testing/testhelper"
 )
@@ -49,6 +60,7 @@ var _ = Describe("ReconcileBPM", func() {
 		log                       *zap.SugaredLogger
 		config                    *cfcfg.Config
 		client                    *fakes.FakeClient
+		statusWriter              *fakes.FakeStatusWriter
 		manifestWithVars          *corev1.Secret
 		bpmInformation            *corev1.Secret
 		bpmInformationNoProcesses *corev1.Secret
@@ -235,6 +247,8 @@ variables: []
 			return nil
 		})
 
+		statusWriter = &fakes.FakeStatusWriter{}
+		client.StatusCalls(func() crc.StatusWriter { return statusWriter })
 		manager.GetClientReturns(client)
 
 		request = reconcile.Request{NamespacedName: types.NamespacedName{Name: "foo.bpm.fakepod", Namespace: "default"}}
@@ -324,6 +338,667 @@ variables: []
 				_, err := reconciler.Reconcile(request)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("failed to start: failed to apply Service for instance group 'fakepod'"))
+
+				Expect(statusWriter.UpdateCallCount()).To(Equal(1))
+				_, object, _ := statusWriter.UpdateArgsForCall(0)
+				ig := object.(*bdv1.BOSHDeployment).Status.GetInstanceGroup("fakepod")
+				Expect(ig).NotTo(BeNil())
+				Expect(ig.LastError).To(ContainSubstring("failed to apply Service for instance group 'fakepod'"))
+			})
+
+			It("skips the reconcile while the BOSHDeployment is paused", func() {
+				client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+					switch object := object.(type) {
+					case *corev1.Secret:
+						if nn.Name == bpmInformation.Name {
+							bpmInformation.DeepCopyInto(object)
+						}
+					case *bdv1.BOSHDeployment:
+						object.Name = nn.Name
+						object.Namespace = nn.Namespace
+						object.Spec.Paused = true
+					}
+					return nil
+				})
+
+				result, err := reconciler.Reconcile(request)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(Equal(reconcile.Result{}))
+				Expect(kubeConverter.ResourcesCallCount()).To(Equal(0))
+				// the BPM secret is not marked as reconciled
+				Expect(client.UpdateCallCount()).To(Equal(0))
+			})
+
+			It("applies the cron job of a scheduled errand", func() {
+				labels := map[string]string{bdv1.LabelInstanceGroupName: "fakepod"}
+				kubeConverter.ResourcesReturns(&bpmconverter.Resources{
+					Errands: []qjv1a1.QuarksJob{
+						{ObjectMeta: metav1.ObjectMeta{Name: "foo-fakepod", Labels: labels}},
+					},
+					ScheduledErrands: []batchv1b1.CronJob{
+						{
+							ObjectMeta: metav1.ObjectMeta{Name: "foo-fakepod", Labels: labels},
+							Spec:       batchv1b1.CronJobSpec{Schedule: "@daily"},
+						},
+					},
+				}, nil)
+
+				client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+					switch object := object.(type) {
+					case *corev1.Secret:
+						if nn.Name == bpmInformation.Name {
+							bpmInformation.DeepCopyInto(object)
+						}
+					case *batchv1b1.CronJob:
+						return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+					}
+					return nil
+				})
+				cronJobs := []*batchv1b1.CronJob{}
+				client.CreateCalls(func(context context.Context, object runtime.Object, _ ...crc.CreateOption) error {
+					if cronJob, ok := object.(*batchv1b1.CronJob); ok {
+						cronJobs = append(cronJobs, cronJob)
+					}
+					return nil
+				})
+
+				_, err := reconciler.Reconcile(request)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cronJobs).To(HaveLen(1))
+				Expect(cronJobs[0].Spec.Schedule).To(Equal("@daily"))
+				Expect(cronJobs[0].OwnerReferences).To(HaveLen(1))
+				Expect(client.DeleteCallCount()).To(Equal(0))
+			})
+
+			Context("when an errand is no longer scheduled", func() {
+				var cronJobExists bool
+
+				BeforeEach(func() {
+					cronJobExists = true
+					kubeConverter.ResourcesReturns(&bpmconverter.Resources{
+						Errands: []qjv1a1.QuarksJob{
+							{ObjectMeta: metav1.ObjectMeta{Name: "foo-fakepod", Namespace: "default", Labels: map[string]string{bdv1.LabelInstanceGroupName: "fakepod"}}},
+						},
+					}, nil)
+
+					get := client.GetStub
+					client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+						switch object := object.(type) {
+						case *bdv1.BOSHDeployment:
+							object.Name = nn.Name
+							object.Namespace = nn.Namespace
+							return nil
+						case *batchv1b1.CronJob:
+							if !cronJobExists {
+								return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+							}
+							object.Labels = map[string]string{
+								bdv1.LabelDeploymentName:    "foo",
+								bdv1.LabelInstanceGroupName: "fakepod",
+							}
+							return nil
+						}
+						if get != nil {
+							return get(context, nn, object)
+						}
+						return nil
+					})
+				})
+
+				It("deletes the cron job of the errand", func() {
+					_, err := reconciler.Reconcile(request)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(client.DeleteCallCount()).To(Equal(1))
+					_, object, _ := client.DeleteArgsForCall(0)
+					Expect(object).To(BeAssignableToTypeOf(&batchv1b1.CronJob{}))
+					Expect(object.(*batchv1b1.CronJob).Name).To(Equal("foo-fakepod"))
+				})
+
+				It("doesn't delete anything, if the errand has no cron job", func() {
+					cronJobExists = false
+
+					_, err := reconciler.Reconcile(request)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(client.DeleteCallCount()).To(Equal(0))
+				})
+			})
+
+			It("applies the disruption budget of an instance group", func() {
+				labels := map[string]string{bdv1.LabelInstanceGroupName: "fakepod"}
+				maxUnavailable := intstr.FromInt(1)
+				kubeConverter.ResourcesReturns(&bpmconverter.Resources{
+					InstanceGroups: []qstsv1a1.QuarksStatefulSet{
+						{ObjectMeta: metav1.ObjectMeta{Name: "fakepod", Labels: labels}},
+					},
+					DisruptionBudgets: []policyv1beta1.PodDisruptionBudget{
+						{
+							ObjectMeta: metav1.ObjectMeta{Name: "fakepod", Labels: labels},
+							Spec:       policyv1beta1.PodDisruptionBudgetSpec{MaxUnavailable: &maxUnavailable},
+						},
+					},
+				}, nil)
+
+				client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+					switch object := object.(type) {
+					case *corev1.Secret:
+						if nn.Name == bpmInformation.Name {
+							bpmInformation.DeepCopyInto(object)
+						}
+					case *policyv1beta1.PodDisruptionBudget:
+						return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+					}
+					return nil
+				})
+				budgets := []*policyv1beta1.PodDisruptionBudget{}
+				client.CreateCalls(func(context context.Context, object runtime.Object, _ ...crc.CreateOption) error {
+					if pdb, ok := object.(*policyv1beta1.PodDisruptionBudget); ok {
+						budgets = append(budgets, pdb)
+					}
+					return nil
+				})
+
+				_, err := reconciler.Reconcile(request)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(budgets).To(HaveLen(1))
+				Expect(budgets[0].Spec.MaxUnavailable).To(Equal(&maxUnavailable))
+				Expect(budgets[0].OwnerReferences).To(HaveLen(1))
+				Expect(client.DeleteCallCount()).To(Equal(0))
+			})
+
+			It("deletes the disruption budget of an instance group, which no longer needs one", func() {
+				kubeConverter.ResourcesReturns(&bpmconverter.Resources{
+					InstanceGroups: []qstsv1a1.QuarksStatefulSet{
+						{ObjectMeta: metav1.ObjectMeta{Name: "fakepod", Labels: map[string]string{bdv1.LabelInstanceGroupName: "fakepod"}}},
+					},
+				}, nil)
+				client.DeleteReturns(apierrors.NewNotFound(schema.GroupResource{}, "fakepod"))
+
+				_, err := reconciler.Reconcile(request)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(client.DeleteCallCount()).To(Equal(1))
+				_, object, _ := client.DeleteArgsForCall(0)
+				Expect(object).To(BeAssignableToTypeOf(&policyv1beta1.PodDisruptionBudget{}))
+				Expect(object.(*policyv1beta1.PodDisruptionBudget).Name).To(Equal("fakepod"))
+			})
+
+			It("applies the ingress of an exposed instance group", func() {
+				labels := map[string]string{bdv1.LabelInstanceGroupName: "fakepod"}
+				kubeConverter.ResourcesReturns(&bpmconverter.Resources{
+					InstanceGroups: []qstsv1a1.QuarksStatefulSet{
+						{ObjectMeta: metav1.ObjectMeta{Name: "fakepod", Labels: labels}},
+					},
+					Ingresses: []networkingv1beta1.Ingress{
+						{
+							ObjectMeta: metav1.ObjectMeta{Name: "foo-fakepod-exposed", Labels: labels},
+							Spec:       networkingv1beta1.IngressSpec{Rules: []networkingv1beta1.IngressRule{{Host: "fakepod.example.com"}}},
+						},
+					},
+				}, nil)
+
+				client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+					switch object := object.(type) {
+					case *corev1.Secret:
+						if nn.Name == bpmInformation.Name {
+							bpmInformation.DeepCopyInto(object)
+						}
+					case *networkingv1beta1.Ingress:
+						return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+					}
+					return nil
+				})
+				ingresses := []*networkingv1beta1.Ingress{}
+				client.CreateCalls(func(context context.Context, object runtime.Object, _ ...crc.CreateOption) error {
+					if ingress, ok := object.(*networkingv1beta1.Ingress); ok {
+						ingresses = append(ingresses, ingress)
+					}
+					return nil
+				})
+
+				_, err := reconciler.Reconcile(request)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ingresses).To(HaveLen(1))
+				Expect(ingresses[0].Spec.Rules[0].Host).To(Equal("fakepod.example.com"))
+				Expect(ingresses[0].OwnerReferences).To(HaveLen(1))
+			})
+
+			It("deletes the service and ingress of an instance group, which is no longer exposed", func() {
+				labels := map[string]string{bdv1.LabelInstanceGroupName: "fakepod"}
+				kubeConverter.ResourcesReturns(&bpmconverter.Resources{
+					InstanceGroups: []qstsv1a1.QuarksStatefulSet{
+						{ObjectMeta: metav1.ObjectMeta{Name: "fakepod", Namespace: "default", Labels: labels}},
+					},
+					Services: []corev1.Service{
+						{ObjectMeta: metav1.ObjectMeta{Name: "foo-fakepod", Namespace: "default", Labels: labels}},
+					},
+				}, nil)
+				client.ListCalls(func(context context.Context, object runtime.Object, _ ...crc.ListOption) error {
+					switch object := object.(type) {
+					case *corev1.SecretList:
+						list := &corev1.SecretList{Items: []corev1.Secret{*manifestWithVars, *bpmInformation}}
+						list.DeepCopyInto(object)
+					case *corev1.ServiceList:
+						object.Items = []corev1.Service{
+							{ObjectMeta: metav1.ObjectMeta{Name: "foo-fakepod", Labels: labels}},
+							{ObjectMeta: metav1.ObjectMeta{Name: "foo-fakepod-custom", Labels: labels}},
+						}
+					}
+					return nil
+				})
+				get := client.GetStub
+				client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+					exposedLabels := map[string]string{
+						bdv1.LabelDeploymentName:    "foo",
+						bdv1.LabelInstanceGroupName: "fakepod",
+					}
+					switch object := object.(type) {
+					case *bdv1.BOSHDeployment:
+						object.Name = nn.Name
+						object.Namespace = nn.Namespace
+						return nil
+					case *corev1.Service:
+						object.Labels = exposedLabels
+						return nil
+					case *networkingv1beta1.Ingress:
+						object.Labels = exposedLabels
+						return nil
+					}
+					if get != nil {
+						return get(context, nn, object)
+					}
+					return nil
+				})
+
+				_, err := reconciler.Reconcile(request)
+				Expect(err).NotTo(HaveOccurred())
+
+				deleted := []string{}
+				for i := 0; i < client.DeleteCallCount(); i++ {
+					_, object, _ := client.DeleteArgsForCall(i)
+					switch object := object.(type) {
+					case *corev1.Service:
+						deleted = append(deleted, "service/"+object.Name)
+					case *networkingv1beta1.Ingress:
+						deleted = append(deleted, "ingress/"+object.Name)
+					}
+				}
+				Expect(deleted).To(Equal([]string{"service/foo-fakepod-exposed", "ingress/foo-fakepod-exposed"}))
+			})
+
+			Context("when the instance group is autoscaled", func() {
+				var updatedQSts []*qstsv1a1.QuarksStatefulSet
+
+				BeforeEach(func() {
+					labels := map[string]string{bdv1.LabelInstanceGroupName: "fakepod"}
+					kubeConverter.ResourcesReturns(&bpmconverter.Resources{
+						InstanceGroups: []qstsv1a1.QuarksStatefulSet{
+							{
+								ObjectMeta: metav1.ObjectMeta{Name: "fakepod", Namespace: "default", Labels: labels},
+								Spec: qstsv1a1.QuarksStatefulSetSpec{
+									Template: appsv1.StatefulSet{Spec: appsv1.StatefulSetSpec{Replicas: pointers.Int32(2)}},
+								},
+							},
+						},
+						Autoscalers: []autoscalingv2beta2.HorizontalPodAutoscaler{
+							{
+								ObjectMeta: metav1.ObjectMeta{Name: "fakepod", Namespace: "default", Labels: labels},
+								Spec:       autoscalingv2beta2.HorizontalPodAutoscalerSpec{MinReplicas: pointers.Int32(2), MaxReplicas: 10},
+							},
+						},
+					}, nil)
+
+					client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+						switch object := object.(type) {
+						case *corev1.Secret:
+							if nn.Name == bpmInformation.Name {
+								bpmInformation.DeepCopyInto(object)
+							}
+						case *bdv1.BOSHDeployment:
+							object.Name = nn.Name
+							object.Namespace = nn.Namespace
+						case *qstsv1a1.QuarksStatefulSet:
+							*object = qstsv1a1.QuarksStatefulSet{}
+							object.Name = nn.Name
+							object.Namespace = nn.Namespace
+							object.UID = "qsts-uid"
+						case *autoscalingv2beta2.HorizontalPodAutoscaler:
+							return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+						}
+						return nil
+					})
+					client.ListCalls(func(context context.Context, object runtime.Object, _ ...crc.ListOption) error {
+						switch object := object.(type) {
+						case *corev1.SecretList:
+							list := &corev1.SecretList{Items: []corev1.Secret{*manifestWithVars, *bpmInformation}}
+							list.DeepCopyInto(object)
+						case *appsv1.StatefulSetList:
+							object.Items = []appsv1.StatefulSet{
+								{
+									ObjectMeta: metav1.ObjectMeta{
+										Name:            "fakepod",
+										Namespace:       "default",
+										Annotations:     map[string]string{qstsv1a1.AnnotationVersion: "1"},
+										OwnerReferences: []metav1.OwnerReference{{UID: "qsts-uid", Controller: pointers.Bool(true)}},
+									},
+									Spec: appsv1.StatefulSetSpec{Replicas: pointers.Int32(5)},
+								},
+							}
+						case *autoscalingv2beta2.HorizontalPodAutoscalerList:
+							object.Items = []autoscalingv2beta2.HorizontalPodAutoscaler{
+								{ObjectMeta: metav1.ObjectMeta{Name: "fakepod"}},
+								{ObjectMeta: metav1.ObjectMeta{Name: "fakepod-z1"}},
+							}
+						}
+						return nil
+					})
+
+					updatedQSts = []*qstsv1a1.QuarksStatefulSet{}
+					client.UpdateCalls(func(context context.Context, object runtime.Object, _ ...crc.UpdateOption) error {
+						if qSts, ok := object.(*qstsv1a1.QuarksStatefulSet); ok {
+							updatedQSts = append(updatedQSts, qSts)
+						}
+						return nil
+					})
+				})
+
+				It("applies the autoscalers and keeps the replicas they set", func() {
+					_, err := reconciler.Reconcile(request)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(client.CreateCallCount()).To(Equal(1))
+					_, object, _ := client.CreateArgsForCall(0)
+					Expect(object).To(BeAssignableToTypeOf(&autoscalingv2beta2.HorizontalPodAutoscaler{}))
+
+					Expect(updatedQSts).To(HaveLen(1))
+					Expect(*updatedQSts[0].Spec.Template.Spec.Replicas).To(Equal(int32(5)))
+				})
+
+				It("deletes the autoscalers, which are no longer needed", func() {
+					_, err := reconciler.Reconcile(request)
+					Expect(err).NotTo(HaveOccurred())
+
+					deleted := []string{}
+					for i := 0; i < client.DeleteCallCount(); i++ {
+						_, object, _ := client.DeleteArgsForCall(i)
+						if hpa, ok := object.(*autoscalingv2beta2.HorizontalPodAutoscaler); ok {
+							deleted = append(deleted, hpa.Name)
+						}
+					}
+					Expect(deleted).To(Equal([]string{"fakepod-z1"}))
+				})
+			})
+
+			Context("when the persistent disk of an instance group grew", func() {
+				var (
+					allowExpansion bool
+					claimSize      string
+					claimLabels    map[string]string
+					updatedPVCs    []*corev1.PersistentVolumeClaim
+				)
+
+				BeforeEach(func() {
+					allowExpansion = true
+					claimSize = "1024Mi"
+					updatedPVCs = []*corev1.PersistentVolumeClaim{}
+					qStsLabels := map[string]string{bdv1.LabelDeploymentName: "foo", bdv1.LabelInstanceGroupName: "fakepod"}
+					claimLabels = map[string]string{qstsv1a1.LabelQStsName: "fakepod", bdv1.LabelDeploymentName: "foo", bdv1.LabelInstanceGroupName: "fakepod"}
+					storageClassName := "expandable"
+
+					kubeConverter.ResourcesReturns(&bpmconverter.Resources{
+						InstanceGroups: []qstsv1a1.QuarksStatefulSet{
+							{
+								ObjectMeta: metav1.ObjectMeta{Name: "fakepod", Namespace: "default", Labels: qStsLabels},
+								Spec: qstsv1a1.QuarksStatefulSetSpec{
+									Template: appsv1.StatefulSet{
+										Spec: appsv1.StatefulSetSpec{
+											VolumeClaimTemplates: []corev1.PersistentVolumeClaim{
+												{
+													ObjectMeta: metav1.ObjectMeta{Name: "fakepod-pvc"},
+													Spec: corev1.PersistentVolumeClaimSpec{
+														Resources: corev1.ResourceRequirements{
+															Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("2048Mi")},
+														},
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					}, nil)
+
+					client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+						switch object := object.(type) {
+						case *corev1.Secret:
+							if nn.Name == bpmInformation.Name {
+								bpmInformation.DeepCopyInto(object)
+							}
+						case *storagev1.StorageClass:
+							object.Name = nn.Name
+							object.AllowVolumeExpansion = &allowExpansion
+						case *bdv1.BOSHDeployment:
+							object.Name = nn.Name
+							object.Namespace = nn.Namespace
+						}
+						return nil
+					})
+					client.ListCalls(func(context context.Context, object runtime.Object, opts ...crc.ListOption) error {
+						switch object := object.(type) {
+						case *corev1.PersistentVolumeClaimList:
+							options := crc.ListOptions{}
+							options.ApplyOptions(opts)
+							if options.LabelSelector != nil && !options.LabelSelector.Matches(labels.Set(claimLabels)) {
+								return nil
+							}
+							list := &corev1.PersistentVolumeClaimList{Items: []corev1.PersistentVolumeClaim{
+								{
+									ObjectMeta: metav1.ObjectMeta{Name: "fakepod-pvc-fakepod-0", Namespace: "default", Labels: claimLabels},
+									Spec: corev1.PersistentVolumeClaimSpec{
+										StorageClassName: &storageClassName,
+										Resources: corev1.ResourceRequirements{
+											Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(claimSize)},
+										},
+									},
+								},
+							}}
+							list.DeepCopyInto(object)
+						}
+						return nil
+					})
+					client.UpdateCalls(func(context context.Context, object runtime.Object, _ ...crc.UpdateOption) error {
+						if pvc, ok := object.(*corev1.PersistentVolumeClaim); ok {
+							updatedPVCs = append(updatedPVCs, pvc)
+							claimLabels = pvc.Labels
+						}
+						return nil
+					})
+				})
+
+				It("expands the persistent volume claims", func() {
+					_, err := reconciler.Reconcile(request)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(updatedPVCs).To(HaveLen(1))
+					Expect(updatedPVCs[0].Name).To(Equal("fakepod-pvc-fakepod-0"))
+					size := updatedPVCs[0].Spec.Resources.Requests[corev1.ResourceStorage]
+					Expect(size.String()).To(Equal("2Gi"))
+				})
+
+				It("labels and expands the claims of stateful sets, which were created before the claim templates were labeled", func() {
+					claimLabels = map[string]string{qstsv1a1.LabelQStsName: "fakepod"}
+
+					_, err := reconciler.Reconcile(request)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(updatedPVCs).To(HaveLen(2))
+					Expect(updatedPVCs[0].Labels).To(HaveKeyWithValue(bdv1.LabelDeploymentName, "foo"))
+					Expect(updatedPVCs[0].Labels).To(HaveKeyWithValue(bdv1.LabelInstanceGroupName, "fakepod"))
+					size := updatedPVCs[1].Spec.Resources.Requests[corev1.ResourceStorage]
+					Expect(size.String()).To(Equal("2Gi"))
+				})
+
+				It("doesn't expand the claims, if the storage class doesn't allow it", func() {
+					allowExpansion = false
+
+					_, err := reconciler.Reconcile(request)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(updatedPVCs).To(BeEmpty())
+					Expect(logs.FilterMessageSnippet("storage class doesn't allow volume expansion").Len()).To(Equal(1))
+				})
+
+				It("doesn't update the instance group, if the interpolated manifest shrinks a disk", func() {
+					claimSize = "4096Mi"
+
+					_, err := reconciler.Reconcile(request)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("persistent volume claim 'default/fakepod-pvc-fakepod-0' can't be shrunk from 4Gi to 2Gi"))
+					Expect(updatedPVCs).To(BeEmpty())
+				})
+			})
+
+			Context("when the instance group is migrated from another instance group", func() {
+				var (
+					created []runtime.Object
+					updated []runtime.Object
+					deleted []runtime.Object
+					oldPods []corev1.Pod
+					// the stateful set only labeled the claim with its selector
+					oldClaim *corev1.PersistentVolumeClaim
+				)
+
+				BeforeEach(func() {
+					created = []runtime.Object{}
+					updated = []runtime.Object{}
+					deleted = []runtime.Object{}
+					oldPods = []corev1.Pod{}
+					oldClaim = &corev1.PersistentVolumeClaim{
+						ObjectMeta: metav1.ObjectMeta{Name: "oldpod-pvc-oldpod-0", Namespace: "default", Labels: map[string]string{qstsv1a1.LabelQStsName: "oldpod"}},
+						Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "pv-1"},
+					}
+					manifest.InstanceGroups[0].MigratedFrom = []*bdm.MigratedFrom{{Name: "oldpod"}}
+					oldLabels := map[string]string{bdv1.LabelDeploymentName: "foo", bdv1.LabelInstanceGroupName: "oldpod"}
+					kubeConverter.ResourcesReturns(&bpmconverter.Resources{
+						InstanceGroups: []qstsv1a1.QuarksStatefulSet{
+							{ObjectMeta: metav1.ObjectMeta{Name: "fakepod", Namespace: "default", Labels: map[string]string{bdv1.LabelInstanceGroupName: "fakepod"}}},
+						},
+					}, nil)
+
+					client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+						switch object := object.(type) {
+						case *corev1.Secret:
+							if nn.Name == bpmInformation.Name {
+								bpmInformation.DeepCopyInto(object)
+							}
+						case *corev1.PersistentVolumeClaim, *qstsv1a1.QuarksStatefulSet:
+							return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+						case *corev1.PersistentVolume:
+							object.Name = nn.Name
+						case *bdv1.BOSHDeployment:
+							object.Name = nn.Name
+							object.Namespace = nn.Namespace
+						}
+						return nil
+					})
+					client.ListCalls(func(context context.Context, object runtime.Object, opts ...crc.ListOption) error {
+						if secrets, ok := object.(*corev1.SecretList); ok {
+							list := &corev1.SecretList{Items: []corev1.Secret{*manifestWithVars, *bpmInformation}}
+							list.DeepCopyInto(secrets)
+							return nil
+						}
+						options := crc.ListOptions{}
+						options.ApplyOptions(opts)
+						if options.LabelSelector == nil {
+							return nil
+						}
+						if list, ok := object.(*corev1.PersistentVolumeClaimList); ok {
+							if options.LabelSelector.Matches(labels.Set(oldClaim.Labels)) {
+								list.Items = []corev1.PersistentVolumeClaim{*oldClaim.DeepCopy()}
+							}
+							return nil
+						}
+						if !options.LabelSelector.Matches(labels.Set(oldLabels)) {
+							return nil
+						}
+						switch object := object.(type) {
+						case *qstsv1a1.QuarksStatefulSetList:
+							list := &qstsv1a1.QuarksStatefulSetList{Items: []qstsv1a1.QuarksStatefulSet{
+								{ObjectMeta: metav1.ObjectMeta{Name: "oldpod", Namespace: "default", Labels: oldLabels}},
+							}}
+							list.DeepCopyInto(object)
+						case *corev1.PodList:
+							list := &corev1.PodList{Items: oldPods}
+							list.DeepCopyInto(object)
+						}
+						return nil
+					})
+					client.CreateCalls(func(context context.Context, object runtime.Object, _ ...crc.CreateOption) error {
+						created = append(created, object)
+						return nil
+					})
+					client.UpdateCalls(func(context context.Context, object runtime.Object, _ ...crc.UpdateOption) error {
+						updated = append(updated, object)
+						if pvc, ok := object.(*corev1.PersistentVolumeClaim); ok && pvc.Name == oldClaim.Name {
+							pvc.DeepCopyInto(oldClaim)
+						}
+						return nil
+					})
+					client.DeleteCalls(func(context context.Context, object runtime.Object, _ ...crc.DeleteOption) error {
+						switch object.(type) {
+						case *qstsv1a1.QuarksStatefulSet, *corev1.PersistentVolumeClaim:
+							deleted = append(deleted, object)
+						}
+						return nil
+					})
+				})
+
+				It("deletes the old instance group, before it moves the persistent disks", func() {
+					_, err := reconciler.Reconcile(request)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(deleted).To(HaveLen(2))
+					Expect(deleted[0].(*qstsv1a1.QuarksStatefulSet).Name).To(Equal("oldpod"))
+					Expect(deleted[1].(*corev1.PersistentVolumeClaim).Name).To(Equal("oldpod-pvc-oldpod-0"))
+
+					Expect(created).ToNot(BeEmpty())
+					pvc, ok := created[0].(*corev1.PersistentVolumeClaim)
+					Expect(ok).To(BeTrue())
+					Expect(pvc.Name).To(Equal("fakepod-pvc-fakepod-0"))
+					Expect(pvc.Spec.VolumeName).To(Equal("pv-1"))
+					Expect(pvc.Labels[bdv1.LabelInstanceGroupName]).To(Equal("fakepod"))
+
+					Expect(updated[0].(*corev1.PersistentVolumeClaim).Labels).To(HaveKeyWithValue(bdv1.LabelInstanceGroupName, "oldpod"))
+					Expect(updated[1].(*corev1.PersistentVolumeClaim).Labels).To(HaveKeyWithValue(bdv1.LabelOrphanedDisk, "true"))
+					pv, ok := updated[2].(*corev1.PersistentVolume)
+					Expect(ok).To(BeTrue())
+					Expect(pv.Spec.ClaimRef.Name).To(Equal("fakepod-pvc-fakepod-0"))
+					Expect(created).To(ContainElement(BeAssignableToTypeOf(&qstsv1a1.QuarksStatefulSet{})))
+				})
+
+				Context("when pods of the old instance group still exist", func() {
+					BeforeEach(func() {
+						oldPods = []corev1.Pod{{ObjectMeta: metav1.ObjectMeta{Name: "oldpod-0", Namespace: "default"}}}
+					})
+
+					It("waits for the pods, before it moves the disks and deploys the instance group", func() {
+						result, err := reconciler.Reconcile(request)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+						Expect(deleted).To(HaveLen(1))
+						Expect(deleted[0].(*qstsv1a1.QuarksStatefulSet).Name).To(Equal("oldpod"))
+						Expect(created).To(BeEmpty())
+						for _, object := range updated {
+							Expect(object).ToNot(BeAssignableToTypeOf(&corev1.PersistentVolume{}))
+							Expect(object).ToNot(BeAssignableToTypeOf(&qstsv1a1.QuarksStatefulSet{}))
+						}
+					})
+				})
+
+				It("doesn't migrate instance groups, which are still part of the manifest", func() {
+					manifest.InstanceGroups = append(manifest.InstanceGroups, &bdm.InstanceGroup{Name: "oldpod"})
+
+					_, err := reconciler.Reconcile(request)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(deleted).To(BeEmpty())
+				})
 			})
 
 			It("creates instance groups and updates bpm configs created state to deploying state successfully", func() {
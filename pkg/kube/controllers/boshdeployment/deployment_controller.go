@@ -9,6 +9,7 @@ import (
 	"github.com/pkg/errors"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -23,6 +24,7 @@ import (
 	"code.cloudfoundry.org/quarks-operator/pkg/bosh/converter"
 	"code.cloudfoundry.org/quarks-operator/pkg/bosh/qjobs"
 	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/metrics"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/reference"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/withops"
 	"code.cloudfoundry.org/quarks-utils/pkg/config"
@@ -41,6 +43,7 @@ func AddDeployment(ctx context.Context, config *config.Config, mgr manager.Manag
 		withops.NewResolver(
 			mgr.GetClient(),
 			func() withops.Interpolator { return withops.NewInterpolator() },
+			config.OperatorNamespace,
 		),
 		qjobs.NewJobFactory(),
 		converter.NewVariablesConverter(),
@@ -49,7 +52,7 @@ func AddDeployment(ctx context.Context, config *config.Config, mgr manager.Manag
 
 	// Create a new controller
 	c, err := controller.New("boshdeployment-controller", mgr, controller.Options{
-		Reconciler:              r,
+		Reconciler:              metrics.InstrumentReconciler(metrics.ControllerDeployment, r),
 		MaxConcurrentReconciles: config.MaxBoshDeploymentWorkers,
 	})
 	if err != nil {
@@ -169,6 +172,72 @@ func AddDeployment(ctx context.Context, config *config.Config, mgr manager.Manag
 
 	}
 
+	// Watch RuntimeConfigs, which add releases and addons to the BOSHDeployments
+	// of their namespace, or of all monitored namespaces if they live in the
+	// operator namespace
+	inScope := func(namespace string) bool {
+		return namespace == config.OperatorNamespace || nsPred.Create(event.CreateEvent{Meta: &metav1.ObjectMeta{Namespace: namespace}})
+	}
+	p = predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return inScope(e.Meta.GetNamespace()) },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return inScope(e.Meta.GetNamespace()) },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			o := e.ObjectOld.(*bdv1.RuntimeConfig)
+			n := e.ObjectNew.(*bdv1.RuntimeConfig)
+
+			return !reflect.DeepEqual(o.Spec, n.Spec) && inScope(n.Namespace)
+		},
+	}
+	err = c.Watch(&source.Kind{Type: &bdv1.RuntimeConfig{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+			reconciles, err := reconcilesForConfig(ctx, mgr.GetClient(), config, a.Meta.GetNamespace())
+			if err != nil {
+				ctxlog.Errorf(ctx, "Failed to calculate reconciles for runtime config '%s/%s': %v", a.Meta.GetNamespace(), a.Meta.GetName(), err)
+			}
+
+			for _, reconciliation := range reconciles {
+				ctxlog.NewMappingEvent(a.Object).Debug(ctx, reconciliation, "BOSHDeployment", a.Meta.GetName(), bdv1.RuntimeConfigResourceKind)
+			}
+
+			return reconciles
+		}),
+	}, p)
+	if err != nil {
+		return errors.Wrapf(err, "Watching runtime configs failed in bosh deployment controller.")
+	}
+
+	// Watch CloudConfigs, which define the vm types, vm extensions, disk types
+	// and availability zones of the BOSHDeployments in the same scope
+	p = predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return inScope(e.Meta.GetNamespace()) },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return inScope(e.Meta.GetNamespace()) },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			o := e.ObjectOld.(*bdv1.CloudConfig)
+			n := e.ObjectNew.(*bdv1.CloudConfig)
+
+			return !reflect.DeepEqual(o.Spec, n.Spec) && inScope(n.Namespace)
+		},
+	}
+	err = c.Watch(&source.Kind{Type: &bdv1.CloudConfig{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+			reconciles, err := reconcilesForConfig(ctx, mgr.GetClient(), config, a.Meta.GetNamespace())
+			if err != nil {
+				ctxlog.Errorf(ctx, "Failed to calculate reconciles for cloud config '%s/%s': %v", a.Meta.GetNamespace(), a.Meta.GetName(), err)
+			}
+
+			for _, reconciliation := range reconciles {
+				ctxlog.NewMappingEvent(a.Object).Debug(ctx, reconciliation, "BOSHDeployment", a.Meta.GetName(), bdv1.CloudConfigResourceKind)
+			}
+
+			return reconciles
+		}),
+	}, p)
+	if err != nil {
+		return errors.Wrapf(err, "Watching cloud configs failed in bosh deployment controller.")
+	}
+
 	// Watch Services that route (select) pods that are external link providers
 	p = predicate.Funcs{
 		CreateFunc: func(e event.CreateEvent) bool {
@@ -270,3 +339,35 @@ func getEndpointsService(ctx context.Context, client client.Client, ep corev1.En
 	err := client.Get(ctx, id, svc)
 	return svc, err
 }
+
+// reconcilesForConfig returns reconcile requests for all BOSHDeployments
+// affected by a runtime or cloud config in namespace
+func reconcilesForConfig(ctx context.Context, c client.Client, config *config.Config, namespace string) ([]reconcile.Request, error) {
+	namespaces := []string{namespace}
+	if namespace == config.OperatorNamespace {
+		nsList := &corev1.NamespaceList{}
+		err := c.List(ctx, nsList, client.MatchingLabels{monitorednamespace.LabelNamespace: config.MonitoredID})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list monitored namespaces")
+		}
+		namespaces = make([]string, 0, len(nsList.Items))
+		for _, ns := range nsList.Items {
+			namespaces = append(namespaces, ns.Name)
+		}
+	}
+
+	reconciles := []reconcile.Request{}
+	for _, ns := range namespaces {
+		bdpls := &bdv1.BOSHDeploymentList{}
+		if err := c.List(ctx, bdpls, client.InNamespace(ns)); err != nil {
+			return reconciles, errors.Wrapf(err, "failed to list BOSHDeployments in '%s'", ns)
+		}
+		for _, bdpl := range bdpls.Items {
+			reconciles = append(reconciles, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: bdpl.Namespace, Name: bdpl.Name},
+			})
+		}
+	}
+
+	return reconciles, nil
+}
@@ -7,9 +7,13 @@ import (
 	"github.com/pkg/errors"
 
 	corev1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -19,7 +23,10 @@ import (
 	"code.cloudfoundry.org/quarks-operator/pkg/bosh/converter"
 	bdm "code.cloudfoundry.org/quarks-operator/pkg/bosh/manifest"
 	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/metrics"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/mutate"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/names"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/orphaneddisk"
 	qsv1a1 "code.cloudfoundry.org/quarks-secret/pkg/kube/apis/quarkssecret/v1alpha1"
 	mutateqs "code.cloudfoundry.org/quarks-secret/pkg/kube/util/mutate"
 	qstsv1a1 "code.cloudfoundry.org/quarks-statefulset/pkg/kube/apis/quarksstatefulset/v1alpha1"
@@ -29,8 +36,12 @@ import (
 	"code.cloudfoundry.org/quarks-utils/pkg/meltdown"
 )
 
-// BDPLStateCreating is the Bosh Deployment Status spec Creating State
-const BDPLStateCreating = "Creating/Updating"
+const (
+	// BDPLStateCreating is the Bosh Deployment Status spec Creating State
+	BDPLStateCreating = "Creating/Updating"
+	// BDPLStateResolveFailed is the Bosh Deployment Status spec State, if the manifest or ops can't be resolved
+	BDPLStateResolveFailed = "Failed to resolve manifest"
+)
 
 // JobFactory creates Jobs for a given manifest
 type JobFactory interface {
@@ -39,7 +50,7 @@ type JobFactory interface {
 
 // VariablesConverter converts BOSH variables into QuarksSecrets
 type VariablesConverter interface {
-	Variables(namespace string, manifestName string, variables []bdm.Variable) ([]qsv1a1.QuarksSecret, error)
+	Variables(namespace string, manifestName string, variables []bdm.Variable, tags bdm.Tags) ([]qsv1a1.QuarksSecret, error)
 }
 
 // WithOps interpolates BOSH manifests and operations files to create the WithOps manifest
@@ -106,6 +117,24 @@ func (r *ReconcileBOSHDeployment) Reconcile(request reconcile.Request) (reconcil
 			log.WithEvent(bdpl, "GetBOSHDeploymentError").Errorf(ctx, "failed to get BOSHDeployment '%s': %v", request.NamespacedName, err)
 	}
 
+	if bdpl.Spec.Paused {
+		log.Infof(ctx, "Skip reconcile: BOSHDeployment '%s' is paused", request.NamespacedName)
+		if bdpl.Status.SetCondition(bdv1.Paused, corev1.ConditionTrue, "Paused", "changes are applied when the deployment is resumed") {
+			r.updateStatus(ctx, bdpl)
+		}
+		return reconcile.Result{}, nil
+	}
+	if bdpl.Status.IsConditionTrue(bdv1.Paused) {
+		// the resumed generation is recorded after the with-ops manifest
+		// secret was written, see below
+		bdpl.Status.SetCondition(bdv1.Paused, corev1.ConditionFalse, "Resuming", "")
+		err = r.client.Status().Update(ctx, bdpl)
+		if err != nil {
+			return reconcile.Result{},
+				log.WithEvent(bdpl, "UpdateError").Errorf(ctx, "failed to update paused condition on bdpl '%s' (%v): %s", request.NamespacedName, bdpl.ResourceVersion, err)
+		}
+	}
+
 	if bdpl.Status.LastReconcile == nil {
 		now := metav1.Now()
 		bdpl.Status.LastReconcile = &now
@@ -121,6 +150,7 @@ func (r *ReconcileBOSHDeployment) Reconcile(request reconcile.Request) (reconcil
 
 	if meltdown.NewWindow(ReconcileSkipDuration, bdpl.Status.LastReconcile).Contains(time.Now()) {
 		log.Infof(ctx, "Meltdown in progress for '%s'", request.NamespacedName)
+		metrics.MeltdownSkipped(metrics.ControllerDeployment)
 		return reconcile.Result{}, nil
 	}
 
@@ -131,6 +161,8 @@ func (r *ReconcileBOSHDeployment) Reconcile(request reconcile.Request) (reconcil
 	now := metav1.Now()
 	bdpl.Status.StateTimestamp = &now
 	bdpl.Status.State = BDPLStateCreating
+	bdpl.Status.Message = ""
+	bdpl.Status.ObservedGeneration = bdpl.Generation
 
 	err = r.client.Status().Update(ctx, bdpl)
 	if err != nil {
@@ -140,9 +172,18 @@ func (r *ReconcileBOSHDeployment) Reconcile(request reconcile.Request) (reconcil
 
 	manifest, err := r.resolveManifest(ctx, bdpl)
 	if err != nil {
+		now := metav1.Now()
+		bdpl.Status.StateTimestamp = &now
+		bdpl.Status.State = BDPLStateResolveFailed
+		bdpl.Status.Message = err.Error()
+		bdpl.Status.SetCondition(bdv1.ManifestResolved, corev1.ConditionFalse, "ResolveFailed", err.Error())
+		if statusErr := r.client.Status().Update(ctx, bdpl); statusErr != nil {
+			log.WithEvent(bdpl, "UpdateError").Errorf(ctx, "failed to update state on bdpl '%s' (%v): %s", request.NamespacedName, bdpl.ResourceVersion, statusErr)
+		}
 		return reconcile.Result{},
 			log.WithEvent(bdpl, "WithOpsManifestError").Errorf(ctx, "failed to get with-ops manifest for BOSHDeployment '%s': %v", request.NamespacedName, err)
 	}
+	bdpl.Status.SetCondition(bdv1.ManifestResolved, corev1.ConditionTrue, "Resolved", "")
 
 	// Find the required native-to-bosh links, add the properties to the manifest and error if links are missing
 	l := linkInfoService{
@@ -163,10 +204,18 @@ func (r *ReconcileBOSHDeployment) Reconcile(request reconcile.Request) (reconcil
 			log.WithEvent(bdpl, "DeleteQuarksStatefulSet").Error(ctx, "failed to delete orphan QuarksStatefulSets", err)
 	}
 
+	// keep the variables of deployments created before names were prefixed with the deployment name
+	err = r.migrateLegacyNames(ctx, bdpl, manifest)
+	if err != nil {
+		return reconcile.Result{},
+			log.WithEvent(bdpl, "MigrateLegacyNamesError").Errorf(ctx, "failed to migrate resources of BOSHDeployment '%s' to names prefixed with the deployment name: %v", request.NamespacedName, err)
+	}
+
 	// Create all QuarksSecret variables
 	log.Debug(ctx, "Converting BOSH manifest variables to QuarksSecret resources")
-	secrets, err := r.converter.Variables(request.Namespace, bdpl.Name, manifest.Variables)
+	secrets, err := r.converter.Variables(request.Namespace, bdpl.Name, manifest.Variables, manifest.Tags)
 	if err != nil {
+		r.setCondition(ctx, bdpl, bdv1.VariablesGenerated, corev1.ConditionFalse, "BadManifest", err.Error())
 		return reconcile.Result{},
 			log.WithEvent(bdpl, "BadManifestError").Error(ctx, errors.Wrap(err, "failed to generate quarks secrets from manifest"))
 
@@ -176,15 +225,18 @@ func (r *ReconcileBOSHDeployment) Reconcile(request reconcile.Request) (reconcil
 	if len(secrets) > 0 {
 		err = r.createQuarksSecrets(ctx, bdpl, secrets)
 		if err != nil {
+			r.setCondition(ctx, bdpl, bdv1.VariablesGenerated, corev1.ConditionFalse, "VariableGenerationFailed", err.Error())
 			return reconcile.Result{},
 				log.WithEvent(bdpl, "VariableGenerationError").Errorf(ctx, "failed to create quarks secrets for BOSH manifest '%s': %v", request.NamespacedName, err)
 		}
 	}
+	bdpl.Status.SetCondition(bdv1.VariablesGenerated, corev1.ConditionTrue, "QuarksSecretsCreated", "")
 
 	// Apply the "Instance group manifest" QuarksJob, which creates instance group manifests (ig-resolved) secrets and BPM config secrets
 	// once the "Variable Interpolation" job created the desired manifest.
 	qJob, err := r.jobFactory.InstanceGroupManifestJob(request.Namespace, bdpl.Name, *manifest, linkInfos, bdpl.ObjectMeta.Generation == 1)
 	if err != nil {
+		r.setCondition(ctx, bdpl, bdv1.InstanceGroupsResolved, corev1.ConditionFalse, "InstanceGroupManifestFailed", err.Error())
 		return reconcile.Result{},
 			log.WithEvent(bdpl, "InstanceGroupManifestError").Errorf(ctx, "failed to build instance group manifest qJob: %v", err)
 	}
@@ -192,6 +244,7 @@ func (r *ReconcileBOSHDeployment) Reconcile(request reconcile.Request) (reconcil
 	log.Debug(ctx, "Creating instance group manifest QuarksJob")
 	err = r.createQuarksJob(ctx, bdpl, qJob)
 	if err != nil {
+		r.setCondition(ctx, bdpl, bdv1.InstanceGroupsResolved, corev1.ConditionFalse, "InstanceGroupManifestFailed", err.Error())
 		return reconcile.Result{},
 			log.WithEvent(bdpl, "InstanceGroupManifestError").Errorf(ctx, "failed to create instance group manifest qJob for BOSHDeployment '%s': %v", request.NamespacedName, err)
 	}
@@ -204,9 +257,39 @@ func (r *ReconcileBOSHDeployment) Reconcile(request reconcile.Request) (reconcil
 			log.WithEvent(bdpl, "WithOpsManifestError").Errorf(ctx, "failed to create with-ops manifest secret for BOSHDeployment '%s': %v", request.NamespacedName, err)
 	}
 
+	if c := bdpl.Status.GetCondition(bdv1.Paused); c != nil && c.Reason == "Resuming" {
+		// the resumed generation triggers the other controllers once, to
+		// apply the changes they skipped while paused. Recording it only
+		// now makes them read the with-ops manifest of this generation.
+		bdpl.Status.SetCondition(bdv1.Paused, corev1.ConditionFalse, "Resumed", "")
+		bdpl.Status.ResumedGeneration = bdpl.Generation
+		err = r.client.Status().Update(ctx, bdpl)
+		if err != nil {
+			return reconcile.Result{},
+				log.WithEvent(bdpl, "UpdateError").Errorf(ctx, "failed to update resumed generation on bdpl '%s' (%v): %s", request.NamespacedName, bdpl.ResourceVersion, err)
+		}
+		return reconcile.Result{}, nil
+	}
+
+	r.updateStatus(ctx, bdpl)
+
 	return reconcile.Result{}, nil
 }
 
+// setCondition sets a condition on the BOSHDeployment's status and updates it
+func (r *ReconcileBOSHDeployment) setCondition(ctx context.Context, bdpl *bdv1.BOSHDeployment, t bdv1.BOSHDeploymentConditionType, status corev1.ConditionStatus, reason string, message string) {
+	bdpl.Status.SetCondition(t, status, reason, message)
+	r.updateStatus(ctx, bdpl)
+}
+
+// updateStatus updates the BOSHDeployment's status. Failures are only
+// logged, as the status is informational.
+func (r *ReconcileBOSHDeployment) updateStatus(ctx context.Context, bdpl *bdv1.BOSHDeployment) {
+	if err := r.client.Status().Update(ctx, bdpl); err != nil {
+		log.WithEvent(bdpl, "UpdateError").Errorf(ctx, "failed to update conditions on bdpl '%s' (%v): %s", bdpl.GetNamespacedName(), bdpl.ResourceVersion, err)
+	}
+}
+
 // resolveManifest resolves manifest with ops manifest
 func (r *ReconcileBOSHDeployment) resolveManifest(ctx context.Context, bdpl *bdv1.BOSHDeployment) (*bdm.Manifest, error) {
 	log.Debug(ctx, "Resolving manifest")
@@ -228,7 +311,7 @@ func (r *ReconcileBOSHDeployment) createManifestWithOps(ctx context.Context, bdp
 		return log.WithEvent(bdpl, "ManifestWithOpsMarshalError").Errorf(ctx, "Error marshaling the manifest '%s': %s", bdpl.GetNamespacedName(), err)
 	}
 
-	manifestSecretName := bdv1.DeploymentSecretTypeManifestWithOps.String()
+	manifestSecretName := names.DeploymentSecretName(bdv1.DeploymentSecretTypeManifestWithOps, bdpl.Name, "")
 
 	// Create a secret object for the manifest
 	manifestSecret := &corev1.Secret{
@@ -316,13 +399,31 @@ func (r *ReconcileBOSHDeployment) deleteQuarksStatefulSets(ctx context.Context,
 		labels := qsts.GetLabels()
 		if labels[bdv1.LabelDeploymentName] == bdpl.Name {
 			_, found := manifest.InstanceGroups.InstanceGroupByName(labels[bdv1.LabelInstanceGroupName])
-			if !found {
-				qstsToBeDeleted = append(qstsToBeDeleted, qsts)
+			if found {
+				continue
 			}
+			// the BPM reconciler of the new instance group moves the
+			// disks, before it deletes the migrated instance group
+			if ig, migrated := manifest.InstanceGroups.MigratedTo(labels[bdv1.LabelInstanceGroupName]); migrated {
+				log.Debugf(ctx, "Skipping deletion of quarksstatefulset '%s', instance group '%s' migrates from it", qsts.Name, ig.Name)
+				continue
+			}
+			qstsToBeDeleted = append(qstsToBeDeleted, qsts)
 		}
 	}
 
 	for _, qsts := range qstsToBeDeleted {
+		name := qsts.Labels[bdv1.LabelInstanceGroupName]
+
+		// keep the persistent disks of the instance group for the retention period
+		count, err := orphaneddisk.Orphan(ctx, r.client, bdpl.Namespace, bdpl.Name, name)
+		if err != nil {
+			return errors.Wrapf(err, "failed to orphan persistent disks of instance group %s", name)
+		}
+		if count > 0 {
+			log.WithEvent(bdpl, "OrphanPersistentDisks").Infof(ctx, "Orphaned %d persistent volume claims of instance group '%s', they are deleted after %s", count, name, orphaneddisk.Retention())
+		}
+
 		log.Infof(ctx, "deleting quarksstatefulset '%s'", qsts.Name)
 		err = r.client.Delete(ctx, &qsts)
 		if err != nil {
@@ -332,24 +433,115 @@ func (r *ReconcileBOSHDeployment) deleteQuarksStatefulSets(ctx context.Context,
 			return err
 		}
 
-		// delete all associated services
-		services := &corev1.ServiceList{}
-		name := qsts.Labels[bdv1.LabelInstanceGroupName]
-		labels := map[string]string{bdv1.LabelInstanceGroupName: name}
-		err := r.client.List(ctx, services, client.InNamespace(bdpl.Namespace), client.MatchingLabels(labels))
+		err = deleteInstanceGroupServices(ctx, r.client, bdpl, name)
 		if err != nil {
-			return errors.Wrapf(err, "failed to list services for instance group %s", name)
+			return err
 		}
-		for _, svc := range services.Items {
-			err = r.client.Delete(ctx, &svc)
-			if err != nil {
-				if apierrors.IsNotFound(err) {
-					return nil
-				}
-				return err
-			}
+
+		err = deleteInstanceGroupDisruptionBudgets(ctx, r.client, bdpl, name)
+		if err != nil {
+			return err
+		}
+
+		err = deleteInstanceGroupAutoscalers(ctx, r.client, bdpl, name, nil)
+		if err != nil {
+			return err
+		}
+
+		err = deleteInstanceGroupIngresses(ctx, r.client, bdpl, name)
+		if err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
+
+// deleteInstanceGroupServices deletes all services of an instance group
+func deleteInstanceGroupServices(ctx context.Context, c client.Client, bdpl *bdv1.BOSHDeployment, instanceGroupName string) error {
+	services := &corev1.ServiceList{}
+	labels := map[string]string{
+		bdv1.LabelDeploymentName:    bdpl.Name,
+		bdv1.LabelInstanceGroupName: instanceGroupName,
+	}
+	err := c.List(ctx, services, client.InNamespace(bdpl.Namespace), client.MatchingLabels(labels))
+	if err != nil {
+		return errors.Wrapf(err, "failed to list services for instance group %s", instanceGroupName)
+	}
+	for i := range services.Items {
+		err = c.Delete(ctx, &services.Items[i])
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteInstanceGroupDisruptionBudgets deletes the pod disruption budgets of an instance group
+func deleteInstanceGroupDisruptionBudgets(ctx context.Context, c client.Client, bdpl *bdv1.BOSHDeployment, instanceGroupName string) error {
+	budgets := &policyv1beta1.PodDisruptionBudgetList{}
+	labels := map[string]string{
+		bdv1.LabelDeploymentName:    bdpl.Name,
+		bdv1.LabelInstanceGroupName: instanceGroupName,
+	}
+	err := c.List(ctx, budgets, client.InNamespace(bdpl.Namespace), client.MatchingLabels(labels))
+	if err != nil {
+		return errors.Wrapf(err, "failed to list pod disruption budgets for instance group %s", instanceGroupName)
+	}
+	for i := range budgets.Items {
+		err = c.Delete(ctx, &budgets.Items[i])
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteInstanceGroupIngresses deletes all ingresses of an instance group
+func deleteInstanceGroupIngresses(ctx context.Context, c client.Client, bdpl *bdv1.BOSHDeployment, instanceGroupName string) error {
+	ingresses := &networkingv1beta1.IngressList{}
+	labels := map[string]string{
+		bdv1.LabelDeploymentName:    bdpl.Name,
+		bdv1.LabelInstanceGroupName: instanceGroupName,
+	}
+	err := c.List(ctx, ingresses, client.InNamespace(bdpl.Namespace), client.MatchingLabels(labels))
+	if err != nil {
+		return errors.Wrapf(err, "failed to list ingresses for instance group %s", instanceGroupName)
+	}
+	for i := range ingresses.Items {
+		err = c.Delete(ctx, &ingresses.Items[i])
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteInstanceGroupObject deletes the named object, if it exists and
+// belongs to the instance group
+func deleteInstanceGroupObject(ctx context.Context, c client.Client, bdpl *bdv1.BOSHDeployment, instanceGroupName string, obj runtime.Object, opts ...client.DeleteOption) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return errors.Wrap(err, "failed to access object meta")
+	}
+	name := accessor.GetName()
+
+	err = c.Get(ctx, types.NamespacedName{Namespace: bdpl.Namespace, Name: name}, obj)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to get '%s/%s'", bdpl.Namespace, name)
+	}
+
+	labels := accessor.GetLabels()
+	if labels[bdv1.LabelDeploymentName] != bdpl.Name || labels[bdv1.LabelInstanceGroupName] != instanceGroupName {
+		return nil
+	}
+
+	err = c.Delete(ctx, obj, opts...)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete '%s/%s'", bdpl.Namespace, name)
+	}
+	return nil
+}
@@ -34,6 +34,7 @@ import (
 	qsv1a1 "code.cloudfoundry.org/quarks-secret/pkg/kube/apis/quarkssecret/v1alpha1"
 	cfcfg "code.cloudfoundry.org/quarks-utils/pkg/config"
 	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+	"code.cloudfoundry.org/quarks-utils/pkg/pointers"
 	helper "code.cloudfoundry.org/quarks-utils/testing/testhelper"
 )
 
@@ -256,7 +257,10 @@ var _ = Describe("ReconcileBoshDeployment", func() {
 			})
 
 			It("handles an error when resolving the BOSHDeployment", func() {
+				statusWriter := &fakes.FakeStatusWriter{}
+				client.StatusCalls(func() crc.StatusWriter { return statusWriter })
 				withops.ManifestReturns(nil, fmt.Errorf("resolver error"))
+				instance.Generation = 3
 
 				_, err := reconciler.Reconcile(request)
 				Expect(err).To(HaveOccurred())
@@ -264,6 +268,106 @@ var _ = Describe("ReconcileBoshDeployment", func() {
 
 				// check for events
 				Expect(<-recorder.Events).To(ContainSubstring("WithOpsManifestError"))
+
+				// check the error is reported in the status
+				Expect(statusWriter.UpdateCallCount()).To(Equal(2))
+				_, object, _ := statusWriter.UpdateArgsForCall(1)
+				bdpl := object.(*bdv1.BOSHDeployment)
+				Expect(bdpl.Status.State).To(Equal(cfd.BDPLStateResolveFailed))
+				Expect(bdpl.Status.Message).To(ContainSubstring("resolver error"))
+				Expect(bdpl.Status.ObservedGeneration).To(Equal(int64(3)))
+				condition := bdpl.Status.GetCondition(bdv1.ManifestResolved)
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+				Expect(condition.Reason).To(Equal("ResolveFailed"))
+				Expect(condition.Message).To(ContainSubstring("resolver error"))
+			})
+		})
+
+		Context("when the BOSHDeployment is paused", func() {
+			var statusWriter *fakes.FakeStatusWriter
+
+			BeforeEach(func() {
+				statusWriter = &fakes.FakeStatusWriter{}
+				client.StatusCalls(func() crc.StatusWriter { return statusWriter })
+			})
+
+			It("skips the reconcile and sets the paused condition", func() {
+				instance.Spec.Paused = true
+
+				result, err := reconciler.Reconcile(request)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(Equal(reconcile.Result{}))
+				Expect(withops.ManifestCallCount()).To(Equal(0))
+				Expect(client.CreateCallCount()).To(Equal(0))
+
+				Expect(statusWriter.UpdateCallCount()).To(Equal(1))
+				_, object, _ := statusWriter.UpdateArgsForCall(0)
+				status := object.(*bdv1.BOSHDeployment).Status
+				Expect(status.IsConditionTrue(bdv1.Paused)).To(BeTrue())
+				Expect(status.LastReconcile).To(Equal(instance.Status.LastReconcile))
+			})
+
+			It("does not update the status again, while it stays paused", func() {
+				instance.Spec.Paused = true
+				instance.Status.SetCondition(bdv1.Paused, corev1.ConditionTrue, "Paused", "changes are applied when the deployment is resumed")
+
+				_, err := reconciler.Reconcile(request)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(statusWriter.UpdateCallCount()).To(Equal(0))
+			})
+
+			Context("when it is resumed", func() {
+				var steps []string
+
+				BeforeEach(func() {
+					instance.Generation = 4
+					instance.Status.SetCondition(bdv1.Paused, corev1.ConditionTrue, "Paused", "")
+
+					steps = []string{}
+					statusWriter.UpdateCalls(func(context context.Context, object runtime.Object, _ ...crc.UpdateOption) error {
+						status := object.(*bdv1.BOSHDeployment).Status
+						condition := status.GetCondition(bdv1.Paused)
+						steps = append(steps, fmt.Sprintf("status %s %d", condition.Reason, status.ResumedGeneration))
+						return nil
+					})
+					client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+						switch object := object.(type) {
+						case *bdv1.BOSHDeployment:
+							instance.DeepCopyInto(object)
+							return nil
+						}
+						return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+					})
+					client.CreateCalls(func(context context.Context, object runtime.Object, _ ...crc.CreateOption) error {
+						if secret, ok := object.(*corev1.Secret); ok {
+							steps = append(steps, "secret "+secret.Name)
+						}
+						return nil
+					})
+				})
+
+				It("records the resumed generation after writing the with-ops manifest", func() {
+					_, err := reconciler.Reconcile(request)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(withops.ManifestCallCount()).To(Equal(1))
+
+					Expect(steps[0]).To(Equal("status Resuming 0"))
+					Expect(steps[len(steps)-2]).To(Equal("secret foo.with-ops"))
+					Expect(steps[len(steps)-1]).To(Equal("status Resumed 4"))
+					for _, step := range steps[:len(steps)-1] {
+						Expect(step).NotTo(HaveSuffix(" 4"))
+					}
+				})
+
+				It("doesn't record the resumed generation, while the meltdown is in progress", func() {
+					instance.Status.LastReconcile = nil
+
+					result, err := reconciler.Reconcile(request)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(result.RequeueAfter).To(Equal(cfd.ReconcileSkipDuration))
+					Expect(steps).To(Equal([]string{"status Resuming 0", "status Resuming 0"}))
+				})
 			})
 		})
 
@@ -297,7 +401,7 @@ var _ = Describe("ReconcileBoshDeployment", func() {
 					case *qjv1a1.QuarksJob:
 						return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
 					case *corev1.Secret:
-						if nn.Name == "with-ops" {
+						if nn.Name == "foo.with-ops" {
 							return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
 						}
 					}
@@ -322,7 +426,7 @@ var _ = Describe("ReconcileBoshDeployment", func() {
 				By("From created state to ops applied state")
 				_, err := reconciler.Reconcile(request)
 				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(ContainSubstring("failed to create with-ops manifest secret for BOSHDeployment 'default/foo': failed to apply Secret 'default/with-ops': fake-error"))
+				Expect(err.Error()).To(ContainSubstring("failed to create with-ops manifest secret for BOSHDeployment 'default/foo': failed to apply Secret 'default/foo.with-ops': fake-error"))
 			})
 
 			It("handles an error generating the new variable secrets", func() {
@@ -372,6 +476,8 @@ var _ = Describe("ReconcileBoshDeployment", func() {
 			})
 
 			It("handles an error when creating instance group manifest qJob", func() {
+				statusWriter := &fakes.FakeStatusWriter{}
+				client.StatusCalls(func() crc.StatusWriter { return statusWriter })
 				client.CreateCalls(func(context context.Context, object runtime.Object, _ ...crc.CreateOption) error {
 					switch object := object.(type) {
 					case *qjv1a1.QuarksJob:
@@ -386,6 +492,15 @@ var _ = Describe("ReconcileBoshDeployment", func() {
 				_, err := reconciler.Reconcile(request)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("failed to create instance group manifest qJob for BOSHDeployment 'default/foo': creating or updating QuarksJob 'default/ig-foo': fake-error"))
+
+				_, object, _ := statusWriter.UpdateArgsForCall(statusWriter.UpdateCallCount() - 1)
+				status := object.(*bdv1.BOSHDeployment).Status
+				Expect(status.IsConditionTrue(bdv1.ManifestResolved)).To(BeTrue())
+				Expect(status.IsConditionTrue(bdv1.VariablesGenerated)).To(BeTrue())
+				condition := status.GetCondition(bdv1.InstanceGroupsResolved)
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+				Expect(condition.Message).To(ContainSubstring("fake-error"))
 			})
 
 			Context("when the manifest contains variables", func() {
@@ -414,6 +529,106 @@ var _ = Describe("ReconcileBoshDeployment", func() {
 					Expect(result).To(Equal(reconcile.Result{}))
 					Expect(client.CreateCallCount()).To(Equal(4))
 				})
+
+				It("sets the manifest and variables conditions", func() {
+					statusWriter := &fakes.FakeStatusWriter{}
+					client.StatusCalls(func() crc.StatusWriter { return statusWriter })
+
+					_, err := reconciler.Reconcile(request)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, object, _ := statusWriter.UpdateArgsForCall(statusWriter.UpdateCallCount() - 1)
+					status := object.(*bdv1.BOSHDeployment).Status
+					Expect(status.IsConditionTrue(bdv1.ManifestResolved)).To(BeTrue())
+					Expect(status.IsConditionTrue(bdv1.VariablesGenerated)).To(BeTrue())
+					Expect(status.GetCondition(bdv1.InstanceGroupsResolved)).To(BeNil())
+				})
+			})
+
+			Context("when the deployment was created before names were prefixed with the deployment name", func() {
+				var (
+					created []runtime.Object
+					deleted []runtime.Object
+				)
+
+				BeforeEach(func() {
+					created = []runtime.Object{}
+					deleted = []runtime.Object{}
+					instance.UID = "bdpl-uid"
+					owner := []metav1.OwnerReference{{
+						APIVersion: "quarks.cloudfoundry.org/v1alpha1",
+						Kind:       "BOSHDeployment",
+						Name:       deploymentName,
+						UID:        instance.UID,
+						Controller: pointers.Bool(true),
+					}}
+
+					client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+						switch object := object.(type) {
+						case *bdv1.BOSHDeployment:
+							instance.DeepCopyInto(object)
+						case *qsv1a1.QuarksSecret:
+							if nn.Name != "var-foo-password" {
+								return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+							}
+							object.ObjectMeta = metav1.ObjectMeta{Name: nn.Name, Namespace: nn.Namespace, OwnerReferences: owner}
+							object.Spec.SecretName = "var-foo-password"
+						case *qjv1a1.QuarksJob:
+							if nn.Name != "fakepod" {
+								return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+							}
+							object.ObjectMeta = metav1.ObjectMeta{Name: nn.Name, Namespace: nn.Namespace, OwnerReferences: owner}
+						case *corev1.Secret:
+							if nn.Name != "var-foo-password" {
+								return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+							}
+							object.ObjectMeta = metav1.ObjectMeta{
+								Name:      nn.Name,
+								Namespace: nn.Namespace,
+								Labels:    map[string]string{qsv1a1.LabelKind: qsv1a1.GeneratedSecretKind},
+							}
+							object.Data = map[string][]byte{"password": []byte("old-password")}
+						}
+						return nil
+					})
+					client.CreateCalls(func(context context.Context, object runtime.Object, _ ...crc.CreateOption) error {
+						created = append(created, object)
+						return nil
+					})
+					client.DeleteCalls(func(context context.Context, object runtime.Object, _ ...crc.DeleteOption) error {
+						deleted = append(deleted, object)
+						return nil
+					})
+				})
+
+				It("keeps the values of the variables and deletes the old QuarksSecrets and errand QuarksJobs", func() {
+					_, err := reconciler.Reconcile(request)
+					Expect(err).NotTo(HaveOccurred())
+
+					secret, ok := created[0].(*corev1.Secret)
+					Expect(ok).To(BeTrue())
+					Expect(secret.Name).To(Equal("foo.var-foo-password"))
+					Expect(secret.Data).To(HaveKeyWithValue("password", []byte("old-password")))
+					Expect(secret.Labels).ToNot(HaveKey(qsv1a1.LabelKind))
+					Expect(metav1.IsControlledBy(secret, instance)).To(BeTrue())
+
+					Expect(deleted).To(HaveLen(2))
+					Expect(deleted[0].(*qsv1a1.QuarksSecret).Name).To(Equal("var-foo-password"))
+					Expect(deleted[1].(*qjv1a1.QuarksJob).Name).To(Equal("fakepod"))
+				})
+
+				It("doesn't touch resources of other deployments", func() {
+					instance.UID = "other-uid"
+
+					_, err := reconciler.Reconcile(request)
+					Expect(err).NotTo(HaveOccurred())
+					for _, object := range created {
+						if secret, ok := object.(*corev1.Secret); ok {
+							Expect(secret.Name).ToNot(Equal("foo.var-foo-password"))
+						}
+					}
+					Expect(deleted).To(BeEmpty())
+				})
 			})
 
 			Context("when the manifest contains explicit links to native k8s resources", func() {
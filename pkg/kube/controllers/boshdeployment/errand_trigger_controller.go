@@ -0,0 +1,53 @@
+package boshdeployment
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/metrics"
+	"code.cloudfoundry.org/quarks-utils/pkg/config"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+	"code.cloudfoundry.org/quarks-utils/pkg/monitorednamespace"
+)
+
+// AddErrandTrigger creates a new controller, which triggers the QuarksJobs of
+// scheduled errands, when their cron jobs create a job
+func AddErrandTrigger(ctx context.Context, config *config.Config, mgr manager.Manager) error {
+	ctx = ctxlog.NewContextWithRecorder(ctx, "errand-trigger-reconciler", mgr.GetEventRecorderFor("errand-trigger-recorder"))
+	r := NewErrandTriggerReconciler(ctx, config, mgr)
+
+	c, err := controller.New("errand-trigger-controller", mgr, controller.Options{
+		Reconciler: metrics.InstrumentReconciler(metrics.ControllerErrandTrigger, r),
+	})
+	if err != nil {
+		return errors.Wrap(err, "Adding errand trigger controller to manager failed.")
+	}
+
+	nsPred := monitorednamespace.NewNSPredicate(ctx, mgr.GetClient(), config.MonitoredID)
+
+	// Failed reconciles are requeued, so only new trigger jobs are of interest
+	p := predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			_, ok := e.Meta.GetLabels()[bdv1.LabelErrandTrigger]
+			return ok
+		},
+		UpdateFunc:  func(e event.UpdateEvent) bool { return false },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+	}
+	err = c.Watch(&source.Kind{Type: &batchv1.Job{}}, &handler.EnqueueRequestForObject{}, nsPred, p)
+	if err != nil {
+		return errors.Wrapf(err, "Watching jobs failed in errand trigger controller.")
+	}
+
+	return nil
+}
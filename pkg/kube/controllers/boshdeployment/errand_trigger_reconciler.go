@@ -0,0 +1,176 @@
+package boshdeployment
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1b1 "k8s.io/api/batch/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	qjv1a1 "code.cloudfoundry.org/quarks-job/pkg/kube/apis/quarksjob/v1alpha1"
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/quarks-utils/pkg/config"
+	log "code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+)
+
+const (
+	// defaultSuccessfulErrandJobs is the number of succeeded jobs kept per scheduled errand, like for cron jobs
+	defaultSuccessfulErrandJobs = 3
+	// defaultFailedErrandJobs is the number of failed jobs kept per scheduled errand, like for cron jobs
+	defaultFailedErrandJobs = 1
+)
+
+// NewErrandTriggerReconciler returns a new reconciler, which triggers the
+// QuarksJob of a scheduled errand for each job of the errand's cron job
+func NewErrandTriggerReconciler(ctx context.Context, config *config.Config, mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileErrandTrigger{
+		ctx:    ctx,
+		config: config,
+		client: mgr.GetClient(),
+	}
+}
+
+// ReconcileErrandTrigger reconciles the trigger jobs of scheduled errands
+type ReconcileErrandTrigger struct {
+	ctx    context.Context
+	client client.Client
+	config *config.Config
+}
+
+// Reconcile triggers the errand's QuarksJob, following the concurrency policy
+// of the errand's cron job, and deletes the trigger job afterwards. The jobs
+// of the QuarksJob are pruned to the history limits of the cron job.
+func (r *ReconcileErrandTrigger) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	// Set the ctx to be Background, as the top-level context for incoming requests.
+	ctx, cancel := context.WithTimeout(r.ctx, r.config.CtxTimeOut)
+	defer cancel()
+
+	trigger := &batchv1.Job{}
+	err := r.client.Get(ctx, request.NamespacedName, trigger)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Debug(ctx, "Skip reconcile: trigger job not found")
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	qJobName, ok := trigger.Labels[bdv1.LabelErrandTrigger]
+	if !ok {
+		return reconcile.Result{}, nil
+	}
+
+	// the cron job has the name of the errand's QuarksJob
+	cronJob := &batchv1b1.CronJob{}
+	err = r.client.Get(ctx, types.NamespacedName{Namespace: request.Namespace, Name: qJobName}, cronJob)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Infof(ctx, "Errand '%s' is no longer scheduled, deleting trigger job '%s'", qJobName, request.NamespacedName)
+			return reconcile.Result{}, r.deleteJob(ctx, trigger)
+		}
+		return reconcile.Result{}, errors.Wrapf(err, "could not get cron job '%s/%s'", request.Namespace, qJobName)
+	}
+
+	jobs := &batchv1.JobList{}
+	err = r.client.List(ctx, jobs, client.InNamespace(request.Namespace), client.MatchingLabels{qjv1a1.LabelQJobName: qJobName})
+	if err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "could not list jobs of QuarksJob '%s/%s'", request.Namespace, qJobName)
+	}
+	running := []batchv1.Job{}
+	for _, job := range jobs.Items {
+		if _, finished := jobResult(job); finished == nil {
+			running = append(running, job)
+		}
+	}
+
+	switch {
+	case len(running) > 0 && cronJob.Spec.ConcurrencyPolicy == batchv1b1.ForbidConcurrent:
+		log.WithEvent(cronJob, "ErrandSkipped").Infof(ctx, "Skip scheduled run of errand '%s/%s': the previous run is still active", request.Namespace, qJobName)
+		return reconcile.Result{}, r.deleteJob(ctx, trigger)
+	case len(running) > 0 && cronJob.Spec.ConcurrencyPolicy == batchv1b1.ReplaceConcurrent:
+		for i := range running {
+			log.Infof(ctx, "Replacing active run '%s' of errand '%s/%s'", running[i].Name, request.Namespace, qJobName)
+			if err := r.deleteJob(ctx, &running[i]); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+	}
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		qJob := &qjv1a1.QuarksJob{}
+		if err := r.client.Get(ctx, types.NamespacedName{Namespace: request.Namespace, Name: qJobName}, qJob); err != nil {
+			return err
+		}
+		qJob.Spec.Trigger.Strategy = qjv1a1.TriggerNow
+		return r.client.Update(ctx, qJob)
+	})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Infof(ctx, "QuarksJob of errand '%s/%s' not found, deleting trigger job", request.Namespace, qJobName)
+			return reconcile.Result{}, r.deleteJob(ctx, trigger)
+		}
+		return reconcile.Result{}, log.WithEvent(cronJob, "TriggerErrandError").Errorf(ctx, "Failed to trigger QuarksJob '%s/%s': %v", request.Namespace, qJobName, err)
+	}
+	log.WithEvent(cronJob, "ErrandTriggered").Infof(ctx, "Triggered scheduled run of errand '%s/%s'", request.Namespace, qJobName)
+
+	if err := r.deleteJob(ctx, trigger); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, r.pruneJobs(ctx, cronJob, jobs.Items)
+}
+
+// pruneJobs deletes the oldest finished jobs of the errand, which exceed the
+// history limits of its cron job
+func (r *ReconcileErrandTrigger) pruneJobs(ctx context.Context, cronJob *batchv1b1.CronJob, jobs []batchv1.Job) error {
+	successfulLimit := defaultSuccessfulErrandJobs
+	if cronJob.Spec.SuccessfulJobsHistoryLimit != nil {
+		successfulLimit = int(*cronJob.Spec.SuccessfulJobsHistoryLimit)
+	}
+	failedLimit := defaultFailedErrandJobs
+	if cronJob.Spec.FailedJobsHistoryLimit != nil {
+		failedLimit = int(*cronJob.Spec.FailedJobsHistoryLimit)
+	}
+
+	finished := map[bdv1.ErrandRunPhase][]batchv1.Job{}
+	finishedAt := map[string]*metav1.Time{}
+	for _, job := range jobs {
+		phase, t := jobResult(job)
+		if t == nil {
+			continue
+		}
+		finished[phase] = append(finished[phase], job)
+		finishedAt[job.Name] = t
+	}
+
+	for phase, limit := range map[bdv1.ErrandRunPhase]int{bdv1.ErrandRunSucceeded: successfulLimit, bdv1.ErrandRunFailed: failedLimit} {
+		old := finished[phase]
+		if len(old) <= limit {
+			continue
+		}
+		sort.Slice(old, func(i, j int) bool { return finishedAt[old[i].Name].Before(finishedAt[old[j].Name]) })
+		for i := range old[:len(old)-limit] {
+			if err := r.deleteJob(ctx, &old[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *ReconcileErrandTrigger) deleteJob(ctx context.Context, job *batchv1.Job) error {
+	err := r.client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "could not delete job '%s/%s'", job.Namespace, job.Name)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package boshdeployment_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1b1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	crc "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	qjv1a1 "code.cloudfoundry.org/quarks-job/pkg/kube/apis/quarksjob/v1alpha1"
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/controllers"
+	cfd "code.cloudfoundry.org/quarks-operator/pkg/kube/controllers/boshdeployment"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/controllers/fakes"
+	cfcfg "code.cloudfoundry.org/quarks-utils/pkg/config"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+	"code.cloudfoundry.org/quarks-utils/pkg/pointers"
+	helper "code.cloudfoundry.org/quarks-utils/testing/testhelper"
+)
+
+var _ = Describe("ReconcileErrandTrigger", func() {
+	var (
+		ctx        context.Context
+		manager    *fakes.FakeManager
+		client     crc.Client
+		reconciler reconcile.Reconciler
+		request    reconcile.Request
+		cronJob    *batchv1b1.CronJob
+		objects    []runtime.Object
+	)
+
+	errandJob := func(name string, condition batchv1.JobConditionType, finished time.Time) *batchv1.Job {
+		job := &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels:    map[string]string{qjv1a1.LabelQJobName: "foo-smoke"},
+			},
+		}
+		if condition != "" {
+			job.Status.Conditions = []batchv1.JobCondition{
+				{Type: condition, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(finished)},
+			}
+		}
+		return job
+	}
+
+	jobExists := func(name string) bool {
+		err := client.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, &batchv1.Job{})
+		if apierrors.IsNotFound(err) {
+			return false
+		}
+		Expect(err).ToNot(HaveOccurred())
+		return true
+	}
+
+	strategy := func() qjv1a1.Strategy {
+		qJob := &qjv1a1.QuarksJob{}
+		Expect(client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "foo-smoke"}, qJob)).To(Succeed())
+		return qJob.Spec.Trigger.Strategy
+	}
+
+	BeforeEach(func() {
+		_, log := helper.NewTestLogger()
+		ctx = ctxlog.NewParentContext(log)
+
+		cronJob = &batchv1b1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo-smoke", Namespace: "default"},
+			Spec: batchv1b1.CronJobSpec{
+				Schedule:          "@hourly",
+				ConcurrencyPolicy: batchv1b1.ForbidConcurrent,
+			},
+		}
+		objects = []runtime.Object{
+			&qjv1a1.QuarksJob{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo-smoke", Namespace: "default"},
+				Spec:       qjv1a1.QuarksJobSpec{Trigger: qjv1a1.Trigger{Strategy: qjv1a1.TriggerManual}},
+			},
+			&batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo-smoke-1234",
+					Namespace: "default",
+					Labels:    map[string]string{bdv1.LabelErrandTrigger: "foo-smoke"},
+				},
+			},
+		}
+		request = reconcile.Request{NamespacedName: types.NamespacedName{Name: "foo-smoke-1234", Namespace: "default"}}
+	})
+
+	JustBeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(batchv1.AddToScheme(scheme)).To(Succeed())
+		Expect(batchv1b1.AddToScheme(scheme)).To(Succeed())
+		Expect(controllers.AddToScheme(scheme)).To(Succeed())
+		client = fake.NewFakeClientWithScheme(scheme, append(objects, cronJob)...)
+
+		manager = &fakes.FakeManager{}
+		manager.GetClientReturns(client)
+		reconciler = cfd.NewErrandTriggerReconciler(ctx, &cfcfg.Config{CtxTimeOut: 10 * time.Second}, manager)
+	})
+
+	It("triggers the errand's quarks job and deletes the trigger job", func() {
+		result, err := reconciler.Reconcile(request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(reconcile.Result{}))
+
+		Expect(strategy()).To(Equal(qjv1a1.TriggerNow))
+		Expect(jobExists("foo-smoke-1234")).To(BeFalse())
+	})
+
+	Context("when the previous run is still active", func() {
+		BeforeEach(func() {
+			objects = append(objects, errandJob("foo-smoke-running", "", time.Time{}))
+		})
+
+		It("skips the run, if concurrent runs are forbidden", func() {
+			_, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(strategy()).To(Equal(qjv1a1.TriggerManual))
+			Expect(jobExists("foo-smoke-running")).To(BeTrue())
+			Expect(jobExists("foo-smoke-1234")).To(BeFalse())
+		})
+
+		Context("if the concurrency policy is Replace", func() {
+			BeforeEach(func() {
+				cronJob.Spec.ConcurrencyPolicy = batchv1b1.ReplaceConcurrent
+			})
+
+			It("replaces the active run", func() {
+				_, err := reconciler.Reconcile(request)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(strategy()).To(Equal(qjv1a1.TriggerNow))
+				Expect(jobExists("foo-smoke-running")).To(BeFalse())
+			})
+		})
+
+		Context("if the concurrency policy is Allow", func() {
+			BeforeEach(func() {
+				cronJob.Spec.ConcurrencyPolicy = batchv1b1.AllowConcurrent
+			})
+
+			It("starts another run", func() {
+				_, err := reconciler.Reconcile(request)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(strategy()).To(Equal(qjv1a1.TriggerNow))
+				Expect(jobExists("foo-smoke-running")).To(BeTrue())
+			})
+		})
+	})
+
+	Context("when there are finished runs", func() {
+		BeforeEach(func() {
+			cronJob.Spec.SuccessfulJobsHistoryLimit = pointers.Int32(1)
+			start := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+			objects = append(objects,
+				errandJob("foo-smoke-succeeded-1", batchv1.JobComplete, start),
+				errandJob("foo-smoke-succeeded-2", batchv1.JobComplete, start.Add(time.Hour)),
+				errandJob("foo-smoke-failed-1", batchv1.JobFailed, start.Add(2*time.Hour)),
+			)
+		})
+
+		It("prunes them to the history limits of the cron job", func() {
+			_, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobExists("foo-smoke-succeeded-1")).To(BeFalse())
+			Expect(jobExists("foo-smoke-succeeded-2")).To(BeTrue())
+			Expect(jobExists("foo-smoke-failed-1")).To(BeTrue())
+		})
+	})
+
+	Context("when the errand is no longer scheduled", func() {
+		BeforeEach(func() {
+			cronJob.Name = "foo-other"
+		})
+
+		It("only deletes the trigger job", func() {
+			_, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(strategy()).To(Equal(qjv1a1.TriggerManual))
+			Expect(jobExists("foo-smoke-1234")).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,145 @@
+package boshdeployment
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	qjv1a1 "code.cloudfoundry.org/quarks-job/pkg/kube/apis/quarksjob/v1alpha1"
+	bdm "code.cloudfoundry.org/quarks-operator/pkg/bosh/manifest"
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/names"
+	qsv1a1 "code.cloudfoundry.org/quarks-secret/pkg/kube/apis/quarkssecret/v1alpha1"
+	log "code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+)
+
+// migrateLegacyNames upgrades deployments created before the names of their
+// resources were prefixed with the deployment name. The values of generated
+// variables are copied to the secrets with the new names, so passwords and
+// certificates stay the same. Copies are not labeled as generated, so
+// quarks-secret doesn't replace them. The QuarksSecrets with the old names are
+// deleted afterwards, which also deletes their secrets, as are the errand
+// QuarksJobs with the old names, so they can't be triggered by mistake.
+// Only resources controlled by the BOSHDeployment are migrated.
+func (r *ReconcileBOSHDeployment) migrateLegacyNames(ctx context.Context, bdpl *bdv1.BOSHDeployment, manifest *bdm.Manifest) error {
+	for _, v := range manifest.Variables {
+		if err := r.migrateVariableSecret(ctx, bdpl, v.Name); err != nil {
+			return err
+		}
+	}
+
+	for _, ig := range manifest.InstanceGroups {
+		qJob := &qjv1a1.QuarksJob{}
+		if err := r.deleteLegacyObject(ctx, bdpl, ig.Name, qJob); err != nil {
+			return errors.Wrapf(err, "failed to delete QuarksJob '%s/%s'", bdpl.Namespace, ig.Name)
+		}
+	}
+
+	return nil
+}
+
+// migrateVariableSecret copies the secret of an explicit variable from
+// `var-<name>` to `<deployment-name>.var-<name>`, unless the new secret exists
+func (r *ReconcileBOSHDeployment) migrateVariableSecret(ctx context.Context, bdpl *bdv1.BOSHDeployment, variableName string) error {
+	oldName := names.SecretVariableName(variableName)
+	newName := names.DeploymentSecretName(bdv1.DeploymentSecretTypeVariable, bdpl.Name, variableName)
+
+	qsec := &qsv1a1.QuarksSecret{}
+	found, err := r.getLegacyObject(ctx, bdpl, oldName, qsec)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get QuarksSecret '%s/%s'", bdpl.Namespace, oldName)
+	}
+	if !found {
+		return nil
+	}
+
+	err = r.client.Get(ctx, types.NamespacedName{Namespace: bdpl.Namespace, Name: newName}, &corev1.Secret{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to get secret '%s/%s'", bdpl.Namespace, newName)
+	}
+	if apierrors.IsNotFound(err) {
+		old := &corev1.Secret{}
+		err := r.client.Get(ctx, types.NamespacedName{Namespace: bdpl.Namespace, Name: qsec.Spec.SecretName}, old)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to get secret '%s/%s'", bdpl.Namespace, qsec.Spec.SecretName)
+		}
+		if err == nil {
+			if err := r.copyVariableSecret(ctx, bdpl, old, newName); err != nil {
+				return err
+			}
+			log.WithEvent(bdpl, "MigrateVariable").Infof(ctx, "Copied secret '%s/%s' of variable '%s' to '%s'", bdpl.Namespace, old.Name, variableName, newName)
+		}
+	}
+
+	if err := r.client.Delete(ctx, qsec); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete QuarksSecret '%s/%s'", bdpl.Namespace, oldName)
+	}
+	return nil
+}
+
+// copyVariableSecret creates a copy of the variable's old secret, which is
+// controlled by the BOSHDeployment instead of the old QuarksSecret
+func (r *ReconcileBOSHDeployment) copyVariableSecret(ctx context.Context, bdpl *bdv1.BOSHDeployment, old *corev1.Secret, name string) error {
+	labels := map[string]string{}
+	for k, v := range old.Labels {
+		if k == qsv1a1.LabelKind {
+			continue
+		}
+		labels[k] = v
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   bdpl.Namespace,
+			Labels:      labels,
+			Annotations: old.Annotations,
+		},
+		Type: old.Type,
+		Data: old.Data,
+	}
+	if err := r.setReference(bdpl, secret, r.scheme); err != nil {
+		return errors.Wrapf(err, "failed to set ownership for secret '%s/%s'", bdpl.Namespace, name)
+	}
+	if err := r.client.Create(ctx, secret); err != nil {
+		return errors.Wrapf(err, "failed to copy secret '%s/%s' to '%s'", bdpl.Namespace, old.Name, name)
+	}
+	return nil
+}
+
+// deleteLegacyObject deletes an object with an old name, if it's controlled by the BOSHDeployment
+func (r *ReconcileBOSHDeployment) deleteLegacyObject(ctx context.Context, bdpl *bdv1.BOSHDeployment, name string, object legacyObject) error {
+	found, err := r.getLegacyObject(ctx, bdpl, name, object)
+	if err != nil || !found {
+		return err
+	}
+
+	log.Infof(ctx, "Deleting '%s/%s', it was created before names were prefixed with the deployment name", bdpl.Namespace, name)
+	if err := r.client.Delete(ctx, object); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+type legacyObject interface {
+	metav1.Object
+	runtime.Object
+}
+
+// getLegacyObject gets an object with an old name and returns true, if it's
+// controlled by the BOSHDeployment
+func (r *ReconcileBOSHDeployment) getLegacyObject(ctx context.Context, bdpl *bdv1.BOSHDeployment, name string, object legacyObject) (bool, error) {
+	err := r.client.Get(ctx, types.NamespacedName{Namespace: bdpl.Namespace, Name: name}, object)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return metav1.IsControlledBy(object, bdpl), nil
+}
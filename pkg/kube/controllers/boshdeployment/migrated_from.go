@@ -0,0 +1,175 @@
+package boshdeployment
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	bdm "code.cloudfoundry.org/quarks-operator/pkg/bosh/manifest"
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/orphaneddisk"
+	qstsv1a1 "code.cloudfoundry.org/quarks-statefulset/pkg/kube/apis/quarksstatefulset/v1alpha1"
+	log "code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+	"code.cloudfoundry.org/quarks-utils/pkg/names"
+)
+
+// migrateInstanceGroups implements migrated_from for renamed instance groups.
+// The old QuarksStatefulSets and their services are deleted first. Once all
+// pods of an old instance group are gone, the volumes of its claims, found by
+// the name of the old QuarksStatefulSet, are moved to claims with the names
+// the stateful set of the instance group expects.
+// It returns true, while the pods of an old instance group are terminating,
+// the instance group must not be deployed until then.
+func migrateInstanceGroups(ctx context.Context, c client.Client, bdpl *bdv1.BOSHDeployment, manifest *bdm.Manifest, ig *bdm.InstanceGroup) (bool, error) {
+	for _, from := range ig.MigratedFrom {
+		if from == nil || from.Name == "" {
+			continue
+		}
+		if _, found := manifest.InstanceGroups.InstanceGroupByName(from.Name); found {
+			log.Debugf(ctx, "Skipping migration of instance group '%s', it's still part of the manifest", from.Name)
+			continue
+		}
+
+		if err := deleteMigratedInstanceGroup(ctx, c, bdpl, from.Name); err != nil {
+			return false, err
+		}
+
+		igLabels := client.MatchingLabels{
+			bdv1.LabelDeploymentName:    bdpl.Name,
+			bdv1.LabelInstanceGroupName: from.Name,
+		}
+		pods := &corev1.PodList{}
+		if err := c.List(ctx, pods, client.InNamespace(bdpl.Namespace), igLabels); err != nil {
+			return false, errors.Wrapf(err, "failed to list pods of instance group '%s'", from.Name)
+		}
+		if len(pods.Items) > 0 {
+			log.Infof(ctx, "Waiting for %d pods of instance group '%s' to terminate, before migrating it to '%s'", len(pods.Items), from.Name, ig.Name)
+			return true, nil
+		}
+
+		// claims only carry the instance group labels if their claim templates
+		// were labeled, all of them have the name of their QuarksStatefulSet
+		pvcs := &corev1.PersistentVolumeClaimList{}
+		err := c.List(ctx, pvcs, client.InNamespace(bdpl.Namespace), client.MatchingLabels{
+			qstsv1a1.LabelQStsName: names.Sanitize(from.Name),
+		})
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to list persistent volume claims of instance group '%s'", from.Name)
+		}
+
+		for i := range pvcs.Items {
+			pvc := &pvcs.Items[i]
+			if pvc.DeletionTimestamp != nil {
+				continue
+			}
+
+			name, err := migratedClaimName(pvc.Name, from, ig)
+			if err != nil {
+				return false, err
+			}
+
+			// the stateful set of the instance group created the claim already,
+			// the old claim stays orphaned
+			existing := &corev1.PersistentVolumeClaim{}
+			err = c.Get(ctx, types.NamespacedName{Namespace: pvc.Namespace, Name: name}, existing)
+			if err == nil && existing.Spec.VolumeName != pvc.Spec.VolumeName {
+				log.WithEvent(bdpl, "MigratePersistentDiskSkipped").Infof(ctx, "Can't migrate persistent volume claim '%s/%s' of instance group '%s', claim '%s' already exists", pvc.Namespace, pvc.Name, from.Name, name)
+				continue
+			}
+			if err != nil && !apierrors.IsNotFound(err) {
+				return false, errors.Wrapf(err, "failed to get persistent volume claim '%s/%s'", pvc.Namespace, name)
+			}
+
+			if _, err := orphaneddisk.Move(ctx, c, pvc, name, ig.Name); err != nil {
+				return false, err
+			}
+			log.WithEvent(bdpl, "MigratePersistentDisk").Infof(ctx, "Moved persistent volume claim '%s/%s' of instance group '%s' to '%s' of instance group '%s'", pvc.Namespace, pvc.Name, from.Name, name, ig.Name)
+		}
+		log.WithEvent(bdpl, "MigrateInstanceGroup").Infof(ctx, "Migrated instance group '%s' to '%s'", from.Name, ig.Name)
+	}
+
+	return false, nil
+}
+
+// deleteMigratedInstanceGroup deletes the QuarksStatefulSets, services,
+// disruption budgets, autoscalers and ingresses of an instance group, before
+// its claims are moved. The claims are orphaned first, so they outlive the
+// stateful sets. Claims, which can't be moved, are kept as orphaned disks.
+func deleteMigratedInstanceGroup(ctx context.Context, c client.Client, bdpl *bdv1.BOSHDeployment, instanceGroupName string) error {
+	count, err := orphaneddisk.Orphan(ctx, c, bdpl.Namespace, bdpl.Name, instanceGroupName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to orphan persistent disks of instance group %s", instanceGroupName)
+	}
+	if count > 0 {
+		log.WithEvent(bdpl, "OrphanPersistentDisks").Infof(ctx, "Orphaned %d persistent volume claims of instance group '%s', they are deleted after %s", count, instanceGroupName, orphaneddisk.Retention())
+	}
+
+	quarksStatefulSets := &qstsv1a1.QuarksStatefulSetList{}
+	err = c.List(ctx, quarksStatefulSets, client.InNamespace(bdpl.Namespace), client.MatchingLabels{
+		bdv1.LabelDeploymentName:    bdpl.Name,
+		bdv1.LabelInstanceGroupName: instanceGroupName,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list QuarksStatefulSets of instance group '%s'", instanceGroupName)
+	}
+	for i := range quarksStatefulSets.Items {
+		qsts := &quarksStatefulSets.Items[i]
+		if qsts.Labels[bdv1.LabelInstanceGroupName] != instanceGroupName {
+			continue
+		}
+		log.Infof(ctx, "deleting quarksstatefulset '%s'", qsts.Name)
+		if err := c.Delete(ctx, qsts); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete QuarksStatefulSet '%s/%s'", qsts.Namespace, qsts.Name)
+		}
+	}
+
+	if err := deleteInstanceGroupServices(ctx, c, bdpl, instanceGroupName); err != nil {
+		return err
+	}
+	if err := deleteInstanceGroupDisruptionBudgets(ctx, c, bdpl, instanceGroupName); err != nil {
+		return err
+	}
+	if err := deleteInstanceGroupAutoscalers(ctx, c, bdpl, instanceGroupName, nil); err != nil {
+		return err
+	}
+	return deleteInstanceGroupIngresses(ctx, c, bdpl, instanceGroupName)
+}
+
+// migratedClaimName returns the name of the claim, which takes over the
+// volume of the old instance group's claim. Without zones the old stateful
+// set's claims move to the zone of the migrated_from entry, if the instance
+// group uses zones.
+func migratedClaimName(pvcName string, from *bdm.MigratedFrom, ig *bdm.InstanceGroup) (string, error) {
+	name, err := orphaneddisk.ClaimName(pvcName, from.Name, ig.Name)
+	if err != nil {
+		return "", err
+	}
+	if from.Az == "" || len(ig.AZs) == 0 {
+		return name, nil
+	}
+
+	zone := -1
+	for i, az := range ig.AZs {
+		if az == from.Az {
+			zone = i
+			break
+		}
+	}
+	if zone < 0 {
+		return "", errors.Errorf("availability zone '%s' of migrated_from '%s' is not used by instance group '%s'", from.Az, from.Name, ig.Name)
+	}
+
+	// '<ordinal>' or 'z<zone>-<ordinal>'
+	separator := fmt.Sprintf("-pvc-%s-", names.Sanitize(ig.Name))
+	suffix := name[strings.LastIndex(name, separator)+len(separator):]
+	if strings.Contains(suffix, "-") {
+		return name, nil
+	}
+	return fmt.Sprintf("%sz%d-%s", strings.TrimSuffix(name, suffix), zone, suffix), nil
+}
@@ -0,0 +1,57 @@
+package boshdeployment
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/metrics"
+	"code.cloudfoundry.org/quarks-utils/pkg/config"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+	"code.cloudfoundry.org/quarks-utils/pkg/monitorednamespace"
+)
+
+// AddOrphanedDisk creates a new controller, which deletes the orphaned
+// persistent volume claims of removed instance groups after the retention period
+func AddOrphanedDisk(ctx context.Context, config *config.Config, mgr manager.Manager) error {
+	ctx = ctxlog.NewContextWithRecorder(ctx, "orphaned-disk-reconciler", mgr.GetEventRecorderFor("orphaned-disk-recorder"))
+	r := NewOrphanedDiskReconciler(ctx, config, mgr)
+
+	c, err := controller.New("orphaned-disk-controller", mgr, controller.Options{
+		Reconciler: metrics.InstrumentReconciler(metrics.ControllerOrphanedDisk, r),
+	})
+	if err != nil {
+		return errors.Wrap(err, "Adding orphaned disk controller to manager failed.")
+	}
+
+	nsPred := monitorednamespace.NewNSPredicate(ctx, mgr.GetClient(), config.MonitoredID)
+
+	isOrphaned := func(labels map[string]string) bool {
+		_, ok := labels[bdv1.LabelOrphanedDisk]
+		return ok
+	}
+	p := predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return isOrphaned(e.Meta.GetLabels())
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return isOrphaned(e.MetaNew.GetLabels())
+		},
+		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+	}
+	err = c.Watch(&source.Kind{Type: &corev1.PersistentVolumeClaim{}}, &handler.EnqueueRequestForObject{}, nsPred, p)
+	if err != nil {
+		return errors.Wrapf(err, "Watching persistent volume claims failed in orphaned disk controller.")
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package boshdeployment
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/orphaneddisk"
+	qstsv1a1 "code.cloudfoundry.org/quarks-statefulset/pkg/kube/apis/quarksstatefulset/v1alpha1"
+	"code.cloudfoundry.org/quarks-utils/pkg/config"
+	log "code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+)
+
+// NewOrphanedDiskReconciler returns a new reconciler, which deletes orphaned
+// persistent volume claims after the retention period
+func NewOrphanedDiskReconciler(ctx context.Context, config *config.Config, mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileOrphanedDisk{
+		ctx:    ctx,
+		config: config,
+		client: mgr.GetClient(),
+	}
+}
+
+// ReconcileOrphanedDisk reconciles orphaned persistent volume claims
+type ReconcileOrphanedDisk struct {
+	ctx    context.Context
+	client client.Client
+	config *config.Config
+}
+
+// Reconcile deletes an orphaned persistent volume claim, once its retention
+// period is over, and requeues it until then. Claims of instance groups, which
+// were added to the deployment again, are no longer orphaned.
+func (r *ReconcileOrphanedDisk) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	// Set the ctx to be Background, as the top-level context for incoming requests.
+	ctx, cancel := context.WithTimeout(r.ctx, r.config.CtxTimeOut)
+	defer cancel()
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	err := r.client.Get(ctx, request.NamespacedName, pvc)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Debug(ctx, "Skip reconcile: persistent volume claim not found")
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	if !orphaneddisk.IsOrphaned(pvc) {
+		return reconcile.Result{}, nil
+	}
+
+	deploymentName := pvc.Labels[bdv1.LabelDeploymentName]
+	instanceGroupName := pvc.Labels[bdv1.LabelInstanceGroupName]
+	qstsList := &qstsv1a1.QuarksStatefulSetList{}
+	err = r.client.List(ctx, qstsList, client.InNamespace(pvc.Namespace), client.MatchingLabels{
+		bdv1.LabelDeploymentName:    deploymentName,
+		bdv1.LabelInstanceGroupName: instanceGroupName,
+	})
+	if err != nil {
+		return reconcile.Result{}, log.WithEvent(pvc, "ListError").Errorf(ctx, "Failed to list QuarksStatefulSets of instance group '%s': %v", instanceGroupName, err)
+	}
+	if len(qstsList.Items) > 0 {
+		log.Infof(ctx, "Instance group '%s' of orphaned persistent volume claim '%s' exists again", instanceGroupName, request.NamespacedName)
+		err := orphaneddisk.Adopt(ctx, r.client, pvc.Namespace, deploymentName, instanceGroupName)
+		return reconcile.Result{}, err
+	}
+
+	expiresAt, ok := orphaneddisk.ExpiresAt(pvc)
+	if !ok {
+		log.WithEvent(pvc, "OrphanedAtMissing").Infof(ctx, "Orphaned persistent volume claim '%s' has no valid '%s' annotation, keeping it", request.NamespacedName, bdv1.AnnotationOrphanedAt)
+		return reconcile.Result{}, nil
+	}
+
+	if remaining := time.Until(expiresAt); remaining > 0 {
+		log.Debugf(ctx, "Orphaned persistent volume claim '%s' expires in %s", request.NamespacedName, remaining)
+		return reconcile.Result{RequeueAfter: remaining}, nil
+	}
+
+	log.WithEvent(pvc, "DeleteOrphanedDisk").Infof(ctx, "Deleting orphaned persistent volume claim '%s', its retention period ended at %s", request.NamespacedName, expiresAt.Format(time.RFC3339))
+	err = r.client.Delete(ctx, pvc)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return reconcile.Result{}, log.WithEvent(pvc, "DeleteError").Errorf(ctx, "Failed to delete orphaned persistent volume claim '%s': %v", request.NamespacedName, err)
+	}
+
+	return reconcile.Result{}, nil
+}
@@ -0,0 +1,149 @@
+package boshdeployment_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	crc "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/controllers"
+	cfd "code.cloudfoundry.org/quarks-operator/pkg/kube/controllers/boshdeployment"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/controllers/fakes"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/orphaneddisk"
+	qstsv1a1 "code.cloudfoundry.org/quarks-statefulset/pkg/kube/apis/quarksstatefulset/v1alpha1"
+	cfcfg "code.cloudfoundry.org/quarks-utils/pkg/config"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+	helper "code.cloudfoundry.org/quarks-utils/testing/testhelper"
+)
+
+var _ = Describe("ReconcileOrphanedDisk", func() {
+	var (
+		ctx        context.Context
+		manager    *fakes.FakeManager
+		client     crc.Client
+		reconciler reconcile.Reconciler
+		request    reconcile.Request
+		pvc        *corev1.PersistentVolumeClaim
+		objects    []runtime.Object
+	)
+
+	BeforeEach(func() {
+		_, log := helper.NewTestLogger()
+		ctx = ctxlog.NewParentContext(log)
+
+		pvc = &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "nats-pvc-nats-0",
+				Namespace: "default",
+				Labels: map[string]string{
+					bdv1.LabelDeploymentName:    "foo",
+					bdv1.LabelInstanceGroupName: "nats",
+					bdv1.LabelOrphanedDisk:      "true",
+				},
+				Annotations: map[string]string{
+					bdv1.AnnotationOrphanedAt: time.Now().Add(-time.Hour).UTC().Format(time.RFC3339),
+				},
+			},
+		}
+		objects = []runtime.Object{}
+		request = reconcile.Request{NamespacedName: types.NamespacedName{Name: "nats-pvc-nats-0", Namespace: "default"}}
+	})
+
+	JustBeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(controllers.AddToScheme(scheme)).To(Succeed())
+		client = fake.NewFakeClientWithScheme(scheme, append(objects, pvc)...)
+
+		manager = &fakes.FakeManager{}
+		manager.GetClientReturns(client)
+		reconciler = cfd.NewOrphanedDiskReconciler(ctx, &cfcfg.Config{CtxTimeOut: 10 * time.Second}, manager)
+	})
+
+	AfterEach(func() {
+		orphaneddisk.SetRetention(orphaneddisk.DefaultRetention)
+	})
+
+	It("requeues the claim until the retention period is over", func() {
+		result, err := reconciler.Reconcile(request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeNumerically("~", orphaneddisk.DefaultRetention-time.Hour, time.Minute))
+
+		Expect(client.Get(ctx, request.NamespacedName, &corev1.PersistentVolumeClaim{})).To(Succeed())
+	})
+
+	It("deletes the claim after the retention period", func() {
+		orphaneddisk.SetRetention(time.Minute)
+
+		result, err := reconciler.Reconcile(request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(reconcile.Result{}))
+
+		err = client.Get(ctx, request.NamespacedName, &corev1.PersistentVolumeClaim{})
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("when the instance group of a claim created by its stateful set was removed", func() {
+		BeforeEach(func() {
+			pvc.Labels = map[string]string{qstsv1a1.LabelQStsName: "nats"}
+			pvc.Annotations = nil
+		})
+
+		JustBeforeEach(func() {
+			count, err := orphaneddisk.Orphan(ctx, client, "default", "foo", "nats")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(1))
+		})
+
+		It("requeues the claim for the whole retention period", func() {
+			result, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically("~", orphaneddisk.DefaultRetention, time.Minute))
+		})
+
+		It("deletes the claim after the retention period", func() {
+			orphaneddisk.SetRetention(0)
+
+			_, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = client.Get(ctx, request.NamespacedName, &corev1.PersistentVolumeClaim{})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when the instance group was added again", func() {
+		BeforeEach(func() {
+			objects = append(objects, &qstsv1a1.QuarksStatefulSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "nats",
+					Namespace: "default",
+					Labels: map[string]string{
+						bdv1.LabelDeploymentName:    "foo",
+						bdv1.LabelInstanceGroupName: "nats",
+					},
+				},
+			})
+			orphaneddisk.SetRetention(time.Minute)
+		})
+
+		It("keeps the claim and removes the orphaned mark", func() {
+			_, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+
+			adopted := &corev1.PersistentVolumeClaim{}
+			Expect(client.Get(ctx, request.NamespacedName, adopted)).To(Succeed())
+			Expect(orphaneddisk.IsOrphaned(adopted)).To(BeFalse())
+		})
+	})
+})
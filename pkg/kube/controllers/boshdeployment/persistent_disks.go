@@ -0,0 +1,137 @@
+package boshdeployment
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/orphaneddisk"
+	qstsv1a1 "code.cloudfoundry.org/quarks-statefulset/pkg/kube/apis/quarksstatefulset/v1alpha1"
+	log "code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+)
+
+// resizePersistentDisks expands the persistent volume claims of an instance
+// group, if the size of their volume claim template grew. StatefulSets don't
+// update existing claims, when their templates change.
+func resizePersistentDisks(ctx context.Context, c client.Client, bdpl *bdv1.BOSHDeployment, qSts *qstsv1a1.QuarksStatefulSet) error {
+	pvcs, err := listPersistentVolumeClaims(ctx, c, bdpl.Namespace, bdpl.Name, qSts.Labels[bdv1.LabelInstanceGroupName])
+	if err != nil {
+		return err
+	}
+
+	for i := range pvcs {
+		pvc := &pvcs[i]
+		size, found := claimTemplateSize(qSts, pvc.Name)
+		if !found {
+			continue
+		}
+
+		requested := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		if size.Cmp(requested) <= 0 {
+			continue
+		}
+
+		allowed, err := volumeExpansionAllowed(ctx, c, pvc)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			log.WithEvent(bdpl, "ResizeNotSupported").Infof(ctx, "Can't resize persistent volume claim '%s/%s' to %s, its storage class doesn't allow volume expansion", pvc.Namespace, pvc.Name, size.String())
+			continue
+		}
+
+		pvc.Spec.Resources.Requests[corev1.ResourceStorage] = size
+		if err := c.Update(ctx, pvc); err != nil {
+			return errors.Wrapf(err, "failed to resize persistent volume claim '%s/%s'", pvc.Namespace, pvc.Name)
+		}
+		log.WithEvent(bdpl, "ResizePersistentDisk").Infof(ctx, "Resizing persistent volume claim '%s/%s' from %s to %s", pvc.Namespace, pvc.Name, requested.String(), size.String())
+	}
+
+	return nil
+}
+
+// persistentDisksNotShrunk verifies that the volume claim templates of the
+// interpolated manifest are not smaller than the existing claims. The
+// validating webhook only sees the manifest before variables are interpolated.
+func persistentDisksNotShrunk(ctx context.Context, c client.Client, bdpl *bdv1.BOSHDeployment, qSts *qstsv1a1.QuarksStatefulSet) error {
+	pvcs, err := listPersistentVolumeClaims(ctx, c, bdpl.Namespace, bdpl.Name, qSts.Labels[bdv1.LabelInstanceGroupName])
+	if err != nil {
+		return err
+	}
+
+	for _, pvc := range pvcs {
+		size, found := claimTemplateSize(qSts, pvc.Name)
+		if !found {
+			continue
+		}
+
+		requested := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		if size.Cmp(requested) < 0 {
+			return errors.Errorf("persistent volume claim '%s/%s' can't be shrunk from %s to %s", pvc.Namespace, pvc.Name, requested.String(), size.String())
+		}
+	}
+
+	return nil
+}
+
+// listPersistentVolumeClaims lists the claims of a deployment. The claim
+// templates of the instance groups are labeled with deployment and instance
+// group name. All instance groups are listed, if none is given.
+// Orphaned claims of removed instance groups are skipped.
+func listPersistentVolumeClaims(ctx context.Context, c client.Client, namespace string, deploymentName string, instanceGroupName string) ([]corev1.PersistentVolumeClaim, error) {
+	labels := client.MatchingLabels{bdv1.LabelDeploymentName: deploymentName}
+	if instanceGroupName != "" {
+		labels[bdv1.LabelInstanceGroupName] = instanceGroupName
+	}
+
+	list := &corev1.PersistentVolumeClaimList{}
+	err := c.List(ctx, list, client.InNamespace(namespace), labels)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list persistent volume claims of deployment '%s/%s'", namespace, deploymentName)
+	}
+
+	pvcs := []corev1.PersistentVolumeClaim{}
+	for _, pvc := range list.Items {
+		if !orphaneddisk.IsOrphaned(&pvc) {
+			pvcs = append(pvcs, pvc)
+		}
+	}
+	return pvcs, nil
+}
+
+// claimTemplateSize returns the size of the volume claim template, which the
+// claim was created from. Claims are named '<template>-<statefulset>-<ordinal>'.
+func claimTemplateSize(qSts *qstsv1a1.QuarksStatefulSet, pvcName string) (resource.Quantity, bool) {
+	for _, template := range qSts.Spec.Template.Spec.VolumeClaimTemplates {
+		if strings.HasPrefix(pvcName, template.Name+"-"+qSts.Name+"-") {
+			size, ok := template.Spec.Resources.Requests[corev1.ResourceStorage]
+			return size, ok
+		}
+	}
+	return resource.Quantity{}, false
+}
+
+// volumeExpansionAllowed returns true if the storage class of the claim allows volume expansion
+func volumeExpansionAllowed(ctx context.Context, c client.Client, pvc *corev1.PersistentVolumeClaim) (bool, error) {
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return false, nil
+	}
+
+	sc := &storagev1.StorageClass{}
+	err := c.Get(ctx, types.NamespacedName{Name: *pvc.Spec.StorageClassName}, sc)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get storage class '%s'", *pvc.Spec.StorageClassName)
+	}
+	return sc.AllowVolumeExpansion != nil && *sc.AllowVolumeExpansion, nil
+}
@@ -5,6 +5,7 @@ import (
 
 	qjv1a1 "code.cloudfoundry.org/quarks-job/pkg/kube/apis/quarksjob/v1alpha1"
 	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/metrics"
 
 	qstsv1a1 "code.cloudfoundry.org/quarks-statefulset/pkg/kube/apis/quarksstatefulset/v1alpha1"
 	"code.cloudfoundry.org/quarks-utils/pkg/config"
@@ -12,11 +13,16 @@ import (
 	"code.cloudfoundry.org/quarks-utils/pkg/monitorednamespace"
 
 	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1b1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
@@ -25,10 +31,12 @@ func AddBDPLStatusReconcilers(ctx context.Context, config *config.Config, mgr ma
 	ctx = ctxlog.NewContextWithRecorder(ctx, "quarks-bdpl-status-reconciler", mgr.GetEventRecorderFor("quarks-bdpl-status-recorder"))
 	r := NewStatusQSTSReconciler(ctx, config, mgr)
 	rjobs := NewQJobStatusReconciler(ctx, config, mgr)
+	rcron := NewCronJobStatusReconciler(ctx, config, mgr)
+	rpvcs := NewPVCStatusReconciler(ctx, config, mgr)
 
 	// Create a new controller for qsts
 	c, err := controller.New("quarks-bdpl-qsts-status-controller", mgr, controller.Options{
-		Reconciler:              r,
+		Reconciler:              metrics.InstrumentReconciler(metrics.ControllerStatus, r),
 		MaxConcurrentReconciles: config.MaxQuarksStatefulSetWorkers,
 	})
 	if err != nil {
@@ -37,13 +45,29 @@ func AddBDPLStatusReconcilers(ctx context.Context, config *config.Config, mgr ma
 
 	// Create a new controller for qjobs
 	cjobs, err := controller.New("quarks-bdpl-qjobs-status-controller", mgr, controller.Options{
-		Reconciler:              rjobs,
+		Reconciler:              metrics.InstrumentReconciler(metrics.ControllerStatus, rjobs),
 		MaxConcurrentReconciles: config.MaxQuarksStatefulSetWorkers,
 	})
 	if err != nil {
 		return errors.Wrap(err, "Adding StatusQJobsReconciler controller to manager failed.")
 	}
 
+	// Create a new controller for the cron jobs of scheduled errands
+	ccron, err := controller.New("quarks-bdpl-cronjobs-status-controller", mgr, controller.Options{
+		Reconciler: metrics.InstrumentReconciler(metrics.ControllerStatus, rcron),
+	})
+	if err != nil {
+		return errors.Wrap(err, "Adding StatusCronJobsReconciler controller to manager failed.")
+	}
+
+	// Create a new controller for the persistent volume claims of instance groups
+	cpvcs, err := controller.New("quarks-bdpl-pvcs-status-controller", mgr, controller.Options{
+		Reconciler: metrics.InstrumentReconciler(metrics.ControllerStatus, rpvcs),
+	})
+	if err != nil {
+		return errors.Wrap(err, "Adding StatusPVCsReconciler controller to manager failed.")
+	}
+
 	nsPred := monitorednamespace.NewNSPredicate(ctx, mgr.GetClient(), config.MonitoredID)
 
 	p := predicate.Funcs{
@@ -67,5 +91,46 @@ func AddBDPLStatusReconcilers(ctx context.Context, config *config.Config, mgr ma
 		return errors.Wrapf(err, "Watching QJobs in QuarksBDPLStatus controller failed.")
 	}
 
+	err = ccron.Watch(&source.Kind{Type: &batchv1b1.CronJob{}}, &handler.EnqueueRequestForObject{}, nsPred, p)
+	if err != nil {
+		return errors.Wrapf(err, "Watching CronJobs in QuarksBDPLStatus controller failed.")
+	}
+
+	// The jobs of a scheduled errand's QuarksJob report its result, the
+	// cron job has the name of the QuarksJob
+	qJobPred := predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			_, ok := e.Meta.GetLabels()[qjv1a1.LabelQJobName]
+			return ok
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			_, ok := e.MetaNew.GetLabels()[qjv1a1.LabelQJobName]
+			return ok
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			_, ok := e.Meta.GetLabels()[qjv1a1.LabelQJobName]
+			return ok
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+	err = ccron.Watch(&source.Kind{Type: &batchv1.Job{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+			return []reconcile.Request{{
+				NamespacedName: types.NamespacedName{Namespace: a.Meta.GetNamespace(), Name: a.Meta.GetLabels()[qjv1a1.LabelQJobName]},
+			}}
+		}),
+	}, nsPred, qJobPred)
+	if err != nil {
+		return errors.Wrapf(err, "Watching Jobs in QuarksBDPLStatus controller failed.")
+	}
+
+	// Persistent volume claims report the progress of a disk resize
+	err = cpvcs.Watch(&source.Kind{Type: &corev1.PersistentVolumeClaim{}}, &handler.EnqueueRequestForObject{}, nsPred, p)
+	if err != nil {
+		return errors.Wrapf(err, "Watching PVCs in QuarksBDPLStatus controller failed.")
+	}
+
 	return nil
 }
@@ -2,16 +2,25 @@ package boshdeployment
 
 import (
 	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 
 	qjv1a1 "code.cloudfoundry.org/quarks-job/pkg/kube/apis/quarksjob/v1alpha1"
 
 	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/reference"
 	qstsv1a1 "code.cloudfoundry.org/quarks-statefulset/pkg/kube/apis/quarksstatefulset/v1alpha1"
+	qstscontroller "code.cloudfoundry.org/quarks-statefulset/pkg/kube/controllers/quarksstatefulset"
 	"code.cloudfoundry.org/quarks-utils/pkg/config"
 	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
 
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1b1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -49,6 +58,26 @@ func NewQJobStatusReconciler(ctx context.Context, config *config.Config, mgr man
 	}
 }
 
+// NewCronJobStatusReconciler returns a new reconcile.Reconciler for the status of scheduled errands
+func NewCronJobStatusReconciler(ctx context.Context, config *config.Config, mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileBoshDeploymentCronJobStatus{
+		ctx:    ctx,
+		config: config,
+		client: mgr.GetClient(),
+		scheme: mgr.GetScheme(),
+	}
+}
+
+// NewPVCStatusReconciler returns a new reconcile.Reconciler for the status of persistent disks
+func NewPVCStatusReconciler(ctx context.Context, config *config.Config, mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileBoshDeploymentPVCStatus{
+		ctx:    ctx,
+		config: config,
+		client: mgr.GetClient(),
+		scheme: mgr.GetScheme(),
+	}
+}
+
 // ReconcileBoshDeploymentQSTSStatus reconciles an QuarksStatefulSet object for its status
 type ReconcileBoshDeploymentQSTSStatus struct {
 	ctx    context.Context
@@ -65,60 +94,31 @@ type ReconcileBoshDeploymentQJobStatus struct {
 	config *config.Config
 }
 
+// ReconcileBoshDeploymentCronJobStatus reconciles a CronJob object of a scheduled errand for its status
+type ReconcileBoshDeploymentCronJobStatus struct {
+	ctx    context.Context
+	client client.Client
+	scheme *runtime.Scheme
+	config *config.Config
+}
+
+// ReconcileBoshDeploymentPVCStatus reconciles a PersistentVolumeClaim object of an instance group for its status
+type ReconcileBoshDeploymentPVCStatus struct {
+	ctx    context.Context
+	client client.Client
+	scheme *runtime.Scheme
+	config *config.Config
+}
+
 // Reconcile reads that state of QuarksJobs and QuarksStatefulSets and updates the bosh deployment status accordingly.
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileBoshDeploymentQJobStatus) Reconcile(request reconcile.Request) (reconcile.Result, error) {
-
-	// Fetch the QuarksStatefulSet we need to reconcile
-	qJob := &qjv1a1.QuarksJob{}
-
 	// Set the ctx to be Background, as the top-level context for incoming requests.
 	ctx, cancel := context.WithTimeout(r.ctx, r.config.CtxTimeOut)
 	defer cancel()
 
-	ctxlog.Info(ctx, "Reconciling Bosh Deployment from qjob ", request.NamespacedName)
-	err := r.client.Get(ctx, request.NamespacedName, qJob)
-	if err != nil {
-		if apierrors.IsNotFound(err) {
-			// Request object not found, could have been deleted after reconcile request.
-			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
-			// Return and don't requeue
-			ctxlog.Debug(ctx, "Skip qJob reconcile: qJob not found")
-			return reconcile.Result{}, nil
-		}
-
-		// Error reading the object - requeue the request.
-		return reconcile.Result{}, err
-	}
-	deploymentName, ok := qJob.GetLabels()[bdv1.LabelDeploymentName]
-	if !ok {
-		ctxlog.WithEvent(qJob, "LabelMissingError").Infof(ctx, "There's no label for a BoshDeployment name on the qjob '%s'", request.NamespacedName)
-		return reconcile.Result{Requeue: false}, nil
-	}
-	bdpl := &bdv1.BOSHDeployment{}
-	err = r.client.Get(ctx, types.NamespacedName{Namespace: request.Namespace, Name: deploymentName}, bdpl)
-	if err != nil {
-		return reconcile.Result{Requeue: false},
-			ctxlog.WithEvent(qJob, "GetBOSHDeployment").Errorf(ctx, "Failed to get BoshDeployment instance '%s/%s': %v", request.Namespace, deploymentName, err)
-	}
-
-	toUpdate, err := resolveDeploymentState(r.ctx, r.client, bdpl)
-	if err != nil {
-		return reconcile.Result{Requeue: false}, err
-	}
-
-	if toUpdate {
-		now := metav1.Now()
-		bdpl.Status.StateTimestamp = &now
-
-		err = r.client.Status().Update(ctx, bdpl)
-		if err != nil {
-			return reconcile.Result{Requeue: false}, ctxlog.WithEvent(bdpl, "UpdateStatusError").Errorf(ctx, "Failed to update status on BDPL '%s' (%v): %s", request.NamespacedName, bdpl.ResourceVersion, err)
-		}
-	}
-
-	return reconcile.Result{}, nil
+	return reconcileDeploymentStatus(ctx, r.client, request, &qjv1a1.QuarksJob{}, "qjob")
 }
 
 // Reconcile reads that state of QuarksJobs and QuarksStatefulSets and updates the bosh deployment status accordingly.
@@ -127,22 +127,43 @@ func (r *ReconcileBoshDeploymentQJobStatus) Reconcile(request reconcile.Request)
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileBoshDeploymentQSTSStatus) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	// Set the ctx to be Background, as the top-level context for incoming requests.
+	ctx, cancel := context.WithTimeout(r.ctx, r.config.CtxTimeOut)
+	defer cancel()
+
+	return reconcileDeploymentStatus(ctx, r.client, request, &qstsv1a1.QuarksStatefulSet{}, "QuarksStatefulSet")
+}
+
+// Reconcile reads the state of a scheduled errand's CronJob and its jobs and updates the bosh deployment status accordingly.
+func (r *ReconcileBoshDeploymentCronJobStatus) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	// Set the ctx to be Background, as the top-level context for incoming requests.
+	ctx, cancel := context.WithTimeout(r.ctx, r.config.CtxTimeOut)
+	defer cancel()
 
-	// Fetch the QuarksStatefulSet we need to reconcile
-	qStatefulSet := &qstsv1a1.QuarksStatefulSet{}
+	return reconcileDeploymentStatus(ctx, r.client, request, &batchv1b1.CronJob{}, "cron job")
+}
 
+// Reconcile reads the state of a persistent volume claim and updates the persistent disk status of the bosh deployment accordingly.
+func (r *ReconcileBoshDeploymentPVCStatus) Reconcile(request reconcile.Request) (reconcile.Result, error) {
 	// Set the ctx to be Background, as the top-level context for incoming requests.
 	ctx, cancel := context.WithTimeout(r.ctx, r.config.CtxTimeOut)
 	defer cancel()
 
-	ctxlog.Info(ctx, "Reconciling QuarksStatefulSet ", request.NamespacedName)
-	err := r.client.Get(ctx, request.NamespacedName, qStatefulSet)
+	return reconcileDeploymentStatus(ctx, r.client, request, &corev1.PersistentVolumeClaim{}, "persistent volume claim")
+}
+
+// reconcileDeploymentStatus fetches an object of a deployment and updates the
+// status of the BOSHDeployment, which the object is labeled with. It's shared
+// by the status reconcilers of the different kinds of objects.
+func reconcileDeploymentStatus(ctx context.Context, c client.Client, request reconcile.Request, obj runtime.Object, kind string) (reconcile.Result, error) {
+	ctxlog.Infof(ctx, "Reconciling Bosh Deployment from %s '%s'", kind, request.NamespacedName)
+	err := c.Get(ctx, request.NamespacedName, obj)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
-			ctxlog.Debug(ctx, "Skip QuarksStatefulSet reconcile: QuarksStatefulSet not found")
+			ctxlog.Debugf(ctx, "Skip %s reconcile: %s not found", kind, kind)
 			return reconcile.Result{}, nil
 		}
 
@@ -150,20 +171,24 @@ func (r *ReconcileBoshDeploymentQSTSStatus) Reconcile(request reconcile.Request)
 		return reconcile.Result{}, err
 	}
 
-	deploymentName, ok := qStatefulSet.GetLabels()[bdv1.LabelDeploymentName]
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	deploymentName, ok := m.GetLabels()[bdv1.LabelDeploymentName]
 	if !ok {
-		ctxlog.WithEvent(qStatefulSet, "LabelMissingError").Infof(ctx, "There's no label for a BoshDeployment name on the QSTS '%s'", request.NamespacedName)
+		ctxlog.WithEvent(obj, "LabelMissingError").Infof(ctx, "There's no label for a BoshDeployment name on the %s '%s'", kind, request.NamespacedName)
 		return reconcile.Result{Requeue: false}, nil
 	}
 
 	bdpl := &bdv1.BOSHDeployment{}
-	err = r.client.Get(ctx, types.NamespacedName{Namespace: request.Namespace, Name: deploymentName}, bdpl)
+	err = c.Get(ctx, types.NamespacedName{Namespace: request.Namespace, Name: deploymentName}, bdpl)
 	if err != nil {
 		return reconcile.Result{Requeue: false},
-			ctxlog.WithEvent(qStatefulSet, "GetBOSHDeployment").Errorf(ctx, "Failed to get BoshDeployment instance '%s/%s': %v", request.Namespace, deploymentName, err)
+			ctxlog.WithEvent(obj, "GetBOSHDeployment").Errorf(ctx, "Failed to get BoshDeployment instance '%s/%s': %v", request.Namespace, deploymentName, err)
 	}
 
-	toUpdate, err := resolveDeploymentState(r.ctx, r.client, bdpl)
+	toUpdate, err := resolveDeploymentState(ctx, c, bdpl)
 	if err != nil {
 		return reconcile.Result{Requeue: false}, err
 	}
@@ -171,9 +196,9 @@ func (r *ReconcileBoshDeploymentQSTSStatus) Reconcile(request reconcile.Request)
 	if toUpdate {
 		now := metav1.Now()
 		bdpl.Status.StateTimestamp = &now
-		err = r.client.Status().Update(ctx, bdpl)
+		err = c.Status().Update(ctx, bdpl)
 		if err != nil {
-			return reconcile.Result{Requeue: false}, ctxlog.WithEvent(bdpl, "UpdateStatusError").Errorf(ctx, "Failed to update status on BDPL '%s' (%v): %s", request.NamespacedName, bdpl.ResourceVersion, err)
+			return reconcile.Result{Requeue: false}, ctxlog.WithEvent(bdpl, "UpdateStatusError").Errorf(ctx, "Failed to update status on BDPL '%s' (%v): %s", bdpl.GetNamespacedName(), bdpl.ResourceVersion, err)
 		}
 	}
 
@@ -262,5 +287,307 @@ func resolveDeploymentState(ctx context.Context, client client.Client, bdpl *bdv
 		bdpl.Status.State = BDPLStateDeployed
 		toUpdate = true
 	}
+
+	igs, err := instanceGroupStatus(ctx, client, bdpl)
+	if err != nil {
+		return toUpdate, ctxlog.WithEvent(bdpl, "UpdateStatusError").Errorf(ctx, "Failed to get instance group status of BDPL (%v): %s", bdpl.Name, err)
+	}
+	if !reflect.DeepEqual(bdpl.Status.InstanceGroups, igs) {
+		bdpl.Status.InstanceGroups = igs
+		toUpdate = true
+	}
+
+	errands, err := scheduledErrandStatus(ctx, client, bdpl)
+	if err != nil {
+		return toUpdate, ctxlog.WithEvent(bdpl, "UpdateStatusError").Errorf(ctx, "Failed to get scheduled errand status of BDPL (%v): %s", bdpl.Name, err)
+	}
+	if !reflect.DeepEqual(bdpl.Status.ScheduledErrands, errands) {
+		bdpl.Status.ScheduledErrands = errands
+		toUpdate = true
+	}
+
+	disks, err := persistentDiskStatus(ctx, client, bdpl)
+	if err != nil {
+		return toUpdate, ctxlog.WithEvent(bdpl, "UpdateStatusError").Errorf(ctx, "Failed to get persistent disk status of BDPL (%v): %s", bdpl.Name, err)
+	}
+	if !reflect.DeepEqual(bdpl.Status.PersistentDisks, disks) {
+		bdpl.Status.PersistentDisks = disks
+		toUpdate = true
+	}
+
+	if updateConditions(&bdpl.Status, deployedState) {
+		toUpdate = true
+	}
+
 	return toUpdate, nil
 }
+
+// instanceGroupStatus returns the status of all instance groups of the
+// deployment, sorted by name. The last error is kept from the current status,
+// as it's set by the BPM reconciler, also for instance groups without a
+// QuarksStatefulSet.
+func instanceGroupStatus(ctx context.Context, c client.Client, bdpl *bdv1.BOSHDeployment) ([]bdv1.InstanceGroupStatus, error) {
+	list := &qstsv1a1.QuarksStatefulSetList{}
+	err := c.List(ctx, list, client.InNamespace(bdpl.Namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	var result []bdv1.InstanceGroupStatus
+	found := map[string]bool{}
+	for i := range list.Items {
+		qsts := &list.Items[i]
+		if qsts.GetLabels()[bdv1.LabelDeploymentName] != bdpl.Name {
+			continue
+		}
+
+		name := qsts.GetLabels()[bdv1.LabelInstanceGroupName]
+		if name == "" {
+			name = qsts.Name
+		}
+
+		replicas := int32(1)
+		if qsts.Spec.Template.Spec.Replicas != nil {
+			replicas = *qsts.Spec.Template.Spec.Replicas
+		}
+		if len(qsts.Spec.Zones) > 0 {
+			replicas = replicas * int32(len(qsts.Spec.Zones))
+		}
+
+		statefulSets, version, err := qstscontroller.GetMaxStatefulSetVersion(ctx, c, qsts)
+		if err != nil {
+			return nil, err
+		}
+		ready := int32(0)
+		for _, sts := range statefulSets {
+			ready += sts.Status.ReadyReplicas
+		}
+
+		ig := bdv1.InstanceGroupStatus{
+			Name:          name,
+			Replicas:      replicas,
+			ReadyReplicas: ready,
+			Version:       version,
+		}
+		if current := bdpl.Status.GetInstanceGroup(name); current != nil {
+			ig.LastError = current.LastError
+		}
+		result = append(result, ig)
+		found[name] = true
+	}
+
+	// the first deployment of an instance group failed before its
+	// QuarksStatefulSet was created
+	for _, current := range bdpl.Status.InstanceGroups {
+		if current.LastError != "" && !found[current.Name] {
+			result = append(result, bdv1.InstanceGroupStatus{Name: current.Name, LastError: current.LastError})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+
+	return result, nil
+}
+
+// scheduledErrandStatus returns the status of all errands of the deployment,
+// which run on a cron schedule, sorted by name. The cron jobs trigger the
+// errands' QuarksJobs, so active runs and the last result are taken from the
+// jobs of the QuarksJob, which has the name of the cron job.
+func scheduledErrandStatus(ctx context.Context, c client.Client, bdpl *bdv1.BOSHDeployment) ([]bdv1.ScheduledErrandStatus, error) {
+	cronJobs := &batchv1b1.CronJobList{}
+	err := c.List(ctx, cronJobs, client.InNamespace(bdpl.Namespace), client.MatchingLabels{bdv1.LabelDeploymentName: bdpl.Name})
+	if err != nil {
+		return nil, err
+	}
+	if len(cronJobs.Items) == 0 {
+		return nil, nil
+	}
+
+	var result []bdv1.ScheduledErrandStatus
+	for _, cronJob := range cronJobs.Items {
+		name := cronJob.GetLabels()[bdv1.LabelInstanceGroupName]
+		if name == "" {
+			name = cronJob.Name
+		}
+
+		errand := bdv1.ScheduledErrandStatus{
+			Name:             name,
+			Schedule:         cronJob.Spec.Schedule,
+			LastScheduleTime: cronJob.Status.LastScheduleTime,
+		}
+
+		jobs := &batchv1.JobList{}
+		err = c.List(ctx, jobs, client.InNamespace(bdpl.Namespace), client.MatchingLabels{qjv1a1.LabelQJobName: cronJob.Name})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, job := range jobs.Items {
+			phase, finished := jobResult(job)
+			if finished == nil {
+				errand.Active++
+				continue
+			}
+			if errand.LastCompletionTime == nil || errand.LastCompletionTime.Before(finished) {
+				errand.LastCompletionTime = finished
+				errand.LastResult = phase
+			}
+		}
+
+		result = append(result, errand)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+
+	return result, nil
+}
+
+// jobResult returns the phase of a finished job and the time it finished, or
+// nil if the job is still running
+func jobResult(job batchv1.Job) (bdv1.ErrandRunPhase, *metav1.Time) {
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			t := c.LastTransitionTime
+			return bdv1.ErrandRunSucceeded, &t
+		case batchv1.JobFailed:
+			t := c.LastTransitionTime
+			return bdv1.ErrandRunFailed, &t
+		}
+	}
+	return "", nil
+}
+
+// persistentDiskStatus returns the status of the persistent volume claims of
+// the deployment, sorted by name. A claim is resizing until its capacity
+// matches the size of the volume claim template it was created from.
+func persistentDiskStatus(ctx context.Context, c client.Client, bdpl *bdv1.BOSHDeployment) ([]bdv1.PersistentDiskStatus, error) {
+	pvcs, err := listPersistentVolumeClaims(ctx, c, bdpl.Namespace, bdpl.Name, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(pvcs) == 0 {
+		return nil, nil
+	}
+
+	list := &qstsv1a1.QuarksStatefulSetList{}
+	err = c.List(ctx, list, client.InNamespace(bdpl.Namespace), client.MatchingLabels{bdv1.LabelDeploymentName: bdpl.Name})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []bdv1.PersistentDiskStatus
+	for i := range pvcs {
+		pvc := &pvcs[i]
+		igName := pvc.GetLabels()[bdv1.LabelInstanceGroupName]
+
+		requested := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		capacity := pvc.Status.Capacity[corev1.ResourceStorage]
+		size := requested
+		for j := range list.Items {
+			if list.Items[j].GetLabels()[bdv1.LabelInstanceGroupName] != igName {
+				continue
+			}
+			if s, found := claimTemplateSize(&list.Items[j], pvc.Name); found {
+				size = s
+				break
+			}
+		}
+
+		phase := bdv1.PersistentDiskReady
+		switch {
+		case size.Cmp(requested) > 0:
+			allowed, err := volumeExpansionAllowed(ctx, c, pvc)
+			if err != nil {
+				return nil, err
+			}
+			phase = bdv1.PersistentDiskResizeNotSupported
+			if allowed {
+				phase = bdv1.PersistentDiskResizing
+			}
+		case pvc.Status.Phase == corev1.ClaimBound && requested.Cmp(capacity) > 0, resizeInProgress(pvc):
+			phase = bdv1.PersistentDiskResizing
+		}
+
+		disk := bdv1.PersistentDiskStatus{
+			Name:          pvc.Name,
+			InstanceGroup: igName,
+			Size:          size.String(),
+			Phase:         phase,
+		}
+		if !capacity.IsZero() {
+			disk.Capacity = capacity.String()
+		}
+		result = append(result, disk)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+
+	return result, nil
+}
+
+// resizeInProgress returns true if the claim has a condition, which is set
+// while the volume or its file system are expanded
+func resizeInProgress(pvc *corev1.PersistentVolumeClaim) bool {
+	for _, c := range pvc.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		if c.Type == corev1.PersistentVolumeClaimResizing || c.Type == corev1.PersistentVolumeClaimFileSystemResizePending {
+			return true
+		}
+	}
+	return false
+}
+
+// updateConditions computes the InstanceGroupsResolved, Deployed and Degraded
+// conditions from the counters and instance group status. Returns true if any
+// condition changed.
+func updateConditions(status *bdv1.BOSHDeploymentStatus, deployedState bool) bool {
+	changed := false
+
+	if status.TotalJobCount > 0 {
+		if status.CompletedJobCount == status.TotalJobCount {
+			changed = status.SetCondition(bdv1.InstanceGroupsResolved, corev1.ConditionTrue, "QuarksJobsCompleted", "") || changed
+		} else {
+			changed = status.SetCondition(bdv1.InstanceGroupsResolved, corev1.ConditionFalse, "QuarksJobsRunning",
+				fmt.Sprintf("%d of %d QuarksJobs completed", status.CompletedJobCount, status.TotalJobCount)) || changed
+		}
+	}
+
+	// Degraded only applies to deployments, which have been deployed before
+	wasDeployed := status.IsConditionTrue(bdv1.Deployed) || status.IsConditionTrue(bdv1.Degraded)
+	failed := []string{}
+	notReady := []string{}
+	for _, ig := range status.InstanceGroups {
+		if ig.LastError != "" {
+			failed = append(failed, ig.Name)
+		}
+		if ig.ReadyReplicas < ig.Replicas {
+			notReady = append(notReady, ig.Name)
+		}
+	}
+
+	switch {
+	case len(failed) > 0:
+		changed = status.SetCondition(bdv1.Degraded, corev1.ConditionTrue, "InstanceGroupFailed",
+			fmt.Sprintf("instance groups failed to deploy: %s", strings.Join(failed, ", "))) || changed
+	case wasDeployed && len(notReady) > 0:
+		changed = status.SetCondition(bdv1.Degraded, corev1.ConditionTrue, "InstanceGroupNotReady",
+			fmt.Sprintf("instance groups are not ready: %s", strings.Join(notReady, ", "))) || changed
+	default:
+		changed = status.SetCondition(bdv1.Degraded, corev1.ConditionFalse, "AsExpected", "") || changed
+	}
+
+	if deployedState {
+		changed = status.SetCondition(bdv1.Deployed, corev1.ConditionTrue, "InstanceGroupsReady", "") || changed
+	} else {
+		changed = status.SetCondition(bdv1.Deployed, corev1.ConditionFalse, "InstanceGroupsNotReady",
+			fmt.Sprintf("%d of %d instance groups ready", status.DeployedInstanceGroups, status.TotalInstanceGroups)) || changed
+	}
+
+	return changed
+}
@@ -8,8 +8,15 @@ import (
 	. "github.com/onsi/gomega"
 	"go.uber.org/zap"
 
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1b1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
@@ -45,6 +52,11 @@ var _ = Describe("ReconcileBDPL", func() {
 		desiredQJob         *qjv1a1.QuarksJob
 		reconcileRequest    func()
 		status              *cfakes.FakeStatusWriter
+		statefulSets        []appsv1.StatefulSet
+		cronJobs            []batchv1b1.CronJob
+		jobs                []batchv1.Job
+		pvcs                []corev1.PersistentVolumeClaim
+		storageClass        *storagev1.StorageClass
 	)
 
 	BeforeEach(func() {
@@ -82,6 +94,11 @@ var _ = Describe("ReconcileBDPL", func() {
 			case *bdv1.BOSHDeployment:
 				bdpl.DeepCopyInto(object)
 				return nil
+			case *storagev1.StorageClass:
+				if storageClass != nil {
+					storageClass.DeepCopyInto(object)
+					return nil
+				}
 			}
 
 			return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
@@ -97,10 +114,37 @@ var _ = Describe("ReconcileBDPL", func() {
 				list := &qstsv1a1.QuarksStatefulSetList{Items: []qstsv1a1.QuarksStatefulSet{*desiredQStatefulSet}}
 				list.DeepCopyInto(object)
 				return nil
+			case *appsv1.StatefulSetList:
+				list := &appsv1.StatefulSetList{Items: statefulSets}
+				list.DeepCopyInto(object)
+				return nil
+			case *batchv1b1.CronJobList:
+				list := &batchv1b1.CronJobList{Items: cronJobs}
+				list.DeepCopyInto(object)
+				return nil
+			case *batchv1.JobList:
+				listOpts := (&crc.ListOptions{}).ApplyOptions(opts)
+				list := &batchv1.JobList{}
+				for _, job := range jobs {
+					if listOpts.LabelSelector == nil || listOpts.LabelSelector.Matches(labels.Set(job.Labels)) {
+						list.Items = append(list.Items, job)
+					}
+				}
+				list.DeepCopyInto(object)
+				return nil
+			case *corev1.PersistentVolumeClaimList:
+				list := &corev1.PersistentVolumeClaimList{Items: pvcs}
+				list.DeepCopyInto(object)
+				return nil
 			}
 
 			return apierrors.NewNotFound(schema.GroupResource{}, "test")
 		})
+		statefulSets = []appsv1.StatefulSet{}
+		cronJobs = []batchv1b1.CronJob{}
+		jobs = []batchv1.Job{}
+		pvcs = []corev1.PersistentVolumeClaim{}
+		storageClass = nil
 
 		manager.GetClientReturns(client)
 
@@ -282,6 +326,324 @@ var _ = Describe("ReconcileBDPL", func() {
 		})
 	})
 
+	Context("BDPL instance group status and conditions", func() {
+		var replicas int32 = 2
+
+		JustBeforeEach(func() {
+			desiredQStatefulSet.UID = "qsts-uid"
+			desiredQStatefulSet.Labels[bdv1.LabelInstanceGroupName] = "nats"
+			desiredQStatefulSet.Spec.Template.Spec.Replicas = &replicas
+			desiredQStatefulSet.Status = qstsv1a1.QuarksStatefulSetStatus{Ready: true}
+			desiredQJob.Labels = map[string]string{bdv1.LabelDeploymentName: "deployment-name"}
+			desiredQJob.Status.Completed = true
+
+			controller := true
+			statefulSets = []appsv1.StatefulSet{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "nats-v1",
+						Namespace: "default",
+						Annotations: map[string]string{
+							qstsv1a1.AnnotationVersion: "1",
+						},
+						OwnerReferences: []metav1.OwnerReference{{Name: "foo", UID: "qsts-uid", Controller: &controller}},
+					},
+					Status: appsv1.StatefulSetStatus{ReadyReplicas: 1},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "nats-v2",
+						Namespace: "default",
+						Annotations: map[string]string{
+							qstsv1a1.AnnotationVersion: "2",
+						},
+						OwnerReferences: []metav1.OwnerReference{{Name: "foo", UID: "qsts-uid", Controller: &controller}},
+					},
+					Status: appsv1.StatefulSetStatus{ReadyReplicas: 2},
+				},
+			}
+		})
+
+		It("sets the instance group status from the latest statefulset", func() {
+			reconcileRequest()
+
+			Expect(bdpl.Status.InstanceGroups).To(Equal([]bdv1.InstanceGroupStatus{
+				{Name: "nats", Replicas: 2, ReadyReplicas: 2, Version: 2},
+			}))
+			Expect(bdpl.Status.IsConditionTrue(bdv1.InstanceGroupsResolved)).To(BeTrue())
+			Expect(bdpl.Status.IsConditionTrue(bdv1.Deployed)).To(BeTrue())
+			Expect(bdpl.Status.IsConditionTrue(bdv1.Degraded)).To(BeFalse())
+		})
+
+		It("reports a deployed instance group with missing replicas as degraded", func() {
+			reconcileRequest()
+			Expect(bdpl.Status.IsConditionTrue(bdv1.Deployed)).To(BeTrue())
+
+			desiredQStatefulSet.Status = qstsv1a1.QuarksStatefulSetStatus{Ready: false}
+			statefulSets[1].Status.ReadyReplicas = 1
+			reconcileRequest()
+
+			Expect(bdpl.Status.InstanceGroups[0].ReadyReplicas).To(Equal(int32(1)))
+			Expect(bdpl.Status.IsConditionTrue(bdv1.Deployed)).To(BeFalse())
+			condition := bdpl.Status.GetCondition(bdv1.Degraded)
+			Expect(condition.Status).To(Equal(corev1.ConditionTrue))
+			Expect(condition.Reason).To(Equal("InstanceGroupNotReady"))
+			Expect(condition.Message).To(ContainSubstring("nats"))
+		})
+
+		It("keeps the last error of an instance group and reports it as degraded", func() {
+			bdpl.Status.InstanceGroups = []bdv1.InstanceGroupStatus{{Name: "nats", LastError: "fake-error"}}
+
+			reconcileRequest()
+
+			Expect(bdpl.Status.InstanceGroups[0].LastError).To(Equal("fake-error"))
+			Expect(bdpl.Status.InstanceGroups[0].ReadyReplicas).To(Equal(int32(2)))
+			condition := bdpl.Status.GetCondition(bdv1.Degraded)
+			Expect(condition.Status).To(Equal(corev1.ConditionTrue))
+			Expect(condition.Reason).To(Equal("InstanceGroupFailed"))
+		})
+
+		It("keeps the last error of an instance group, which has no QuarksStatefulSet yet", func() {
+			bdpl.Status.InstanceGroups = []bdv1.InstanceGroupStatus{
+				{Name: "doppler", LastError: "fake-error"},
+				{Name: "router"},
+			}
+
+			reconcileRequest()
+
+			Expect(bdpl.Status.InstanceGroups).To(Equal([]bdv1.InstanceGroupStatus{
+				{Name: "doppler", LastError: "fake-error"},
+				{Name: "nats", Replicas: 2, ReadyReplicas: 2, Version: 2},
+			}))
+			condition := bdpl.Status.GetCondition(bdv1.Degraded)
+			Expect(condition.Status).To(Equal(corev1.ConditionTrue))
+			Expect(condition.Reason).To(Equal("InstanceGroupFailed"))
+			Expect(condition.Message).To(ContainSubstring("doppler"))
+		})
+	})
+
+	Context("BDPL with scheduled errands", func() {
+		var (
+			lastSchedule metav1.Time
+			earlier      metav1.Time
+			later        metav1.Time
+		)
+
+		BeforeEach(func() {
+			lastSchedule = metav1.NewTime(time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC))
+			earlier = metav1.NewTime(time.Date(2020, 1, 1, 11, 5, 0, 0, time.UTC))
+			later = metav1.NewTime(time.Date(2020, 1, 1, 12, 5, 0, 0, time.UTC))
+			qJobLabels := map[string]string{qjv1a1.LabelQJobName: "deployment-name-smoke-tests"}
+
+			cronJobs = []batchv1b1.CronJob{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "deployment-name-smoke-tests",
+						Namespace: "default",
+						UID:       "cronjob-uid",
+						Labels: map[string]string{
+							bdv1.LabelDeploymentName:    "deployment-name",
+							bdv1.LabelInstanceGroupName: "smoke-tests",
+						},
+					},
+					Spec: batchv1b1.CronJobSpec{Schedule: "@hourly"},
+					Status: batchv1b1.CronJobStatus{
+						LastScheduleTime: &lastSchedule,
+					},
+				},
+			}
+			jobs = []batchv1.Job{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "deployment-name-smoke-tests-1",
+						Labels: qJobLabels,
+					},
+					Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+						{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: earlier},
+					}},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "deployment-name-smoke-tests-2",
+						Labels: qJobLabels,
+					},
+					Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+						{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, LastTransitionTime: later},
+					}},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "deployment-name-smoke-tests-3",
+						Labels: qJobLabels,
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "unrelated-job",
+						Labels: map[string]string{qjv1a1.LabelQJobName: "deployment-name-other"},
+					},
+					Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+						{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(later.Add(time.Hour))},
+					}},
+				},
+			}
+		})
+
+		It("reports the active runs and the result of the last finished job of the errand's quarks job", func() {
+			reconcileRequest()
+
+			Expect(bdpl.Status.ScheduledErrands).To(Equal([]bdv1.ScheduledErrandStatus{
+				{
+					Name:               "smoke-tests",
+					Schedule:           "@hourly",
+					Active:             1,
+					LastScheduleTime:   &lastSchedule,
+					LastCompletionTime: &later,
+					LastResult:         bdv1.ErrandRunFailed,
+				},
+			}))
+		})
+
+		It("updates the status from the cron job status reconciler", func() {
+			cronReconciler := bdplcontroller.NewCronJobStatusReconciler(ctx, config, manager)
+			client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+				switch object := object.(type) {
+				case *batchv1b1.CronJob:
+					cronJobs[0].DeepCopyInto(object)
+					return nil
+				case *bdv1.BOSHDeployment:
+					bdpl.DeepCopyInto(object)
+					return nil
+				}
+				return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+			})
+
+			result, err := cronReconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "deployment-name-smoke-tests", Namespace: "default"}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(reconcile.Result{}))
+			Expect(status.UpdateCallCount()).To(Equal(1))
+			Expect(bdpl.Status.ScheduledErrands).To(HaveLen(1))
+			Expect(bdpl.Status.ScheduledErrands[0].LastResult).To(Equal(bdv1.ErrandRunFailed))
+		})
+	})
+
+	Context("BDPL with persistent disks", func() {
+		var storageClassName string
+
+		pvc := func(name string, requested string, capacity string) corev1.PersistentVolumeClaim {
+			return corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: "default",
+					Labels: map[string]string{
+						bdv1.LabelDeploymentName:    "deployment-name",
+						bdv1.LabelInstanceGroupName: "nats",
+					},
+				},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					StorageClassName: &storageClassName,
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(requested)},
+					},
+				},
+				Status: corev1.PersistentVolumeClaimStatus{
+					Phase:    corev1.ClaimBound,
+					Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(capacity)},
+				},
+			}
+		}
+
+		BeforeEach(func() {
+			storageClassName = "expandable"
+			allow := true
+			storageClass = &storagev1.StorageClass{
+				ObjectMeta:           metav1.ObjectMeta{Name: "expandable"},
+				AllowVolumeExpansion: &allow,
+			}
+		})
+
+		JustBeforeEach(func() {
+			desiredQStatefulSet.Name = "deployment-name-nats"
+			desiredQStatefulSet.Labels[bdv1.LabelInstanceGroupName] = "nats"
+			desiredQStatefulSet.Spec.Template.Spec.VolumeClaimTemplates = []corev1.PersistentVolumeClaim{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "nats-pvc"},
+					Spec: corev1.PersistentVolumeClaimSpec{
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("2Gi")},
+						},
+					},
+				},
+			}
+		})
+
+		It("reports disks, which have the requested size, as ready", func() {
+			pvcs = []corev1.PersistentVolumeClaim{pvc("nats-pvc-deployment-name-nats-0", "2Gi", "2Gi")}
+
+			reconcileRequest()
+
+			Expect(bdpl.Status.PersistentDisks).To(Equal([]bdv1.PersistentDiskStatus{
+				{
+					Name:          "nats-pvc-deployment-name-nats-0",
+					InstanceGroup: "nats",
+					Size:          "2Gi",
+					Capacity:      "2Gi",
+					Phase:         bdv1.PersistentDiskReady,
+				},
+			}))
+		})
+
+		It("reports disks, which are being expanded, as resizing", func() {
+			pvcs = []corev1.PersistentVolumeClaim{
+				pvc("nats-pvc-deployment-name-nats-1", "1Gi", "1Gi"),
+				pvc("nats-pvc-deployment-name-nats-0", "2Gi", "1Gi"),
+			}
+
+			reconcileRequest()
+
+			Expect(bdpl.Status.PersistentDisks).To(HaveLen(2))
+			Expect(bdpl.Status.PersistentDisks[0].Name).To(Equal("nats-pvc-deployment-name-nats-0"))
+			Expect(bdpl.Status.PersistentDisks[0].Phase).To(Equal(bdv1.PersistentDiskResizing))
+			Expect(bdpl.Status.PersistentDisks[1].Name).To(Equal("nats-pvc-deployment-name-nats-1"))
+			Expect(bdpl.Status.PersistentDisks[1].Phase).To(Equal(bdv1.PersistentDiskResizing))
+			Expect(bdpl.Status.PersistentDisks[1].Size).To(Equal("2Gi"))
+			Expect(bdpl.Status.PersistentDisks[1].Capacity).To(Equal("1Gi"))
+		})
+
+		It("reports disks, whose storage class doesn't allow volume expansion", func() {
+			storageClass = nil
+			pvcs = []corev1.PersistentVolumeClaim{pvc("nats-pvc-deployment-name-nats-0", "1Gi", "1Gi")}
+
+			reconcileRequest()
+
+			Expect(bdpl.Status.PersistentDisks).To(HaveLen(1))
+			Expect(bdpl.Status.PersistentDisks[0].Phase).To(Equal(bdv1.PersistentDiskResizeNotSupported))
+		})
+
+		It("updates the status from the persistent volume claim status reconciler", func() {
+			pvcs = []corev1.PersistentVolumeClaim{pvc("nats-pvc-deployment-name-nats-0", "2Gi", "2Gi")}
+			pvcReconciler := bdplcontroller.NewPVCStatusReconciler(ctx, config, manager)
+			client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+				switch object := object.(type) {
+				case *corev1.PersistentVolumeClaim:
+					pvcs[0].DeepCopyInto(object)
+					return nil
+				case *bdv1.BOSHDeployment:
+					bdpl.DeepCopyInto(object)
+					return nil
+				}
+				return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+			})
+
+			result, err := pvcReconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "nats-pvc-deployment-name-nats-0", Namespace: "default"}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(reconcile.Result{}))
+			Expect(status.UpdateCallCount()).To(Equal(1))
+			Expect(bdpl.Status.PersistentDisks).To(HaveLen(1))
+			Expect(bdpl.Status.PersistentDisks[0].Phase).To(Equal(bdv1.PersistentDiskReady))
+		})
+	})
+
 	Context("BDPL with multiple instance groups", func() {
 		BeforeEach(func() {
 			client.ListCalls(func(context context.Context, object runtime.Object, opts ...crc.ListOption) error {
@@ -304,6 +666,12 @@ var _ = Describe("ReconcileBDPL", func() {
 					}}
 					list.DeepCopyInto(object)
 					return nil
+				case *appsv1.StatefulSetList:
+					list := &appsv1.StatefulSetList{Items: statefulSets}
+					list.DeepCopyInto(object)
+					return nil
+				case *batchv1b1.CronJobList, *batchv1.JobList, *corev1.PersistentVolumeClaimList:
+					return nil
 				}
 
 				return apierrors.NewNotFound(schema.GroupResource{}, "test")
@@ -19,6 +19,7 @@ import (
 
 	"code.cloudfoundry.org/quarks-operator/pkg/bosh/manifest"
 	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/desiredmanifest"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/withops"
 	"code.cloudfoundry.org/quarks-utils/pkg/config"
 	"code.cloudfoundry.org/quarks-utils/pkg/logger"
@@ -95,17 +96,11 @@ func (v *Validator) Handle(ctx context.Context, req admission.Request) admission
 		denied(fmt.Sprintf("Failed to decode BOSHDeployment: %s", err.Error()))
 	}
 
-	// verify only one bdpl exists in this namespace
-	bdpls := &bdv1.BOSHDeploymentList{}
-	err = v.client.List(ctx, bdpls, client.InNamespace(boshDeployment.GetNamespace()))
-	if err != nil {
-		return denied(fmt.Sprintf("Failed to list bdpl: %s", err.Error()))
-	}
-	if len(bdpls.Items) > 0 {
-		for _, bdpl := range bdpls.Items {
-			if bdpl.Name != boshDeployment.Name {
-				return denied(fmt.Sprintf("Only one deployment allowed per namespace. Namespace is used for '%s'", bdpl.Name))
-			}
+	// verify references
+	for _, ref := range append([]bdv1.ResourceReference{boshDeployment.Spec.Manifest}, boshDeployment.Spec.Ops...) {
+		err = withops.ValidateReference(ref)
+		if err != nil {
+			return denied(fmt.Sprintf("Invalid reference: %s", err.Error()))
 		}
 	}
 
@@ -121,6 +116,7 @@ func (v *Validator) Handle(ctx context.Context, req admission.Request) admission
 	resolver := withops.NewResolver(
 		v.client,
 		func() withops.Interpolator { return withops.NewInterpolator() },
+		v.config.OperatorNamespace,
 	)
 	manifest, err := resolver.ManifestDetailed(ctx, boshDeployment, boshDeployment.GetNamespace())
 	if err != nil {
@@ -131,6 +127,26 @@ func (v *Validator) Handle(ctx context.Context, req admission.Request) admission
 	if err != nil {
 		return denied(fmt.Sprintf("Failed to validate update block: %s", err.Error()))
 	}
+	for _, ig := range manifest.InstanceGroups {
+		err = validateUpdateBlock(ig.Update)
+		if err != nil {
+			return denied(fmt.Sprintf("Failed to validate update block of instance group '%s': %s", ig.Name, err.Error()))
+		}
+	}
+
+	// verify instance groups don't collide with other deployments in this namespace
+	v.log.Debugf("Verifying instance groups of deployment '%s' are unique in namespace '%s'", boshDeployment.Name, boshDeployment.Namespace)
+	err = v.instanceGroupsUnique(ctx, resolver, boshDeployment, manifest)
+	if err != nil {
+		return denied(err.Error())
+	}
+
+	// verify persistent disks are not shrunk, volumes can only be expanded
+	v.log.Debugf("Verifying persistent disks of deployment '%s' are not shrunk", boshDeployment.Name)
+	err = v.persistentDisksNotShrunk(ctx, boshDeployment, manifest)
+	if err != nil {
+		return denied(err.Error())
+	}
 
 	return admission.Response{
 		AdmissionResponse: v1beta1.AdmissionResponse{
@@ -223,6 +239,76 @@ func (v *Validator) opsResourcesExist(ctx context.Context, specOpsResource []bdv
 	}
 }
 
+// instanceGroupsUnique verifies that none of the manifest's instance groups
+// is already used by another deployment in the same namespace. Instance
+// groups are converted into QuarksStatefulSets, which are not prefixed with
+// the deployment name.
+func (v *Validator) instanceGroupsUnique(ctx context.Context, resolver *withops.Resolver, boshDeployment *bdv1.BOSHDeployment, m *manifest.Manifest) error {
+	bdpls := &bdv1.BOSHDeploymentList{}
+	err := v.client.List(ctx, bdpls, client.InNamespace(boshDeployment.GetNamespace()))
+	if err != nil {
+		return errors.Wrap(err, "Failed to list bdpl")
+	}
+
+	dm := desiredmanifest.NewDesiredManifest(v.client)
+	for _, bdpl := range bdpls.Items {
+		if bdpl.Name == boshDeployment.Name {
+			continue
+		}
+
+		// deployments without a desired manifest are still being
+		// resolved, use the instance groups of their spec instead
+		other, err := dm.DesiredManifest(ctx, bdpl.Name, bdpl.Namespace)
+		if err != nil {
+			other, err = resolver.ManifestWithOps(ctx, &bdpl, bdpl.Namespace)
+			if err != nil {
+				v.log.Debugf("Skipping deployment '%s' when verifying instance groups, its manifest can't be loaded: %v", bdpl.Name, err)
+				continue
+			}
+		}
+
+		for _, ig := range m.InstanceGroups {
+			if _, found := other.InstanceGroups.InstanceGroupByName(ig.Name); found {
+				return fmt.Errorf("Instance group '%s' is already used by deployment '%s' in namespace '%s'", ig.Name, bdpl.Name, bdpl.Namespace)
+			}
+		}
+	}
+
+	return nil
+}
+
+// persistentDisksNotShrunk verifies that none of the persistent disks of the
+// deployed manifest is smaller in the new manifest. Kubernetes can expand
+// persistent volume claims, but not shrink them.
+func (v *Validator) persistentDisksNotShrunk(ctx context.Context, boshDeployment *bdv1.BOSHDeployment, m *manifest.Manifest) error {
+	// deployments without a desired manifest have not created any disks yet
+	deployed, err := desiredmanifest.NewDesiredManifest(v.client).DesiredManifest(ctx, boshDeployment.Name, boshDeployment.Namespace)
+	if err != nil {
+		return nil
+	}
+
+	for _, ig := range m.InstanceGroups {
+		old, found := deployed.InstanceGroups.InstanceGroupByName(ig.Name)
+		if !found {
+			continue
+		}
+
+		if old.PersistentDisk != nil && ig.PersistentDisk != nil && *ig.PersistentDisk < *old.PersistentDisk {
+			return fmt.Errorf("Persistent disk of instance group '%s' can't be shrunk from %dMB to %dMB", ig.Name, *old.PersistentDisk, *ig.PersistentDisk)
+		}
+
+		for _, disk := range ig.PersistentDisks {
+			for _, oldDisk := range old.PersistentDisks {
+				if disk.Name == oldDisk.Name && disk.Size < oldDisk.Size {
+					return fmt.Errorf("Persistent disk '%s' of instance group '%s' can't be shrunk from %dMB to %dMB", disk.Name, ig.Name, oldDisk.Size, disk.Size)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
 func validateUpdateBlock(update *manifest.Update) error {
 	if update == nil {
 		return nil
@@ -233,6 +319,15 @@ func validateUpdateBlock(update *manifest.Update) error {
 	if _, err := manifest.ExtractWatchTime(update.UpdateWatchTime); err != nil {
 		return errors.Wrap(err, "update block has invalid update_watch_time")
 	}
+	if err := manifest.ValidateCanaries(update.Canaries); err != nil {
+		return errors.Wrap(err, "update block has invalid canaries")
+	}
+	if _, err := manifest.ExtractMaxInFlight(update.MaxInFlight, 1); err != nil {
+		return errors.Wrap(err, "update block has invalid max_in_flight")
+	}
+	if err := manifest.ValidateVMStrategy(update.VMStrategy); err != nil {
+		return errors.Wrap(err, "update block has invalid vm_strategy")
+	}
 	return nil
 }
 
@@ -18,12 +18,14 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
-	"code.cloudfoundry.org/quarks-operator/pkg/bosh/manifest"
+	bdm "code.cloudfoundry.org/quarks-operator/pkg/bosh/manifest"
 	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/controllers/boshdeployment"
 	"code.cloudfoundry.org/quarks-operator/testing"
 	cfcfg "code.cloudfoundry.org/quarks-utils/pkg/config"
 	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+	"code.cloudfoundry.org/quarks-utils/pkg/pointers"
+	"code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore"
 	helper "code.cloudfoundry.org/quarks-utils/testing/testhelper"
 )
 
@@ -34,7 +36,7 @@ var _ = Describe("When the validating webhook handles a manifest", func() {
 		env                    testing.Catalog
 		client                 client.Client
 		decoder                *admission.Decoder
-		manifest               *manifest.Manifest
+		manifest               *bdm.Manifest
 		validator              admission.Handler
 		boshDeploymentBytes    []byte
 		validateBoshDeployment func() admission.Response
@@ -136,4 +138,251 @@ var _ = Describe("When the validating webhook handles a manifest", func() {
 			Expect(response.AdmissionResponse.Allowed).To(BeFalse())
 		})
 	})
+
+	Context("with an invalid max_in_flight", func() {
+		BeforeEach(func() {
+			manifest.Update.MaxInFlight = "all"
+		})
+
+		It("the manifest is rejected", func() {
+			response := validateBoshDeployment()
+			Expect(response.AdmissionResponse.Allowed).To(BeFalse())
+			Expect(response.AdmissionResponse.Result.Message).To(ContainSubstring("invalid max_in_flight"))
+		})
+	})
+
+	Context("with a percentage max_in_flight", func() {
+		BeforeEach(func() {
+			manifest.Update.MaxInFlight = "50%"
+		})
+
+		It("the manifest is accepted", func() {
+			response := validateBoshDeployment()
+			Expect(response.AdmissionResponse.Allowed).To(BeTrue(), response.Result.String)
+		})
+	})
+
+	Context("with a checksum on a config map reference", func() {
+		BeforeEach(func() {
+			boshDeploymentBytes, _ = json.Marshal(bdv1.BOSHDeployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "deployment", Namespace: "default"},
+				Spec: bdv1.BOSHDeploymentSpec{
+					Manifest: bdv1.ResourceReference{
+						Type:   bdv1.ConfigMapReference,
+						Name:   "base-manifest",
+						SHA256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+					},
+				},
+			})
+		})
+
+		It("the manifest is rejected", func() {
+			response := validateBoshDeployment()
+			Expect(response.AdmissionResponse.Allowed).To(BeFalse())
+			Expect(response.AdmissionResponse.Result.Message).To(ContainSubstring("only supported for url references"))
+		})
+	})
+
+	Context("with more than one canary", func() {
+		BeforeEach(func() {
+			manifest.Update.Canaries = 2
+		})
+
+		It("the manifest is accepted", func() {
+			response := validateBoshDeployment()
+			Expect(response.AdmissionResponse.Allowed).To(BeTrue(), response.Result.String)
+		})
+	})
+
+	Context("with negative canaries", func() {
+		BeforeEach(func() {
+			manifest.Update.Canaries = -1
+		})
+
+		It("the manifest is rejected", func() {
+			response := validateBoshDeployment()
+			Expect(response.AdmissionResponse.Allowed).To(BeFalse())
+			Expect(response.AdmissionResponse.Result.Message).To(ContainSubstring("update block has invalid canaries"))
+		})
+	})
+
+	Context("with an invalid vm_strategy on an instance group", func() {
+		BeforeEach(func() {
+			vmStrategy := "swap"
+			manifest.InstanceGroups[0].Update = &bdm.Update{VMStrategy: &vmStrategy}
+		})
+
+		It("the manifest is rejected", func() {
+			response := validateBoshDeployment()
+			Expect(response.AdmissionResponse.Allowed).To(BeFalse())
+			Expect(response.AdmissionResponse.Result.Message).To(ContainSubstring("instance group 'nats': update block has invalid vm_strategy"))
+		})
+	})
+
+	Context("when another deployment exists in the namespace", func() {
+		var otherInstanceGroup string
+
+		BeforeEach(func() {
+			otherInstanceGroup = "nats"
+		})
+
+		JustBeforeEach(func() {
+			otherManifest, _ := env.BOSHManifestWithZeroInstances()
+			otherManifest.InstanceGroups[0].Name = otherInstanceGroup
+			otherManifestBytes, _ := otherManifest.Marshal()
+			Expect(client.Create(ctx, &bdv1.BOSHDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "other",
+					Namespace: "default",
+				},
+			})).To(Succeed())
+			Expect(client.Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "other.desired-manifest-v1",
+					Namespace: "default",
+					Labels: map[string]string{
+						versionedsecretstore.LabelSecretKind: versionedsecretstore.VersionSecretKind,
+						versionedsecretstore.LabelVersion:    "1",
+					},
+				},
+				Data: map[string][]byte{
+					"manifest.yaml": otherManifestBytes,
+				},
+			})).To(Succeed())
+		})
+
+		Context("which uses the same instance group names", func() {
+			It("the manifest is rejected", func() {
+				response := validateBoshDeployment()
+				Expect(response.AdmissionResponse.Allowed).To(BeFalse())
+				Expect(response.AdmissionResponse.Result.Message).To(ContainSubstring("Instance group 'nats' is already used by deployment 'other' in namespace 'default'"))
+			})
+		})
+
+		Context("which uses different instance group names", func() {
+			BeforeEach(func() {
+				otherInstanceGroup = "other-nats"
+			})
+
+			It("the manifest is accepted", func() {
+				response := validateBoshDeployment()
+				Expect(response.AdmissionResponse.Allowed).To(BeTrue(), response.Result.String)
+			})
+		})
+	})
+
+	Context("when another deployment without a desired manifest exists in the namespace", func() {
+		var otherInstanceGroup string
+
+		BeforeEach(func() {
+			otherInstanceGroup = "nats"
+		})
+
+		JustBeforeEach(func() {
+			otherManifest, _ := env.BOSHManifestWithZeroInstances()
+			otherManifest.InstanceGroups[0].Name = otherInstanceGroup
+			otherManifestBytes, _ := otherManifest.Marshal()
+			Expect(client.Create(ctx, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "other-manifest",
+					Namespace: "default",
+				},
+				Data: map[string]string{
+					bdv1.ManifestSpecName: string(otherManifestBytes),
+				},
+			})).To(Succeed())
+			Expect(client.Create(ctx, &bdv1.BOSHDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "other",
+					Namespace: "default",
+				},
+				Spec: bdv1.BOSHDeploymentSpec{
+					Manifest: bdv1.ResourceReference{
+						Type: bdv1.ConfigMapReference,
+						Name: "other-manifest",
+					},
+				},
+			})).To(Succeed())
+		})
+
+		Context("which uses the same instance group names", func() {
+			It("the manifest is rejected", func() {
+				response := validateBoshDeployment()
+				Expect(response.AdmissionResponse.Allowed).To(BeFalse())
+				Expect(response.AdmissionResponse.Result.Message).To(ContainSubstring("Instance group 'nats' is already used by deployment 'other' in namespace 'default'"))
+			})
+		})
+
+		Context("which uses different instance group names", func() {
+			BeforeEach(func() {
+				otherInstanceGroup = "other-nats"
+			})
+
+			It("the manifest is accepted", func() {
+				response := validateBoshDeployment()
+				Expect(response.AdmissionResponse.Allowed).To(BeTrue(), response.Result.String)
+			})
+		})
+	})
+
+	Context("when the deployment has already been deployed with a persistent disk", func() {
+		var diskSize int
+
+		BeforeEach(func() {
+			diskSize = 2048
+			manifest.InstanceGroups[0].PersistentDisk = &diskSize
+			manifest.InstanceGroups[0].PersistentDisks = []bdm.PersistentDisk{{Name: "data", Size: 1024}}
+		})
+
+		JustBeforeEach(func() {
+			deployed, _ := env.BOSHManifestWithZeroInstances()
+			deployed.InstanceGroups[0].PersistentDisk = pointers.Int(1024)
+			deployed.InstanceGroups[0].PersistentDisks = []bdm.PersistentDisk{{Name: "data", Size: 1024}}
+			deployedBytes, _ := deployed.Marshal()
+			Expect(client.Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "deployment.desired-manifest-v1",
+					Namespace: "default",
+					Labels: map[string]string{
+						versionedsecretstore.LabelSecretKind: versionedsecretstore.VersionSecretKind,
+						versionedsecretstore.LabelVersion:    "1",
+					},
+				},
+				Data: map[string][]byte{
+					"manifest.yaml": deployedBytes,
+				},
+			})).To(Succeed())
+		})
+
+		Context("and the disk grows", func() {
+			It("the manifest is accepted", func() {
+				response := validateBoshDeployment()
+				Expect(response.AdmissionResponse.Allowed).To(BeTrue(), response.Result.String)
+			})
+		})
+
+		Context("and the disk shrinks", func() {
+			BeforeEach(func() {
+				diskSize = 512
+			})
+
+			It("the manifest is rejected", func() {
+				response := validateBoshDeployment()
+				Expect(response.AdmissionResponse.Allowed).To(BeFalse())
+				Expect(response.AdmissionResponse.Result.Message).To(ContainSubstring("Persistent disk of instance group 'nats' can't be shrunk from 1024MB to 512MB"))
+			})
+		})
+
+		Context("and a named disk shrinks", func() {
+			BeforeEach(func() {
+				manifest.InstanceGroups[0].PersistentDisks[0].Size = 100
+			})
+
+			It("the manifest is rejected", func() {
+				response := validateBoshDeployment()
+				Expect(response.AdmissionResponse.Allowed).To(BeFalse())
+				Expect(response.AdmissionResponse.Result.Message).To(ContainSubstring("Persistent disk 'data' of instance group 'nats' can't be shrunk from 1024MB to 100MB"))
+			})
+		})
+	})
 })
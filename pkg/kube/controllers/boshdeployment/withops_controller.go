@@ -21,6 +21,8 @@ import (
 	bdm "code.cloudfoundry.org/quarks-operator/pkg/bosh/manifest"
 	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/boshdns"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/metrics"
+	boshnames "code.cloudfoundry.org/quarks-operator/pkg/kube/util/names"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/withops"
 	qsv1a1 "code.cloudfoundry.org/quarks-secret/pkg/kube/apis/quarkssecret/v1alpha1"
 	"code.cloudfoundry.org/quarks-utils/pkg/config"
@@ -40,14 +42,17 @@ func AddWithOps(ctx context.Context, config *config.Config, mgr manager.Manager)
 		withops.NewResolver(
 			mgr.GetClient(),
 			func() withops.Interpolator { return withops.NewInterpolator() },
+			config.OperatorNamespace,
 		),
 		controllerutil.SetControllerReference,
-		func(m bdm.Manifest) (boshdns.DomainNameService, error) { return boshdns.New(m) },
+		func(deploymentName string, m bdm.Manifest) (boshdns.DomainNameService, error) {
+			return boshdns.New(deploymentName, m)
+		},
 	)
 
 	// Create a new controller
 	c, err := controller.New("withops-controller", mgr, controller.Options{
-		Reconciler:              r,
+		Reconciler:              metrics.InstrumentReconciler(metrics.ControllerWithOps, r),
 		MaxConcurrentReconciles: config.MaxBoshDeploymentWorkers,
 	})
 	if err != nil {
@@ -115,10 +120,11 @@ func AddWithOps(ctx context.Context, config *config.Config, mgr manager.Manager)
 			if skip.Reconciles(ctx, mgr.GetClient(), s) {
 				return []reconcile.Request{}
 			}
+			deploymentName := s.GetLabels()[bdv1.LabelDeploymentName]
 			result := []reconcile.Request{
 				{
 					NamespacedName: types.NamespacedName{
-						Name:      bdv1.DeploymentSecretTypeManifestWithOps.String(),
+						Name:      boshnames.DeploymentSecretName(bdv1.DeploymentSecretTypeManifestWithOps, deploymentName, ""),
 						Namespace: s.Namespace,
 					},
 				},
@@ -135,9 +141,50 @@ func AddWithOps(ctx context.Context, config *config.Config, mgr manager.Manager)
 		return errors.Wrapf(err, "Watching secrets failed in withops controller.")
 	}
 
+	// Watch for resumed BOSHDeployments, to apply the changes skipped while paused
+	p = predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return false },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+		UpdateFunc:  isResumed,
+	}
+	err = c.Watch(&source.Kind{Type: &bdv1.BOSHDeployment{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+			result := []reconcile.Request{
+				{
+					NamespacedName: types.NamespacedName{
+						Name:      boshnames.DeploymentSecretName(bdv1.DeploymentSecretTypeManifestWithOps, a.Meta.GetName(), ""),
+						Namespace: a.Meta.GetNamespace(),
+					},
+				},
+			}
+			ctxlog.NewMappingEvent(a.Object).Debug(ctx, result[0], names.Secret, a.Meta.GetName(), "bdv1.BOSHDeployment")
+
+			return result
+		}),
+	}, nsPred, p)
+	if err != nil {
+		return errors.Wrapf(err, "Watching bosh deployment failed in withops controller.")
+	}
+
 	return nil
 }
 
+// isResumed returns true if the deployment reconciler recorded a resume of
+// the BOSHDeployment. It's true for a single update event per resume.
+func isResumed(e event.UpdateEvent) bool {
+	o, ok := e.ObjectOld.(*bdv1.BOSHDeployment)
+	if !ok {
+		return false
+	}
+	n, ok := e.ObjectNew.(*bdv1.BOSHDeployment)
+	if !ok {
+		return false
+	}
+
+	return !n.Spec.Paused && n.Status.ResumedGeneration != o.Status.ResumedGeneration
+}
+
 func isWithOpsSecret(secret *corev1.Secret) bool {
 	secretLabels := secret.GetLabels()
 	deploymentSecretType, ok := secretLabels[bdv1.LabelDeploymentSecretType]
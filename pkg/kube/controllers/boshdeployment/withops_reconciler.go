@@ -18,6 +18,8 @@ import (
 	bdm "code.cloudfoundry.org/quarks-operator/pkg/bosh/manifest"
 	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/boshdns"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/metrics"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/names"
 	"code.cloudfoundry.org/quarks-utils/pkg/config"
 	log "code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
 	"code.cloudfoundry.org/quarks-utils/pkg/meltdown"
@@ -29,8 +31,8 @@ type InterpolateSecrets interface {
 	InterpolateVariableFromSecrets(ctx context.Context, withOpsManifestData []byte, namespace string, boshdeploymentName string) ([]byte, error)
 }
 
-// NewDNSFunc returns a dns client for the manifest
-type NewDNSFunc func(m bdm.Manifest) (boshdns.DomainNameService, error)
+// NewDNSFunc returns a dns client for the manifest of a deployment
+type NewDNSFunc func(deploymentName string, m bdm.Manifest) (boshdns.DomainNameService, error)
 
 // NewWithOpsReconciler returns a new reconcile.Reconciler
 func NewWithOpsReconciler(ctx context.Context, config *config.Config, mgr manager.Manager, resolver InterpolateSecrets, srf setReferenceFunc, dns NewDNSFunc) reconcile.Reconciler {
@@ -108,6 +110,7 @@ func (r *ReconcileWithOps) Reconcile(request reconcile.Request) (reconcile.Resul
 
 	if meltdown.NewAnnotationWindow(ReconcileSkipDuration, annotations).Contains(time.Now()) {
 		log.Infof(ctx, "Meltdown in progress for '%s'", request.NamespacedName)
+		metrics.MeltdownSkipped(metrics.ControllerWithOps)
 		return reconcile.Result{}, nil
 	}
 	log.Infof(ctx, "Meltdown ended for '%s'", request.NamespacedName)
@@ -119,6 +122,11 @@ func (r *ReconcileWithOps) Reconcile(request reconcile.Request) (reconcile.Resul
 			log.WithEvent(withOpsSecret, "WithOpsManifestError").Errorf(ctx, "failed to get BOSHDeployment '%s': %v", boshdeploymentName, err)
 	}
 
+	if boshdeployment.Spec.Paused {
+		log.Infof(ctx, "Skip reconcile: BOSHDeployment '%s' is paused", boshdeployment.GetNamespacedName())
+		return reconcile.Result{}, nil
+	}
+
 	annotations[meltdown.AnnotationLastReconcile] = ""
 	withOpsSecret.SetAnnotations(annotations)
 	err = r.client.Update(ctx, withOpsSecret)
@@ -151,7 +159,7 @@ func (r *ReconcileWithOps) Reconcile(request reconcile.Request) (reconcile.Resul
 			log.WithEvent(withOpsSecret, "WithOpsManifestError").Errorf(ctx, "failed to unmarshal manifest bytes for boshdeployment '%s': %v", boshdeploymentName, err)
 	}
 
-	dns, err := r.newDNSFunc(*manifest)
+	dns, err := r.newDNSFunc(boshdeploymentName, *manifest)
 	if err != nil {
 		return reconcile.Result{},
 			log.WithEvent(withOpsSecret, "WithOpsManifestError").Errorf(ctx, "failed to create desired manifest secret for BOSHDeployment '%s': %v", boshdeploymentName, err)
@@ -183,7 +191,7 @@ func (r *ReconcileWithOps) createDesiredManifest(ctx context.Context, desiredMan
 		return err
 	}
 
-	desiredManifestSecretName := "desired-manifest"
+	desiredManifestSecretName := names.DesiredManifestName(boshdeployment.Name)
 	secretLabels := map[string]string{
 		bdv1.LabelDeploymentName:       boshdeployment.Name,
 		bdv1.LabelDeploymentSecretType: bdv1.DeploymentSecretTypeDesiredManifest.String(),
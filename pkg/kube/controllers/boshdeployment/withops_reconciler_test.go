@@ -128,7 +128,7 @@ variables:
 			ctx, config, manager,
 			&resolver,
 			controllerutil.SetControllerReference,
-			func(m bdm.Manifest) (boshdns.DomainNameService, error) {
+			func(deploymentName string, m bdm.Manifest) (boshdns.DomainNameService, error) {
 				return boshdns.NewSimpleDomainNameService(), nil
 			},
 		)
@@ -140,7 +140,7 @@ variables:
 				switch object := object.(type) {
 				case *corev1.Secret:
 					secret := object
-					Expect(secret.Name).To(Equal("desired-manifest-v1"))
+					Expect(secret.Name).To(Equal("gora.desired-manifest-v1"))
 					Expect(secret.Labels).To(Equal(map[string]string{
 						"quarks.cloudfoundry.org/deployment-name": "gora",
 						"quarks.cloudfoundry.org/secret-kind":     "versionedSecret",
@@ -165,5 +165,16 @@ variables:
 			Expect(err).To(HaveOccurred())
 			Expect(logs.FilterMessageSnippet("Expected to find variables: password").Len()).To(Equal(1))
 		})
+
+		It("skips the reconcile while the BOSHDeployment is paused", func() {
+			boshDeployment.Spec.Paused = true
+
+			result, err := reconciler.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(reconcile.Result{}))
+			Expect(resolver.InterpolateVariableFromSecretsCallCount()).To(Equal(0))
+			Expect(client.UpdateCallCount()).To(Equal(0))
+			Expect(client.CreateCallCount()).To(Equal(0))
+		})
 	})
 })
@@ -8,16 +8,20 @@ import (
 	"go.uber.org/zap"
 
 	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
 	qjv1a1 "code.cloudfoundry.org/quarks-job/pkg/kube/apis/quarksjob/v1alpha1"
 	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/controllers/boshdeployment"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/controllers/errandrun"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/controllers/quarkslink"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/controllers/quarksrestart"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/controllers/versionedsecret"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/controllers/waitservice"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/metrics"
 	qsv1a1 "code.cloudfoundry.org/quarks-secret/pkg/kube/apis/quarkssecret/v1alpha1"
 	qstsv1a1 "code.cloudfoundry.org/quarks-statefulset/pkg/kube/apis/quarksstatefulset/v1alpha1"
 	"code.cloudfoundry.org/quarks-utils/pkg/config"
@@ -41,7 +45,10 @@ var addToManagerFuncs = []func(context.Context, *config.Config, manager.Manager)
 	boshdeployment.AddBPM,
 	boshdeployment.AddWithOps,
 	boshdeployment.AddBDPLStatusReconcilers,
+	boshdeployment.AddOrphanedDisk,
+	boshdeployment.AddErrandTrigger,
 	quarksrestart.AddRestart,
+	errandrun.AddErrandRun,
 }
 
 var addToSchemes = runtime.SchemeBuilder{
@@ -92,6 +99,7 @@ func AddHooks(ctx context.Context, config *config.Config, m manager.Manager, gen
 	log := ctxlog.ExtractLogger(ctx)
 	for idx, f := range validatingHookFuncs {
 		hook := f(log, config)
+		hook.Webhook.Handler = metrics.InstrumentWebhook(hook.Name, hook.Webhook.Handler)
 		validatingWebhooks[idx] = hook
 		hookServer.Register(hook.Path, hook.Webhook)
 	}
@@ -99,24 +107,35 @@ func AddHooks(ctx context.Context, config *config.Config, m manager.Manager, gen
 	mutatingWebhooks := make([]*webhook.OperatorWebhook, len(mutatingHookFuncs))
 	for idx, f := range mutatingHookFuncs {
 		hook := f(log, config)
+		hook.Webhook.Handler = metrics.InstrumentWebhook(hook.Name, hook.Webhook.Handler)
 		mutatingWebhooks[idx] = hook
 		hookServer.Register(hook.Path, hook.Webhook)
 	}
 
+	// All operator replicas set up the webhooks concurrently. If another
+	// replica created the certificate secret or a webhook configuration in
+	// the meantime, retrying picks up the existing secret or replaces the
+	// configuration, which is identical as it's based on the same CA.
 	ctxlog.Info(ctx, "Generating webhook certificates")
-	err := webhookConfig.SetupCertificate(ctx, "cf-operator-webhook")
+	err := retry.OnError(retry.DefaultBackoff, apierrors.IsAlreadyExists, func() error {
+		return webhookConfig.SetupCertificate(ctx, "cf-operator-webhook")
+	})
 	if err != nil {
 		return errors.Wrap(err, "setting up the webhook server certificate")
 	}
 
 	ctxlog.Info(ctx, "Generating validating webhook server configuration")
-	err = webhookConfig.CreateValidationWebhookServerConfig(ctx, validatingWebhooks)
+	err = retry.OnError(retry.DefaultBackoff, apierrors.IsAlreadyExists, func() error {
+		return webhookConfig.CreateValidationWebhookServerConfig(ctx, validatingWebhooks)
+	})
 	if err != nil {
 		return errors.Wrap(err, "generating the validating webhook server configuration")
 	}
 
 	ctxlog.Info(ctx, "Generating mutating webhook server configuration")
-	err = webhookConfig.CreateMutationWebhookServerConfig(ctx, "cf-operator-webhook", mutatingWebhooks)
+	err = retry.OnError(retry.DefaultBackoff, apierrors.IsAlreadyExists, func() error {
+		return webhookConfig.CreateMutationWebhookServerConfig(ctx, "cf-operator-webhook", mutatingWebhooks)
+	})
 	if err != nil {
 		return errors.Wrap(err, "generating the webhook server configuration")
 	}
@@ -39,6 +39,7 @@ var _ = Describe("Controllers", func() {
 				kinds = append(kinds, k.Kind)
 			}
 			Expect(kinds).To(ContainElement("BOSHDeployment"))
+			Expect(kinds).To(ContainElement("ErrandRun"))
 			Expect(kinds).To(ContainElement("QuarksSecret"))
 			Expect(kinds).To(ContainElement("QuarksStatefulSet"))
 		})
@@ -126,6 +127,43 @@ var _ = Describe("Controllers", func() {
 			})
 		})
 
+		Context("if another replica creates the cert secret concurrently", func() {
+			It("uses the secret created by the other replica", func() {
+				secretCreated := false
+				client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+					switch object := object.(type) {
+					case *unstructured.Unstructured:
+						if !secretCreated {
+							return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+						}
+						object.Object = map[string]interface{}{
+							"data": map[string]interface{}{
+								"certificate":    base64.StdEncoding.EncodeToString([]byte("the-cert")),
+								"private_key":    base64.StdEncoding.EncodeToString([]byte("the-key")),
+								"ca_certificate": base64.StdEncoding.EncodeToString([]byte("the-ca-cert")),
+								"ca_private_key": base64.StdEncoding.EncodeToString([]byte("the-ca-key")),
+							},
+						}
+						return nil
+					}
+					return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+				})
+				client.CreateCalls(func(context context.Context, object runtime.Object, _ ...crc.CreateOption) error {
+					switch object.(type) {
+					case *admissionregistration.MutatingWebhookConfiguration, *admissionregistration.ValidatingWebhookConfiguration:
+						return nil
+					}
+					secretCreated = true
+					return apierrors.NewAlreadyExists(schema.GroupResource{}, "cf-operator-webhook-server-cert")
+				})
+
+				err := controllers.AddHooks(ctx, config, manager, generator)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(client.GetCallCount()).To(Equal(2))
+				Expect(client.CreateCallCount()).To(Equal(3)) // failed secret and the 2 webhook configs
+			})
+		})
+
 		Context("if there is a persisted cert secret already", func() {
 			BeforeEach(func() {
 				secret := &unstructured.Unstructured{
@@ -0,0 +1,112 @@
+// Package errandrun runs errands of a BOSH deployment on demand and records their outcome
+package errandrun
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"code.cloudfoundry.org/quarks-job/pkg/kube/controllers/quarksjob"
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/metrics"
+	"code.cloudfoundry.org/quarks-utils/pkg/config"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+	"code.cloudfoundry.org/quarks-utils/pkg/monitorednamespace"
+	vss "code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore"
+)
+
+const name = "errandrun"
+
+// AddErrandRun creates a new ErrandRun controller, which starts a job of the
+// errand's QuarksJob for every new ErrandRun and records the job's outcome in its status
+func AddErrandRun(ctx context.Context, config *config.Config, mgr manager.Manager) error {
+	ctx = ctxlog.NewContextWithRecorder(ctx, name+"-reconciler", mgr.GetEventRecorderFor(name+"-recorder"))
+
+	clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return errors.Wrap(err, "Could not get kubernetes clientset for errand run controller")
+	}
+	jobCreator := quarksjob.NewJobCreator(mgr.GetClient(), mgr.GetScheme(), controllerutil.SetControllerReference, config, vss.NewVersionedSecretStore(mgr.GetClient()))
+	r := NewErrandRunReconciler(ctx, config, mgr, NewPodLogs(clientset), jobCreator)
+
+	c, err := controller.New(name+"-controller", mgr, controller.Options{
+		Reconciler: metrics.InstrumentReconciler(metrics.ControllerErrandRun, r),
+	})
+	if err != nil {
+		return errors.Wrap(err, "Adding errand run controller to manager failed.")
+	}
+
+	nsPred := monitorednamespace.NewNSPredicate(ctx, mgr.GetClient(), config.MonitoredID)
+
+	// Watch for new errand runs, spec changes are ignored
+	p := predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			ctxlog.NewPredicateEvent(e.Object).Debug(
+				ctx, e.Meta, "bdv1.ErrandRun",
+				fmt.Sprintf("Create predicate passed for '%s/%s'", e.Meta.GetNamespace(), e.Meta.GetName()),
+			)
+			return true
+		},
+		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+		UpdateFunc:  func(e event.UpdateEvent) bool { return false },
+	}
+	err = c.Watch(&source.Kind{Type: &bdv1.ErrandRun{}}, &handler.EnqueueRequestForObject{}, nsPred, p)
+	if err != nil {
+		return errors.Wrapf(err, "Watching errand runs failed in errand run controller.")
+	}
+
+	// Watch the jobs of errand runs, to record their name and trigger when they finish or
+	// are deleted by quarks-job
+	p = predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return bdv1.HasErrandRunName(e.Object.(*batchv1.Job).Spec.Template.GetLabels())
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return bdv1.HasErrandRunName(e.Object.(*batchv1.Job).Spec.Template.GetLabels())
+		},
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			o := e.ObjectOld.(*batchv1.Job)
+			n := e.ObjectNew.(*batchv1.Job)
+			if !bdv1.HasErrandRunName(n.Spec.Template.GetLabels()) {
+				return false
+			}
+			finished := n.Status.Succeeded > 0 || n.Status.Failed > 0
+			if finished && (o.Status.Succeeded != n.Status.Succeeded || o.Status.Failed != n.Status.Failed) {
+				ctxlog.NewPredicateEvent(e.ObjectNew).Debug(
+					ctx, e.MetaNew, "batchv1.Job",
+					fmt.Sprintf("Update predicate passed for '%s/%s'", e.MetaNew.GetNamespace(), e.MetaNew.GetName()),
+				)
+				return true
+			}
+			return false
+		},
+	}
+	err = c.Watch(&source.Kind{Type: &batchv1.Job{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+			job := a.Object.(*batchv1.Job)
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{
+				Namespace: job.Namespace,
+				Name:      job.Spec.Template.Labels[bdv1.LabelErrandRunName],
+			}}}
+		}),
+	}, nsPred, p)
+	if err != nil {
+		return errors.Wrapf(err, "Watching jobs failed in errand run controller.")
+	}
+
+	return nil
+}
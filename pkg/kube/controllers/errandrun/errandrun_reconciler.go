@@ -0,0 +1,271 @@
+package errandrun
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	qjv1a1 "code.cloudfoundry.org/quarks-job/pkg/kube/apis/quarksjob/v1alpha1"
+	"code.cloudfoundry.org/quarks-job/pkg/kube/controllers/quarksjob"
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/names"
+	"code.cloudfoundry.org/quarks-utils/pkg/config"
+	log "code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+	"code.cloudfoundry.org/quarks-utils/pkg/pointers"
+)
+
+const (
+	// logTailLines is the number of log lines kept per container
+	logTailLines = 50
+	// maxOutputLength limits the size of the output in the errand run's status
+	maxOutputLength = 4096
+)
+
+// PodLogs fetches the logs of a pod's container
+type PodLogs interface {
+	Logs(ctx context.Context, namespace string, pod string, container string) (string, error)
+}
+
+// NewPodLogs returns a PodLogs implementation using the kubernetes API
+func NewPodLogs(clientset kubernetes.Interface) PodLogs {
+	return &podLogs{clientset: clientset}
+}
+
+type podLogs struct {
+	clientset kubernetes.Interface
+}
+
+// Logs returns the last lines of the container's logs
+func (p *podLogs) Logs(ctx context.Context, namespace string, pod string, container string) (string, error) {
+	req := p.clientset.CoreV1().Pods(namespace).GetLogs(pod, &corev1.PodLogOptions{
+		Container: container,
+		TailLines: pointers.Int64(logTailLines),
+	})
+	b, err := req.DoRaw(ctx)
+	if err != nil {
+		return "", errors.Wrapf(err, "getting logs of container '%s' in pod '%s/%s'", container, namespace, pod)
+	}
+	return string(b), nil
+}
+
+// NewErrandRunReconciler returns a new reconciler for errand runs
+func NewErrandRunReconciler(ctx context.Context, config *config.Config, mgr manager.Manager, logs PodLogs, jobCreator quarksjob.JobCreator) reconcile.Reconciler {
+	return &ReconcileErrandRun{
+		ctx:        ctx,
+		config:     config,
+		client:     mgr.GetClient(),
+		logs:       logs,
+		jobCreator: jobCreator,
+	}
+}
+
+// ReconcileErrandRun reconciles an ErrandRun object
+type ReconcileErrandRun struct {
+	ctx        context.Context
+	client     client.Client
+	config     *config.Config
+	logs       PodLogs
+	jobCreator quarksjob.JobCreator
+}
+
+// Reconcile starts a job of the errand's QuarksJob for a new ErrandRun and
+// records the outcome of the job in the ErrandRun's status, once it has finished
+func (r *ReconcileErrandRun) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	// Set the ctx to be Background, as the top-level context for incoming requests.
+	ctx, cancel := context.WithTimeout(r.ctx, r.config.CtxTimeOut)
+	defer cancel()
+
+	log.Infof(ctx, "Reconciling ErrandRun '%s'", request.NamespacedName)
+	run := &bdv1.ErrandRun{}
+	err := r.client.Get(ctx, request.NamespacedName, run)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			// Return and don't requeue
+			log.Debug(ctx, "Skip reconcile: ErrandRun not found")
+			return reconcile.Result{}, nil
+		}
+
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, errors.Wrap(err, "could not get ErrandRun")
+	}
+
+	if run.IsFinished() {
+		log.Debugf(ctx, "Skip reconcile: ErrandRun '%s' already finished", run.GetNamespacedName())
+		return reconcile.Result{}, nil
+	}
+
+	qJob := &qjv1a1.QuarksJob{}
+	qJobName := names.QuarksJobName(run.Spec.Deployment, run.Spec.Errand)
+	err = r.client.Get(ctx, types.NamespacedName{Namespace: run.Namespace, Name: qJobName}, qJob)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			msg := fmt.Sprintf("errand '%s' not found in deployment '%s'", run.Spec.Errand, run.Spec.Deployment)
+			_ = log.WithEvent(run, "NotFoundError").Errorf(ctx, "Failed to reconcile ErrandRun '%s': %s", run.GetNamespacedName(), msg)
+			return reconcile.Result{}, r.finish(ctx, run, bdv1.ErrandRunFailed, msg)
+		}
+		return reconcile.Result{}, errors.Wrapf(err, "could not get QuarksJob '%s/%s'", run.Namespace, qJobName)
+	}
+
+	job, err := r.jobForRun(ctx, run, qJob)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if job == nil && run.Status.Phase != bdv1.ErrandRunRunning {
+		return r.start(ctx, run, qJob)
+	}
+
+	return r.collect(ctx, run, job)
+}
+
+// start creates a job for the errand run with quarks-job's job creator. The
+// job is never restarted, so the outcome of a run is the outcome of a single
+// pod. The pod template is labeled with the run's name, to find the job.
+func (r *ReconcileErrandRun) start(ctx context.Context, run *bdv1.ErrandRun, qJob *qjv1a1.QuarksJob) (reconcile.Result, error) {
+	qJob = qJob.DeepCopy()
+	jobSpec := &qJob.Spec.Template.Spec
+	jobSpec.BackoffLimit = pointers.Int32(0)
+	jobSpec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+	if len(run.Spec.Env) > 0 {
+		for i := range jobSpec.Template.Spec.Containers {
+			jobSpec.Template.Spec.Containers[i].Env = append(jobSpec.Template.Spec.Containers[i].Env, run.Spec.Env...)
+		}
+	}
+	if jobSpec.Template.Labels == nil {
+		jobSpec.Template.Labels = map[string]string{}
+	}
+	jobSpec.Template.Labels[bdv1.LabelErrandRunName] = run.Name
+	jobSpec.Template.Labels[bdv1.LabelDeploymentName] = run.Spec.Deployment
+
+	retry, err := r.jobCreator.Create(ctx, *qJob)
+	if err != nil {
+		return reconcile.Result{}, log.WithEvent(run, "CreateJobForErrandRunError").Errorf(ctx, "Failed to create job for QuarksJob '%s': %s", qJob.GetNamespacedName(), err)
+	}
+	if retry {
+		log.Infof(ctx, "Retrying to create job for QuarksJob '%s'", qJob.GetNamespacedName())
+		return reconcile.Result{Requeue: true, RequeueAfter: time.Second * 5}, nil
+	}
+
+	now := metav1.Now()
+	run.Status.Phase = bdv1.ErrandRunRunning
+	run.Status.StartTime = &now
+	if err := r.client.Status().Update(ctx, run); err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "could not update status of ErrandRun '%s'", run.GetNamespacedName())
+	}
+
+	log.WithEvent(run, "ErrandStarted").Infof(ctx, "Started errand '%s' of deployment '%s' with QuarksJob '%s'", run.Spec.Errand, run.Spec.Deployment, qJob.GetNamespacedName())
+	return reconcile.Result{}, nil
+}
+
+// collect records the name of the errand run's job and its exit code and
+// output, once it finished. quarks-job deletes succeeded jobs, so if the job
+// is gone the outcome is taken from its pods.
+func (r *ReconcileErrandRun) collect(ctx context.Context, run *bdv1.ErrandRun, job *batchv1.Job) (reconcile.Result, error) {
+	if job != nil && run.Status.JobName != job.Name {
+		run.Status.JobName = job.Name
+		if err := r.client.Status().Update(ctx, run); err != nil {
+			return reconcile.Result{}, errors.Wrapf(err, "could not update status of ErrandRun '%s'", run.GetNamespacedName())
+		}
+	}
+
+	if job != nil && job.Status.Succeeded == 0 && job.Status.Failed == 0 {
+		log.Debugf(ctx, "Skip reconcile: job '%s/%s' for ErrandRun is still running", job.Namespace, job.Name)
+		return reconcile.Result{}, nil
+	}
+
+	pods := &corev1.PodList{}
+	err := r.client.List(ctx, pods, client.InNamespace(run.Namespace), client.MatchingLabels{bdv1.LabelErrandRunName: run.Name})
+	if err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "could not list pods of ErrandRun '%s'", run.GetNamespacedName())
+	}
+
+	succeeded := false
+	if job != nil {
+		succeeded = job.Status.Succeeded > 0
+	} else {
+		if len(pods.Items) == 0 {
+			return reconcile.Result{}, r.finish(ctx, run, bdv1.ErrandRunFailed, fmt.Sprintf("no job found for errand '%s'", run.Spec.Errand))
+		}
+		for _, pod := range pods.Items {
+			if pod.Status.Phase == corev1.PodSucceeded {
+				succeeded = true
+			}
+		}
+	}
+
+	exitCode := int32(0)
+	output := []string{}
+	for _, pod := range pods.Items {
+		for _, s := range pod.Status.ContainerStatuses {
+			if t := s.State.Terminated; t != nil && t.ExitCode != 0 && exitCode == 0 {
+				exitCode = t.ExitCode
+			}
+
+			logs, err := r.logs.Logs(ctx, pod.Namespace, pod.Name, s.Name)
+			if err != nil {
+				log.Infof(ctx, "Failed to collect output for ErrandRun '%s': %s", run.GetNamespacedName(), err)
+				continue
+			}
+			output = append(output, fmt.Sprintf("[%s/%s]\n%s", pod.Name, s.Name, logs))
+		}
+	}
+	run.Status.ExitCode = &exitCode
+	run.Status.Output = truncate(strings.Join(output, "\n"), maxOutputLength)
+
+	if succeeded {
+		log.WithEvent(run, "ErrandSucceeded").Infof(ctx, "Errand '%s' of deployment '%s' succeeded", run.Spec.Errand, run.Spec.Deployment)
+		return reconcile.Result{}, r.finish(ctx, run, bdv1.ErrandRunSucceeded, "errand succeeded")
+	}
+
+	msg := fmt.Sprintf("errand failed with exit code %d", exitCode)
+	log.WithEvent(run, "ErrandFailed").Infof(ctx, "Errand '%s' of deployment '%s' failed: %s", run.Spec.Errand, run.Spec.Deployment, msg)
+	return reconcile.Result{}, r.finish(ctx, run, bdv1.ErrandRunFailed, msg)
+}
+
+// finish sets the final phase of the errand run
+func (r *ReconcileErrandRun) finish(ctx context.Context, run *bdv1.ErrandRun, phase bdv1.ErrandRunPhase, msg string) error {
+	now := metav1.Now()
+	run.Status.Phase = phase
+	run.Status.CompletionTime = &now
+	run.Status.Message = msg
+	if err := r.client.Status().Update(ctx, run); err != nil {
+		return errors.Wrapf(err, "could not update status of ErrandRun '%s'", run.GetNamespacedName())
+	}
+	return nil
+}
+
+// jobForRun returns the job of the QuarksJob, which was created for the
+// errand run, or nil if there is none
+func (r *ReconcileErrandRun) jobForRun(ctx context.Context, run *bdv1.ErrandRun, qJob *qjv1a1.QuarksJob) (*batchv1.Job, error) {
+	jobs := &batchv1.JobList{}
+	err := r.client.List(ctx, jobs, client.InNamespace(run.Namespace), client.MatchingLabels{qjv1a1.LabelQJobName: qJob.Name})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not list jobs of QuarksJob '%s'", qJob.GetNamespacedName())
+	}
+
+	for i := range jobs.Items {
+		if jobs.Items[i].Spec.Template.Labels[bdv1.LabelErrandRunName] == run.Name {
+			return &jobs.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[len(s)-max:]
+}
@@ -0,0 +1,315 @@
+package errandrun_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1b1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	crc "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	qjv1a1 "code.cloudfoundry.org/quarks-job/pkg/kube/apis/quarksjob/v1alpha1"
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/controllers"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/controllers/errandrun"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/controllers/fakes"
+	cfcfg "code.cloudfoundry.org/quarks-utils/pkg/config"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+	helper "code.cloudfoundry.org/quarks-utils/testing/testhelper"
+)
+
+var _ = Describe("ReconcileErrandRun", func() {
+	var (
+		manager      *fakes.FakeManager
+		client       *fakes.FakeClient
+		statusWriter *fakes.FakeStatusWriter
+		podLogs      *fakes.FakePodLogs
+		jobCreator   *fakes.FakeJobCreator
+		reconciler   reconcile.Reconciler
+		request      reconcile.Request
+		ctx          context.Context
+		run          *bdv1.ErrandRun
+		qJob         *qjv1a1.QuarksJob
+		job          *batchv1.Job
+		pod          *corev1.Pod
+	)
+
+	BeforeEach(func() {
+		_ = controllers.AddToScheme(scheme.Scheme)
+		manager = &fakes.FakeManager{}
+		manager.GetSchemeReturns(scheme.Scheme)
+		client = &fakes.FakeClient{}
+		manager.GetClientReturns(client)
+		statusWriter = &fakes.FakeStatusWriter{}
+		client.StatusCalls(func() crc.StatusWriter { return statusWriter })
+		podLogs = &fakes.FakePodLogs{}
+		podLogs.LogsReturns("smoke tests passed", nil)
+
+		_, log := helper.NewTestLogger()
+		ctx = ctxlog.NewParentContext(log)
+		config := &cfcfg.Config{CtxTimeOut: 10 * time.Second}
+		jobCreator = &fakes.FakeJobCreator{}
+		reconciler = errandrun.NewErrandRunReconciler(ctx, config, manager, podLogs, jobCreator)
+
+		request = reconcile.Request{NamespacedName: types.NamespacedName{Name: "run-1", Namespace: "default"}}
+		run = &bdv1.ErrandRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "run-1", Namespace: "default"},
+			Spec: bdv1.ErrandRunSpec{
+				Deployment: "cf",
+				Errand:     "smoke-tests",
+				Env:        []corev1.EnvVar{{Name: "SUITE", Value: "fast"}},
+			},
+		}
+		qJob = &qjv1a1.QuarksJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "cf-smoke-tests", Namespace: "default"},
+			Spec: qjv1a1.QuarksJobSpec{
+				Template: batchv1b1.JobTemplateSpec{
+					Spec: batchv1.JobSpec{
+						Template: corev1.PodTemplateSpec{
+							ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "smoke"}},
+							Spec: corev1.PodSpec{
+								RestartPolicy: corev1.RestartPolicyOnFailure,
+								Containers:    []corev1.Container{{Name: "smoke-tests-run"}},
+							},
+						},
+					},
+				},
+			},
+		}
+		job = &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cf-smoke-tests-job-abcde",
+				Namespace: "default",
+				Labels:    map[string]string{qjv1a1.LabelQJobName: "cf-smoke-tests"},
+			},
+			Spec: batchv1.JobSpec{
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{bdv1.LabelErrandRunName: "run-1"}},
+				},
+			},
+		}
+		pod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "cf-smoke-tests-job-abcde-fghij", Namespace: "default"},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodSucceeded,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "smoke-tests-run",
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}},
+				}},
+			},
+		}
+
+		client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+			switch object := object.(type) {
+			case *bdv1.ErrandRun:
+				run.DeepCopyInto(object)
+				return nil
+			case *qjv1a1.QuarksJob:
+				if nn.Name == qJob.Name {
+					qJob.DeepCopyInto(object)
+					return nil
+				}
+			}
+			return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+		})
+		client.ListCalls(func(context context.Context, object runtime.Object, _ ...crc.ListOption) error {
+			switch object := object.(type) {
+			case *batchv1.JobList:
+				object.Items = []batchv1.Job{}
+				if job != nil {
+					object.Items = append(object.Items, *job)
+				}
+			case *corev1.PodList:
+				object.Items = []corev1.Pod{}
+				if pod != nil {
+					object.Items = append(object.Items, *pod)
+				}
+			}
+			return nil
+		})
+	})
+
+	Context("when the errand run is new", func() {
+		BeforeEach(func() {
+			job = nil
+			pod = nil
+		})
+
+		It("creates a job with quarks-job's job creator", func() {
+			_, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(client.CreateCallCount()).To(Equal(0))
+
+			Expect(jobCreator.CreateCallCount()).To(Equal(1))
+			_, created := jobCreator.CreateArgsForCall(0)
+			Expect(created.Name).To(Equal("cf-smoke-tests"))
+			spec := created.Spec.Template.Spec
+			Expect(*spec.BackoffLimit).To(Equal(int32(0)))
+			Expect(spec.Template.Labels).To(HaveKeyWithValue("app", "smoke"))
+			Expect(spec.Template.Labels).To(HaveKeyWithValue(bdv1.LabelErrandRunName, "run-1"))
+			Expect(spec.Template.Labels).To(HaveKeyWithValue(bdv1.LabelDeploymentName, "cf"))
+			Expect(spec.Template.Spec.RestartPolicy).To(Equal(corev1.RestartPolicyNever))
+			Expect(spec.Template.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "SUITE", Value: "fast"}))
+			Expect(qJob.Spec.Template.Spec.Template.Spec.Containers[0].Env).To(BeEmpty())
+
+			Expect(statusWriter.UpdateCallCount()).To(Equal(1))
+			_, object, _ := statusWriter.UpdateArgsForCall(0)
+			status := object.(*bdv1.ErrandRun).Status
+			Expect(status.Phase).To(Equal(bdv1.ErrandRunRunning))
+			Expect(status.StartTime).ToNot(BeNil())
+		})
+
+		It("fails the run if the errand does not exist", func() {
+			run.Spec.Errand = "unknown"
+
+			_, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(client.CreateCallCount()).To(Equal(0))
+
+			Expect(statusWriter.UpdateCallCount()).To(Equal(1))
+			_, object, _ := statusWriter.UpdateArgsForCall(0)
+			status := object.(*bdv1.ErrandRun).Status
+			Expect(status.Phase).To(Equal(bdv1.ErrandRunFailed))
+			Expect(status.Message).To(ContainSubstring("errand 'unknown' not found in deployment 'cf'"))
+			Expect(status.CompletionTime).ToNot(BeNil())
+		})
+
+		It("returns an error if the job can't be created", func() {
+			jobCreator.CreateReturns(false, errors.New("fake-error"))
+
+			_, err := reconciler.Reconcile(request)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("failed to create job for QuarksJob 'default/cf-smoke-tests'"))
+			Expect(statusWriter.UpdateCallCount()).To(Equal(0))
+		})
+
+		It("requeues, if the job creator has to retry", func() {
+			jobCreator.CreateReturns(true, nil)
+
+			result, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Requeue).To(BeTrue())
+			Expect(statusWriter.UpdateCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the errand's job is running", func() {
+		BeforeEach(func() {
+			run.Status.Phase = bdv1.ErrandRunRunning
+			run.Status.JobName = job.Name
+		})
+
+		It("waits for the job to finish", func() {
+			_, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(jobCreator.CreateCallCount()).To(Equal(0))
+			Expect(statusWriter.UpdateCallCount()).To(Equal(0))
+		})
+
+		It("records the name of the job", func() {
+			run.Status.JobName = ""
+
+			_, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(jobCreator.CreateCallCount()).To(Equal(0))
+
+			Expect(statusWriter.UpdateCallCount()).To(Equal(1))
+			_, object, _ := statusWriter.UpdateArgsForCall(0)
+			status := object.(*bdv1.ErrandRun).Status
+			Expect(status.Phase).To(Equal(bdv1.ErrandRunRunning))
+			Expect(status.JobName).To(Equal("cf-smoke-tests-job-abcde"))
+		})
+
+		It("records exit code and output when the job succeeded", func() {
+			job.Status.Succeeded = 1
+
+			_, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(podLogs.LogsCallCount()).To(Equal(1))
+			_, namespace, podName, container := podLogs.LogsArgsForCall(0)
+			Expect(namespace).To(Equal("default"))
+			Expect(podName).To(Equal(pod.Name))
+			Expect(container).To(Equal("smoke-tests-run"))
+
+			Expect(statusWriter.UpdateCallCount()).To(Equal(1))
+			_, object, _ := statusWriter.UpdateArgsForCall(0)
+			status := object.(*bdv1.ErrandRun).Status
+			Expect(status.Phase).To(Equal(bdv1.ErrandRunSucceeded))
+			Expect(*status.ExitCode).To(Equal(int32(0)))
+			Expect(status.Output).To(ContainSubstring("smoke tests passed"))
+			Expect(status.CompletionTime).ToNot(BeNil())
+		})
+
+		It("records the non-zero exit code when the job failed", func() {
+			job.Status.Failed = 1
+			pod.Status.Phase = corev1.PodFailed
+			pod.Status.ContainerStatuses[0].State.Terminated.ExitCode = 3
+
+			_, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(statusWriter.UpdateCallCount()).To(Equal(1))
+			_, object, _ := statusWriter.UpdateArgsForCall(0)
+			status := object.(*bdv1.ErrandRun).Status
+			Expect(status.Phase).To(Equal(bdv1.ErrandRunFailed))
+			Expect(*status.ExitCode).To(Equal(int32(3)))
+			Expect(status.Message).To(Equal("errand failed with exit code 3"))
+		})
+	})
+
+	Context("when quarks-job deleted the succeeded job", func() {
+		BeforeEach(func() {
+			run.Status.Phase = bdv1.ErrandRunRunning
+			run.Status.JobName = job.Name
+			job = nil
+		})
+
+		It("records the outcome of the job's pod", func() {
+			_, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(jobCreator.CreateCallCount()).To(Equal(0))
+
+			Expect(statusWriter.UpdateCallCount()).To(Equal(1))
+			_, object, _ := statusWriter.UpdateArgsForCall(0)
+			status := object.(*bdv1.ErrandRun).Status
+			Expect(status.Phase).To(Equal(bdv1.ErrandRunSucceeded))
+			Expect(status.Output).To(ContainSubstring("smoke tests passed"))
+		})
+
+		It("fails the run, if the pods are gone, too", func() {
+			pod = nil
+
+			_, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(statusWriter.UpdateCallCount()).To(Equal(1))
+			_, object, _ := statusWriter.UpdateArgsForCall(0)
+			status := object.(*bdv1.ErrandRun).Status
+			Expect(status.Phase).To(Equal(bdv1.ErrandRunFailed))
+			Expect(status.Message).To(Equal("no job found for errand 'smoke-tests'"))
+		})
+	})
+
+	Context("when the errand run finished", func() {
+		It("does nothing", func() {
+			run.Status.Phase = bdv1.ErrandRunSucceeded
+
+			_, err := reconciler.Reconcile(request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(client.GetCallCount()).To(Equal(1))
+			Expect(statusWriter.UpdateCallCount()).To(Equal(0))
+		})
+	})
+})
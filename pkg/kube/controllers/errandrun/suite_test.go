@@ -0,0 +1,13 @@
+package errandrun_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestErrandRun(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ErrandRun Suite")
+}
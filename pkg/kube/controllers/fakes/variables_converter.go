@@ -10,12 +10,13 @@ import (
 )
 
 type FakeVariablesConverter struct {
-	VariablesStub        func(string, string, []manifest.Variable) ([]v1alpha1.QuarksSecret, error)
+	VariablesStub        func(string, string, []manifest.Variable, manifest.Tags) ([]v1alpha1.QuarksSecret, error)
 	variablesMutex       sync.RWMutex
 	variablesArgsForCall []struct {
 		arg1 string
 		arg2 string
 		arg3 []manifest.Variable
+		arg4 manifest.Tags
 	}
 	variablesReturns struct {
 		result1 []v1alpha1.QuarksSecret
@@ -29,7 +30,7 @@ type FakeVariablesConverter struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeVariablesConverter) Variables(arg1 string, arg2 string, arg3 []manifest.Variable) ([]v1alpha1.QuarksSecret, error) {
+func (fake *FakeVariablesConverter) Variables(arg1 string, arg2 string, arg3 []manifest.Variable, arg4 manifest.Tags) ([]v1alpha1.QuarksSecret, error) {
 	var arg3Copy []manifest.Variable
 	if arg3 != nil {
 		arg3Copy = make([]manifest.Variable, len(arg3))
@@ -41,11 +42,12 @@ func (fake *FakeVariablesConverter) Variables(arg1 string, arg2 string, arg3 []m
 		arg1 string
 		arg2 string
 		arg3 []manifest.Variable
-	}{arg1, arg2, arg3Copy})
-	fake.recordInvocation("Variables", []interface{}{arg1, arg2, arg3Copy})
+		arg4 manifest.Tags
+	}{arg1, arg2, arg3Copy, arg4})
+	fake.recordInvocation("Variables", []interface{}{arg1, arg2, arg3Copy, arg4})
 	fake.variablesMutex.Unlock()
 	if fake.VariablesStub != nil {
-		return fake.VariablesStub(arg1, arg2, arg3)
+		return fake.VariablesStub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -60,17 +62,17 @@ func (fake *FakeVariablesConverter) VariablesCallCount() int {
 	return len(fake.variablesArgsForCall)
 }
 
-func (fake *FakeVariablesConverter) VariablesCalls(stub func(string, string, []manifest.Variable) ([]v1alpha1.QuarksSecret, error)) {
+func (fake *FakeVariablesConverter) VariablesCalls(stub func(string, string, []manifest.Variable, manifest.Tags) ([]v1alpha1.QuarksSecret, error)) {
 	fake.variablesMutex.Lock()
 	defer fake.variablesMutex.Unlock()
 	fake.VariablesStub = stub
 }
 
-func (fake *FakeVariablesConverter) VariablesArgsForCall(i int) (string, string, []manifest.Variable) {
+func (fake *FakeVariablesConverter) VariablesArgsForCall(i int) (string, string, []manifest.Variable, manifest.Tags) {
 	fake.variablesMutex.RLock()
 	defer fake.variablesMutex.RUnlock()
 	argsForCall := fake.variablesArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
 func (fake *FakeVariablesConverter) VariablesReturns(result1 []v1alpha1.QuarksSecret, result2 error) {
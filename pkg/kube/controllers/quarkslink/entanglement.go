@@ -92,7 +92,7 @@ func (e entanglement) find(secret corev1.Secret) (link, bool) {
 	}
 
 	for _, link := range e.links {
-		name := names.QuarksLinkSecretName(link.LinkType, link.Name)
+		name := names.QuarksLinkSecretName(e.deployment, link.LinkType, link.Name)
 		if _, ok := secret.Labels[bdv1.LabelEntanglementKey]; ok && secret.Name == name {
 			return link, true
 		}
@@ -46,9 +46,9 @@ var _ = Describe("Mount quarks link secret on entangled pods", func() {
 	)
 	annotationPatch := `{"op":"add","path":"/metadata/annotations/quarks.cloudfoundry.org~1restart-on-update","value":"true"}`
 
-	podPatch := `{"op":"add","path":"/spec/volumes","value":[{"name":"link-nats-nats","secret":{"secretName":"link-nats-nats"}}]}`
-	containerPatch := `{"op":"add","path":"/spec/containers/0/volumeMounts","value":[{"mountPath":"/quarks/link/nats-deployment/nats-nats","name":"link-nats-nats","readOnly":true}]}`
-	secondContainerPatch := `{"op":"add","path":"/spec/containers/1/volumeMounts","value":[{"mountPath":"/quarks/link/nats-deployment/nats-nats","name":"link-nats-nats","readOnly":true}]}`
+	podPatch := `{"op":"add","path":"/spec/volumes","value":[{"name":"link-nats-deployment-nats-nats","secret":{"secretName":"link-nats-deployment-nats-nats"}}]}`
+	containerPatch := `{"op":"add","path":"/spec/containers/0/volumeMounts","value":[{"mountPath":"/quarks/link/nats-deployment/nats-nats","name":"link-nats-deployment-nats-nats","readOnly":true}]}`
+	secondContainerPatch := `{"op":"add","path":"/spec/containers/1/volumeMounts","value":[{"mountPath":"/quarks/link/nats-deployment/nats-nats","name":"link-nats-deployment-nats-nats","readOnly":true}]}`
 
 	jsonPatches := func(operations []jsonpatch.Operation) []string {
 		patches := make([]string, len(operations))
@@ -176,9 +176,9 @@ var _ = Describe("Mount quarks link secret on entangled pods", func() {
 	})
 
 	Context("when pod has existing volumes", func() {
-		podPatch := `{"op":"add","path":"/spec/volumes/1","value":{"name":"link-nats-nats","secret":{"secretName":"link-nats-nats"}}}`
-		containerPatch := `{"op":"add","path":"/spec/containers/0/volumeMounts/1","value":{"mountPath":"/quarks/link/nats-deployment/nats-nats","name":"link-nats-nats","readOnly":true}}`
-		envVarsPatch := `{"op":"add","path":"/spec/containers/0/env","value":[{"name":"LINK_NATS_PASSWORD","valueFrom":{"secretKeyRef":{"key":"nats.password","name":"link-nats-nats"}}},{"name":"LINK_NATS_PORT","valueFrom":{"secretKeyRef":{"key":"nats.port","name":"link-nats-nats"}}},{"name":"LINK_NATS_USER","valueFrom":{"secretKeyRef":{"key":"nats.user","name":"link-nats-nats"}}}]}`
+		podPatch := `{"op":"add","path":"/spec/volumes/1","value":{"name":"link-nats-deployment-nats-nats","secret":{"secretName":"link-nats-deployment-nats-nats"}}}`
+		containerPatch := `{"op":"add","path":"/spec/containers/0/volumeMounts/1","value":{"mountPath":"/quarks/link/nats-deployment/nats-nats","name":"link-nats-deployment-nats-nats","readOnly":true}}`
+		envVarsPatch := `{"op":"add","path":"/spec/containers/0/env","value":[{"name":"LINK_NATS_PASSWORD","valueFrom":{"secretKeyRef":{"key":"nats.password","name":"link-nats-deployment-nats-nats"}}},{"name":"LINK_NATS_PORT","valueFrom":{"secretKeyRef":{"key":"nats.port","name":"link-nats-deployment-nats-nats"}}},{"name":"LINK_NATS_USER","valueFrom":{"secretKeyRef":{"key":"nats.user","name":"link-nats-deployment-nats-nats"}}}]}`
 
 		BeforeEach(func() {
 			pod = env.NatsPod("entangled-pod")
@@ -17,6 +17,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/apis"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/metrics"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/reference"
 	"code.cloudfoundry.org/quarks-utils/pkg/config"
 	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
@@ -36,7 +37,7 @@ func AddRestart(ctx context.Context, config *config.Config, mgr manager.Manager)
 	r := NewRestartReconciler(ctx, config, mgr)
 
 	c, err := controller.New(name+"-controller", mgr, controller.Options{
-		Reconciler: r,
+		Reconciler: metrics.InstrumentReconciler(metrics.ControllerRestart, r),
 	})
 	if err != nil {
 		return errors.Wrap(err, "Adding restart controller to manager failed.")
@@ -13,6 +13,7 @@ import (
 
 	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/controllers"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/metrics"
 	"code.cloudfoundry.org/quarks-utils/pkg/config"
 	"code.cloudfoundry.org/quarks-utils/pkg/crd"
 	credsgen "code.cloudfoundry.org/quarks-utils/pkg/credsgen/in_memory_generator"
@@ -48,10 +49,13 @@ func NewManager(ctx context.Context, config *config.Config, cfg *rest.Config, op
 		return nil, errors.Wrap(err, "failed to add controllers to manager")
 	}
 
+	// Export the number of resources per deployment
+	metrics.SetResourceClient(mgr.GetClient())
+
 	return mgr, nil
 }
 
-// ApplyCRDs applies a bdpl CRD into the cluster
+// ApplyCRDs applies the bdpl, runtime config, cloud config and errand run CRDs into the cluster
 func ApplyCRDs(ctx context.Context, config *rest.Config) error {
 	client, err := extv1client.NewForConfig(config)
 	if err != nil {
@@ -80,5 +84,66 @@ func ApplyCRDs(ctx context.Context, config *rest.Config) error {
 	if err != nil {
 		return errors.Wrapf(err, "failed to wait for CRD '%s' ready", bdv1.BOSHDeploymentResourceName)
 	}
+
+	// Add runtime config crd
+	err = crd.New(
+		bdv1.RuntimeConfigResourceName,
+		extv1.CustomResourceDefinitionNames{
+			Kind:       bdv1.RuntimeConfigResourceKind,
+			Plural:     bdv1.RuntimeConfigResourcePlural,
+			ShortNames: bdv1.RuntimeConfigResourceShortNames,
+		},
+		bdv1.SchemeGroupVersion,
+	).WithValidation(&bdv1.RuntimeConfigValidation).
+		Build().
+		Apply(ctx, client)
+	if err != nil {
+		return errors.Wrapf(err, "failed to apply CRD '%s'", bdv1.RuntimeConfigResourceName)
+	}
+	err = crd.WaitForCRDReady(ctx, client, bdv1.RuntimeConfigResourceName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to wait for CRD '%s' ready", bdv1.RuntimeConfigResourceName)
+	}
+
+	// Add cloud config crd
+	err = crd.New(
+		bdv1.CloudConfigResourceName,
+		extv1.CustomResourceDefinitionNames{
+			Kind:       bdv1.CloudConfigResourceKind,
+			Plural:     bdv1.CloudConfigResourcePlural,
+			ShortNames: bdv1.CloudConfigResourceShortNames,
+		},
+		bdv1.SchemeGroupVersion,
+	).WithValidation(&bdv1.CloudConfigValidation).
+		Build().
+		Apply(ctx, client)
+	if err != nil {
+		return errors.Wrapf(err, "failed to apply CRD '%s'", bdv1.CloudConfigResourceName)
+	}
+	err = crd.WaitForCRDReady(ctx, client, bdv1.CloudConfigResourceName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to wait for CRD '%s' ready", bdv1.CloudConfigResourceName)
+	}
+
+	// Add errand run crd
+	err = crd.New(
+		bdv1.ErrandRunResourceName,
+		extv1.CustomResourceDefinitionNames{
+			Kind:       bdv1.ErrandRunResourceKind,
+			Plural:     bdv1.ErrandRunResourcePlural,
+			ShortNames: bdv1.ErrandRunResourceShortNames,
+		},
+		bdv1.SchemeGroupVersion,
+	).WithValidation(&bdv1.ErrandRunValidation).
+		WithAdditionalPrinterColumns(bdv1.ErrandRunAdditionalPrinterColumns).
+		Build().
+		Apply(ctx, client)
+	if err != nil {
+		return errors.Wrapf(err, "failed to apply CRD '%s'", bdv1.ErrandRunResourceName)
+	}
+	err = crd.WaitForCRDReady(ctx, client, bdv1.ErrandRunResourceName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to wait for CRD '%s' ready", bdv1.ErrandRunResourceName)
+	}
 	return nil
 }
@@ -15,11 +15,14 @@ import (
 
 	bdm "code.cloudfoundry.org/quarks-operator/pkg/bosh/manifest"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/apis"
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/mutate"
+	"code.cloudfoundry.org/quarks-utils/pkg/names"
 )
 
 const (
-	// AppName is the name os the DNS deployed by quarks.
+	// AppName is the name of the DNS deployed by quarks. It's used
+	// as a suffix for the per deployment DNS resources.
 	AppName        = "coredns-quarks"
 	coreConfigFile = "Corefile"
 	// CorednsServiceAccountLabel is the label of coredns service account on ns.
@@ -63,17 +66,33 @@ type Target struct {
 type BoshDomainNameService struct {
 	Corefile       *Corefile
 	LocalDNSIP     string
+	DeploymentName string
 	InstanceGroups bdm.InstanceGroups
 }
 
 // NewBoshDomainNameService create a new DomainNameService to setup BOSH DNS.
-func NewBoshDomainNameService(instanceGroups bdm.InstanceGroups) *BoshDomainNameService {
+func NewBoshDomainNameService(deploymentName string, instanceGroups bdm.InstanceGroups) *BoshDomainNameService {
 	return &BoshDomainNameService{
 		Corefile:       &Corefile{},
+		DeploymentName: deploymentName,
 		InstanceGroups: instanceGroups,
 	}
 }
 
+// DNSName returns the name of the DNS k8s resources for a deployment:
+// `<deployment-name>-coredns-quarks`
+func DNSName(deploymentName string) string {
+	return names.Sanitize(fmt.Sprintf("%s-%s", deploymentName, AppName))
+}
+
+// labels returns the labels used for and to select the DNS k8s resources
+func (dns *BoshDomainNameService) labels() map[string]string {
+	return map[string]string{
+		"app":                    AppName,
+		bdv1.LabelDeploymentName: dns.DeploymentName,
+	}
+}
+
 // Add create a new DomainNameService to setup BOSH DNS.
 func (dns *BoshDomainNameService) Add(addOn *bdm.AddOn) error {
 	for _, job := range addOn.Jobs {
@@ -88,13 +107,13 @@ func (dns *BoshDomainNameService) Add(addOn *bdm.AddOn) error {
 func (dns *BoshDomainNameService) CorefileConfigMap(namespace string) (corev1.ConfigMap, error) {
 	cm := corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      AppName,
+			Name:      DNSName(dns.DeploymentName),
 			Namespace: namespace,
-			Labels:    map[string]string{"app": AppName},
+			Labels:    dns.labels(),
 		},
 	}
 
-	corefile, err := dns.Corefile.Create(namespace, dns.InstanceGroups)
+	corefile, err := dns.Corefile.Create(dns.DeploymentName, namespace, dns.InstanceGroups)
 	if err != nil {
 		return cm, err
 	}
@@ -110,18 +129,18 @@ func (dns *BoshDomainNameService) Deployment(namespace string, corednsServiceAcc
 	const volumeName = "bosh-dns-volume"
 	return appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      AppName,
+			Name:      DNSName(dns.DeploymentName),
 			Namespace: namespace,
-			Labels:    map[string]string{"app": AppName},
+			Labels:    dns.labels(),
 		},
 		Spec: appsv1.DeploymentSpec{
 			Selector: &metav1.LabelSelector{
-				MatchLabels: map[string]string{"app": AppName},
+				MatchLabels: dns.labels(),
 			},
 			Replicas: &replicas,
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels:      map[string]string{"app": AppName},
+					Labels:      dns.labels(),
 					Annotations: map[string]string{annotationRestartOnUpdate: "true"},
 				},
 				Spec: corev1.PodSpec{
@@ -171,7 +190,7 @@ func (dns *BoshDomainNameService) Deployment(namespace string, corednsServiceAcc
 								ConfigMap: &corev1.ConfigMapVolumeSource{
 									DefaultMode: &corefileMode,
 									LocalObjectReference: corev1.LocalObjectReference{
-										Name: AppName,
+										Name: DNSName(dns.DeploymentName),
 									},
 									Items: []corev1.KeyToPath{
 										{Key: coreConfigFile, Path: coreConfigFile},
@@ -190,9 +209,9 @@ func (dns *BoshDomainNameService) Deployment(namespace string, corednsServiceAcc
 func (dns *BoshDomainNameService) Service(namespace string) corev1.Service {
 	return corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      AppName,
+			Name:      DNSName(dns.DeploymentName),
 			Namespace: namespace,
-			Labels:    map[string]string{"app": AppName},
+			Labels:    dns.labels(),
 		},
 		Spec: corev1.ServiceSpec{
 			Ports: []corev1.ServicePort{
@@ -200,7 +219,7 @@ func (dns *BoshDomainNameService) Service(namespace string) corev1.Service {
 				{Name: dnsTCPPort.Name, Port: 53, Protocol: dnsTCPPort.Protocol, TargetPort: intstr.FromString(dnsTCPPort.Name)},
 				{Name: metricsPort.Name, Port: 9153, Protocol: metricsPort.Protocol, TargetPort: intstr.FromString(metricsPort.Name)},
 			},
-			Selector: map[string]string{"app": AppName},
+			Selector: dns.labels(),
 			Type:     "ClusterIP",
 		},
 	}
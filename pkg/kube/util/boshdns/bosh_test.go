@@ -209,7 +209,7 @@ var _ = Describe("BOSHDomainNameService", func() {
 					&manifest.InstanceGroup{Name: "bits", AZs: []string{"az1", "az2"}},
 					&manifest.InstanceGroup{Name: "diego-cell", AZs: []string{"az1", "az2"}, Instances: 1},
 				}
-				dns = boshdns.NewBoshDomainNameService(igs)
+				dns = boshdns.NewBoshDomainNameService("scf", igs)
 				err := dns.Add(loadAddOn(aliasAddon))
 				Expect(err).NotTo(HaveOccurred())
 
@@ -243,17 +243,17 @@ var _ = Describe("BOSHDomainNameService", func() {
 				Expect(corefile).To(ContainSubstring(`
 	template IN A bits.service.cf.internal {
 		match ^bits\.service\.cf\.internal\.$
-		answer "{{ .Name }} 60 IN CNAME bits.default.svc."
+		answer "{{ .Name }} 60 IN CNAME scf-bits.default.svc."
 		fallthrough`))
 				Expect(corefile).To(ContainSubstring(`
 	template IN AAAA bits.service.cf.internal {
 		match ^bits\.service\.cf\.internal\.$
-		answer "{{ .Name }} 60 IN CNAME bits.default.svc."
+		answer "{{ .Name }} 60 IN CNAME scf-bits.default.svc."
 		fallthrough`))
 				Expect(corefile).To(ContainSubstring(`
 	template IN CNAME bbs1.service.cf.internal {
 		match ^bbs1\.service\.cf\.internal\.$
-		answer "{{ .Name }} 60 IN CNAME diego-api.default.svc."
+		answer "{{ .Name }} 60 IN CNAME scf-diego-api.default.svc."
 		fallthrough
 	}`))
 
@@ -268,14 +268,14 @@ var _ = Describe("BOSHDomainNameService", func() {
 				Expect(corefile).To(ContainSubstring(`
 	template IN A diego-cell-z0-0.cell.service.cf.internal {
 		match ^diego-cell-z0-0\.cell\.service\.cf\.internal\.$
-		answer "{{ .Name }} 60 IN CNAME diego-cell-z0-0.default.svc."
+		answer "{{ .Name }} 60 IN CNAME scf-diego-cell-z0-0.default.svc."
 		fallthrough`))
 			})
 		})
 
 		When("BOSHDNS Addon has handlers", func() {
 			BeforeEach(func() {
-				dns = boshdns.NewBoshDomainNameService(manifest.InstanceGroups{})
+				dns = boshdns.NewBoshDomainNameService("scf", manifest.InstanceGroups{})
 				err := dns.Add(loadAddOn(handlerAddon))
 				Expect(err).NotTo(HaveOccurred())
 
@@ -304,7 +304,7 @@ var _ = Describe("BOSHDomainNameService", func() {
 
 		When("adding multiple dns addons", func() {
 			BeforeEach(func() {
-				dns = boshdns.NewBoshDomainNameService(manifest.InstanceGroups{})
+				dns = boshdns.NewBoshDomainNameService("scf", manifest.InstanceGroups{})
 				err := dns.Add(loadAddOn(handlerAddon))
 				Expect(err).NotTo(HaveOccurred())
 				err = dns.Add(loadAddOn(aliasAddon))
@@ -71,7 +71,7 @@ func (c *Corefile) Add(props map[string]interface{}) error {
 }
 
 // Create the coredns corefile
-func (c *Corefile) Create(namespace string, instanceGroups bdm.InstanceGroups) (string, error) {
+func (c *Corefile) Create(deploymentName string, namespace string, instanceGroups bdm.InstanceGroups) (string, error) {
 	rewrites := make([]string, 0)
 	for _, alias := range c.Aliases {
 		for _, target := range alias.Targets {
@@ -86,6 +86,7 @@ func (c *Corefile) Create(namespace string, instanceGroups bdm.InstanceGroups) (
 				)
 			} else {
 				rewrites = gatherAllRewrites(rewrites,
+					deploymentName,
 					*instanceGroup,
 					target,
 					namespace,
@@ -125,6 +126,7 @@ func gatherSimpleRewrites(rewrites []string,
 }
 
 func gatherAllRewrites(rewrites []string,
+	deploymentName string,
 	instanceGroup bdm.InstanceGroup,
 	target Target,
 	namespace string,
@@ -133,6 +135,7 @@ func gatherAllRewrites(rewrites []string,
 		if len(instanceGroup.AZs) > 0 {
 			for azIndex := range instanceGroup.AZs {
 				rewrites = gatherRewritesForInstances(rewrites,
+					deploymentName,
 					instanceGroup,
 					target,
 					namespace,
@@ -141,6 +144,7 @@ func gatherAllRewrites(rewrites []string,
 			}
 		} else {
 			rewrites = gatherRewritesForInstances(rewrites,
+				deploymentName,
 				instanceGroup,
 				target,
 				namespace,
@@ -150,7 +154,7 @@ func gatherAllRewrites(rewrites []string,
 	} else {
 		from := alias.Domain
 		to := fmt.Sprintf("%s.%s.svc.%s",
-			names.ServiceName(target.InstanceGroup),
+			names.ServiceName(deploymentName, target.InstanceGroup),
 			namespace,
 			clusterDomain)
 		rewrites = append(rewrites, newTemplate(from, to))
@@ -160,6 +164,7 @@ func gatherAllRewrites(rewrites []string,
 }
 
 func gatherRewritesForInstances(rewrites []string,
+	deploymentName string,
 	instanceGroup bdm.InstanceGroup,
 	target Target,
 	namespace string,
@@ -173,7 +178,7 @@ func gatherRewritesForInstances(rewrites []string,
 			id = fmt.Sprintf("%s-%d", target.InstanceGroup, i)
 		}
 		from := strings.Replace(alias.Domain, "_", id, 1)
-		serviceName := instanceGroup.IndexedServiceName(i, azIndex)
+		serviceName := instanceGroup.IndexedServiceName(deploymentName, i, azIndex)
 		to := fmt.Sprintf("%s.%s.svc.%s", serviceName, namespace, clusterDomain)
 		rewrites = append(rewrites, newTemplate(from, to))
 	}
@@ -91,7 +91,7 @@ var _ = Describe("Corefile", func() {
 				err = corefile.Add(load(handlerAddon))
 				Expect(err).NotTo(HaveOccurred())
 
-				corefile, err := corefile.Create("default", igs)
+				corefile, err := corefile.Create("scf", "default", igs)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(corefile).To(ContainSubstring(`corp.intranet.local:8053 {`))
@@ -122,7 +122,7 @@ var _ = Describe("Corefile", func() {
 					err := corefile.Add(load(strings.Replace(handlerAddon, "dns", t.Type, 1)))
 					Expect(err).NotTo(HaveOccurred())
 
-					corefile, err := corefile.Create("default", igs)
+					corefile, err := corefile.Create("scf", "default", igs)
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(corefile).To(ContainSubstring(fmt.Sprintf(`forward . %[1]s://10.0.0.2 %[1]s://127.0.0.1`, t.Protocol)))
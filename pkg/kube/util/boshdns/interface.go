@@ -20,9 +20,9 @@ type DomainNameService interface {
 	Apply(ctx context.Context, namespace string, c client.Client, setOwner func(object metav1.Object) error) error
 }
 
-// New returns the DNS service management struct
-func New(m bdm.Manifest) (DomainNameService, error) {
-	dns := NewBoshDomainNameService(m.InstanceGroups)
+// New returns the DNS service management struct for a deployment
+func New(deploymentName string, m bdm.Manifest) (DomainNameService, error) {
+	dns := NewBoshDomainNameService(deploymentName, m.InstanceGroups)
 	found := false
 	for index, addon := range m.AddOns {
 		for _, job := range addon.Jobs {
@@ -43,7 +43,7 @@ func New(m bdm.Manifest) (DomainNameService, error) {
 
 // Validate that all job properties of the addon section can be decoded
 func Validate(m bdm.Manifest) error {
-	_, err := New(m)
+	_, err := New("", m)
 	return err
 }
 
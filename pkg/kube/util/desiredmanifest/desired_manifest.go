@@ -9,16 +9,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	bdm "code.cloudfoundry.org/quarks-operator/pkg/bosh/manifest"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/names"
 	"code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore"
 )
 
-var (
-	// Name is the name of the container that
-	// performs variable interpolation for a manifest. It's also part of
-	// the output secret's name
-	Name = "desired-manifest"
-)
-
 // DesiredManifest resolves references from bdpl CRD to a BOSH manifest
 type DesiredManifest struct {
 	client               client.Client
@@ -35,17 +29,18 @@ func NewDesiredManifest(client client.Client) *DesiredManifest {
 
 // DesiredManifest reads the versioned secret created by the variable interpolation job
 // and unmarshals it into a Manifest object
-func (r *DesiredManifest) DesiredManifest(ctx context.Context, namespace string) (*bdm.Manifest, error) {
-	secret, err := r.versionedSecretStore.Latest(ctx, namespace, Name)
+func (r *DesiredManifest) DesiredManifest(ctx context.Context, deploymentName string, namespace string) (*bdm.Manifest, error) {
+	name := names.DesiredManifestName(deploymentName)
+	secret, err := r.versionedSecretStore.Latest(ctx, namespace, name)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to read latest versioned secret %s for bosh deployment in %s", Name, namespace)
+		return nil, errors.Wrapf(err, "failed to read latest versioned secret %s for bosh deployment '%s' in %s", name, deploymentName, namespace)
 	}
 
 	manifestData := secret.Data["manifest.yaml"]
 
 	manifest, err := bdm.LoadYAML(manifestData)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to unmarshal manifest from secret %s for boshdeployment in %s", Name, namespace)
+		return nil, errors.Wrapf(err, "failed to unmarshal manifest from secret %s for boshdeployment '%s' in %s", name, deploymentName, namespace)
 	}
 
 	return manifest, nil
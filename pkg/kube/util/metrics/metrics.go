@@ -0,0 +1,118 @@
+// Package metrics defines the prometheus metrics exported by the operator.
+// All metrics are registered with the controller-runtime registry and served
+// by the manager's metrics listener.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// Namespace is the prefix of all operator metrics
+	Namespace = "quarks_operator"
+
+	// ControllerDeployment is the label value for the BOSHDeployment controller
+	ControllerDeployment = "deployment"
+	// ControllerBPM is the label value for the BPM controller
+	ControllerBPM = "bpm"
+	// ControllerWithOps is the label value for the with-ops controller
+	ControllerWithOps = "withops"
+	// ControllerStatus is the label value for the status controllers
+	ControllerStatus = "status"
+	// ControllerRestart is the label value for the restart controllers
+	ControllerRestart = "restart"
+	// ControllerErrandRun is the label value for the errand run controller
+	ControllerErrandRun = "errandrun"
+	// ControllerOrphanedDisk is the label value for the orphaned disk controller
+	ControllerOrphanedDisk = "orphaneddisk"
+	// ControllerErrandTrigger is the label value for the errand trigger controller
+	ControllerErrandTrigger = "errandtrigger"
+)
+
+var (
+	// ReconcileTotal counts reconciles per controller and result
+	ReconcileTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "reconcile_total",
+			Help:      "Total number of reconciles per controller and result",
+		},
+		[]string{"controller", "result"},
+	)
+
+	// ReconcileDuration observes the reconcile durations per controller
+	ReconcileDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "reconcile_duration_seconds",
+			Help:      "Duration of reconciles per controller",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"controller"},
+	)
+
+	// MeltdownSkipsTotal counts reconciles skipped because the resource is in meltdown
+	MeltdownSkipsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "meltdown_skips_total",
+			Help:      "Total number of reconciles skipped because of a meltdown per controller",
+		},
+		[]string{"controller"},
+	)
+
+	// ManifestResolutionDuration observes how long it takes to resolve a BOSH manifest with ops files and variables
+	ManifestResolutionDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "manifest_resolution_duration_seconds",
+			Help:      "Duration of resolving the with-ops manifest of a BOSHDeployment",
+			Buckets:   prometheus.DefBuckets,
+		},
+	)
+
+	// AdmissionDuration observes the latency of admission requests per webhook
+	AdmissionDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "admission_duration_seconds",
+			Help:      "Duration of admission requests per webhook",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"webhook"},
+	)
+
+	// AdmissionDeniedTotal counts denied admission requests per webhook
+	AdmissionDeniedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "admission_denied_total",
+			Help:      "Total number of denied admission requests per webhook",
+		},
+		[]string{"webhook"},
+	)
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(
+		ReconcileTotal,
+		ReconcileDuration,
+		MeltdownSkipsTotal,
+		ManifestResolutionDuration,
+		AdmissionDuration,
+		AdmissionDeniedTotal,
+	)
+}
+
+// MeltdownSkipped counts a reconcile which was skipped by a controller, because of a meltdown
+func MeltdownSkipped(controller string) {
+	MeltdownSkipsTotal.WithLabelValues(controller).Inc()
+}
+
+// ManifestResolved records the time it took to resolve a manifest since start
+func ManifestResolved(start time.Time) {
+	ManifestResolutionDuration.Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,136 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	qjv1a1 "code.cloudfoundry.org/quarks-job/pkg/kube/apis/quarksjob/v1alpha1"
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/metrics"
+	qsv1a1 "code.cloudfoundry.org/quarks-secret/pkg/kube/apis/quarkssecret/v1alpha1"
+	qstsv1a1 "code.cloudfoundry.org/quarks-statefulset/pkg/kube/apis/quarksstatefulset/v1alpha1"
+)
+
+type fakeReconciler struct {
+	result reconcile.Result
+	err    error
+}
+
+func (r fakeReconciler) Reconcile(reconcile.Request) (reconcile.Result, error) {
+	return r.result, r.err
+}
+
+var _ = Describe("Metrics", func() {
+	Describe("InstrumentReconciler", func() {
+		reconcileCount := func(result string) float64 {
+			return testutil.ToFloat64(metrics.ReconcileTotal.WithLabelValues("test", result))
+		}
+
+		It("counts reconciles by result", func() {
+			success := reconcileCount(metrics.ResultSuccess)
+			failed := reconcileCount(metrics.ResultError)
+			requeued := reconcileCount(metrics.ResultRequeueAfter)
+
+			_, _ = metrics.InstrumentReconciler("test", fakeReconciler{}).Reconcile(reconcile.Request{})
+			_, _ = metrics.InstrumentReconciler("test", fakeReconciler{err: errors.New("fake-error")}).Reconcile(reconcile.Request{})
+			_, _ = metrics.InstrumentReconciler("test", fakeReconciler{result: reconcile.Result{RequeueAfter: time.Second}}).Reconcile(reconcile.Request{})
+
+			Expect(reconcileCount(metrics.ResultSuccess)).To(Equal(success + 1))
+			Expect(reconcileCount(metrics.ResultError)).To(Equal(failed + 1))
+			Expect(reconcileCount(metrics.ResultRequeueAfter)).To(Equal(requeued + 1))
+		})
+
+		It("passes the result of the wrapped reconciler on", func() {
+			result, err := metrics.InstrumentReconciler("test", fakeReconciler{result: reconcile.Result{Requeue: true}}).Reconcile(reconcile.Request{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Requeue).To(BeTrue())
+		})
+	})
+
+	Describe("InstrumentWebhook", func() {
+		handler := func(allowed bool) admission.Handler {
+			return admission.HandlerFunc(func(context.Context, admission.Request) admission.Response {
+				return admission.Response{AdmissionResponse: v1beta1.AdmissionResponse{Allowed: allowed}}
+			})
+		}
+
+		It("counts denied admission requests", func() {
+			denied := testutil.ToFloat64(metrics.AdmissionDeniedTotal.WithLabelValues("test-hook"))
+
+			response := metrics.InstrumentWebhook("test-hook", handler(true)).Handle(context.Background(), admission.Request{})
+			Expect(response.Allowed).To(BeTrue())
+			Expect(testutil.ToFloat64(metrics.AdmissionDeniedTotal.WithLabelValues("test-hook"))).To(Equal(denied))
+
+			response = metrics.InstrumentWebhook("test-hook", handler(false)).Handle(context.Background(), admission.Request{})
+			Expect(response.Allowed).To(BeFalse())
+			Expect(testutil.ToFloat64(metrics.AdmissionDeniedTotal.WithLabelValues("test-hook"))).To(Equal(denied + 1))
+		})
+	})
+
+	Describe("ResourceCollector", func() {
+		objectMeta := func(name, deployment string) metav1.ObjectMeta {
+			return metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels:    map[string]string{bdv1.LabelDeploymentName: deployment},
+			}
+		}
+
+		It("counts the resources per deployment", func() {
+			scheme := runtime.NewScheme()
+			Expect(qjv1a1.AddToScheme(scheme)).To(Succeed())
+			Expect(qsv1a1.AddToScheme(scheme)).To(Succeed())
+			Expect(qstsv1a1.AddToScheme(scheme)).To(Succeed())
+			client := fake.NewFakeClientWithScheme(scheme,
+				&qsv1a1.QuarksSecret{ObjectMeta: objectMeta("foo.var-a", "foo")},
+				&qsv1a1.QuarksSecret{ObjectMeta: objectMeta("foo.var-b", "foo")},
+				&qsv1a1.QuarksSecret{ObjectMeta: objectMeta("bar.var-a", "bar")},
+				&qstsv1a1.QuarksStatefulSet{ObjectMeta: objectMeta("nats", "foo")},
+				&qjv1a1.QuarksJob{ObjectMeta: objectMeta("foo-ig", "foo")},
+				&qjv1a1.QuarksJob{ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "default"}},
+			)
+
+			expected := `
+# HELP quarks_operator_deployment_resources Number of resources per BOSHDeployment and kind
+# TYPE quarks_operator_deployment_resources gauge
+quarks_operator_deployment_resources{deployment="bar",kind="QuarksSecret",namespace="default"} 1
+quarks_operator_deployment_resources{deployment="foo",kind="QuarksJob",namespace="default"} 1
+quarks_operator_deployment_resources{deployment="foo",kind="QuarksSecret",namespace="default"} 2
+quarks_operator_deployment_resources{deployment="foo",kind="QuarksStatefulSet",namespace="default"} 1
+`
+			Expect(testutil.CollectAndCompare(metrics.NewResourceCollector(client), strings.NewReader(expected))).To(Succeed())
+		})
+
+		It("collects with the client of the latest manager", func() {
+			scheme := runtime.NewScheme()
+			Expect(qsv1a1.AddToScheme(scheme)).To(Succeed())
+			first := fake.NewFakeClientWithScheme(scheme, &qsv1a1.QuarksSecret{ObjectMeta: objectMeta("foo.var-a", "foo")})
+			second := fake.NewFakeClientWithScheme(scheme, &qsv1a1.QuarksSecret{ObjectMeta: objectMeta("bar.var-a", "bar")})
+			defer metrics.SetResourceClient(nil)
+
+			metrics.SetResourceClient(first)
+			metrics.SetResourceClient(second)
+
+			expected := `
+# HELP quarks_operator_deployment_resources Number of resources per BOSHDeployment and kind
+# TYPE quarks_operator_deployment_resources gauge
+quarks_operator_deployment_resources{deployment="bar",kind="QuarksSecret",namespace="default"} 1
+`
+			Expect(testutil.GatherAndCompare(crmetrics.Registry, strings.NewReader(expected), "quarks_operator_deployment_resources")).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Reconcile result label values
+const (
+	ResultError        = "error"
+	ResultRequeue      = "requeue"
+	ResultRequeueAfter = "requeue_after"
+	ResultSuccess      = "success"
+)
+
+// InstrumentReconciler wraps a reconciler to record the reconcile count and
+// duration for the controller
+func InstrumentReconciler(controller string, r reconcile.Reconciler) reconcile.Reconciler {
+	return &instrumentedReconciler{controller: controller, reconciler: r}
+}
+
+type instrumentedReconciler struct {
+	controller string
+	reconciler reconcile.Reconciler
+}
+
+// Reconcile calls the wrapped reconciler and records its metrics
+func (r *instrumentedReconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	start := time.Now()
+	result, err := r.reconciler.Reconcile(request)
+	ReconcileDuration.WithLabelValues(r.controller).Observe(time.Since(start).Seconds())
+	ReconcileTotal.WithLabelValues(r.controller, resultLabel(result, err)).Inc()
+	return result, err
+}
+
+func resultLabel(result reconcile.Result, err error) string {
+	switch {
+	case err != nil:
+		return ResultError
+	case result.RequeueAfter > 0:
+		return ResultRequeueAfter
+	case result.Requeue:
+		return ResultRequeue
+	}
+	return ResultSuccess
+}
@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	qjv1a1 "code.cloudfoundry.org/quarks-job/pkg/kube/apis/quarksjob/v1alpha1"
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	qsv1a1 "code.cloudfoundry.org/quarks-secret/pkg/kube/apis/quarkssecret/v1alpha1"
+	qstsv1a1 "code.cloudfoundry.org/quarks-statefulset/pkg/kube/apis/quarksstatefulset/v1alpha1"
+)
+
+// Resource kind label values
+const (
+	KindQuarksSecret      = "QuarksSecret"
+	KindQuarksStatefulSet = "QuarksStatefulSet"
+	KindQuarksJob         = "QuarksJob"
+)
+
+const collectTimeout = 10 * time.Second
+
+var deploymentResourcesDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(Namespace, "", "deployment_resources"),
+	"Number of resources per BOSHDeployment and kind",
+	[]string{"namespace", "deployment", "kind"},
+	nil,
+)
+
+// resources is registered once, every manager replaces its client
+var resources = &ResourceCollector{}
+
+func init() {
+	crmetrics.Registry.MustRegister(resources)
+}
+
+// ResourceCollector counts the quarks resources, which belong to a BOSHDeployment
+type ResourceCollector struct {
+	mu     sync.RWMutex
+	client client.Client
+}
+
+// NewResourceCollector returns a collector, which lists the resources with the client on every scrape
+func NewResourceCollector(c client.Client) *ResourceCollector {
+	return &ResourceCollector{client: c}
+}
+
+// SetResourceClient sets the client, which the collector registered with the
+// controller-runtime registry uses. Nothing is collected without a client.
+func SetResourceClient(c client.Client) {
+	resources.mu.Lock()
+	defer resources.mu.Unlock()
+	resources.client = c
+}
+
+// Describe implements prometheus.Collector
+func (c *ResourceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- deploymentResourcesDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *ResourceCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	cl := c.client
+	c.mu.RUnlock()
+	if cl == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	counts := map[deploymentKey]map[string]int{}
+	count := func(kind string, objects []labeledObject) {
+		for _, o := range objects {
+			name, ok := o.labels[bdv1.LabelDeploymentName]
+			if !ok {
+				continue
+			}
+			key := deploymentKey{namespace: o.namespace, name: name}
+			if counts[key] == nil {
+				counts[key] = map[string]int{}
+			}
+			counts[key][kind]++
+		}
+	}
+
+	qsecs := &qsv1a1.QuarksSecretList{}
+	if err := cl.List(ctx, qsecs); err == nil {
+		objects := make([]labeledObject, len(qsecs.Items))
+		for i, o := range qsecs.Items {
+			objects[i] = labeledObject{namespace: o.Namespace, labels: o.Labels}
+		}
+		count(KindQuarksSecret, objects)
+	}
+
+	qstss := &qstsv1a1.QuarksStatefulSetList{}
+	if err := cl.List(ctx, qstss); err == nil {
+		objects := make([]labeledObject, len(qstss.Items))
+		for i, o := range qstss.Items {
+			objects[i] = labeledObject{namespace: o.Namespace, labels: o.Labels}
+		}
+		count(KindQuarksStatefulSet, objects)
+	}
+
+	qjobs := &qjv1a1.QuarksJobList{}
+	if err := cl.List(ctx, qjobs); err == nil {
+		objects := make([]labeledObject, len(qjobs.Items))
+		for i, o := range qjobs.Items {
+			objects[i] = labeledObject{namespace: o.Namespace, labels: o.Labels}
+		}
+		count(KindQuarksJob, objects)
+	}
+
+	for key, kinds := range counts {
+		for kind, n := range kinds {
+			ch <- prometheus.MustNewConstMetric(deploymentResourcesDesc, prometheus.GaugeValue, float64(n), key.namespace, key.name, kind)
+		}
+	}
+}
+
+type deploymentKey struct {
+	namespace string
+	name      string
+}
+
+type labeledObject struct {
+	namespace string
+	labels    map[string]string
+}
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// InstrumentWebhook wraps an admission handler to record the latency and the
+// denied requests of the webhook
+func InstrumentWebhook(webhook string, h admission.Handler) admission.Handler {
+	return &instrumentedHandler{webhook: webhook, handler: h}
+}
+
+type instrumentedHandler struct {
+	webhook string
+	handler admission.Handler
+}
+
+// Handle calls the wrapped handler and records its metrics
+func (h *instrumentedHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	start := time.Now()
+	response := h.handler.Handle(ctx, req)
+	AdmissionDuration.WithLabelValues(h.webhook).Observe(time.Since(start).Seconds())
+	if !response.Allowed {
+		AdmissionDeniedTotal.WithLabelValues(h.webhook).Inc()
+	}
+	return response
+}
+
+// InjectFunc passes the manager's injections, like the client and the
+// decoder, on to the wrapped handler
+func (h *instrumentedHandler) InjectFunc(f inject.Func) error {
+	return f(h.handler)
+}
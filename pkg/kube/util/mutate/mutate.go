@@ -2,7 +2,11 @@
 package mutate
 
 import (
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	batchv1b1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	qjv1a1 "code.cloudfoundry.org/quarks-job/pkg/kube/apis/quarksjob/v1alpha1"
@@ -61,15 +65,93 @@ func QuarksJobMutateFn(qJob *qjv1a1.QuarksJob) controllerutil.MutateFn {
 
 // ServiceMutateFn returns MutateFn which mutates Service including:
 // - labels, annotations
-// - spec.ports, spec.selector
+// - spec.type, spec.ports, spec.selector
+// - spec.externalIPs, spec.loadBalancerIP, spec.loadBalancerSourceRanges, spec.externalTrafficPolicy
 func ServiceMutateFn(svc *corev1.Service) controllerutil.MutateFn {
 	updated := svc.DeepCopy()
 	return func() error {
 		svc.Labels = updated.Labels
 		svc.Annotations = updated.Annotations
 		// Should keep the existing ClusterIP
+		if updated.Spec.Type != "" {
+			svc.Spec.Type = updated.Spec.Type
+		}
+		// The API server defaults the protocol to TCP
+		for i := range updated.Spec.Ports {
+			if updated.Spec.Ports[i].Protocol == "" {
+				updated.Spec.Ports[i].Protocol = corev1.ProtocolTCP
+			}
+		}
+		if svc.Spec.Type == corev1.ServiceTypeNodePort || svc.Spec.Type == corev1.ServiceTypeLoadBalancer {
+			// Should keep the allocated node ports
+			for i := range updated.Spec.Ports {
+				for _, port := range svc.Spec.Ports {
+					if updated.Spec.Ports[i].NodePort == 0 && updated.Spec.Ports[i].Port == port.Port && updated.Spec.Ports[i].Protocol == port.Protocol {
+						updated.Spec.Ports[i].NodePort = port.NodePort
+					}
+				}
+			}
+		}
 		svc.Spec.Ports = updated.Spec.Ports
 		svc.Spec.Selector = updated.Spec.Selector
+		svc.Spec.ExternalIPs = updated.Spec.ExternalIPs
+		svc.Spec.LoadBalancerIP = updated.Spec.LoadBalancerIP
+		svc.Spec.LoadBalancerSourceRanges = updated.Spec.LoadBalancerSourceRanges
+		if updated.Spec.ExternalTrafficPolicy != "" || svc.Spec.Type == corev1.ServiceTypeClusterIP {
+			svc.Spec.ExternalTrafficPolicy = updated.Spec.ExternalTrafficPolicy
+		}
+		return nil
+	}
+}
+
+// CronJobMutateFn returns MutateFn which mutates CronJob including:
+// - labels, annotations
+// - spec
+func CronJobMutateFn(cronJob *batchv1b1.CronJob) controllerutil.MutateFn {
+	updated := cronJob.DeepCopy()
+	return func() error {
+		cronJob.Labels = updated.Labels
+		cronJob.Annotations = updated.Annotations
+		cronJob.Spec = updated.Spec
+		return nil
+	}
+}
+
+// PodDisruptionBudgetMutateFn returns MutateFn which mutates PodDisruptionBudget including:
+// - labels, annotations
+// - spec
+func PodDisruptionBudgetMutateFn(pdb *policyv1beta1.PodDisruptionBudget) controllerutil.MutateFn {
+	updated := pdb.DeepCopy()
+	return func() error {
+		pdb.Labels = updated.Labels
+		pdb.Annotations = updated.Annotations
+		pdb.Spec = updated.Spec
+		return nil
+	}
+}
+
+// HorizontalPodAutoscalerMutateFn returns MutateFn which mutates HorizontalPodAutoscaler including:
+// - labels, annotations
+// - spec
+func HorizontalPodAutoscalerMutateFn(hpa *autoscalingv2beta2.HorizontalPodAutoscaler) controllerutil.MutateFn {
+	updated := hpa.DeepCopy()
+	return func() error {
+		hpa.Labels = updated.Labels
+		hpa.Annotations = updated.Annotations
+		hpa.Spec = updated.Spec
+		return nil
+	}
+}
+
+// IngressMutateFn returns MutateFn which mutates Ingress including:
+// - labels, annotations
+// - spec
+func IngressMutateFn(ingress *networkingv1beta1.Ingress) controllerutil.MutateFn {
+	updated := ingress.DeepCopy()
+	return func() error {
+		ingress.Labels = updated.Labels
+		ingress.Annotations = updated.Annotations
+		ingress.Spec = updated.Spec
 		return nil
 	}
 }
@@ -7,6 +7,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1b1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -398,6 +399,147 @@ var _ = Describe("Mutate", func() {
 				Expect(err).ToNot(HaveOccurred())
 				Expect(ops).To(Equal(controllerutil.OperationResultNone))
 			})
+
+			It("does not update the allocated node ports", func() {
+				svc.Spec.Type = corev1.ServiceTypeLoadBalancer
+				client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+					switch object := object.(type) {
+					case *corev1.Service:
+						existing := &corev1.Service{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      "foo",
+								Namespace: "default",
+							},
+							Spec: corev1.ServiceSpec{
+								Type:      corev1.ServiceTypeLoadBalancer,
+								ClusterIP: "10.10.10.10",
+								Ports: []corev1.ServicePort{
+									{
+										Name:     "exposed-port",
+										Protocol: corev1.ProtocolTCP,
+										Port:     8080,
+										NodePort: 30080,
+									},
+								},
+								Selector: map[string]string{
+									"foo": "bar",
+								},
+								ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeCluster,
+							},
+						}
+						existing.DeepCopyInto(object)
+
+						return nil
+					}
+
+					return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+				})
+				ops, err := controllerutil.CreateOrUpdate(ctx, client, svc, mutate.ServiceMutateFn(svc))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ops).To(Equal(controllerutil.OperationResultNone))
+				Expect(svc.Spec.Ports[0].NodePort).To(Equal(int32(30080)))
+			})
+
+			It("keeps the allocated node ports of ports without a protocol", func() {
+				svc.Spec.Type = corev1.ServiceTypeNodePort
+				svc.Spec.Ports[0].Protocol = ""
+				client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+					switch object := object.(type) {
+					case *corev1.Service:
+						existing := &corev1.Service{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      "foo",
+								Namespace: "default",
+							},
+							Spec: corev1.ServiceSpec{
+								Type:      corev1.ServiceTypeNodePort,
+								ClusterIP: "10.10.10.10",
+								Ports: []corev1.ServicePort{
+									{
+										Name:     "exposed-port",
+										Protocol: corev1.ProtocolTCP,
+										Port:     8080,
+										NodePort: 30080,
+									},
+								},
+								Selector: map[string]string{
+									"foo": "bar",
+								},
+								ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeCluster,
+							},
+						}
+						existing.DeepCopyInto(object)
+
+						return nil
+					}
+
+					return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+				})
+				ops, err := controllerutil.CreateOrUpdate(ctx, client, svc, mutate.ServiceMutateFn(svc))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ops).To(Equal(controllerutil.OperationResultNone))
+				Expect(svc.Spec.Ports[0].NodePort).To(Equal(int32(30080)))
+				Expect(svc.Spec.Ports[0].Protocol).To(Equal(corev1.ProtocolTCP))
+			})
+		})
+	})
+
+	Describe("CronJobMutateFn", func() {
+		var (
+			cronJob *batchv1b1.CronJob
+		)
+
+		BeforeEach(func() {
+			cronJob = &batchv1b1.CronJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "default",
+				},
+				Spec: batchv1b1.CronJobSpec{
+					Schedule:          "@daily",
+					ConcurrencyPolicy: batchv1b1.ForbidConcurrent,
+				},
+			}
+		})
+
+		Context("when the cron job is not found", func() {
+			It("creates the cron job", func() {
+				client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+					return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+				})
+
+				ops, err := controllerutil.CreateOrUpdate(ctx, client, cronJob, mutate.CronJobMutateFn(cronJob))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ops).To(Equal(controllerutil.OperationResultCreated))
+			})
+		})
+
+		Context("when the cron job is found", func() {
+			It("updates the cron job when the schedule is changed", func() {
+				client.GetCalls(func(context context.Context, nn types.NamespacedName, object runtime.Object) error {
+					switch object := object.(type) {
+					case *batchv1b1.CronJob:
+						existing := &batchv1b1.CronJob{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      "foo",
+								Namespace: "default",
+							},
+							Spec: batchv1b1.CronJobSpec{
+								Schedule:          "@hourly",
+								ConcurrencyPolicy: batchv1b1.ForbidConcurrent,
+							},
+						}
+						existing.DeepCopyInto(object)
+
+						return nil
+					}
+
+					return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+				})
+				ops, err := controllerutil.CreateOrUpdate(ctx, client, cronJob, mutate.CronJobMutateFn(cronJob))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ops).To(Equal(controllerutil.OperationResultUpdated))
+			})
 		})
 	})
 })
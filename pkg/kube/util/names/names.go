@@ -8,8 +8,31 @@ import (
 	"code.cloudfoundry.org/quarks-utils/pkg/names"
 )
 
+// DesiredManifestSecretType is the name part of the desired manifest secret
+const DesiredManifestSecretType = "desired-manifest"
+
+// DeploymentSecretName returns the name of a secret, which belongs to a deployment:
+// `<deployment-name>.<secretType>` or `<deployment-name>.<secretType>-<name>`
+func DeploymentSecretName(secretType bdv1.DeploymentSecretType, deploymentName string, name string) string {
+	if name == "" {
+		name = secretType.String()
+	} else {
+		name = fmt.Sprintf("%s-%s", secretType, name)
+	}
+	return names.SanitizeSubdomain(fmt.Sprintf("%s.%s", deploymentName, name))
+}
+
+// DesiredManifestName returns the unversioned name of the desired manifest secret:
+// `<deployment-name>.desired-manifest`
+func DesiredManifestName(deploymentName string) string {
+	return names.SanitizeSubdomain(fmt.Sprintf("%s.%s", deploymentName, DesiredManifestSecretType))
+}
+
 // SecretVariableName generates a valid secret name for a given name
 // `var-<name>`
+//
+// It is used for user-provided implicit variables, which are not
+// owned by a single deployment.
 func SecretVariableName(name string) string {
 	secretType := bdv1.DeploymentSecretTypeVariable
 	if name == "" {
@@ -21,12 +44,12 @@ func SecretVariableName(name string) string {
 }
 
 // InstanceGroupSecretName returns the name of a k8s secret:
-// `<secretType>.<instance-group>-v<version>` secret.
+// `<deployment-name>.<secretType>.<instance-group>-v<version>` secret.
 //
 // These secrets are created by QuarksJob and mounted on containers, e.g.
 // for the template rendering.
-func InstanceGroupSecretName(igName string, version string) string {
-	prefix := bdv1.DeploymentSecretTypeInstanceGroupResolvedProperties.Prefix()
+func InstanceGroupSecretName(secretType bdv1.DeploymentSecretType, deploymentName string, igName string, version string) string {
+	prefix := deploymentName + "." + secretType.Prefix()
 	finalName := names.SanitizeSubdomain(prefix + igName)
 
 	if version != "" {
@@ -37,12 +60,31 @@ func InstanceGroupSecretName(igName string, version string) string {
 }
 
 // TruncatedServiceName returns the service name for a deployment
-func TruncatedServiceName(igName string, maxLength int) string {
-	s := names.DNSLabelSafe(igName)
+func TruncatedServiceName(deploymentName string, igName string, maxLength int) string {
+	s := names.DNSLabelSafe(fmt.Sprintf("%s-%s", deploymentName, igName))
 	return names.TruncateMD5(s, maxLength)
 }
 
-// ServiceName constructs the headless service name for the instance group.
-func ServiceName(instanceGroupName string) string {
-	return names.Sanitize(instanceGroupName)
+// ServiceName constructs the headless service name for the instance group:
+// `<deployment-name>-<instance-group>`
+func ServiceName(deploymentName string, instanceGroupName string) string {
+	return names.Sanitize(fmt.Sprintf("%s-%s", deploymentName, instanceGroupName))
+}
+
+// ExtensionServiceName returns the name of the service a vm extension adds to an instance group:
+// `<deployment-name>-<ig-name>-<extension-name>`
+func ExtensionServiceName(deploymentName string, instanceGroupName string, extensionName string) string {
+	return names.Sanitize(fmt.Sprintf("%s-%s-%s", deploymentName, instanceGroupName, extensionName))
+}
+
+// ExposedServiceName returns the name of the service and ingress, which expose an instance group:
+// `<deployment-name>-<ig-name>-exposed`
+func ExposedServiceName(deploymentName string, instanceGroupName string) string {
+	return names.Sanitize(fmt.Sprintf("%s-%s-exposed", deploymentName, instanceGroupName))
+}
+
+// QuarksJobName returns the name of a QuarksJob, which belongs to a deployment:
+// `<deployment-name>-<name>`
+func QuarksJobName(deploymentName string, name string) string {
+	return names.Sanitize(fmt.Sprintf("%s-%s", deploymentName, name))
 }
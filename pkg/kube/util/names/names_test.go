@@ -6,46 +6,85 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/names"
 )
 
 var _ = Describe("Names", func() {
+	Context("DeploymentSecretName", func() {
+		It("prefixes the secret name with the deployment name", func() {
+			Expect(names.DeploymentSecretName(bdv1.DeploymentSecretTypeManifestWithOps, "foo", "")).To(Equal("foo.with-ops"))
+			Expect(names.DeploymentSecretName(bdv1.DeploymentSecretTypeVariable, "foo", "system_domain")).To(Equal("foo.var-system-domain"))
+		})
+	})
+
+	Context("DesiredManifestName", func() {
+		It("prefixes the secret name with the deployment name", func() {
+			Expect(names.DesiredManifestName("Foo")).To(Equal("foo.desired-manifest"))
+		})
+	})
+
 	Context("InstanceGroupSecretName", func() {
 		type test struct {
+			arg1 bdv1.DeploymentSecretType
+			arg2 string
 			arg3 string
 			arg4 string
 			name string
 		}
 		tests := []test{
 			{
+				arg1: bdv1.DeploymentSecretTypeInstanceGroupResolvedProperties,
+				arg2: "foo",
 				arg3: "ig-Name",
 				arg4: "", // "0.1",
-				name: "ig-resolved.ig-name",
+				name: "foo.ig-resolved.ig-name",
 			},
 			{
+				arg1: bdv1.DeploymentSecretTypeInstanceGroupResolvedProperties,
+				arg2: "foo",
 				arg3: "ig_Name",
 				arg4: "",
-				name: "ig-resolved.ig-name",
+				name: "foo.ig-resolved.ig-name",
+			},
+			{
+				arg1: bdv1.DeploymentSecretBPMInformation,
+				arg2: "foo",
+				arg3: "ig_Name",
+				arg4: "1",
+				name: "foo.bpm.ig-name-v1",
 			},
 			{
+				arg1: bdv1.DeploymentSecretTypeInstanceGroupResolvedProperties,
+				arg2: "foo",
 				arg3: "igname12345678901234567890ABC" + text225,
 				arg4: "",
-				name: "ig-resolved.igname12345678901234567890abcthis-is-w" + text171[:170] + "-d1a31c527eb0ad85b571d5028e28573c",
+				name: "foo.ig-resolved.igname12345678901234567890abcthis-is-w" + text171[:166] + "-8df9bdec2f50cac088e062464fb3f5d4",
 			},
 		}
 
 		It("produces valid k8s secret names", func() {
 			for _, t := range tests {
-				r := names.InstanceGroupSecretName(t.arg3, t.arg4)
+				r := names.InstanceGroupSecretName(t.arg1, t.arg2, t.arg3, t.arg4)
 				Expect(r).To(Equal(t.name), fmt.Sprintf("%#v", t))
 			}
 		})
 	})
 
 	Context("ServiceName", func() {
+		It("prefixes the service name with the deployment name", func() {
+			Expect(names.ServiceName("foo", "nats_server")).To(Equal("foo-nats-server"))
+		})
+
 		It("shortens long service names", func() {
-			Expect(len(names.ServiceName("scheduler-scheduler-scheduler-scheduler-scheduler-scheduler-scheduler-scheduler"))).
+			Expect(len(names.ServiceName("foo", "scheduler-scheduler-scheduler-scheduler-scheduler-scheduler-scheduler-scheduler"))).
 				To(Equal(63))
 		})
 	})
+
+	Context("QuarksJobName", func() {
+		It("prefixes the job name with the deployment name", func() {
+			Expect(names.QuarksJobName("foo", "ig")).To(Equal("foo-ig"))
+		})
+	})
 })
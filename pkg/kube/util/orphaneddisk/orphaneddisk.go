@@ -0,0 +1,312 @@
+// Package orphaneddisk keeps the persistent volume claims of removed instance
+// groups for a retention period, like BOSH keeps orphaned disks
+package orphaneddisk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	qstsv1a1 "code.cloudfoundry.org/quarks-statefulset/pkg/kube/apis/quarksstatefulset/v1alpha1"
+	"code.cloudfoundry.org/quarks-utils/pkg/names"
+)
+
+// DefaultRetention is the time orphaned claims are kept, before they are
+// deleted. BOSH keeps orphaned disks for five days.
+const DefaultRetention = 5 * 24 * time.Hour
+
+var retention = DefaultRetention
+
+// SetRetention sets the time orphaned claims are kept, before they are deleted
+func SetRetention(d time.Duration) {
+	retention = d
+}
+
+// Retention returns the time orphaned claims are kept, before they are deleted
+func Retention() time.Duration {
+	return retention
+}
+
+// IsOrphaned returns true if the claim belongs to a removed instance group
+func IsOrphaned(pvc *corev1.PersistentVolumeClaim) bool {
+	return pvc.GetLabels()[bdv1.LabelOrphanedDisk] == "true"
+}
+
+// OrphanedAt returns the time the claim was orphaned
+func OrphanedAt(pvc *corev1.PersistentVolumeClaim) (time.Time, bool) {
+	if !IsOrphaned(pvc) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, pvc.GetAnnotations()[bdv1.AnnotationOrphanedAt])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// ExpiresAt returns the time after which the orphaned claim is deleted
+func ExpiresAt(pvc *corev1.PersistentVolumeClaim) (time.Time, bool) {
+	t, ok := OrphanedAt(pvc)
+	if !ok {
+		return time.Time{}, false
+	}
+	return t.Add(retention), true
+}
+
+// Label adds the deployment and instance group labels to the claims of an
+// instance group, which don't have them. Stateful sets created before their
+// claim templates were labeled only label the claims with their selector, so
+// the claims are found by the name of their QuarksStatefulSet.
+func Label(ctx context.Context, c client.Client, namespace string, deploymentName string, instanceGroupName string) error {
+	pvcs, err := list(ctx, c, namespace, client.MatchingLabels{qstsv1a1.LabelQStsName: names.Sanitize(instanceGroupName)})
+	if err != nil {
+		return err
+	}
+
+	for i := range pvcs {
+		pvc := &pvcs[i]
+		if _, labeled := pvc.Labels[bdv1.LabelDeploymentName]; labeled {
+			continue
+		}
+
+		pvc.Labels[bdv1.LabelDeploymentName] = deploymentName
+		pvc.Labels[bdv1.LabelInstanceGroupName] = instanceGroupName
+		if err := c.Update(ctx, pvc); err != nil {
+			return errors.Wrapf(err, "failed to label persistent volume claim '%s/%s'", pvc.Namespace, pvc.Name)
+		}
+	}
+
+	return nil
+}
+
+// Orphan marks the claims of an instance group as orphaned and returns their
+// number. Owner references are removed, so the claims outlive the stateful
+// sets, regardless of the cluster's retention policy for their claims.
+// Claims without the instance group labels are labeled first.
+func Orphan(ctx context.Context, c client.Client, namespace string, deploymentName string, instanceGroupName string) (int, error) {
+	if err := Label(ctx, c, namespace, deploymentName, instanceGroupName); err != nil {
+		return 0, err
+	}
+
+	pvcs, err := list(ctx, c, namespace, client.MatchingLabels{
+		bdv1.LabelDeploymentName:    deploymentName,
+		bdv1.LabelInstanceGroupName: instanceGroupName,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	count := 0
+	for i := range pvcs {
+		pvc := &pvcs[i]
+		if IsOrphaned(pvc) || pvc.DeletionTimestamp != nil {
+			continue
+		}
+
+		if pvc.Labels == nil {
+			pvc.Labels = map[string]string{}
+		}
+		if pvc.Annotations == nil {
+			pvc.Annotations = map[string]string{}
+		}
+		pvc.Labels[bdv1.LabelOrphanedDisk] = "true"
+		pvc.Annotations[bdv1.AnnotationOrphanedAt] = now
+		pvc.OwnerReferences = nil
+
+		if err := c.Update(ctx, pvc); err != nil {
+			return count, errors.Wrapf(err, "failed to orphan persistent volume claim '%s/%s'", pvc.Namespace, pvc.Name)
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// Adopt removes the orphaned mark from the claims of an instance group. It's
+// used when an instance group is added again, as its stateful set reuses the
+// claims with the same name.
+func Adopt(ctx context.Context, c client.Client, namespace string, deploymentName string, instanceGroupName string) error {
+	pvcs, err := list(ctx, c, namespace, client.MatchingLabels{
+		bdv1.LabelDeploymentName:    deploymentName,
+		bdv1.LabelInstanceGroupName: instanceGroupName,
+		bdv1.LabelOrphanedDisk:      "true",
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range pvcs {
+		pvc := &pvcs[i]
+		if !IsOrphaned(pvc) {
+			continue
+		}
+		unmark(pvc)
+		if err := c.Update(ctx, pvc); err != nil {
+			return errors.Wrapf(err, "failed to adopt orphaned persistent volume claim '%s/%s'", pvc.Namespace, pvc.Name)
+		}
+	}
+
+	return nil
+}
+
+// List returns the orphaned claims in a namespace. Only the claims of the
+// given deployment are returned, if its name is not empty.
+func List(ctx context.Context, c client.Client, namespace string, deploymentName string) ([]corev1.PersistentVolumeClaim, error) {
+	labels := client.MatchingLabels{bdv1.LabelOrphanedDisk: "true"}
+	if deploymentName != "" {
+		labels[bdv1.LabelDeploymentName] = deploymentName
+	}
+	return list(ctx, c, namespace, labels)
+}
+
+// ClaimName returns the name the stateful set of another instance group
+// expects for the claim. Claims of instance groups are named
+// '<instance group>[-<disk>]-pvc-<instance group>-<ordinal>'.
+func ClaimName(name string, instanceGroupName string, newInstanceGroupName string) (string, error) {
+	oldName := names.Sanitize(instanceGroupName)
+	newName := names.Sanitize(newInstanceGroupName)
+
+	separator := fmt.Sprintf("-pvc-%s-", oldName)
+	idx := strings.LastIndex(name, separator)
+	if !strings.HasPrefix(name, oldName+"-") || idx < len(oldName) {
+		return "", errors.Errorf("persistent volume claim '%s' doesn't belong to a stateful set of instance group '%s'", name, instanceGroupName)
+	}
+
+	disk := name[len(oldName):idx]
+	suffix := name[idx+len(separator):]
+	return fmt.Sprintf("%s%s-pvc-%s-%s", newName, disk, newName, suffix), nil
+}
+
+// Reattach hands an orphaned claim over to an instance group. If it's the
+// instance group the claim belonged to, the claim is just no longer
+// orphaned. Otherwise its volume is moved to a new claim, which has the name
+// the stateful set of the other instance group expects.
+func Reattach(ctx context.Context, c client.Client, pvc *corev1.PersistentVolumeClaim, instanceGroupName string) (*corev1.PersistentVolumeClaim, error) {
+	if !IsOrphaned(pvc) {
+		return nil, errors.Errorf("persistent volume claim '%s/%s' is not orphaned", pvc.Namespace, pvc.Name)
+	}
+
+	oldInstanceGroupName := pvc.GetLabels()[bdv1.LabelInstanceGroupName]
+	if instanceGroupName == "" || instanceGroupName == oldInstanceGroupName {
+		unmark(pvc)
+		if err := c.Update(ctx, pvc); err != nil {
+			return nil, errors.Wrapf(err, "failed to reattach persistent volume claim '%s/%s'", pvc.Namespace, pvc.Name)
+		}
+		return pvc, nil
+	}
+
+	name, err := ClaimName(pvc.Name, oldInstanceGroupName, instanceGroupName)
+	if err != nil {
+		return nil, err
+	}
+	return Move(ctx, c, pvc, name, instanceGroupName)
+}
+
+// Move moves the volume of a claim to a new claim with the given name, which
+// belongs to another instance group. The volume is reserved for the new claim
+// before the old claim is deleted, so it's never released. Claims, which are
+// still used by a pod, are not moved. Moving again after a failure continues
+// where it stopped.
+func Move(ctx context.Context, c client.Client, pvc *corev1.PersistentVolumeClaim, name string, instanceGroupName string) (*corev1.PersistentVolumeClaim, error) {
+	if pvc.Spec.VolumeName == "" {
+		return nil, errors.Errorf("persistent volume claim '%s/%s' is not bound to a volume", pvc.Namespace, pvc.Name)
+	}
+
+	pod, err := usedBy(ctx, c, pvc)
+	if err != nil {
+		return nil, err
+	}
+	if pod != "" {
+		return nil, errors.Errorf("can't move persistent volume claim '%s/%s', it's still used by pod '%s'", pvc.Namespace, pvc.Name, pod)
+	}
+
+	newPVC := &corev1.PersistentVolumeClaim{}
+	err = c.Get(ctx, types.NamespacedName{Namespace: pvc.Namespace, Name: name}, newPVC)
+	if err == nil && newPVC.Spec.VolumeName != pvc.Spec.VolumeName {
+		return nil, errors.Errorf("can't move persistent volume claim '%s/%s', claim '%s' already exists", pvc.Namespace, pvc.Name, name)
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, errors.Wrapf(err, "failed to get persistent volume claim '%s/%s'", pvc.Namespace, name)
+	}
+
+	if apierrors.IsNotFound(err) {
+		pv := &corev1.PersistentVolume{}
+		if err := c.Get(ctx, types.NamespacedName{Name: pvc.Spec.VolumeName}, pv); err != nil {
+			return nil, errors.Wrapf(err, "failed to get persistent volume '%s'", pvc.Spec.VolumeName)
+		}
+		pv.Spec.ClaimRef = &corev1.ObjectReference{
+			Kind:      "PersistentVolumeClaim",
+			Namespace: pvc.Namespace,
+			Name:      name,
+		}
+		if err := c.Update(ctx, pv); err != nil {
+			return nil, errors.Wrapf(err, "failed to reserve persistent volume '%s' for claim '%s'", pv.Name, name)
+		}
+
+		labels := map[string]string{}
+		for k, v := range pvc.Labels {
+			labels[k] = v
+		}
+		labels[bdv1.LabelInstanceGroupName] = instanceGroupName
+		delete(labels, bdv1.LabelOrphanedDisk)
+
+		newPVC = &corev1.PersistentVolumeClaim{}
+		newPVC.Name = name
+		newPVC.Namespace = pvc.Namespace
+		newPVC.Labels = labels
+		newPVC.Spec = corev1.PersistentVolumeClaimSpec{
+			AccessModes:      pvc.Spec.AccessModes,
+			Resources:        pvc.Spec.Resources,
+			StorageClassName: pvc.Spec.StorageClassName,
+			VolumeMode:       pvc.Spec.VolumeMode,
+			VolumeName:       pv.Name,
+		}
+		if err := c.Create(ctx, newPVC); err != nil {
+			return nil, errors.Wrapf(err, "failed to create persistent volume claim '%s/%s'", newPVC.Namespace, newPVC.Name)
+		}
+	}
+
+	if err := c.Delete(ctx, pvc); err != nil && !apierrors.IsNotFound(err) {
+		return nil, errors.Wrapf(err, "failed to delete persistent volume claim '%s/%s'", pvc.Namespace, pvc.Name)
+	}
+
+	return newPVC, nil
+}
+
+// usedBy returns the name of a pod, which mounts the claim, or an empty string
+func usedBy(ctx context.Context, c client.Client, pvc *corev1.PersistentVolumeClaim) (string, error) {
+	pods := &corev1.PodList{}
+	if err := c.List(ctx, pods, client.InNamespace(pvc.Namespace)); err != nil {
+		return "", errors.Wrapf(err, "failed to list pods in namespace '%s'", pvc.Namespace)
+	}
+	for _, pod := range pods.Items {
+		for _, volume := range pod.Spec.Volumes {
+			if claim := volume.PersistentVolumeClaim; claim != nil && claim.ClaimName == pvc.Name {
+				return pod.Name, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+func list(ctx context.Context, c client.Client, namespace string, labels client.MatchingLabels) ([]corev1.PersistentVolumeClaim, error) {
+	pvcs := &corev1.PersistentVolumeClaimList{}
+	if err := c.List(ctx, pvcs, client.InNamespace(namespace), labels); err != nil {
+		return nil, errors.Wrapf(err, "failed to list persistent volume claims in namespace '%s'", namespace)
+	}
+	return pvcs.Items, nil
+}
+
+func unmark(pvc *corev1.PersistentVolumeClaim) {
+	delete(pvc.Labels, bdv1.LabelOrphanedDisk)
+	delete(pvc.Annotations, bdv1.AnnotationOrphanedAt)
+}
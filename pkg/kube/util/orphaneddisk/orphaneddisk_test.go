@@ -0,0 +1,237 @@
+package orphaneddisk_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	crc "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/orphaneddisk"
+	qstsv1a1 "code.cloudfoundry.org/quarks-statefulset/pkg/kube/apis/quarksstatefulset/v1alpha1"
+)
+
+var _ = Describe("OrphanedDisk", func() {
+	var (
+		ctx    context.Context
+		client crc.Client
+		pvc    *corev1.PersistentVolumeClaim
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		controller := true
+		pvc = &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "nats-pvc-nats-0",
+				Namespace: "default",
+				Labels: map[string]string{
+					bdv1.LabelDeploymentName:    "deployment-name",
+					bdv1.LabelInstanceGroupName: "nats",
+				},
+				OwnerReferences: []metav1.OwnerReference{{Kind: "StatefulSet", Name: "nats", Controller: &controller}},
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{VolumeName: "pv-1"},
+		}
+	})
+
+	JustBeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		client = fake.NewFakeClientWithScheme(scheme,
+			pvc,
+			&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+				Name:      "api-pvc-api-0",
+				Namespace: "default",
+				Labels: map[string]string{
+					bdv1.LabelDeploymentName:    "deployment-name",
+					bdv1.LabelInstanceGroupName: "api",
+				},
+			}},
+			&corev1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{Name: "pv-1"},
+				Spec:       corev1.PersistentVolumeSpec{PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimDelete},
+			},
+		)
+	})
+
+	getPVC := func(name string) *corev1.PersistentVolumeClaim {
+		result := &corev1.PersistentVolumeClaim{}
+		Expect(client.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, result)).To(Succeed())
+		return result
+	}
+
+	Describe("Label", func() {
+		BeforeEach(func() {
+			pvc.Labels = map[string]string{qstsv1a1.LabelQStsName: "nats"}
+		})
+
+		It("labels the claims of the instance group's stateful sets", func() {
+			Expect(orphaneddisk.Label(ctx, client, "default", "deployment-name", "nats")).To(Succeed())
+
+			Expect(getPVC("nats-pvc-nats-0").Labels).To(Equal(map[string]string{
+				qstsv1a1.LabelQStsName:      "nats",
+				bdv1.LabelDeploymentName:    "deployment-name",
+				bdv1.LabelInstanceGroupName: "nats",
+			}))
+		})
+	})
+
+	Describe("Orphan", func() {
+		It("marks the claims of the instance group as orphaned", func() {
+			count, err := orphaneddisk.Orphan(ctx, client, "default", "deployment-name", "nats")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(1))
+
+			orphaned := getPVC("nats-pvc-nats-0")
+			Expect(orphaneddisk.IsOrphaned(orphaned)).To(BeTrue())
+			Expect(orphaned.OwnerReferences).To(BeEmpty())
+			orphanedAt, ok := orphaneddisk.OrphanedAt(orphaned)
+			Expect(ok).To(BeTrue())
+			Expect(orphanedAt).To(BeTemporally("~", time.Now(), time.Minute))
+
+			Expect(orphaneddisk.IsOrphaned(getPVC("api-pvc-api-0"))).To(BeFalse())
+		})
+
+		It("orphans the claims of stateful sets, which didn't label their claims", func() {
+			pvc.Labels = map[string]string{qstsv1a1.LabelQStsName: "nats"}
+
+			count, err := orphaneddisk.Orphan(ctx, client, "default", "deployment-name", "nats")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(1))
+
+			pvcs, err := orphaneddisk.List(ctx, client, "default", "deployment-name")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pvcs).To(HaveLen(1))
+			Expect(pvcs[0].Name).To(Equal("nats-pvc-nats-0"))
+		})
+
+		It("keeps the time claims were orphaned first", func() {
+			_, err := orphaneddisk.Orphan(ctx, client, "default", "deployment-name", "nats")
+			Expect(err).ToNot(HaveOccurred())
+			count, err := orphaneddisk.Orphan(ctx, client, "default", "deployment-name", "nats")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(0))
+		})
+	})
+
+	Context("when a claim is orphaned", func() {
+		BeforeEach(func() {
+			pvc.Labels[bdv1.LabelOrphanedDisk] = "true"
+			pvc.Annotations = map[string]string{bdv1.AnnotationOrphanedAt: "2020-01-01T12:00:00Z"}
+		})
+
+		It("expires after the retention period", func() {
+			expiresAt, ok := orphaneddisk.ExpiresAt(pvc)
+			Expect(ok).To(BeTrue())
+			Expect(expiresAt).To(Equal(time.Date(2020, 1, 6, 12, 0, 0, 0, time.UTC)))
+		})
+
+		It("lists the orphaned claims", func() {
+			pvcs, err := orphaneddisk.List(ctx, client, "default", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pvcs).To(HaveLen(1))
+			Expect(pvcs[0].Name).To(Equal("nats-pvc-nats-0"))
+
+			pvcs, err = orphaneddisk.List(ctx, client, "default", "other")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pvcs).To(BeEmpty())
+		})
+
+		It("adopts the claims, when the instance group is added again", func() {
+			Expect(orphaneddisk.Adopt(ctx, client, "default", "deployment-name", "nats")).To(Succeed())
+
+			adopted := getPVC("nats-pvc-nats-0")
+			Expect(orphaneddisk.IsOrphaned(adopted)).To(BeFalse())
+			Expect(adopted.Annotations).ToNot(HaveKey(bdv1.AnnotationOrphanedAt))
+		})
+
+		It("reattaches the claim to its instance group", func() {
+			reattached, err := orphaneddisk.Reattach(ctx, client, getPVC("nats-pvc-nats-0"), "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reattached.Name).To(Equal("nats-pvc-nats-0"))
+			Expect(orphaneddisk.IsOrphaned(getPVC("nats-pvc-nats-0"))).To(BeFalse())
+		})
+
+		It("moves the volume to a claim of another instance group", func() {
+			reattached, err := orphaneddisk.Reattach(ctx, client, getPVC("nats-pvc-nats-0"), "nats2")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reattached.Name).To(Equal("nats2-pvc-nats2-0"))
+
+			newPVC := getPVC("nats2-pvc-nats2-0")
+			Expect(newPVC.Spec.VolumeName).To(Equal("pv-1"))
+			Expect(newPVC.Labels).To(Equal(map[string]string{
+				bdv1.LabelDeploymentName:    "deployment-name",
+				bdv1.LabelInstanceGroupName: "nats2",
+			}))
+
+			err = client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "nats-pvc-nats-0"}, &corev1.PersistentVolumeClaim{})
+			Expect(err).To(HaveOccurred())
+
+			pv := &corev1.PersistentVolume{}
+			Expect(client.Get(ctx, types.NamespacedName{Name: "pv-1"}, pv)).To(Succeed())
+			Expect(pv.Spec.PersistentVolumeReclaimPolicy).To(Equal(corev1.PersistentVolumeReclaimDelete))
+			Expect(pv.Spec.ClaimRef.Name).To(Equal("nats2-pvc-nats2-0"))
+		})
+
+		It("doesn't move the volume, if the claim of the other instance group exists", func() {
+			Expect(client.Create(ctx, &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "nats2-pvc-nats2-0", Namespace: "default"},
+				Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "pv-2"},
+			})).To(Succeed())
+
+			_, err := orphaneddisk.Reattach(ctx, client, getPVC("nats-pvc-nats-0"), "nats2")
+			Expect(err).To(MatchError(ContainSubstring("already exists")))
+
+			pv := &corev1.PersistentVolume{}
+			Expect(client.Get(ctx, types.NamespacedName{Name: "pv-1"}, pv)).To(Succeed())
+			Expect(pv.Spec.ClaimRef).To(BeNil())
+			Expect(orphaneddisk.IsOrphaned(getPVC("nats-pvc-nats-0"))).To(BeTrue())
+		})
+	})
+
+	It("refuses to move claims, which are still used by a pod", func() {
+		Expect(client.Create(ctx, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "nats-0", Namespace: "default"},
+			Spec: corev1.PodSpec{Volumes: []corev1.Volume{{
+				Name: "store",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "nats-pvc-nats-0"},
+				},
+			}}},
+		})).To(Succeed())
+
+		_, err := orphaneddisk.Move(ctx, client, getPVC("nats-pvc-nats-0"), "nats2-pvc-nats2-0", "nats2")
+		Expect(err).To(MatchError(ContainSubstring("it's still used by pod 'nats-0'")))
+
+		pv := &corev1.PersistentVolume{}
+		Expect(client.Get(ctx, types.NamespacedName{Name: "pv-1"}, pv)).To(Succeed())
+		Expect(pv.Spec.ClaimRef).To(BeNil())
+	})
+
+	It("refuses to reattach claims, which are not orphaned", func() {
+		_, err := orphaneddisk.Reattach(ctx, client, pvc, "")
+		Expect(err).To(MatchError(ContainSubstring("is not orphaned")))
+	})
+
+	Describe("ClaimName", func() {
+		It("renames instance group and stateful set in the claim name", func() {
+			Expect(orphaneddisk.ClaimName("nats-pvc-nats-0", "nats", "queue")).To(Equal("queue-pvc-queue-0"))
+			Expect(orphaneddisk.ClaimName("nats-data-pvc-nats-z1-2", "nats", "queue")).To(Equal("queue-data-pvc-queue-z1-2"))
+			Expect(orphaneddisk.ClaimName("redis-server-pvc-redis-server-0", "redis_server", "redis")).To(Equal("redis-pvc-redis-0"))
+		})
+
+		It("fails for claims of other instance groups", func() {
+			_, err := orphaneddisk.ClaimName("api-pvc-api-0", "nats", "queue")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package orphaneddisk_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestOrphanedDisk(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Orphaned Disk Suite")
+}
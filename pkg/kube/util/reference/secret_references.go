@@ -43,10 +43,11 @@ func getSecretRefFromBdpl(ctx context.Context, client crc.Client, object bdv1.BO
 		result[userVar.Secret] = true
 	}
 
-	// Include secrets of implicit vars
+	// Include secrets of implicit vars, runtime configs are not needed to list them
 	withops := withops.NewResolver(
 		client,
 		func() withops.Interpolator { return withops.NewInterpolator() },
+		"",
 	)
 	implicitVars, err := withops.ImplicitVariables(ctx, &object, object.Namespace)
 	if err != nil {
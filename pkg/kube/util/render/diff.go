@@ -0,0 +1,81 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Diff compares the rendered resources with the live objects in the cluster
+// and returns a human readable report. The resources need to be rendered with
+// a client, so they use the live versions of QuarksStatefulSets and instance
+// group resolved secrets. Objects are compared in full, except for the status
+// and metadata other than labels and annotations, so fields which would be
+// removed and fields defaulted by the cluster are reported, too.
+func Diff(ctx context.Context, c client.Client, scheme *runtime.Scheme, r *Resources) (string, error) {
+	objects, err := r.Objects(scheme)
+	if err != nil {
+		return "", err
+	}
+
+	var report strings.Builder
+	for _, obj := range objects {
+		desired, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to convert rendered object")
+		}
+		u := &unstructured.Unstructured{Object: desired}
+		id := fmt.Sprintf("%s '%s/%s'", u.GetKind(), u.GetNamespace(), u.GetName())
+
+		live := &unstructured.Unstructured{}
+		live.SetGroupVersionKind(u.GroupVersionKind())
+		err = c.Get(ctx, client.ObjectKey{Namespace: u.GetNamespace(), Name: u.GetName()}, live)
+		if apierrors.IsNotFound(err) {
+			fmt.Fprintf(&report, "+ %s would be created\n", id)
+			continue
+		}
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to get %s", id)
+		}
+
+		diff := cmp.Diff(comparedFields(live.Object), comparedFields(desired))
+		if diff == "" {
+			fmt.Fprintf(&report, "  %s is unchanged\n", id)
+			continue
+		}
+		fmt.Fprintf(&report, "~ %s would change (-live +rendered):\n%s\n", id, diff)
+	}
+
+	return report.String(), nil
+}
+
+// comparedFields returns the labels, annotations and all top-level fields of
+// obj, except metadata and status
+func comparedFields(obj map[string]interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	for key, value := range obj {
+		switch key {
+		case "apiVersion", "kind", "status":
+			continue
+		case "metadata":
+			metadata, _ := value.(map[string]interface{})
+			m := map[string]interface{}{}
+			for _, field := range []string{"labels", "annotations"} {
+				if v, ok := metadata[field]; ok {
+					m[field] = v
+				}
+			}
+			result[key] = m
+		default:
+			result[key] = value
+		}
+	}
+	return result
+}
@@ -0,0 +1,78 @@
+package render_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/render"
+)
+
+var _ = Describe("Diff", func() {
+	var (
+		scheme    *runtime.Scheme
+		resources *render.Resources
+		service   corev1.Service
+	)
+
+	BeforeEach(func() {
+		var err error
+		scheme, err = render.NewScheme()
+		Expect(err).ToNot(HaveOccurred())
+
+		service = corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo-nats", Namespace: "default", Labels: map[string]string{"app": "nats"}},
+			Spec: corev1.ServiceSpec{
+				Ports: []corev1.ServicePort{{Name: "nats", Port: 4222}},
+			},
+		}
+		resources = &render.Resources{Services: []corev1.Service{service}}
+	})
+
+	diff := func(objs ...runtime.Object) string {
+		report, err := render.Diff(context.Background(), fake.NewFakeClientWithScheme(scheme, objs...), scheme, resources)
+		Expect(err).ToNot(HaveOccurred())
+		return report
+	}
+
+	It("reports new objects", func() {
+		Expect(diff()).To(Equal("+ Service 'default/foo-nats' would be created\n"))
+	})
+
+	It("ignores the status and the metadata set by the cluster", func() {
+		live := service.DeepCopy()
+		live.UID = "1234"
+		live.OwnerReferences = []metav1.OwnerReference{{APIVersion: "v1", Kind: "ConfigMap", Name: "foo", UID: "5678"}}
+		live.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}}
+
+		Expect(diff(live)).To(Equal("  Service 'default/foo-nats' is unchanged\n"))
+	})
+
+	It("reports fields, which would be removed", func() {
+		live := service.DeepCopy()
+		live.Spec.ClusterIP = "10.0.0.1"
+		live.Annotations = map[string]string{"foo": "bar"}
+
+		report := diff(live)
+		Expect(report).To(ContainSubstring("~ Service 'default/foo-nats' would change"))
+		Expect(report).To(ContainSubstring(`"10.0.0.1"`))
+		Expect(report).To(ContainSubstring(`"bar"`))
+	})
+
+	It("reports changed objects", func() {
+		live := service.DeepCopy()
+		live.Spec.Ports[0].Port = 4223
+		live.Labels["app"] = "old"
+
+		report := diff(live)
+		Expect(report).To(ContainSubstring("~ Service 'default/foo-nats' would change"))
+		Expect(report).To(ContainSubstring("4223"))
+		Expect(report).To(ContainSubstring(`"old"`))
+	})
+})
@@ -0,0 +1,424 @@
+// Package render renders the Kubernetes resources of a BOSH deployment
+// offline, so changes can be reviewed before they are applied to a cluster.
+package render
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	batchv1b1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	qjv1a1 "code.cloudfoundry.org/quarks-job/pkg/kube/apis/quarksjob/v1alpha1"
+	"code.cloudfoundry.org/quarks-operator/pkg/bosh/bpmconverter"
+	"code.cloudfoundry.org/quarks-operator/pkg/bosh/converter"
+	bdm "code.cloudfoundry.org/quarks-operator/pkg/bosh/manifest"
+	"code.cloudfoundry.org/quarks-operator/pkg/bosh/qjobs"
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/names"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/withops"
+	qsv1a1 "code.cloudfoundry.org/quarks-secret/pkg/kube/apis/quarkssecret/v1alpha1"
+	qstsv1a1 "code.cloudfoundry.org/quarks-statefulset/pkg/kube/apis/quarksstatefulset/v1alpha1"
+	"code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+)
+
+const (
+	// qStsVersion is the version used for rendered QuarksStatefulSets and
+	// instance group resolved secrets, as if the deployment was new
+	qStsVersion = "1"
+
+	manifestConfigMapName = "render-manifest"
+)
+
+// Input is a BOSH deployment read from disk
+type Input struct {
+	Namespace      string
+	DeploymentName string
+	Manifest       []byte
+	Ops            [][]byte
+	// Vars are BOSH vars files. Their values are used for implicit variables
+	// and for explicit variables, which would otherwise be generated.
+	Vars [][]byte
+	// BaseDir contains the job specs and templates of the releases, as found in
+	// the release images, e.g. '<base-dir>/jobs-src/<release>/<job>/job.MF'
+	BaseDir        string
+	InitialRollout bool
+	// Client is used to look up the live versions of the QuarksStatefulSets
+	// and instance group resolved secrets, so rendered resources can be
+	// compared to the live objects. Without it the versions of a new
+	// deployment are used.
+	Client client.Client
+}
+
+// Resources are the Kubernetes resources the operator creates for a BOSH deployment
+type Resources struct {
+	QuarksSecrets          []qsv1a1.QuarksSecret
+	QuarksJobs             []qjv1a1.QuarksJob
+	CronJobs               []batchv1b1.CronJob
+	QuarksStatefulSets     []qstsv1a1.QuarksStatefulSet
+	Services               []corev1.Service
+	PersistentVolumeClaims []corev1.PersistentVolumeClaim
+	DisruptionBudgets      []policyv1beta1.PodDisruptionBudget
+	Autoscalers            []autoscalingv2beta2.HorizontalPodAutoscaler
+	Ingresses              []networkingv1beta1.Ingress
+}
+
+// NewScheme returns a scheme, which knows all rendered resources
+func NewScheme() (*runtime.Scheme, error) {
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{
+		clientgoscheme.AddToScheme,
+		bdv1.AddToScheme,
+		qsv1a1.AddToScheme,
+		qjv1a1.AddToScheme,
+		qstsv1a1.AddToScheme,
+	} {
+		if err := add(scheme); err != nil {
+			return nil, errors.Wrap(err, "failed to build scheme")
+		}
+	}
+	return scheme, nil
+}
+
+// Render runs the with-ops resolution, the variable conversion, the instance
+// group resolver and the BPM converter, like the operator would, and returns
+// the resulting resources.
+func Render(ctx context.Context, fs afero.Fs, in Input) (*Resources, error) {
+	vars, err := loadVars(in.Vars)
+	if err != nil {
+		return nil, err
+	}
+
+	withOpsManifest, err := resolveWithOps(ctx, in, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := &Resources{}
+
+	resources.QuarksSecrets, err = converter.NewVariablesConverter().Variables(in.Namespace, in.DeploymentName, withOpsManifest.Variables, withOpsManifest.Tags)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate quarks secrets from manifest")
+	}
+
+	qJob, err := qjobs.NewJobFactory().InstanceGroupManifestJob(in.Namespace, in.DeploymentName, *withOpsManifest, converter.LinkInfos{}, in.InitialRollout)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build instance group manifest qJob")
+	}
+	resources.QuarksJobs = append(resources.QuarksJobs, *qJob)
+
+	withOpsBytes, err := withOpsManifest.Marshal()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal with-ops manifest")
+	}
+	desiredBytes, err := withops.InterpolateExplicitVariables(withOpsBytes, []boshtpl.Variables{vars}, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to interpolate explicit variables")
+	}
+
+	c := bpmconverter.NewConverter(bpmconverter.NewVolumeFactory(), bpmconverter.NewContainerFactoryImplFunc)
+	for _, ig := range withOpsManifest.InstanceGroups {
+		bpmInfo, err := resolveInstanceGroup(fs, in, desiredBytes, ig.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		// The BPM converter expects a fresh desired manifest, like the one
+		// read from the desired manifest secret
+		desiredManifest, err := bdm.LoadYAML(desiredBytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load desired manifest")
+		}
+		instanceGroup, _ := desiredManifest.InstanceGroups.InstanceGroupByName(ig.Name)
+		instanceGroup.SetConsumedDisks(bpmInfo.InstanceGroup.ConsumedDisks)
+
+		version, igResolvedSecretVersion, err := liveVersions(ctx, in, ig)
+		if err != nil {
+			return nil, err
+		}
+
+		r, err := c.Resources(*desiredManifest, in.Namespace, in.DeploymentName, "", version, instanceGroup, bpmInfo.Configs, igResolvedSecretVersion)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to convert instance group '%s'", ig.Name)
+		}
+
+		resources.QuarksJobs = append(resources.QuarksJobs, r.Errands...)
+		resources.CronJobs = append(resources.CronJobs, r.ScheduledErrands...)
+		resources.QuarksStatefulSets = append(resources.QuarksStatefulSets, r.InstanceGroups...)
+		resources.Services = append(resources.Services, r.Services...)
+		resources.PersistentVolumeClaims = append(resources.PersistentVolumeClaims, r.PersistentVolumeClaims...)
+		resources.DisruptionBudgets = append(resources.DisruptionBudgets, r.DisruptionBudgets...)
+		resources.Autoscalers = append(resources.Autoscalers, r.Autoscalers...)
+		resources.Ingresses = append(resources.Ingresses, r.Ingresses...)
+	}
+
+	return resources, nil
+}
+
+// Objects returns all resources with their type meta set, in the order the
+// operator creates them
+func (r *Resources) Objects(scheme *runtime.Scheme) ([]runtime.Object, error) {
+	objects := []runtime.Object{}
+	for i := range r.QuarksSecrets {
+		objects = append(objects, &r.QuarksSecrets[i])
+	}
+	for i := range r.QuarksJobs {
+		objects = append(objects, &r.QuarksJobs[i])
+	}
+	for i := range r.CronJobs {
+		objects = append(objects, &r.CronJobs[i])
+	}
+	for i := range r.QuarksStatefulSets {
+		objects = append(objects, &r.QuarksStatefulSets[i])
+	}
+	for i := range r.Services {
+		objects = append(objects, &r.Services[i])
+	}
+	for i := range r.PersistentVolumeClaims {
+		objects = append(objects, &r.PersistentVolumeClaims[i])
+	}
+	for i := range r.DisruptionBudgets {
+		objects = append(objects, &r.DisruptionBudgets[i])
+	}
+	for i := range r.Autoscalers {
+		objects = append(objects, &r.Autoscalers[i])
+	}
+	for i := range r.Ingresses {
+		objects = append(objects, &r.Ingresses[i])
+	}
+
+	for _, obj := range objects {
+		gvks, _, err := scheme.ObjectKinds(obj)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to find kind of rendered object")
+		}
+		obj.GetObjectKind().SetGroupVersionKind(gvks[0])
+	}
+
+	return objects, nil
+}
+
+// YAML returns the resources as a multi document YAML
+func (r *Resources) YAML(scheme *runtime.Scheme) ([]byte, error) {
+	objects, err := r.Objects(scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	out := []byte{}
+	for _, obj := range objects {
+		b, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to marshal rendered object")
+		}
+		out = append(out, []byte("---\n")...)
+		out = append(out, b...)
+	}
+	return out, nil
+}
+
+// liveVersions returns the version of the instance group's QuarksStatefulSet
+// and the latest version of its instance group resolved secret in the cluster.
+// qStsVersion is returned for objects, which don't exist.
+func liveVersions(ctx context.Context, in Input, ig *bdm.InstanceGroup) (string, string, error) {
+	if in.Client == nil {
+		return qStsVersion, qStsVersion, nil
+	}
+
+	version := qStsVersion
+	qSts := &qstsv1a1.QuarksStatefulSet{}
+	err := in.Client.Get(ctx, types.NamespacedName{Namespace: in.Namespace, Name: ig.NameSanitized()}, qSts)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return "", "", errors.Wrapf(err, "failed to get QuarksStatefulSet '%s/%s'", in.Namespace, ig.NameSanitized())
+	}
+	if v, ok := qSts.Labels[bdv1.LabelDeploymentVersion]; ok && err == nil {
+		version = v
+	}
+
+	igResolvedSecretVersion := qStsVersion
+	name := names.InstanceGroupSecretName(bdv1.DeploymentSecretTypeInstanceGroupResolvedProperties, in.DeploymentName, ig.Name, "")
+	secrets, err := versionedsecretstore.NewVersionedSecretStore(in.Client).List(ctx, in.Namespace, name)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to list versions of secret '%s/%s'", in.Namespace, name)
+	}
+	latest := 0
+	for _, secret := range secrets {
+		v, err := versionedsecretstore.Version(secret)
+		if err != nil {
+			return "", "", errors.Wrapf(err, "failed to get version of secret '%s/%s'", in.Namespace, secret.Name)
+		}
+		if v > latest {
+			latest = v
+		}
+	}
+	if latest > 0 {
+		igResolvedSecretVersion = strconv.Itoa(latest)
+	}
+
+	return version, igResolvedSecretVersion, nil
+}
+
+// loadVars merges the vars files, later files take precedence
+func loadVars(files [][]byte) (boshtpl.StaticVariables, error) {
+	vars := boshtpl.StaticVariables{}
+	for _, file := range files {
+		fileVars := boshtpl.StaticVariables{}
+		if err := yaml.Unmarshal(file, &fileVars); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal vars file")
+		}
+		for k, v := range fileVars {
+			vars[k] = v
+		}
+	}
+	return vars, nil
+}
+
+// resolveWithOps resolves the with-ops manifest with the same resolver the
+// operator uses, backed by an in-memory client. The client contains config
+// maps for the manifest and ops files and secrets for implicit variables.
+func resolveWithOps(ctx context.Context, in Input, vars boshtpl.StaticVariables) (*bdm.Manifest, error) {
+	scheme, err := NewScheme()
+	if err != nil {
+		return nil, err
+	}
+
+	bdpl := &bdv1.BOSHDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: in.DeploymentName, Namespace: in.Namespace},
+		Spec: bdv1.BOSHDeploymentSpec{
+			Manifest: bdv1.ResourceReference{Name: manifestConfigMapName, Type: bdv1.ConfigMapReference},
+		},
+	}
+	objects := []runtime.Object{
+		configMap(in.Namespace, manifestConfigMapName, bdv1.ManifestSpecName, in.Manifest),
+	}
+	for i, ops := range in.Ops {
+		name := fmt.Sprintf("render-ops-%d", i)
+		bdpl.Spec.Ops = append(bdpl.Spec.Ops, bdv1.ResourceReference{Name: name, Type: bdv1.ConfigMapReference})
+		objects = append(objects, configMap(in.Namespace, name, bdv1.OpsSpecName, ops))
+	}
+	for name, value := range vars {
+		secret, err := implicitVariableSecret(in.Namespace, name, value)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, secret)
+	}
+
+	resolver := withops.NewResolver(
+		fake.NewFakeClientWithScheme(scheme, objects...),
+		func() withops.Interpolator { return withops.NewInterpolator() },
+		"",
+	)
+	manifest, err := resolver.Manifest(ctx, bdpl, in.Namespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve with-ops manifest, implicit variables need to be set in a vars file")
+	}
+	return manifest, nil
+}
+
+// resolveInstanceGroup returns the BPM info, which the instance group
+// resolver QuarksJob would write to the BPM secret
+func resolveInstanceGroup(fs afero.Fs, in Input, desiredBytes []byte, instanceGroupName string) (*bdm.BPMInfo, error) {
+	desiredManifest, err := bdm.LoadYAML(desiredBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load desired manifest")
+	}
+
+	igr, err := bdm.NewInstanceGroupResolver(fs, in.BaseDir, in.DeploymentName, *desiredManifest, instanceGroupName)
+	if err != nil {
+		return nil, err
+	}
+	if err := igr.ResolveWithoutScripts(in.InitialRollout); err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve instance group '%s'", instanceGroupName)
+	}
+
+	bpmInfo, err := igr.BPMInfo()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get BPM info for instance group '%s'", instanceGroupName)
+	}
+
+	ig, _ := desiredManifest.InstanceGroups.InstanceGroupByName(instanceGroupName)
+	preRenderOps := ig.Env.AgentEnvBoshConfig.Agent.Settings.PreRenderOps
+	if preRenderOps == nil || len(preRenderOps.BPM) == 0 {
+		return &bpmInfo, nil
+	}
+
+	// Apply the BPM pre-render ops, like the instance group resolver does
+	opsData, err := preRenderOps.BPM.Bytes()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get bytes for bpm pre render ops in instance group '%s'", instanceGroupName)
+	}
+	interpolator := withops.NewInterpolator()
+	if err := interpolator.AddOps(opsData); err != nil {
+		return nil, errors.Wrapf(err, "interpolation failed for bpm pre-render ops in instance group '%s'", instanceGroupName)
+	}
+	bpmBytes, err := yaml.Marshal(bpmInfo)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal BPM info for instance group '%s'", instanceGroupName)
+	}
+	bpmBytes, err = interpolator.Interpolate(bpmBytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to interpolate bpm pre render ops for instance group '%s'", instanceGroupName)
+	}
+	bpmInfo = bdm.BPMInfo{}
+	if err := yaml.Unmarshal(bpmBytes, &bpmInfo); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal BPM info for instance group '%s'", instanceGroupName)
+	}
+
+	return &bpmInfo, nil
+}
+
+func configMap(namespace, name, key string, data []byte) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Data:       map[string]string{key: string(data)},
+	}
+}
+
+// implicitVariableSecret returns the secret the resolver reads an implicit
+// variable from. Values, which are not strings, are stored as JSON. The keys
+// of maps are also stored separately, to support the 'name/key' syntax.
+func implicitVariableSecret(namespace, name string, value interface{}) (*corev1.Secret, error) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: names.SecretVariableName(name), Namespace: namespace},
+		Data:       map[string][]byte{},
+	}
+
+	if s, ok := value.(string); ok {
+		secret.Data[bdv1.ImplicitVariableKeyName] = []byte(s)
+		return secret, nil
+	}
+
+	values := map[string]interface{}{bdv1.ImplicitVariableKeyName: value}
+	if m, ok := value.(map[string]interface{}); ok {
+		for key, val := range m {
+			values[key] = val
+		}
+	}
+	for key, val := range values {
+		b, err := json.Marshal(val)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to marshal key '%s' of variable '%s'", key, name)
+		}
+		secret.Data[key] = b
+	}
+	secret.Annotations = map[string]string{bdv1.AnnotationJSONValue: "true"}
+
+	return secret, nil
+}
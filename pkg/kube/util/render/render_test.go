@@ -0,0 +1,132 @@
+package render_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/render"
+	qstsv1a1 "code.cloudfoundry.org/quarks-statefulset/pkg/kube/apis/quarksstatefulset/v1alpha1"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+	"code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore"
+	"code.cloudfoundry.org/quarks-utils/testing/testhelper"
+)
+
+var _ = Describe("Render", func() {
+	const manifest = `---
+name: foo
+releases:
+- name: redis
+  version: 36.15.0
+  url: hub.docker.com/cfcontainerization
+  stemcell:
+    os: opensuse-42.3
+    version: 36.g03b4653-30.80-7.0.0_367.g6b06000e
+instance_groups:
+- name: redis-slave
+  instances: 2
+  jobs:
+  - name: redis-server
+    release: redis
+    properties:
+      password: ((redis_password))
+      domain: ((system_domain))
+variables:
+- name: redis_password
+  type: password
+`
+
+	var (
+		ctx context.Context
+		in  render.Input
+	)
+
+	BeforeEach(func() {
+		_, log := testhelper.NewTestLogger()
+		ctx = ctxlog.NewParentContext(log)
+
+		in = render.Input{
+			Namespace:      "default",
+			DeploymentName: "foo",
+			Manifest:       []byte(manifest),
+			Ops: [][]byte{[]byte(`
+- type: replace
+  path: /instance_groups/name=redis-slave/instances
+  value: 3
+`)},
+			Vars:           [][]byte{[]byte("system_domain: example.com")},
+			BaseDir:        assetPath,
+			InitialRollout: true,
+		}
+	})
+
+	It("fails if an implicit variable is missing", func() {
+		in.Vars = nil
+
+		_, err := render.Render(ctx, afero.NewOsFs(), in)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("implicit variables need to be set in a vars file"))
+	})
+
+	It("renders the resources of the deployment", func() {
+		resources, err := render.Render(ctx, afero.NewOsFs(), in)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(resources.QuarksSecrets).To(HaveLen(1))
+		Expect(resources.QuarksSecrets[0].Name).To(Equal("foo.var-redis-password"))
+		Expect(resources.QuarksStatefulSets).To(HaveLen(1))
+		Expect(resources.QuarksStatefulSets[0].Name).To(Equal("redis-slave"))
+		Expect(*resources.QuarksStatefulSets[0].Spec.Template.Spec.Replicas).To(Equal(int32(3)))
+
+		scheme, err := render.NewScheme()
+		Expect(err).ToNot(HaveOccurred())
+		out, err := resources.YAML(scheme)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(out)).To(ContainSubstring("kind: QuarksSecret\n"))
+		Expect(string(out)).To(ContainSubstring("kind: QuarksStatefulSet\n"))
+	})
+
+	It("uses the live versions, if a client is given", func() {
+		scheme, err := render.NewScheme()
+		Expect(err).ToNot(HaveOccurred())
+		in.Client = fake.NewFakeClientWithScheme(scheme,
+			&qstsv1a1.QuarksStatefulSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "redis-slave",
+					Namespace: "default",
+					Labels:    map[string]string{bdv1.LabelDeploymentVersion: "3"},
+				},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo.ig-resolved.redis-slave-v2",
+					Namespace: "default",
+					Labels: map[string]string{
+						versionedsecretstore.LabelSecretKind: versionedsecretstore.VersionSecretKind,
+						versionedsecretstore.LabelVersion:    "2",
+					},
+				},
+			},
+		)
+
+		resources, err := render.Render(ctx, afero.NewOsFs(), in)
+		Expect(err).ToNot(HaveOccurred())
+
+		qSts := resources.QuarksStatefulSets[0]
+		Expect(qSts.Labels).To(HaveKeyWithValue(bdv1.LabelDeploymentVersion, "3"))
+		secretNames := []string{}
+		for _, volume := range qSts.Spec.Template.Spec.Template.Spec.Volumes {
+			if volume.Secret != nil {
+				secretNames = append(secretNames, volume.Secret.SecretName)
+			}
+		}
+		Expect(secretNames).To(ContainElement("foo.ig-resolved.redis-slave-v2"))
+	})
+})
@@ -5,9 +5,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/SUSE/go-patch/patch"
 	"github.com/pkg/errors"
@@ -18,6 +18,8 @@ import (
 	bdm "code.cloudfoundry.org/quarks-operator/pkg/bosh/manifest"
 	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/boshdns"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/desiredmanifest"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/metrics"
 	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/names"
 	qsv1a1 "code.cloudfoundry.org/quarks-secret/pkg/kube/apis/quarkssecret/v1alpha1"
 	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
@@ -31,17 +33,20 @@ type Resolver struct {
 	client               client.Client
 	versionedSecretStore versionedsecretstore.VersionedSecretStore
 	newInterpolatorFunc  NewInterpolatorFunc
+	operatorNamespace    string
 }
 
 // NewInterpolatorFunc returns a fresh Interpolator
 type NewInterpolatorFunc func() Interpolator
 
-// NewResolver constructs a resolver
-func NewResolver(client client.Client, f NewInterpolatorFunc) *Resolver {
+// NewResolver constructs a resolver. Runtime configs in the operator
+// namespace are applied to the deployments of all namespaces.
+func NewResolver(client client.Client, f NewInterpolatorFunc, operatorNamespace string) *Resolver {
 	return &Resolver{
 		client:               client,
 		newInterpolatorFunc:  f,
 		versionedSecretStore: versionedsecretstore.NewVersionedSecretStore(client),
+		operatorNamespace:    operatorNamespace,
 	}
 }
 
@@ -53,7 +58,7 @@ func (r *Resolver) load(ctx context.Context, bdpl *bdv1.BOSHDeployment, namespac
 		spec         = bdpl.Spec
 	)
 
-	m, err = r.resourceData(ctx, namespace, spec.Manifest.Type, spec.Manifest.Name, bdv1.ManifestSpecName)
+	m, err = r.resourceData(ctx, namespace, spec.Manifest, bdv1.ManifestSpecName)
 	if err != nil {
 		return nil, errors.Wrapf(err, "Interpolation failed for bosh deployment '%s' in '%s'", bdpl.Name, namespace)
 	}
@@ -62,7 +67,7 @@ func (r *Resolver) load(ctx context.Context, bdpl *bdv1.BOSHDeployment, namespac
 	ops := spec.Ops
 
 	for _, op := range ops {
-		opsData, err := r.resourceData(ctx, namespace, op.Type, op.Name, bdv1.OpsSpecName)
+		opsData, err := r.resourceData(ctx, namespace, op, bdv1.OpsSpecName)
 		if err != nil {
 			return nil, errors.Wrapf(err, "Interpolation failed for bosh deployment '%s' in '%s'", bdpl.Name, namespace)
 		}
@@ -91,6 +96,8 @@ func (r *Resolver) load(ctx context.Context, bdpl *bdv1.BOSHDeployment, namespac
 // The resulting manifest has variables interpolated and ops files applied.
 // It is the 'with-ops' manifest.
 func (r *Resolver) Manifest(ctx context.Context, bdpl *bdv1.BOSHDeployment, namespace string) (*bdm.Manifest, error) {
+	defer metrics.ManifestResolved(time.Now())
+
 	manifest, err := r.load(ctx, bdpl, namespace)
 	if err != nil {
 		return nil, err
@@ -98,6 +105,12 @@ func (r *Resolver) Manifest(ctx context.Context, bdpl *bdv1.BOSHDeployment, name
 	return r.applyVariables(ctx, bdpl, namespace, manifest, "manifest-addons")
 }
 
+// ManifestWithOps returns the manifest with ops files applied, without
+// interpolating any variables
+func (r *Resolver) ManifestWithOps(ctx context.Context, bdpl *bdv1.BOSHDeployment, namespace string) (*bdm.Manifest, error) {
+	return r.load(ctx, bdpl, namespace)
+}
+
 // ImplicitVariables returns the implicit variables found in the manifest
 func (r *Resolver) ImplicitVariables(ctx context.Context, bdpl *bdv1.BOSHDeployment, namespace string) ([]string, error) {
 	manifest, err := r.load(ctx, bdpl, namespace)
@@ -135,7 +148,7 @@ func (r *Resolver) ManifestDetailed(ctx context.Context, bdpl *bdv1.BOSHDeployme
 		spec = bdpl.Spec
 	)
 
-	m, err = r.resourceData(ctx, namespace, spec.Manifest.Type, spec.Manifest.Name, bdv1.ManifestSpecName)
+	m, err = r.resourceData(ctx, namespace, spec.Manifest, bdv1.ManifestSpecName)
 	if err != nil {
 		return nil, errors.Wrapf(err, "Interpolation failed for bosh deployment %s", namespace)
 	}
@@ -147,7 +160,7 @@ func (r *Resolver) ManifestDetailed(ctx context.Context, bdpl *bdv1.BOSHDeployme
 	for _, op := range ops {
 		interpolator := r.newInterpolatorFunc()
 
-		opsData, err := r.resourceData(ctx, namespace, op.Type, op.Name, bdv1.OpsSpecName)
+		opsData, err := r.resourceData(ctx, namespace, op, bdv1.OpsSpecName)
 		if err != nil {
 			return nil, errors.Wrapf(err, "Failed to get resource data for interpolation of bosh deployment '%s' and ops '%s' in '%s'", bdpl.Name, op.Name, namespace)
 		}
@@ -247,7 +260,8 @@ func (r *Resolver) applyVariables(ctx context.Context, bdpl *bdv1.BOSHDeployment
 				if err != nil {
 					return nil, errors.Wrapf(err, "failed to unmarshal JSON in '%s' from secret '%s/%s'", info.variable, namespace, secName)
 				}
-				impVars[info.variable] = js
+				// the template evaluator expects YAML maps to access fields, e.g. ((ca.certificate))
+				impVars[info.variable] = yamlMaps(js)
 			} else {
 				impVars[info.variable] = string(val)
 			}
@@ -272,6 +286,14 @@ func (r *Resolver) applyVariables(ctx context.Context, bdpl *bdv1.BOSHDeployment
 		return nil, errors.Wrapf(err, "failed to load manifest with evaluated variables")
 	}
 
+	// Merge runtime configs, before their addons are applied
+	if !manifest.AddOnsApplied {
+		err = r.applyRuntimeConfigs(ctx, namespace, manifest)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Apply addons
 	log := ctxlog.ExtractLogger(ctx)
 	err = manifest.ApplyAddons(logger.TraceFilter(log, logName))
@@ -320,19 +342,107 @@ func (r *Resolver) applyVariables(ctx context.Context, bdpl *bdv1.BOSHDeployment
 	if err != nil {
 		return nil, err
 	}
-	manifest.ApplyUpdateBlock()
+	manifest.ApplyUpdateBlock(bdpl.Name)
+	if manifest.RandomizesAZPlacement() {
+		deployed, err := r.deployedManifest(ctx, bdpl.Name, namespace)
+		if err != nil {
+			return nil, err
+		}
+		manifest.RandomizeAZPlacement(bdpl.Name, deployed)
+	}
+
+	err = r.applyCloudConfigs(ctx, namespace, manifest)
+	if err != nil {
+		return nil, err
+	}
 
 	return manifest, err
 }
 
+// deployedManifest returns the latest desired manifest of the deployment, or
+// nil if the deployment has not been deployed yet
+func (r *Resolver) deployedManifest(ctx context.Context, deploymentName string, namespace string) (*bdm.Manifest, error) {
+	name := names.DesiredManifestName(deploymentName)
+	secrets, err := r.versionedSecretStore.List(ctx, namespace, name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list versions of desired manifest '%s/%s'", namespace, name)
+	}
+	if len(secrets) == 0 {
+		return nil, nil
+	}
+
+	return desiredmanifest.NewDesiredManifest(r.client).DesiredManifest(ctx, deploymentName, namespace)
+}
+
+// applyCloudConfigs merges the cloud configs of the namespace and of the
+// operator namespace into the manifest. Cloud configs of the namespace take
+// precedence.
+func (r *Resolver) applyCloudConfigs(ctx context.Context, namespace string, manifest *bdm.Manifest) error {
+	namespaces := []string{namespace}
+	if r.operatorNamespace != "" && r.operatorNamespace != namespace {
+		namespaces = append(namespaces, r.operatorNamespace)
+	}
+
+	for _, ns := range namespaces {
+		cloudConfigs := &bdv1.CloudConfigList{}
+		err := r.client.List(ctx, cloudConfigs, client.InNamespace(ns))
+		if err != nil {
+			return errors.Wrapf(err, "failed to list cloud configs in '%s'", ns)
+		}
+
+		items := cloudConfigs.Items
+		sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+		for _, item := range items {
+			cc, err := bdm.LoadCloudConfigYAML([]byte(item.Spec.Config))
+			if err != nil {
+				return errors.Wrapf(err, "failed to load cloud config '%s/%s'", ns, item.Name)
+			}
+			ctxlog.Debugf(ctx, "Applying cloud config '%s/%s'", ns, item.Name)
+			manifest.ApplyCloudConfig(cc)
+		}
+	}
+	return nil
+}
+
+// applyRuntimeConfigs merges the runtime configs of the namespace and of the
+// operator namespace into the manifest. Runtime configs of the namespace take
+// precedence.
+func (r *Resolver) applyRuntimeConfigs(ctx context.Context, namespace string, manifest *bdm.Manifest) error {
+	namespaces := []string{namespace}
+	if r.operatorNamespace != "" && r.operatorNamespace != namespace {
+		namespaces = append(namespaces, r.operatorNamespace)
+	}
+
+	for _, ns := range namespaces {
+		runtimeConfigs := &bdv1.RuntimeConfigList{}
+		err := r.client.List(ctx, runtimeConfigs, client.InNamespace(ns))
+		if err != nil {
+			return errors.Wrapf(err, "failed to list runtime configs in '%s'", ns)
+		}
+
+		items := runtimeConfigs.Items
+		sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+		for _, item := range items {
+			rc, err := bdm.LoadRuntimeConfigYAML([]byte(item.Spec.Config))
+			if err != nil {
+				return errors.Wrapf(err, "failed to load runtime config '%s/%s'", ns, item.Name)
+			}
+			ctxlog.Debugf(ctx, "Applying runtime config '%s/%s'", ns, item.Name)
+			manifest.ApplyRuntimeConfig(rc)
+		}
+	}
+	return nil
+}
+
 // resourceData resolves different manifest reference types and returns the resource's data
-func (r *Resolver) resourceData(ctx context.Context, namespace string, resType bdv1.ReferenceType, name string, key string) (string, error) {
+func (r *Resolver) resourceData(ctx context.Context, namespace string, ref bdv1.ResourceReference, key string) (string, error) {
 	var (
 		data string
 		ok   bool
+		name = ref.Name
 	)
 
-	switch resType {
+	switch ref.Type {
 	case bdv1.ConfigMapReference:
 		opsConfig := &corev1.ConfigMap{}
 		err := r.client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, opsConfig)
@@ -355,15 +465,7 @@ func (r *Resolver) resourceData(ctx context.Context, namespace string, resType b
 		}
 		data = string(encodedData)
 	case bdv1.URLReference:
-		httpResponse, err := http.Get(name)
-		if err != nil {
-			return data, errors.Wrapf(err, "failed to resolve %s from url '%s' via http.Get", key, name)
-		}
-		body, err := ioutil.ReadAll(httpResponse.Body)
-		if err != nil {
-			return data, errors.Wrapf(err, "failed to read %s response body '%s' via ioutil", key, name)
-		}
-		data = string(body)
+		return r.fetchURL(ctx, namespace, ref, key)
 	default:
 		return data, fmt.Errorf("unrecognized %s ref type %s", key, name)
 	}
@@ -384,7 +486,7 @@ func (r *Resolver) InterpolateVariableFromSecrets(ctx context.Context, withOpsMa
 		staticVars := boshtpl.StaticVariables{}
 
 		varName := variable.Name
-		varSecretName := names.SecretVariableName(varName)
+		varSecretName := names.DeploymentSecretName(bdv1.DeploymentSecretTypeVariable, boshdeploymentName, varName)
 
 		varQuarksSecret := &qsv1a1.QuarksSecret{}
 		err = r.client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: varSecretName}, varQuarksSecret)
@@ -453,6 +555,25 @@ func InterpolateExplicitVariables(boshManifestBytes []byte, vars []boshtpl.Varia
 	return yamlBytes, nil
 }
 
+// yamlMaps converts the maps of an unmarshalled JSON value to the map type
+// of unmarshalled YAML
+func yamlMaps(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		m := make(map[interface{}]interface{}, len(val))
+		for k, e := range val {
+			m[k] = yamlMaps(e)
+		}
+		return m
+	case []interface{}:
+		for i, e := range val {
+			val[i] = yamlMaps(e)
+		}
+		return val
+	}
+	return v
+}
+
 // MergeStaticVar builds a map of values used for BOSH explicit variable interpolation
 func MergeStaticVar(staticVar interface{}, field string, value string) interface{} {
 	if staticVar == nil {
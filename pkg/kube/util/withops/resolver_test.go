@@ -14,6 +14,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	fakeClient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 
@@ -48,6 +49,7 @@ var _ = Describe("WithOps", func() {
 	BeforeEach(func() {
 		_, log := testhelper.NewTestLogger()
 		ctx = ctxlog.NewParentContext(log)
+		Expect(bdc.AddToScheme(scheme.Scheme)).To(Succeed())
 		validManifestPath = "/valid-manifest.yml"
 		validOpsPath = "/valid-ops.yml"
 		invalidOpsPath = "/invalid-ops.yml"
@@ -231,6 +233,7 @@ instance_groups:
     instances: 2
     properties:
       nested: ((implicit_struct))
+      dotted: ((implicit_struct.a.b))
 `},
 			},
 			&corev1.Secret{
@@ -369,7 +372,7 @@ instance_groups:
 		newInterpolatorFunc := func() withops.Interpolator {
 			return interpolator
 		}
-		resolver = withops.NewResolver(client, newInterpolatorFunc)
+		resolver = withops.NewResolver(client, newInterpolatorFunc, "")
 	})
 
 	Describe("Manifest", func() {
@@ -476,6 +479,142 @@ instance_groups:
 			Expect(deep.Equal(manifest, expectedManifest)).To(HaveLen(0))
 		})
 
+		Context("when runtime configs exist", func() {
+			runtimeConfig := func(namespace, name, jobName string) *bdc.RuntimeConfig {
+				return &bdc.RuntimeConfig{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+					Spec: bdc.RuntimeConfigSpec{Config: `---
+releases:
+- name: forwarder
+  version: 1.0.0
+  stemcell:
+    os: opensuse-42.3
+    version: 36.g03b4653-30.80-7.0.0_316.gcf9fe4a7
+addons:
+- name: ` + name + `
+  jobs:
+  - name: ` + jobName + `
+    release: forwarder
+  include:
+    instance_groups:
+    - component1
+`},
+				}
+			}
+
+			BeforeEach(func() {
+				Expect(client.Create(ctx, runtimeConfig("default", "logs", "fluentd"))).To(Succeed())
+				Expect(client.Create(ctx, runtimeConfig("operator", "logs", "global-fluentd"))).To(Succeed())
+				Expect(client.Create(ctx, runtimeConfig("operator", "security", "agent"))).To(Succeed())
+				Expect(client.Create(ctx, runtimeConfig("other", "unrelated", "unrelated"))).To(Succeed())
+
+				resolver = withops.NewResolver(client, func() withops.Interpolator { return interpolator }, "operator")
+			})
+
+			It("applies the addons of the namespace and the operator namespace", func() {
+				deployment := &bdc.BOSHDeployment{
+					Spec: bdc.BOSHDeploymentSpec{
+						Manifest: bdc.ResourceReference{
+							Type: bdc.ConfigMapReference,
+							Name: "base-manifest",
+						},
+					},
+				}
+
+				manifest, err := resolver.Manifest(ctx, deployment, "default")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(manifest.Releases).To(HaveLen(1))
+				Expect(manifest.Releases[0].Name).To(Equal("forwarder"))
+
+				jobs := []string{}
+				for _, job := range manifest.InstanceGroups[0].Jobs {
+					jobs = append(jobs, job.Name)
+				}
+				Expect(jobs).To(ConsistOf("fluentd", "agent"))
+				Expect(manifest.InstanceGroups[1].Jobs).To(BeEmpty())
+			})
+
+			It("fails for invalid runtime configs", func() {
+				invalid := runtimeConfig("default", "invalid", "")
+				invalid.Spec.Config = "addons: {"
+				Expect(client.Create(ctx, invalid)).To(Succeed())
+
+				deployment := &bdc.BOSHDeployment{
+					Spec: bdc.BOSHDeploymentSpec{
+						Manifest: bdc.ResourceReference{
+							Type: bdc.ConfigMapReference,
+							Name: "base-manifest",
+						},
+					},
+				}
+
+				_, err := resolver.Manifest(ctx, deployment, "default")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("failed to load runtime config 'default/invalid'"))
+			})
+		})
+
+		Context("when cloud configs exist", func() {
+			cloudConfig := func(namespace, name, vmType, cpu string) *bdc.CloudConfig {
+				return &bdc.CloudConfig{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+					Spec: bdc.CloudConfigSpec{Config: `---
+vm_types:
+- name: ` + vmType + `
+  cloud_properties:
+    resources:
+      requests:
+        cpu: ` + cpu + `
+`},
+				}
+			}
+
+			var deployment *bdc.BOSHDeployment
+
+			BeforeEach(func() {
+				Expect(client.Create(ctx, cloudConfig("default", "vms", "small", "500m"))).To(Succeed())
+				Expect(client.Create(ctx, cloudConfig("operator", "vms", "small", "1"))).To(Succeed())
+				Expect(client.Create(ctx, cloudConfig("operator", "large-vms", "large", "4"))).To(Succeed())
+				Expect(client.Create(ctx, cloudConfig("other", "unrelated", "unrelated", "1"))).To(Succeed())
+
+				resolver = withops.NewResolver(client, func() withops.Interpolator { return interpolator }, "operator")
+				deployment = &bdc.BOSHDeployment{
+					Spec: bdc.BOSHDeploymentSpec{
+						Manifest: bdc.ResourceReference{
+							Type: bdc.ConfigMapReference,
+							Name: "base-manifest",
+						},
+					},
+				}
+			})
+
+			It("adds the cloud configs of the namespace and the operator namespace to the manifest", func() {
+				manifest, err := resolver.Manifest(ctx, deployment, "default")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(manifest.CloudConfig).ToNot(BeNil())
+				Expect(manifest.CloudConfig.VMTypes).To(HaveLen(2))
+
+				small, err := manifest.CloudConfig.VMType("small")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(small.CloudProperties.Resources.Requests.Cpu().String()).To(Equal("500m"))
+
+				_, err = manifest.CloudConfig.VMType("unrelated")
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("fails for invalid cloud configs", func() {
+				invalid := cloudConfig("default", "invalid", "", "")
+				invalid.Spec.Config = "vm_types: {"
+				Expect(client.Create(ctx, invalid)).To(Succeed())
+
+				_, err := resolver.Manifest(ctx, deployment, "default")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("failed to load cloud config 'default/invalid'"))
+			})
+		})
+
 		It("works for valid CRs containing one ops", func() {
 			interpolator.InterpolateReturns([]byte(`---
 instance_groups:
@@ -891,7 +1030,7 @@ instance_groups:
 			newInterpolatorFunc := func() withops.Interpolator {
 				return interpolator
 			}
-			resolver = withops.NewResolver(client, newInterpolatorFunc)
+			resolver = withops.NewResolver(client, newInterpolatorFunc, "")
 
 			deployment := &bdc.BOSHDeployment{
 				ObjectMeta: metav1.ObjectMeta{
@@ -1034,6 +1173,13 @@ instance_groups:
 				Expect(string(bytes)).To(Equal(`{"a":{"b":3}}`))
 			})
 
+			It("uses fields of json implicit vars", func() {
+				m, err := resolver.Manifest(ctx, deployment, "default")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(m.InstanceGroups[1].Properties.Properties["dotted"]).To(Equal(json.Number("3")))
+			})
+
 			It("lists implicit variables", func() {
 				implicitVars, err := resolver.ImplicitVariables(ctx, deployment, "default")
 
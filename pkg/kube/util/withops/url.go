@@ -0,0 +1,126 @@
+package withops
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	bdv1 "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+)
+
+// DefaultURLTimeout is used for downloading URL references, which don't specify a timeout
+const DefaultURLTimeout = 30 * time.Second
+
+// ValidateReference checks the fields of a resource reference, which only
+// apply to URL references
+func ValidateReference(ref bdv1.ResourceReference) error {
+	if ref.Type != bdv1.URLReference {
+		if ref.SHA256 != "" || ref.Secret != "" || ref.CABundle != "" || ref.Timeout != nil {
+			return fmt.Errorf("sha256, secret, caBundle and timeout are only supported for url references, not for %s '%s'", ref.Type, ref.Name)
+		}
+		return nil
+	}
+
+	if ref.SHA256 != "" {
+		sum, err := hex.DecodeString(ref.SHA256)
+		if err != nil || len(sum) != sha256.Size {
+			return fmt.Errorf("invalid sha256 '%s' for url '%s'", ref.SHA256, ref.Name)
+		}
+	}
+	if ref.CABundle != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(ref.CABundle)) {
+		return fmt.Errorf("invalid CA bundle for url '%s'", ref.Name)
+	}
+	if ref.Timeout != nil && ref.Timeout.Duration < 0 {
+		return fmt.Errorf("invalid negative timeout '%s' for url '%s'", ref.Timeout.Duration, ref.Name)
+	}
+	return nil
+}
+
+// fetchURL downloads the content of a URL reference. It authenticates with
+// the credentials from the reference's secret, verifies the server with the
+// CA bundle and the content with the SHA256 checksum, if they are set.
+func (r *Resolver) fetchURL(ctx context.Context, namespace string, ref bdv1.ResourceReference, key string) (string, error) {
+	timeout := DefaultURLTimeout
+	if ref.Timeout != nil && ref.Timeout.Duration > 0 {
+		timeout = ref.Timeout.Duration
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if ref.CABundle != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(ref.CABundle)) {
+			return "", fmt.Errorf("failed to parse CA bundle for %s from url '%s'", key, ref.Name)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	httpClient := &http.Client{Transport: transport, Timeout: timeout}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ref.Name, nil)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to build request for %s from url '%s'", key, ref.Name)
+	}
+
+	if ref.Secret != "" {
+		err = r.setURLAuth(ctx, namespace, ref.Secret, req)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to authenticate request for %s from url '%s'", key, ref.Name)
+		}
+	}
+
+	httpResponse, err := httpClient.Do(req)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to resolve %s from url '%s' via http.Get", key, ref.Name)
+	}
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to resolve %s from url '%s': unexpected status '%s'", key, ref.Name, httpResponse.Status)
+	}
+
+	body, err := ioutil.ReadAll(httpResponse.Body)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read %s response body '%s' via ioutil", key, ref.Name)
+	}
+
+	if ref.SHA256 != "" {
+		sum := sha256.Sum256(body)
+		actual := hex.EncodeToString(sum[:])
+		if !strings.EqualFold(actual, ref.SHA256) {
+			return "", fmt.Errorf("checksum mismatch for %s from url '%s': expected sha256 '%s', got '%s'", key, ref.Name, ref.SHA256, actual)
+		}
+	}
+
+	return string(body), nil
+}
+
+// setURLAuth adds basic or bearer auth from the secret to the request
+func (r *Resolver) setURLAuth(ctx context.Context, namespace string, name string, req *http.Request) error {
+	secret := &corev1.Secret{}
+	err := r.client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, secret)
+	if err != nil {
+		return errors.Wrapf(err, "failed to retrieve auth secret '%s/%s' via client.Get", namespace, name)
+	}
+
+	if token, ok := secret.Data[bdv1.URLAuthTokenKey]; ok {
+		req.Header.Set("Authorization", "Bearer "+string(token))
+		return nil
+	}
+
+	username, ok := secret.Data[bdv1.URLAuthUsernameKey]
+	if !ok {
+		return fmt.Errorf("auth secret '%s/%s' contains neither key '%s' nor '%s'", namespace, name, bdv1.URLAuthTokenKey, bdv1.URLAuthUsernameKey)
+	}
+	req.SetBasicAuth(string(username), string(secret.Data[bdv1.URLAuthPasswordKey]))
+	return nil
+}
@@ -0,0 +1,198 @@
+package withops_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	fakeClient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	bdc "code.cloudfoundry.org/quarks-operator/pkg/kube/apis/boshdeployment/v1alpha1"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/controllers/fakes"
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/withops"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+	"code.cloudfoundry.org/quarks-utils/testing/testhelper"
+)
+
+var _ = Describe("URL references", func() {
+	const (
+		manifestPath = "/manifest.yml"
+		manifestStr  = `---
+instance_groups:
+  - name: component5
+    instances: 1`
+	)
+
+	var (
+		ctx        context.Context
+		resolver   *withops.Resolver
+		server     *ghttp.Server
+		ref        bdc.ResourceReference
+		deployment *bdc.BOSHDeployment
+	)
+
+	resolve := func() error {
+		deployment = &bdc.BOSHDeployment{Spec: bdc.BOSHDeploymentSpec{Manifest: ref}}
+		_, err := resolver.Manifest(ctx, deployment, "default")
+		return err
+	}
+
+	BeforeEach(func() {
+		_, log := testhelper.NewTestLogger()
+		ctx = ctxlog.NewParentContext(log)
+		Expect(bdc.AddToScheme(scheme.Scheme)).To(Succeed())
+
+		client := fakeClient.NewFakeClient(
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "basic-auth", Namespace: "default"},
+				Data: map[string][]byte{
+					bdc.URLAuthUsernameKey: []byte("admin"),
+					bdc.URLAuthPasswordKey: []byte("secret"),
+				},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "bearer-auth", Namespace: "default"},
+				Data: map[string][]byte{
+					bdc.URLAuthTokenKey: []byte("the-token"),
+				},
+			},
+		)
+		resolver = withops.NewResolver(client, func() withops.Interpolator { return &fakes.FakeInterpolator{} }, "")
+
+		server = ghttp.NewServer()
+		server.RouteToHandler("GET", manifestPath, ghttp.RespondWith(http.StatusOK, manifestStr))
+
+		ref = bdc.ResourceReference{
+			Type: bdc.URLReference,
+			Name: server.URL() + manifestPath,
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("fails for unexpected status codes", func() {
+		ref.Name = server.URL() + "/not-found.yml"
+		server.RouteToHandler("GET", "/not-found.yml", ghttp.RespondWith(http.StatusNotFound, "<html>not found</html>"))
+
+		err := resolve()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("unexpected status '404 Not Found'"))
+	})
+
+	It("fails if the download times out", func() {
+		ref.Timeout = &metav1.Duration{Duration: 10 * time.Millisecond}
+		server.RouteToHandler("GET", manifestPath, func(http.ResponseWriter, *http.Request) {
+			time.Sleep(100 * time.Millisecond)
+		})
+
+		err := resolve()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Client.Timeout exceeded"))
+	})
+
+	Context("with a checksum", func() {
+		It("accepts matching content", func() {
+			sum := sha256.Sum256([]byte(manifestStr))
+			ref.SHA256 = hex.EncodeToString(sum[:])
+
+			Expect(resolve()).To(Succeed())
+		})
+
+		It("rejects content with a different checksum", func() {
+			sum := sha256.Sum256([]byte("something else"))
+			ref.SHA256 = hex.EncodeToString(sum[:])
+
+			err := resolve()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("checksum mismatch for manifest"))
+		})
+	})
+
+	Context("with an auth secret", func() {
+		It("uses basic auth", func() {
+			ref.Secret = "basic-auth"
+			server.RouteToHandler("GET", manifestPath, ghttp.CombineHandlers(
+				ghttp.VerifyBasicAuth("admin", "secret"),
+				ghttp.RespondWith(http.StatusOK, manifestStr),
+			))
+
+			Expect(resolve()).To(Succeed())
+		})
+
+		It("uses bearer auth", func() {
+			ref.Secret = "bearer-auth"
+			server.RouteToHandler("GET", manifestPath, ghttp.CombineHandlers(
+				ghttp.VerifyHeaderKV("Authorization", "Bearer the-token"),
+				ghttp.RespondWith(http.StatusOK, manifestStr),
+			))
+
+			Expect(resolve()).To(Succeed())
+		})
+
+		It("fails if the secret is missing", func() {
+			ref.Secret = "missing"
+
+			err := resolve()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("failed to retrieve auth secret 'default/missing'"))
+		})
+	})
+
+	Context("with a TLS server", func() {
+		var tlsServer *ghttp.Server
+
+		BeforeEach(func() {
+			tlsServer = ghttp.NewTLSServer()
+			tlsServer.RouteToHandler("GET", manifestPath, ghttp.RespondWith(http.StatusOK, manifestStr))
+			ref.Name = tlsServer.URL() + manifestPath
+		})
+
+		AfterEach(func() {
+			tlsServer.Close()
+		})
+
+		It("fails without the CA bundle", func() {
+			err := resolve()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("certificate"))
+		})
+
+		It("verifies the server with the CA bundle", func() {
+			ref.CABundle = string(pem.EncodeToMemory(&pem.Block{
+				Type:  "CERTIFICATE",
+				Bytes: tlsServer.HTTPTestServer.Certificate().Raw,
+			}))
+
+			Expect(resolve()).To(Succeed())
+		})
+	})
+
+	Describe("ValidateReference", func() {
+		It("rejects url fields on other reference types", func() {
+			err := withops.ValidateReference(bdc.ResourceReference{Type: bdc.SecretReference, Name: "foo", Secret: "auth"})
+			Expect(err).To(MatchError(ContainSubstring("only supported for url references")))
+		})
+
+		It("rejects malformed checksums", func() {
+			ref.SHA256 = "abc"
+			Expect(withops.ValidateReference(ref)).To(MatchError(ContainSubstring("invalid sha256")))
+		})
+
+		It("rejects invalid CA bundles", func() {
+			ref.CABundle = "not a certificate"
+			Expect(withops.ValidateReference(ref)).To(MatchError(ContainSubstring("invalid CA bundle")))
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package zones_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestZones(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Zones Suite")
+}
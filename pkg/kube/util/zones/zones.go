@@ -0,0 +1,102 @@
+// Package zones maps BOSH availability zones to the zones of Kubernetes
+// nodes, which are identified by a node label
+package zones
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	qstsv1a1 "code.cloudfoundry.org/quarks-statefulset/pkg/kube/apis/quarksstatefulset/v1alpha1"
+)
+
+// hostnameNodeLabel is the well-known label of a node's hostname
+const hostnameNodeLabel = "kubernetes.io/hostname"
+
+var (
+	nodeLabel = qstsv1a1.DefaultZoneNodeLabel
+	mapping   = map[string]string{}
+)
+
+// SetNodeLabel sets the node label, which holds the zone of a node
+func SetNodeLabel(label string) {
+	if label == "" {
+		label = qstsv1a1.DefaultZoneNodeLabel
+	}
+	nodeLabel = label
+}
+
+// NodeLabel returns the node label, which holds the zone of a node
+func NodeLabel() string {
+	return nodeLabel
+}
+
+// SetMapping sets the zones of the BOSH availability zones
+func SetMapping(m map[string]string) {
+	if m == nil {
+		m = map[string]string{}
+	}
+	mapping = m
+}
+
+// ParseMapping parses a mapping of availability zones to zones, given as a
+// comma separated list of 'az=zone' pairs, e.g. 'z1=eu-west-1a,z2=eu-west-1b'
+func ParseMapping(s string) (map[string]string, error) {
+	m := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, errors.Errorf("invalid availability zone mapping '%s', expected 'az=zone'", pair)
+		}
+		m[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return m, nil
+}
+
+// Zone returns the zone of an availability zone. Availability zones without
+// a mapping are used as zone names.
+func Zone(az string) string {
+	if zone, ok := mapping[az]; ok {
+		return zone
+	}
+	return az
+}
+
+// Zones returns the zones of the availability zones, keeping their order
+func Zones(azs []string) []string {
+	if len(azs) == 0 {
+		return nil
+	}
+	zones := make([]string, len(azs))
+	for i, az := range azs {
+		zones[i] = Zone(az)
+	}
+	return zones
+}
+
+// SpreadConstraints spreads the pods matching the labels evenly across zones
+// and nodes. Pods of instance groups with availability zones are pinned to
+// their zone, so only the spread across nodes takes effect for them.
+func SpreadConstraints(labels map[string]string) []corev1.TopologySpreadConstraint {
+	selector := &metav1.LabelSelector{MatchLabels: labels}
+	return []corev1.TopologySpreadConstraint{
+		{
+			MaxSkew:           1,
+			TopologyKey:       nodeLabel,
+			WhenUnsatisfiable: corev1.ScheduleAnyway,
+			LabelSelector:     selector,
+		},
+		{
+			MaxSkew:           1,
+			TopologyKey:       hostnameNodeLabel,
+			WhenUnsatisfiable: corev1.ScheduleAnyway,
+			LabelSelector:     selector.DeepCopy(),
+		},
+	}
+}
@@ -0,0 +1,61 @@
+package zones_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"code.cloudfoundry.org/quarks-operator/pkg/kube/util/zones"
+	qstsv1a1 "code.cloudfoundry.org/quarks-statefulset/pkg/kube/apis/quarksstatefulset/v1alpha1"
+)
+
+var _ = Describe("Zones", func() {
+	AfterEach(func() {
+		zones.SetMapping(nil)
+		zones.SetNodeLabel("")
+	})
+
+	Describe("ParseMapping", func() {
+		It("parses the availability zones and their zones", func() {
+			m, err := zones.ParseMapping("z1=eu-west-1a, z2=eu-west-1b")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(m).To(Equal(map[string]string{"z1": "eu-west-1a", "z2": "eu-west-1b"}))
+		})
+
+		It("returns an empty mapping for an empty string", func() {
+			m, err := zones.ParseMapping("")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(m).To(BeEmpty())
+		})
+
+		It("fails for pairs without a zone", func() {
+			_, err := zones.ParseMapping("z1=eu-west-1a,z2")
+			Expect(err).To(MatchError(ContainSubstring("invalid availability zone mapping 'z2'")))
+		})
+	})
+
+	Describe("Zones", func() {
+		It("maps the availability zones, keeping unmapped ones", func() {
+			zones.SetMapping(map[string]string{"z1": "eu-west-1a"})
+			Expect(zones.Zones([]string{"z1", "z2"})).To(Equal([]string{"eu-west-1a", "z2"}))
+			Expect(zones.Zones(nil)).To(BeNil())
+		})
+	})
+
+	Describe("SpreadConstraints", func() {
+		It("spreads pods across zones and nodes", func() {
+			zones.SetNodeLabel("topology.kubernetes.io/zone")
+			constraints := zones.SpreadConstraints(map[string]string{"app": "nats"})
+			Expect(constraints).To(HaveLen(2))
+			Expect(constraints[0].TopologyKey).To(Equal("topology.kubernetes.io/zone"))
+			Expect(constraints[1].TopologyKey).To(Equal("kubernetes.io/hostname"))
+			Expect(constraints[0].WhenUnsatisfiable).To(Equal(corev1.ScheduleAnyway))
+			Expect(constraints[0].LabelSelector.MatchLabels).To(Equal(map[string]string{"app": "nats"}))
+		})
+
+		It("defaults to the zone label of quarks statefulsets", func() {
+			Expect(zones.SpreadConstraints(nil)[0].TopologyKey).To(Equal(qstsv1a1.DefaultZoneNodeLabel))
+		})
+	})
+})
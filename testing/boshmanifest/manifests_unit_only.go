@@ -108,6 +108,104 @@ releases:
   url: hub.docker.com/cfcontainerization
   sha1: 6466c44827c3493645ca34b084e7c21de23272b4`
 
+// WithAddonPlacementRules is a BOSH manifest with addons using deployment, network and team placement rules
+const WithAddonPlacementRules = `name: foo-deployment
+addons:
+- name: network-addon
+  include:
+    networks:
+    - internal
+  jobs:
+  - name: network-job
+    release: redis
+- name: deployment-addon
+  include:
+    deployments:
+    - foo-deployment
+  exclude:
+    networks:
+    - internal
+  jobs:
+  - name: deployment-job
+    release: redis
+- name: other-deployment-addon
+  include:
+    deployments:
+    - bar-deployment
+  jobs:
+  - name: other-deployment-job
+    release: redis
+- name: and-addon
+  include:
+    stemcell:
+    - os: opensuse-42.3
+    networks:
+    - default
+    instance_groups:
+    - diego-cell
+  jobs:
+  - name: and-job
+    release: redis
+- name: or-addon
+  include:
+    instance_groups:
+    - redis-slave
+    release:
+    - name: cflinuxfs3-rootfs-setup
+      release: cflinuxfs3
+  jobs:
+  - name: or-job
+    release: redis
+- name: team-addon
+  include:
+    teams:
+    - cf
+  jobs:
+  - name: team-job
+    release: redis
+- name: exclude-team-addon
+  include:
+    instance_groups:
+    - redis-slave
+  exclude:
+    teams:
+    - cf
+  jobs:
+  - name: exclude-team-job
+    release: redis
+stemcells:
+- alias: default
+  os: opensuse-42.3
+  version: 28.g837c5b3-30.263-7.0.0_234.gcd7d1132
+instance_groups:
+- name: redis-slave
+  instances: 1
+  jobs:
+  - name: redis-server
+    release: redis
+    properties: {}
+  stemcell: default
+  networks:
+  - name: default
+- name: diego-cell
+  instances: 1
+  jobs:
+  - name: cflinuxfs3-rootfs-setup
+    release: cflinuxfs3
+    properties: {}
+  stemcell: default
+  networks:
+  - name: default
+  - name: internal
+releases:
+- name: cflinuxfs3
+  version: 0.62.0
+  url: hub.docker.com/cfcontainerization
+- name: redis
+  version: 36.15.0
+  url: hub.docker.com/cfcontainerization
+`
+
 // WithAddons is a BOSH manifest with addons for tests
 const WithAddons = `name: foo-deployment
 addons: